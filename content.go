@@ -252,6 +252,12 @@ type FetchOptions struct {
 }
 
 // Fetch fetches the content identified by the reference.
+// If the resolved descriptor embeds the content in its Data field, the
+// embedded content is returned after verification without fetching.
+//
+// References resolve to manifests, which are not downloaded from URLs. To
+// download a foreign layer of a fetched manifest, use [FetchFromURLs], or
+// [CopyGraphOptions.ForeignLayerClient] when copying.
 func Fetch(ctx context.Context, target ReadOnlyTarget, reference string, opts FetchOptions) (ocispec.Descriptor, io.ReadCloser, error) {
 	if opts.TargetPlatform == nil {
 		if refFetcher, ok := target.(registry.ReferenceFetcher); ok {
@@ -262,7 +268,7 @@ func Fetch(ctx context.Context, target ReadOnlyTarget, reference string, opts Fe
 		if err != nil {
 			return ocispec.Descriptor{}, nil, err
 		}
-		rc, err := target.Fetch(ctx, desc)
+		rc, err := fetchNode(ctx, target, desc)
 		if err != nil {
 			return ocispec.Descriptor{}, nil, err
 		}
@@ -280,7 +286,7 @@ func Fetch(ctx context.Context, target ReadOnlyTarget, reference string, opts Fe
 	// if the content exists in cache, fetch it from cache
 	// otherwise fetch without caching
 	proxy.StopCaching = true
-	rc, err := proxy.Fetch(ctx, desc)
+	rc, err := fetchNode(ctx, proxy, desc)
 	if err != nil {
 		return ocispec.Descriptor{}, nil, err
 	}
@@ -0,0 +1,134 @@
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package content
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/errdef"
+	"oras.land/oras-go/v2/internal/aesgcm"
+	"oras.land/oras-go/v2/internal/verifyutil"
+)
+
+// EncryptedStorage represents a CAS encrypting the content at rest in the
+// underlying storage with AES-GCM.
+//   - The content is encrypted in chunks, and is still addressed by the digest
+//     of its plaintext in the underlying storage.
+//   - The fetched content is decrypted, and verified against the descriptor
+//     on reaching its end.
+//   - Pushing requires the underlying storage to be a RawPusher, and deleting
+//     requires it to be a Deleter. Among the storages of this module,
+//     [oras.land/oras-go/v2/content/oci.Storage] is both, while wrappers such
+//     as [LimitedStorage] and graph targets such as
+//     [oras.land/oras-go/v2/content/memory.Store] are not.
+//
+// An EncryptedStorage over an oci.Storage can be used as the fallback storage
+// of [oras.land/oras-go/v2/content/file.NewWithFallbackStorage] to encrypt
+// the unnamed content of a file store.
+type EncryptedStorage struct {
+	storage ReadOnlyStorage
+	cipher  *aesgcm.Cipher
+}
+
+// NewEncryptedStorage returns a storage encrypting the content at rest in s
+// with the key, which must be 16, 24, or 32 bytes long.
+func NewEncryptedStorage(s ReadOnlyStorage, key []byte) (*EncryptedStorage, error) {
+	cipher, err := aesgcm.New(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid encryption key: %w", err)
+	}
+	return &EncryptedStorage{
+		storage: s,
+		cipher:  cipher,
+	}, nil
+}
+
+// Fetch fetches the content identified by the descriptor.
+func (s *EncryptedStorage) Fetch(ctx context.Context, target ocispec.Descriptor) (io.ReadCloser, error) {
+	rc, err := s.storage.Fetch(ctx, encryptedDescriptor(target))
+	if err != nil {
+		return nil, err
+	}
+	plaintext := s.cipher.NewReader(rc, []byte(target.Digest))
+	return verifyutil.NewReadCloser(NewVerifyReader(plaintext, target), rc), nil
+}
+
+// Exists returns true if the described content exists.
+func (s *EncryptedStorage) Exists(ctx context.Context, target ocispec.Descriptor) (bool, error) {
+	return s.storage.Exists(ctx, encryptedDescriptor(target))
+}
+
+// Push pushes the content, matching the expected descriptor.
+func (s *EncryptedStorage) Push(ctx context.Context, expected ocispec.Descriptor, content io.Reader) error {
+	pusher, ok := s.storage.(RawPusher)
+	if !ok {
+		return fmt.Errorf("push to %T: %w", s.storage, errdef.ErrUnsupported)
+	}
+
+	// encrypt the content while pushing it, so that the underlying storage
+	// fails to read the ciphertext to its end if the content is invalid
+	pr, pw := io.Pipe()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		pw.CloseWithError(s.encrypt(pw, expected, content))
+	}()
+	err := pusher.PushRaw(ctx, encryptedDescriptor(expected), pr)
+	// unblock the encryption if the ciphertext is not fully read
+	pr.Close()
+	<-done
+	return err
+}
+
+// Delete removes the content identified by the descriptor.
+func (s *EncryptedStorage) Delete(ctx context.Context, target ocispec.Descriptor) error {
+	deleter, ok := s.storage.(Deleter)
+	if !ok {
+		return fmt.Errorf("delete from %T: %w", s.storage, errdef.ErrUnsupported)
+	}
+	return deleter.Delete(ctx, encryptedDescriptor(target))
+}
+
+// encrypt writes the ciphertext of the content to w, verifying the content
+// against desc.
+func (s *EncryptedStorage) encrypt(w io.Writer, desc ocispec.Descriptor, content io.Reader) error {
+	cw, err := s.cipher.NewWriter(w, []byte(desc.Digest))
+	if err != nil {
+		return err
+	}
+	vr := NewVerifyReader(content, desc)
+	if _, err := io.Copy(cw, vr); err != nil {
+		return err
+	}
+	if err := vr.Verify(); err != nil {
+		return err
+	}
+	// the last chunk is written only after the content is verified
+	return cw.Close()
+}
+
+// encryptedDescriptor returns the descriptor of the ciphertext of the content
+// described by desc in the underlying storage.
+func encryptedDescriptor(desc ocispec.Descriptor) ocispec.Descriptor {
+	return ocispec.Descriptor{
+		MediaType: desc.MediaType,
+		Digest:    desc.Digest,
+		Size:      aesgcm.CiphertextSize(desc.Size),
+	}
+}
@@ -0,0 +1,151 @@
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package content_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/content/oci"
+	"oras.land/oras-go/v2/errdef"
+	"oras.land/oras-go/v2/internal/aesgcm"
+	"oras.land/oras-go/v2/internal/cas"
+)
+
+func TestEncryptedStorage(t *testing.T) {
+	data := []byte("hello world")
+	desc := content.NewDescriptorFromBytes("test", data)
+	key := bytes.Repeat([]byte("k"), 32)
+
+	tempDir := t.TempDir()
+	storage, err := oci.NewStorage(tempDir)
+	if err != nil {
+		t.Fatal("oci.NewStorage() error =", err)
+	}
+	s, err := content.NewEncryptedStorage(storage, key)
+	if err != nil {
+		t.Fatal("NewEncryptedStorage() error =", err)
+	}
+	ctx := context.Background()
+
+	// test push
+	if err := s.Push(ctx, desc, bytes.NewReader(data)); err != nil {
+		t.Fatal("EncryptedStorage.Push() error =", err)
+	}
+	if err := s.Push(ctx, desc, bytes.NewReader(data)); !errors.Is(err, errdef.ErrAlreadyExists) {
+		t.Errorf("EncryptedStorage.Push() error = %v, want %v", err, errdef.ErrAlreadyExists)
+	}
+	mismatched := ocispec.Descriptor{
+		MediaType: "test",
+		Digest:    digest.FromBytes([]byte("foo")),
+		Size:      desc.Size,
+	}
+	if err := s.Push(ctx, mismatched, bytes.NewReader(data)); !errors.Is(err, content.ErrMismatchedDigest) {
+		t.Errorf("EncryptedStorage.Push() error = %v, want %v", err, content.ErrMismatchedDigest)
+	}
+	exists, err := s.Exists(ctx, mismatched)
+	if err != nil {
+		t.Fatal("EncryptedStorage.Exists() error =", err)
+	}
+	if exists {
+		t.Errorf("EncryptedStorage.Exists() = %v, want %v", exists, false)
+	}
+
+	// the content is stored by the plaintext digest without exposing the
+	// plaintext
+	path := filepath.Join(tempDir, "blobs", desc.Digest.Algorithm().String(), desc.Digest.Encoded())
+	stored, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal("os.ReadFile() error =", err)
+	}
+	if bytes.Contains(stored, data) {
+		t.Error("the stored content contains the plaintext")
+	}
+
+	// test fetch
+	got, err := content.FetchAll(ctx, s, desc)
+	if err != nil {
+		t.Fatal("content.FetchAll() error =", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Errorf("content.FetchAll() = %s, want %s", got, data)
+	}
+	rc, err := s.Fetch(ctx, ocispec.Descriptor{
+		MediaType: desc.MediaType,
+		Digest:    desc.Digest,
+		Size:      desc.Size + 1,
+	})
+	if err != nil {
+		t.Fatal("EncryptedStorage.Fetch() error =", err)
+	}
+	if _, err := io.ReadAll(rc); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("EncryptedStorage.Fetch().Read() error = %v, want %v", err, io.ErrUnexpectedEOF)
+	}
+	rc.Close()
+
+	// test fetch with another key
+	other, err := content.NewEncryptedStorage(storage, bytes.Repeat([]byte("x"), 32))
+	if err != nil {
+		t.Fatal("NewEncryptedStorage() error =", err)
+	}
+	if _, err := content.FetchAll(ctx, other, desc); !errors.Is(err, aesgcm.ErrInvalidCiphertext) {
+		t.Errorf("content.FetchAll() error = %v, want %v", err, aesgcm.ErrInvalidCiphertext)
+	}
+
+	// test delete
+	if err := s.Delete(ctx, desc); err != nil {
+		t.Fatal("EncryptedStorage.Delete() error =", err)
+	}
+	exists, err = s.Exists(ctx, desc)
+	if err != nil {
+		t.Fatal("EncryptedStorage.Exists() error =", err)
+	}
+	if exists {
+		t.Errorf("EncryptedStorage.Exists() = %v, want %v", exists, false)
+	}
+}
+
+func TestEncryptedStorage_Unsupported(t *testing.T) {
+	// the underlying storage is neither a RawPusher nor a Deleter
+	storage := struct{ content.ReadOnlyStorage }{cas.NewMemory()}
+	s, err := content.NewEncryptedStorage(storage, bytes.Repeat([]byte("k"), 32))
+	if err != nil {
+		t.Fatal("NewEncryptedStorage() error =", err)
+	}
+	ctx := context.Background()
+	data := []byte("hello world")
+	desc := content.NewDescriptorFromBytes("test", data)
+	if err := s.Push(ctx, desc, bytes.NewReader(data)); !errors.Is(err, errdef.ErrUnsupported) {
+		t.Errorf("EncryptedStorage.Push() error = %v, want %v", err, errdef.ErrUnsupported)
+	}
+	if err := s.Delete(ctx, desc); !errors.Is(err, errdef.ErrUnsupported) {
+		t.Errorf("EncryptedStorage.Delete() error = %v, want %v", err, errdef.ErrUnsupported)
+	}
+}
+
+func TestNewEncryptedStorage_InvalidKey(t *testing.T) {
+	if _, err := content.NewEncryptedStorage(cas.NewMemory(), []byte("short")); err == nil {
+		t.Error("NewEncryptedStorage() error = nil, want error")
+	}
+}
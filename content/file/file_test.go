@@ -37,7 +37,9 @@ import (
 	"oras.land/oras-go/v2"
 	"oras.land/oras-go/v2/content"
 	"oras.land/oras-go/v2/content/memory"
+	"oras.land/oras-go/v2/content/oci"
 	"oras.land/oras-go/v2/errdef"
+	"oras.land/oras-go/v2/internal/aesgcm"
 	"oras.land/oras-go/v2/internal/cas"
 	"oras.land/oras-go/v2/internal/descriptor"
 	"oras.land/oras-go/v2/internal/spec"
@@ -1701,6 +1703,76 @@ func TestStore_File_Push_RestoreDuplicates_DuplicateName(t *testing.T) {
 	}
 }
 
+func TestStore_FallbackStorage_Encrypted(t *testing.T) {
+	key := bytes.Repeat([]byte{0x42}, 32)
+	blob := []byte("hello world")
+	blobDesc := content.NewDescriptorFromBytes("test", blob)
+	ctx := context.Background()
+
+	for _, tt := range []struct {
+		name    string
+		storage func(t *testing.T) content.Storage
+	}{
+		{
+			name: "memory",
+			storage: func(t *testing.T) content.Storage {
+				return cas.NewMemory()
+			},
+		},
+		{
+			name: "oci",
+			storage: func(t *testing.T) content.Storage {
+				s, err := oci.NewStorage(t.TempDir())
+				if err != nil {
+					t.Fatal("oci.NewStorage() error =", err)
+				}
+				return s
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			underlying := tt.storage(t)
+			encrypted, err := content.NewEncryptedStorage(underlying, key)
+			if err != nil {
+				t.Fatal("content.NewEncryptedStorage() error =", err)
+			}
+			s, err := NewWithFallbackStorage(t.TempDir(), encrypted)
+			if err != nil {
+				t.Fatal("NewWithFallbackStorage() error =", err)
+			}
+			defer s.Close()
+
+			// the unnamed content is encrypted in the fallback storage
+			if err := s.Push(ctx, blobDesc, bytes.NewReader(blob)); err != nil {
+				t.Fatal("Store.Push() error =", err)
+			}
+			got, err := content.FetchAll(ctx, s, blobDesc)
+			if err != nil {
+				t.Fatal("Store.Fetch() error =", err)
+			}
+			if !bytes.Equal(got, blob) {
+				t.Errorf("Store.Fetch() = %v, want %v", got, blob)
+			}
+			rc, err := underlying.Fetch(ctx, ocispec.Descriptor{
+				MediaType: blobDesc.MediaType,
+				Digest:    blobDesc.Digest,
+				Size:      aesgcm.CiphertextSize(blobDesc.Size),
+			})
+			if err != nil {
+				t.Fatal("Storage.Fetch() error =", err)
+			}
+			defer rc.Close()
+			ciphertext, err := io.ReadAll(rc)
+			if err != nil {
+				t.Fatal("io.ReadAll() error =", err)
+			}
+			if bytes.Contains(ciphertext, blob) {
+				t.Errorf("fallback storage content = %v, want encrypted", ciphertext)
+			}
+		})
+	}
+}
+
 func TestStore_File_Push_RestoreDuplicates_Failure(t *testing.T) {
 	mediaType := "test"
 	content := []byte("hello world")
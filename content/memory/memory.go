@@ -17,64 +17,189 @@ limitations under the License.
 package memory
 
 import (
+	"cmp"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/opencontainers/go-digest"
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 	"oras.land/oras-go/v2/content"
 	"oras.land/oras-go/v2/errdef"
 	"oras.land/oras-go/v2/internal/cas"
+	"oras.land/oras-go/v2/internal/container/set"
+	"oras.land/oras-go/v2/internal/descriptor"
 	"oras.land/oras-go/v2/internal/graph"
 	"oras.land/oras-go/v2/internal/resolver"
 )
 
+// checkRetention is the duration for which the content found existing is
+// protected from eviction.
+const checkRetention = time.Hour
+
 // Store represents a memory based store, which implements `oras.Target`.
 type Store struct {
-	storage  content.Storage
-	resolver content.TagResolver
+	// AutoGC controls if the store will automatically clean dangling
+	// (unreferenced) blobs created by the Delete() operation. This includes the
+	// referrers and the unreferenced successor blobs of the deleted content.
+	// Tagged manifests will not be deleted.
+	//   - Default value: true.
+	AutoGC bool
+
+	storage  *cas.Memory
+	resolver *resolver.Memory
 	graph    *graph.Memory
+
+	// sync ensures that most operations can be done concurrently, while
+	// Delete, GC and eviction have the exclusive access to Store.
+	sync sync.RWMutex
+
+	// maxSize is the maximum total size of the content. It is 0 if the size
+	// is unbounded.
+	maxSize int64
+	// evict indicates whether content is evicted instead of rejected when
+	// maxSize is exceeded.
+	evict bool
+	// size is the total size of the content.
+	size atomic.Int64
+	// accessLock guards accessedAt, accessClock and checkedAt.
+	accessLock sync.Mutex
+	// accessedAt records the logical time of the last access to each
+	// manifest.
+	accessedAt map[digest.Digest]uint64
+	// accessClock is the logical clock of the accesses.
+	accessClock uint64
+	// checkedAt records the last time each content is found existing.
+	checkedAt map[digest.Digest]time.Time
+}
+
+// StoreOptions contains parameters for [NewWithOptions].
+type StoreOptions struct {
+	// MaxSize bounds the total size in bytes of the content in the store.
+	// If zero or negative, the size is unbounded.
+	MaxSize int64
+
+	// Evict controls what happens when pushing content would make the store
+	// exceed MaxSize.
+	//   - If false, the push fails with errdef.ErrSizeExceedsLimit.
+	//   - If true, the least recently used roots are evicted along with their
+	//     referrers and the content exclusively referenced by them, until the
+	//     content fits. A root is a tagged descriptor, or an untagged manifest
+	//     not referenced by any index in the store. The access to a root is
+	//     recorded on Fetch(), Resolve(), Push() and Tag(). Untagged content
+	//     not reachable from any root, such as the blobs pushed before their
+	//     manifest, is never evicted. The content found existing by Exists()
+	//     is not evicted within an hour, so that a copy skipping the existing
+	//     content can complete. If the content still does not fit, the push
+	//     fails with errdef.ErrSizeExceedsLimit.
+	Evict bool
 }
 
 // New creates a new memory based store.
 func New() *Store {
-	return &Store{
-		storage:  cas.NewMemory(),
-		resolver: resolver.NewMemory(),
-		graph:    graph.NewMemory(),
+	return NewWithOptions(StoreOptions{})
+}
+
+// NewWithOptions creates a new memory based store with the given options.
+func NewWithOptions(opts StoreOptions) *Store {
+	s := &Store{
+		AutoGC:     true,
+		storage:    cas.NewMemory(),
+		resolver:   resolver.NewMemory(),
+		graph:      graph.NewMemory(),
+		accessedAt: make(map[digest.Digest]uint64),
+		checkedAt:  make(map[digest.Digest]time.Time),
 	}
+	if opts.MaxSize > 0 {
+		s.maxSize = opts.MaxSize
+		s.evict = opts.Evict
+	}
+	return s
 }
 
 // Fetch fetches the content identified by the descriptor.
 func (s *Store) Fetch(ctx context.Context, target ocispec.Descriptor) (io.ReadCloser, error) {
-	return s.storage.Fetch(ctx, target)
+	s.sync.RLock()
+	defer s.sync.RUnlock()
+
+	rc, err := s.storage.Fetch(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+	s.touch(target)
+	return rc, nil
 }
 
 // Push pushes the content, matching the expected descriptor.
+// If StoreOptions.MaxSize is positive and the content does not fit, the least
+// recently used content is evicted if StoreOptions.Evict is true, or
+// errdef.ErrSizeExceedsLimit is returned otherwise.
 func (s *Store) Push(ctx context.Context, expected ocispec.Descriptor, reader io.Reader) error {
-	if err := s.storage.Push(ctx, expected, reader); err != nil {
+	if err := s.reserve(ctx, expected); err != nil {
 		return err
 	}
 
-	// index predecessors.
-	// there is no data consistency issue as long as deletion is not implemented
-	// for the memory store.
+	s.sync.RLock()
+	defer s.sync.RUnlock()
+
+	if err := s.storage.Push(ctx, expected, reader); err != nil {
+		s.size.Add(-expected.Size)
+		return err
+	}
+	s.touch(expected)
 	return s.graph.Index(ctx, s.storage, expected)
 }
 
 // Exists returns true if the described content exists.
 func (s *Store) Exists(ctx context.Context, target ocispec.Descriptor) (bool, error) {
-	return s.storage.Exists(ctx, target)
+	s.sync.RLock()
+	defer s.sync.RUnlock()
+
+	exists, err := s.storage.Exists(ctx, target)
+	if err != nil {
+		return false, err
+	}
+	if exists {
+		s.check(target)
+	}
+	return exists, nil
+}
+
+// Delete deletes the content matching the descriptor from the store.
+//   - If s.AutoGC is set to true, Delete will recursively remove the dangling
+//     blobs caused by the current delete, and the referrers of the manifests
+//     being deleted.
+func (s *Store) Delete(ctx context.Context, target ocispec.Descriptor) error {
+	s.sync.Lock()
+	defer s.sync.Unlock()
+
+	return s.deleteAll(ctx, target, s.AutoGC, nil)
 }
 
 // Resolve resolves a reference to a descriptor.
 func (s *Store) Resolve(ctx context.Context, reference string) (ocispec.Descriptor, error) {
-	return s.resolver.Resolve(ctx, reference)
+	s.sync.RLock()
+	defer s.sync.RUnlock()
+
+	desc, err := s.resolver.Resolve(ctx, reference)
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	s.touch(desc)
+	return desc, nil
 }
 
 // Tag tags a descriptor with a reference string.
 // Returns ErrNotFound if the tagged content does not exist.
 func (s *Store) Tag(ctx context.Context, desc ocispec.Descriptor, reference string) error {
+	s.sync.RLock()
+	defer s.sync.RUnlock()
+
 	exists, err := s.storage.Exists(ctx, desc)
 	if err != nil {
 		return err
@@ -82,7 +207,49 @@ func (s *Store) Tag(ctx context.Context, desc ocispec.Descriptor, reference stri
 	if !exists {
 		return fmt.Errorf("%s: %s: %w", desc.Digest, desc.MediaType, errdef.ErrNotFound)
 	}
-	return s.resolver.Tag(ctx, desc, reference)
+	if err := s.resolver.Tag(ctx, desc, reference); err != nil {
+		return err
+	}
+	s.touch(desc)
+	return nil
+}
+
+// Untag disassociates a reference string from its descriptor.
+// The actual content identified by the descriptor is NOT deleted.
+// Returns ErrNotFound if the reference does not exist.
+func (s *Store) Untag(ctx context.Context, reference string) error {
+	if reference == "" {
+		return errdef.ErrMissingReference
+	}
+
+	s.sync.RLock()
+	defer s.sync.RUnlock()
+
+	if _, err := s.resolver.Resolve(ctx, reference); err != nil {
+		return fmt.Errorf("resolving reference %q: %w", reference, err)
+	}
+	s.resolver.Untag(reference)
+	return nil
+}
+
+// Tags lists the tags in the store, returned in ascending order.
+// If `last` is NOT empty, the entries in the response start after the tag
+// specified by `last`. Otherwise, the response starts from the top of the tags
+// list.
+//
+// See also `Tags()` in the package `registry`.
+func (s *Store) Tags(ctx context.Context, last string, fn func(tags []string) error) error {
+	s.sync.RLock()
+	defer s.sync.RUnlock()
+
+	var tags []string
+	for tag := range s.resolver.Map() {
+		if last == "" || tag > last {
+			tags = append(tags, tag)
+		}
+	}
+	slices.Sort(tags)
+	return fn(tags)
 }
 
 // Predecessors returns the nodes directly pointing to the current node.
@@ -92,5 +259,279 @@ func (s *Store) Tag(ctx context.Context, desc ocispec.Descriptor, reference stri
 // it does not necessarily correspond to any consistent snapshot of the stored
 // contents.
 func (s *Store) Predecessors(ctx context.Context, node ocispec.Descriptor) ([]ocispec.Descriptor, error) {
+	s.sync.RLock()
+	defer s.sync.RUnlock()
+
 	return s.graph.Predecessors(ctx, node)
 }
+
+// GC removes garbage from the store. The garbage to be cleaned are the
+// content not reachable from the tagged descriptors and their referrers,
+// including untagged manifests and the blobs pushed but not yet referenced.
+func (s *Store) GC(ctx context.Context) error {
+	s.sync.Lock()
+	defer s.sync.Unlock()
+
+	// mark the content reachable from the tagged descriptors and their
+	// referrers
+	reachable := set.New[descriptor.Descriptor]()
+	var stack []ocispec.Descriptor
+	for _, desc := range s.resolver.Map() {
+		stack = append(stack, desc)
+	}
+	for len(stack) > 0 {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		node := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		key := descriptor.FromOCI(node)
+		if reachable.Contains(key) {
+			continue
+		}
+		reachable.Add(key)
+		if !descriptor.IsManifest(node) {
+			continue
+		}
+		successors, err := content.Successors(ctx, s.storage, node)
+		if err != nil {
+			return err
+		}
+		stack = append(stack, successors...)
+		referrers, err := s.referrers(ctx, node)
+		if err != nil {
+			return err
+		}
+		stack = append(stack, referrers...)
+	}
+
+	// sweep the unreachable content
+	for key := range s.storage.Map() {
+		if reachable.Contains(key) {
+			continue
+		}
+		desc := ocispec.Descriptor{
+			MediaType: key.MediaType,
+			Digest:    key.Digest,
+			Size:      key.Size,
+		}
+		if _, err := s.deleteNode(ctx, desc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Size returns the total size in bytes of the content in the store.
+func (s *Store) Size() int64 {
+	return s.size.Load()
+}
+
+// deleteAll deletes the target, and its referrers and dangling successors if
+// gc is true. The protected content is not deleted unless it is the target.
+// The caller must hold s.sync.
+func (s *Store) deleteAll(ctx context.Context, target ocispec.Descriptor, gc bool, protected set.Set[digest.Digest]) error {
+	deleteQueue := []ocispec.Descriptor{target}
+	for len(deleteQueue) > 0 {
+		head := deleteQueue[0]
+		deleteQueue = deleteQueue[1:]
+
+		// get referrers if applicable
+		if gc && descriptor.IsManifest(head) {
+			referrers, err := s.referrers(ctx, head)
+			if err != nil {
+				return err
+			}
+			for _, referrer := range referrers {
+				if !protected.Contains(referrer.Digest) {
+					deleteQueue = append(deleteQueue, referrer)
+				}
+			}
+		}
+
+		// delete the head of queue
+		danglings, err := s.deleteNode(ctx, head)
+		if err != nil {
+			return err
+		}
+		if gc {
+			for _, d := range danglings {
+				// do not delete existing tagged manifests
+				if len(s.resolver.TagSet(d)) == 0 && !protected.Contains(d.Digest) {
+					deleteQueue = append(deleteQueue, d)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// deleteNode deletes one node and returns the dangling nodes caused by the
+// delete. The caller must hold s.sync.
+func (s *Store) deleteNode(ctx context.Context, target ocispec.Descriptor) ([]ocispec.Descriptor, error) {
+	if err := s.storage.Delete(ctx, target); err != nil {
+		return nil, err
+	}
+	s.size.Add(-target.Size)
+	for reference := range s.resolver.TagSet(target) {
+		s.resolver.Untag(reference)
+	}
+	s.accessLock.Lock()
+	delete(s.accessedAt, target.Digest)
+	delete(s.checkedAt, target.Digest)
+	s.accessLock.Unlock()
+	return s.graph.Remove(target), nil
+}
+
+// reserve reserves the space for the content to be pushed, evicting the least
+// recently used content if needed and allowed.
+func (s *Store) reserve(ctx context.Context, expected ocispec.Descriptor) error {
+	size := s.size.Add(expected.Size)
+	if s.maxSize <= 0 || size <= s.maxSize {
+		return nil
+	}
+	if s.evict {
+		if err := s.evictLRU(ctx); err != nil {
+			s.size.Add(-expected.Size)
+			return fmt.Errorf("failed to evict content: %w", err)
+		}
+		if s.size.Load() <= s.maxSize {
+			return nil
+		}
+	}
+	s.size.Add(-expected.Size)
+	return fmt.Errorf("content size %v exceeds the store limit %v: %w", expected.Size, s.maxSize, errdef.ErrSizeExceedsLimit)
+}
+
+// evictLRU evicts the least recently used roots and their exclusive content
+// until the reserved size is within the limit, or there is nothing left to
+// evict. The content recently found existing is not evicted.
+func (s *Store) evictLRU(ctx context.Context) error {
+	s.sync.Lock()
+	defer s.sync.Unlock()
+
+	protected := s.checkedSince(time.Now().Add(-checkRetention))
+
+	// collect the roots
+	var roots []ocispec.Descriptor
+	visited := set.New[descriptor.Descriptor]()
+	addRoot := func(desc ocispec.Descriptor) {
+		key := descriptor.FromOCI(desc)
+		if !visited.Contains(key) && !protected.Contains(desc.Digest) {
+			visited.Add(key)
+			roots = append(roots, descriptor.Plain(desc))
+		}
+	}
+	for _, desc := range s.resolver.Map() {
+		addRoot(desc)
+	}
+	for _, node := range s.graph.Nodes() {
+		if !descriptor.IsManifest(node.Descriptor) {
+			continue
+		}
+		predecessors, err := s.graph.Predecessors(ctx, node.Descriptor)
+		if err != nil {
+			return err
+		}
+		if !slices.ContainsFunc(predecessors, descriptor.IsIndex) {
+			addRoot(node.Descriptor)
+		}
+	}
+
+	s.accessLock.Lock()
+	slices.SortFunc(roots, func(a, b ocispec.Descriptor) int {
+		if c := cmp.Compare(s.accessedAt[a.Digest], s.accessedAt[b.Digest]); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Digest, b.Digest)
+	})
+	s.accessLock.Unlock()
+
+	for _, root := range roots {
+		if s.size.Load() <= s.maxSize {
+			break
+		}
+		exists, err := s.storage.Exists(ctx, root)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			// already evicted along with another root
+			continue
+		}
+		if err := s.deleteAll(ctx, root, true, protected); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// touch records the access to the manifest.
+func (s *Store) touch(desc ocispec.Descriptor) {
+	if !s.evict || !descriptor.IsManifest(desc) {
+		return
+	}
+	s.accessLock.Lock()
+	defer s.accessLock.Unlock()
+
+	s.accessClock++
+	s.accessedAt[desc.Digest] = s.accessClock
+}
+
+// check records that the content is found existing, so that it is not evicted
+// while an in-progress copy relies on it.
+func (s *Store) check(desc ocispec.Descriptor) {
+	if !s.evict {
+		return
+	}
+	s.accessLock.Lock()
+	defer s.accessLock.Unlock()
+
+	s.checkedAt[desc.Digest] = time.Now()
+}
+
+// checkedSince returns the digests of the content found existing since the
+// given time, and forgets the content checked earlier.
+func (s *Store) checkedSince(since time.Time) set.Set[digest.Digest] {
+	s.accessLock.Lock()
+	defer s.accessLock.Unlock()
+
+	checked := set.New[digest.Digest]()
+	for dgst, t := range s.checkedAt {
+		if t.Before(since) {
+			delete(s.checkedAt, dgst)
+			continue
+		}
+		checked.Add(dgst)
+	}
+	return checked
+}
+
+// referrers returns the manifests referring to the given manifest as their
+// subject. The caller must hold s.sync.
+func (s *Store) referrers(ctx context.Context, node ocispec.Descriptor) ([]ocispec.Descriptor, error) {
+	predecessors, err := s.graph.Predecessors(ctx, node)
+	if err != nil {
+		return nil, err
+	}
+	var referrers []ocispec.Descriptor
+	for _, predecessor := range predecessors {
+		if !descriptor.IsManifest(predecessor) {
+			continue
+		}
+		manifestJSON, err := content.FetchAll(ctx, s.storage, predecessor)
+		if err != nil {
+			return nil, err
+		}
+		var manifest struct {
+			Subject *ocispec.Descriptor `json:"subject,omitempty"`
+		}
+		if err := json.Unmarshal(manifestJSON, &manifest); err != nil {
+			return nil, err
+		}
+		if manifest.Subject != nil && content.Equal(*manifest.Subject, node) {
+			referrers = append(referrers, predecessor)
+		}
+	}
+	return referrers, nil
+}
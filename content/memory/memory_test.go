@@ -34,8 +34,6 @@ import (
 	"oras.land/oras-go/v2"
 	"oras.land/oras-go/v2/content"
 	"oras.land/oras-go/v2/errdef"
-	"oras.land/oras-go/v2/internal/cas"
-	"oras.land/oras-go/v2/internal/resolver"
 	"oras.land/oras-go/v2/internal/spec"
 )
 
@@ -78,7 +76,7 @@ func TestStoreSuccess(t *testing.T) {
 	if !reflect.DeepEqual(gotDesc, desc) {
 		t.Errorf("Store.Resolve() = %v, want %v", gotDesc, desc)
 	}
-	internalResolver := s.resolver.(*resolver.Memory)
+	internalResolver := s.resolver
 	if got := len(internalResolver.Map()); got != 1 {
 		t.Errorf("resolver.Map() = %v, want %v", got, 1)
 	}
@@ -106,7 +104,7 @@ func TestStoreSuccess(t *testing.T) {
 	if !bytes.Equal(got, content) {
 		t.Errorf("Store.Fetch() = %v, want %v", got, content)
 	}
-	internalStorage := s.storage.(*cas.Memory)
+	internalStorage := s.storage
 	if got := len(internalStorage.Map()); got != 1 {
 		t.Errorf("storage.Map() = %v, want %v", got, 1)
 	}
@@ -220,7 +218,7 @@ func TestStoreRepeatTag(t *testing.T) {
 	ctx := context.Background()
 
 	// get internal resolver
-	internalResolver := s.resolver.(*resolver.Memory)
+	internalResolver := s.resolver
 
 	// initial tag
 	content := []byte("hello world")
@@ -472,6 +470,259 @@ func TestStore_BadDigest(t *testing.T) {
 	})
 }
 
+// testImages pushes test content to a memory store.
+type testImages struct {
+	t   *testing.T
+	ctx context.Context
+	s   *Store
+}
+
+func (ti *testImages) push(mediaType string, blob []byte) ocispec.Descriptor {
+	ti.t.Helper()
+	desc := content.NewDescriptorFromBytes(mediaType, blob)
+	if err := ti.s.Push(ti.ctx, desc, bytes.NewReader(blob)); err != nil && !errors.Is(err, errdef.ErrAlreadyExists) {
+		ti.t.Fatal("Store.Push() error =", err)
+	}
+	return desc
+}
+
+func (ti *testImages) pushManifest(subject *ocispec.Descriptor, layerBlob string) (ocispec.Descriptor, ocispec.Descriptor) {
+	ti.t.Helper()
+	ti.push(ocispec.MediaTypeEmptyJSON, ocispec.DescriptorEmptyJSON.Data)
+	layer := ti.push(ocispec.MediaTypeImageLayer, []byte(layerBlob))
+	manifestJSON, err := json.Marshal(ocispec.Manifest{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Config:    ocispec.DescriptorEmptyJSON,
+		Layers:    []ocispec.Descriptor{layer},
+		Subject:   subject,
+	})
+	if err != nil {
+		ti.t.Fatal("json.Marshal() error =", err)
+	}
+	return ti.push(ocispec.MediaTypeImageManifest, manifestJSON), layer
+}
+
+func (ti *testImages) checkExists(want bool, descs ...ocispec.Descriptor) {
+	ti.t.Helper()
+	for _, desc := range descs {
+		// check the storage directly, as Store.Exists protects the content
+		// from eviction
+		exists, err := ti.s.storage.Exists(ti.ctx, desc)
+		if err != nil {
+			ti.t.Fatal("Store.Exists() error =", err)
+		}
+		if exists != want {
+			ti.t.Errorf("Store.Exists(%s) = %v, want %v", desc.Digest, exists, want)
+		}
+	}
+}
+
+func (ti *testImages) checkSize() {
+	ti.t.Helper()
+	var want int64
+	for _, blob := range ti.s.storage.Map() {
+		want += int64(len(blob))
+	}
+	if got := ti.s.Size(); got != want {
+		ti.t.Errorf("Store.Size() = %v, want %v", got, want)
+	}
+}
+
+func TestStore_Delete(t *testing.T) {
+	ctx := context.Background()
+	s := New()
+	ti := &testImages{t: t, ctx: ctx, s: s}
+	manifest, layer := ti.pushManifest(nil, "layer")
+	shared, sharedLayer := ti.pushManifest(nil, "shared")
+	referrer, referrerLayer := ti.pushManifest(&manifest, "shared")
+	if err := s.Tag(ctx, manifest, "latest"); err != nil {
+		t.Fatal("Store.Tag() error =", err)
+	}
+	if err := s.Tag(ctx, shared, "shared"); err != nil {
+		t.Fatal("Store.Tag() error =", err)
+	}
+
+	// the referrers and the dangling blobs are deleted
+	if err := s.Delete(ctx, manifest); err != nil {
+		t.Fatal("Store.Delete() error =", err)
+	}
+	ti.checkExists(false, manifest, layer, referrer)
+	ti.checkExists(true, shared, sharedLayer, referrerLayer, ocispec.DescriptorEmptyJSON)
+	if _, err := s.Resolve(ctx, "latest"); !errors.Is(err, errdef.ErrNotFound) {
+		t.Errorf("Store.Resolve() error = %v, want %v", err, errdef.ErrNotFound)
+	}
+	if got, err := s.Predecessors(ctx, sharedLayer); err != nil || !reflect.DeepEqual(got, []ocispec.Descriptor{shared}) {
+		t.Errorf("Store.Predecessors() = %v, %v, want %v", got, err, []ocispec.Descriptor{shared})
+	}
+	ti.checkSize()
+
+	// only the target is deleted without auto GC
+	s.AutoGC = false
+	if err := s.Delete(ctx, shared); err != nil {
+		t.Fatal("Store.Delete() error =", err)
+	}
+	ti.checkExists(false, shared)
+	ti.checkExists(true, sharedLayer, ocispec.DescriptorEmptyJSON)
+	if err := s.Delete(ctx, shared); !errors.Is(err, errdef.ErrNotFound) {
+		t.Errorf("Store.Delete() error = %v, want %v", err, errdef.ErrNotFound)
+	}
+	ti.checkSize()
+}
+
+func TestStore_UntagAndTags(t *testing.T) {
+	ctx := context.Background()
+	s := New()
+	ti := &testImages{t: t, ctx: ctx, s: s}
+	manifest, _ := ti.pushManifest(nil, "layer")
+	for _, tag := range []string{"v2", "v1", "latest"} {
+		if err := s.Tag(ctx, manifest, tag); err != nil {
+			t.Fatal("Store.Tag() error =", err)
+		}
+	}
+	listTags := func(last string) []string {
+		var tags []string
+		if err := s.Tags(ctx, last, func(got []string) error {
+			tags = append(tags, got...)
+			return nil
+		}); err != nil {
+			t.Fatal("Store.Tags() error =", err)
+		}
+		return tags
+	}
+	if got, want := listTags(""), []string{"latest", "v1", "v2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Store.Tags() = %v, want %v", got, want)
+	}
+	if got, want := listTags("latest"), []string{"v1", "v2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Store.Tags() = %v, want %v", got, want)
+	}
+
+	if err := s.Untag(ctx, "v1"); err != nil {
+		t.Fatal("Store.Untag() error =", err)
+	}
+	if got, want := listTags(""), []string{"latest", "v2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Store.Tags() = %v, want %v", got, want)
+	}
+	ti.checkExists(true, manifest)
+	if err := s.Untag(ctx, "v1"); !errors.Is(err, errdef.ErrNotFound) {
+		t.Errorf("Store.Untag() error = %v, want %v", err, errdef.ErrNotFound)
+	}
+	if err := s.Untag(ctx, ""); !errors.Is(err, errdef.ErrMissingReference) {
+		t.Errorf("Store.Untag() error = %v, want %v", err, errdef.ErrMissingReference)
+	}
+}
+
+func TestStore_GC(t *testing.T) {
+	ctx := context.Background()
+	s := New()
+	ti := &testImages{t: t, ctx: ctx, s: s}
+	manifest, layer := ti.pushManifest(nil, "layer")
+	referrer, referrerLayer := ti.pushManifest(&manifest, "referrer")
+	untagged, untaggedLayer := ti.pushManifest(nil, "untagged")
+	blob := ti.push(ocispec.MediaTypeImageLayer, []byte("blob"))
+	if err := s.Tag(ctx, manifest, "latest"); err != nil {
+		t.Fatal("Store.Tag() error =", err)
+	}
+
+	if err := s.GC(ctx); err != nil {
+		t.Fatal("Store.GC() error =", err)
+	}
+	ti.checkExists(true, manifest, layer, referrer, referrerLayer, ocispec.DescriptorEmptyJSON)
+	ti.checkExists(false, untagged, untaggedLayer, blob)
+	ti.checkSize()
+}
+
+func TestStore_MaxSize(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("reject", func(t *testing.T) {
+		s := NewWithOptions(StoreOptions{MaxSize: 10})
+		ti := &testImages{t: t, ctx: ctx, s: s}
+		small := ti.push(ocispec.MediaTypeImageLayer, []byte("small"))
+		large := []byte("large content")
+		desc := content.NewDescriptorFromBytes(ocispec.MediaTypeImageLayer, large)
+		if err := s.Push(ctx, desc, bytes.NewReader(large)); !errors.Is(err, errdef.ErrSizeExceedsLimit) {
+			t.Errorf("Store.Push() error = %v, want %v", err, errdef.ErrSizeExceedsLimit)
+		}
+		ti.checkExists(true, small)
+		ti.checkExists(false, desc)
+		ti.checkSize()
+	})
+
+	t.Run("evict", func(t *testing.T) {
+		s := NewWithOptions(StoreOptions{MaxSize: 1 << 20, Evict: true})
+		ti := &testImages{t: t, ctx: ctx, s: s}
+		a, aLayer := ti.pushManifest(nil, "a")
+		b, bLayer := ti.pushManifest(nil, "b")
+		c, cLayer := ti.pushManifest(nil, "c")
+		for i, desc := range []ocispec.Descriptor{a, b, c} {
+			if err := s.Tag(ctx, desc, []string{"a", "b", "c"}[i]); err != nil {
+				t.Fatal("Store.Tag() error =", err)
+			}
+		}
+		// a becomes the most recently used
+		if _, err := s.Resolve(ctx, "a"); err != nil {
+			t.Fatal("Store.Resolve() error =", err)
+		}
+
+		// make room for exactly one more image
+		s.maxSize = s.Size() + aLayer.Size + a.Size - 1
+		d, dLayer := ti.pushManifest(nil, "d")
+		ti.checkExists(false, b, bLayer)
+		ti.checkExists(true, a, aLayer, c, cLayer, d, dLayer, ocispec.DescriptorEmptyJSON)
+		if _, err := s.Resolve(ctx, "b"); !errors.Is(err, errdef.ErrNotFound) {
+			t.Errorf("Store.Resolve() error = %v, want %v", err, errdef.ErrNotFound)
+		}
+		ti.checkSize()
+
+		// content larger than the store is rejected after evicting all
+		large := make([]byte, s.maxSize+1)
+		desc := content.NewDescriptorFromBytes(ocispec.MediaTypeImageLayer, large)
+		if err := s.Push(ctx, desc, bytes.NewReader(large)); !errors.Is(err, errdef.ErrSizeExceedsLimit) {
+			t.Errorf("Store.Push() error = %v, want %v", err, errdef.ErrSizeExceedsLimit)
+		}
+		ti.checkExists(false, a, c, d)
+		ti.checkSize()
+	})
+
+	t.Run("copy", func(t *testing.T) {
+		src := New()
+		srcImages := &testImages{t: t, ctx: ctx, s: src}
+		s := NewWithOptions(StoreOptions{MaxSize: 1 << 20, Evict: true})
+		ti := &testImages{t: t, ctx: ctx, s: s}
+		old, layer := ti.pushManifest(nil, "layer")
+		if err := s.Tag(ctx, old, "old"); err != nil {
+			t.Fatal("Store.Tag() error =", err)
+		}
+
+		// the new image shares the layer of the old image
+		srcImages.push(ocispec.MediaTypeEmptyJSON, ocispec.DescriptorEmptyJSON.Data)
+		srcImages.push(ocispec.MediaTypeImageLayer, []byte("layer"))
+		newLayer := srcImages.push(ocispec.MediaTypeImageLayer, bytes.Repeat([]byte("n"), 100))
+		manifestJSON, err := json.Marshal(ocispec.Manifest{
+			MediaType: ocispec.MediaTypeImageManifest,
+			Config:    ocispec.DescriptorEmptyJSON,
+			Layers:    []ocispec.Descriptor{layer, newLayer},
+		})
+		if err != nil {
+			t.Fatal("json.Marshal() error =", err)
+		}
+		manifest := srcImages.push(ocispec.MediaTypeImageManifest, manifestJSON)
+		if err := src.Tag(ctx, manifest, "new"); err != nil {
+			t.Fatal("Store.Tag() error =", err)
+		}
+
+		// copying the new image evicts the old image, but keeps the layer
+		// skipped by the copy
+		s.maxSize = s.Size() + newLayer.Size + manifest.Size - 1
+		if _, err := oras.Copy(ctx, src, "new", s, "new", oras.DefaultCopyOptions); err != nil {
+			t.Fatal("oras.Copy() error =", err)
+		}
+		ti.checkExists(false, old)
+		ti.checkExists(true, manifest, layer, newLayer, ocispec.DescriptorEmptyJSON)
+		ti.checkSize()
+	})
+}
+
 func equalDescriptorSet(actual []ocispec.Descriptor, expected []ocispec.Descriptor) bool {
 	if len(actual) != len(expected) {
 		return false
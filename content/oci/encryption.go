@@ -0,0 +1,64 @@
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oci
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/internal/aesgcm"
+)
+
+// contentSize returns the size of the content of a blob stored in size
+// bytes.
+func contentSize(size int64, encrypted bool) (int64, error) {
+	if !encrypted {
+		return size, nil
+	}
+	return aesgcm.PlaintextSize(size)
+}
+
+// storedSize returns the size of a blob storing content of the given size.
+func storedSize(size int64, encrypted bool) int64 {
+	if !encrypted {
+		return size
+	}
+	return aesgcm.CiphertextSize(size)
+}
+
+// verifyKey returns an error if none of the manifests can be decrypted from
+// the encrypted storage, which indicates that the storage is opened with a
+// wrong key. Otherwise, the manifests failing the decryption are left to be
+// skipped as corrupted.
+func verifyKey(ctx context.Context, storage content.Fetcher, manifests []ocispec.Descriptor) error {
+	var keyErr error
+	for _, desc := range manifests {
+		_, err := content.FetchAll(ctx, storage, desc)
+		if err == nil {
+			return nil
+		}
+		if errors.Is(err, aesgcm.ErrInvalidCiphertext) {
+			keyErr = err
+		}
+	}
+	if keyErr != nil {
+		return fmt.Errorf("failed to decrypt manifests, wrong encryption key: %w", keyErr)
+	}
+	return nil
+}
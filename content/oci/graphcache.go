@@ -0,0 +1,131 @@
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oci
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/internal/container/set"
+	"oras.land/oras-go/v2/internal/descriptor"
+	"oras.land/oras-go/v2/internal/graph"
+)
+
+// graphCacheFile is the name of the file persisting the graph of the
+// manifests referenced by `index.json`.
+const graphCacheFile = "index.graph.json"
+
+// graphCache is the persisted graph of the manifests referenced by an index.
+type graphCache struct {
+	// IndexDigest is the digest of the `index.json` file that the graph is
+	// built for.
+	IndexDigest digest.Digest `json:"indexDigest"`
+
+	// Nodes are the nodes of the graph.
+	Nodes []graph.Node `json:"nodes"`
+}
+
+// readGraphCache reads the graph cache file at the given path. It returns nil
+// if the file does not exist or is invalid, since the cache can always be
+// rebuilt.
+func readGraphCache(path string) *graphCache {
+	cacheJSON, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var cache graphCache
+	if err := json.Unmarshal(cacheJSON, &cache); err != nil {
+		return nil
+	}
+	return &cache
+}
+
+// writeGraphCache writes the graph built for the index with the given digest
+// to the graph cache file at the given path.
+func writeGraphCache(path string, indexDigest digest.Digest, g *graph.Memory) error {
+	cacheJSON, err := json.Marshal(graphCache{
+		IndexDigest: indexDigest,
+		Nodes:       g.Nodes(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal graph cache: %w", err)
+	}
+	return writeFileAtomic(path, cacheJSON)
+}
+
+// loadGraph indexes the graph of the manifests referenced by the index into
+// g with the help of the cache, and returns true if the cache is up to date
+// with the index.
+//   - If the cache is built for the index of the given digest, the graph is
+//     loaded from the cache without fetching any manifest.
+//   - Otherwise, the nodes reachable from the index are loaded from the cache
+//     if cached, and the uncached nodes are fetched and indexed. Since the
+//     nodes are content-addressable, the cached nodes never go stale.
+//
+// Corrupted or malformed manifests are skipped and recorded in invalid, if
+// invalid is not nil.
+func loadGraph(ctx context.Context, index *ocispec.Index, indexDigest digest.Digest, fetcher content.Fetcher, g *graph.Memory, invalid *invalidManifests, cache *graphCache) (bool, error) {
+	if cache == nil {
+		return false, indexGraph(ctx, index, fetcher, g, invalid)
+	}
+	if cache.IndexDigest == indexDigest {
+		for _, node := range cache.Nodes {
+			g.Add(node.Descriptor, node.Successors)
+		}
+		return true, nil
+	}
+
+	cached := make(map[descriptor.Descriptor]graph.Node, len(cache.Nodes))
+	for _, node := range cache.Nodes {
+		cached[descriptor.FromOCI(node.Descriptor)] = node
+	}
+	visited := set.New[descriptor.Descriptor]()
+	var stack []ocispec.Descriptor
+	for _, desc := range index.Manifests {
+		stack = append(stack, descriptor.Plain(desc))
+	}
+	for len(stack) > 0 {
+		desc := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		key := descriptor.FromOCI(desc)
+		if visited.Contains(key) {
+			continue
+		}
+		visited.Add(key)
+
+		node, ok := cached[key]
+		if !ok {
+			if err := g.IndexAllSkip(ctx, fetcher, desc, invalid.skip); err != nil {
+				return false, err
+			}
+			continue
+		}
+		g.Add(node.Descriptor, node.Successors)
+		for _, successor := range node.Successors {
+			stack = append(stack, ocispec.Descriptor{
+				MediaType: successor.MediaType,
+				Digest:    successor.Digest,
+				Size:      successor.Size,
+			})
+		}
+	}
+	return false, nil
+}
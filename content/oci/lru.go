@@ -0,0 +1,341 @@
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oci
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/internal/container/set"
+	"oras.land/oras-go/v2/internal/descriptor"
+	"oras.land/oras-go/v2/registry"
+)
+
+// accessLogFile is the name of the file persisting the access times of the
+// roots when the size of the store is bounded.
+const accessLogFile = "index.access.json"
+
+// checkRetention is the duration for which the content found existing is
+// protected from eviction.
+const checkRetention = time.Hour
+
+// evictionCandidate is a root which can be evicted.
+type evictionCandidate struct {
+	desc       ocispec.Descriptor
+	accessedAt time.Time
+}
+
+// blobsSize returns the total size of the blobs in fsys.
+func blobsSize(fsys fs.FS) (int64, error) {
+	blobs, err := listBlobs(fsys)
+	if err != nil {
+		return 0, err
+	}
+	var size int64
+	for _, dgst := range blobs {
+		blob, err := blobPath(dgst)
+		if err != nil {
+			return 0, err
+		}
+		fi, err := fs.Stat(fsys, blob)
+		if err != nil {
+			return 0, fmt.Errorf("failed to stat blob %s: %w", dgst, err)
+		}
+		size += fi.Size()
+	}
+	return size, nil
+}
+
+// Size returns the total size in bytes of the blobs in the store.
+// It is tracked only if StoreOptions.MaxSize is positive, and 0 is returned
+// otherwise.
+func (s *Store) Size() int64 {
+	return s.size.Load()
+}
+
+// touch records the access to the manifest, if the size of the store is
+// bounded.
+func (s *Store) touch(desc ocispec.Descriptor) {
+	if s.maxSize <= 0 || !descriptor.IsManifest(desc) {
+		return
+	}
+	s.access.touch(desc.Digest, time.Now())
+}
+
+// check records that the content is found existing, so that it is not evicted
+// while an in-progress copy relies on it, if the size of the store is
+// bounded.
+func (s *Store) check(desc ocispec.Descriptor) {
+	if s.maxSize <= 0 {
+		return
+	}
+	s.access.check(desc.Digest, time.Now())
+}
+
+// evict evicts the least recently used roots and their exclusive content
+// until the total size of the blobs is within the limit, or there is nothing
+// left to evict. The content described by keep is not evicted.
+func (s *Store) evict(ctx context.Context, keep ocispec.Descriptor) error {
+	if s.maxSize <= 0 || s.size.Load() <= s.maxSize {
+		return nil
+	}
+
+	s.sync.Lock()
+	defer s.sync.Unlock()
+
+	protected, err := s.protectedDigests(ctx)
+	if err != nil {
+		return err
+	}
+	protected.Add(keep.Digest)
+	for dgst := range s.access.checkedSince(time.Now().Add(-checkRetention)) {
+		protected.Add(dgst)
+	}
+	candidates, err := s.evictionCandidates(ctx, protected)
+	if err != nil {
+		return err
+	}
+	for _, candidate := range candidates {
+		if s.size.Load() <= s.maxSize {
+			break
+		}
+		exists, err := s.storage.Exists(ctx, candidate.desc)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			// already evicted as a referrer of another root
+			continue
+		}
+		if err := s.evictRoot(ctx, candidate.desc, protected); err != nil {
+			return err
+		}
+	}
+	return s.reloadNested(ctx)
+}
+
+// protectedDigests returns the digests of the manifests tagged by the pinned
+// tags, and the digests of their referrers. The caller must hold s.sync.
+func (s *Store) protectedDigests(ctx context.Context) (set.Set[digest.Digest], error) {
+	protected := set.New[digest.Digest]()
+	refMap := s.tagResolver.Map()
+	for tag := range s.pinnedTags {
+		desc, ok := refMap[tag]
+		if !ok {
+			continue
+		}
+		protected.Add(desc.Digest)
+		if !descriptor.IsManifest(desc) {
+			continue
+		}
+		referrers, err := registry.Referrers(ctx, &unsafeStore{s}, desc, "")
+		if err != nil {
+			return nil, err
+		}
+		for _, referrer := range referrers {
+			protected.Add(referrer.Digest)
+		}
+	}
+	return protected, nil
+}
+
+// evictionCandidates returns the roots which can be evicted, in the order of
+// their last access. A root is a tagged descriptor or an untagged manifest
+// which is not referenced by any index in the store. The caller must hold
+// s.sync.
+func (s *Store) evictionCandidates(ctx context.Context, protected set.Set[digest.Digest]) ([]evictionCandidate, error) {
+	visited := set.New[digest.Digest]()
+	var candidates []evictionCandidate
+	for _, desc := range s.tagResolver.Map() {
+		if visited.Contains(desc.Digest) || protected.Contains(desc.Digest) {
+			continue
+		}
+		visited.Add(desc.Digest)
+
+		predecessors, err := s.graph.Predecessors(ctx, desc)
+		if err != nil {
+			return nil, err
+		}
+		if slices.ContainsFunc(predecessors, descriptor.IsIndex) {
+			// evicted along with the index
+			continue
+		}
+		accessedAt, ok := s.access.accessedAt(desc.Digest)
+		if !ok {
+			// fall back to the time the root is pushed
+			blob, err := blobPath(desc.Digest)
+			if err != nil {
+				return nil, err
+			}
+			fi, err := os.Stat(filepath.Join(s.root, filepath.FromSlash(blob)))
+			if err != nil {
+				if os.IsNotExist(err) {
+					continue
+				}
+				return nil, err
+			}
+			accessedAt = fi.ModTime()
+		}
+		candidates = append(candidates, evictionCandidate{
+			desc:       descriptor.Plain(desc),
+			accessedAt: accessedAt,
+		})
+	}
+	slices.SortFunc(candidates, func(a, b evictionCandidate) int {
+		if c := a.accessedAt.Compare(b.accessedAt); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.desc.Digest, b.desc.Digest)
+	})
+	return candidates, nil
+}
+
+// evictRoot deletes the root along with its referrers and its exclusive
+// content, i.e. the successors that become dangling. Tagged and protected
+// content is not deleted. The caller must hold s.sync.
+func (s *Store) evictRoot(ctx context.Context, root ocispec.Descriptor, protected set.Set[digest.Digest]) error {
+	deleteQueue := []ocispec.Descriptor{root}
+	for len(deleteQueue) > 0 {
+		head := deleteQueue[0]
+		deleteQueue = deleteQueue[1:]
+
+		if descriptor.IsManifest(head) {
+			referrers, err := registry.Referrers(ctx, &unsafeStore{s}, head, "")
+			if err != nil {
+				return err
+			}
+			for _, referrer := range referrers {
+				if !protected.Contains(referrer.Digest) {
+					deleteQueue = append(deleteQueue, referrer)
+				}
+			}
+		}
+
+		danglings, err := s.delete(ctx, head)
+		if err != nil {
+			return fmt.Errorf("failed to evict %s: %w", head.Digest, err)
+		}
+		for _, d := range danglings {
+			if !s.isTagged(d) && !protected.Contains(d.Digest) {
+				deleteQueue = append(deleteQueue, d)
+			}
+		}
+	}
+	return nil
+}
+
+// accessLog records the access to the content of the store. It is safe for
+// concurrent use.
+type accessLog struct {
+	lock sync.Mutex
+	// accessed are the last access times of the roots.
+	accessed map[digest.Digest]time.Time
+	// checked are the last times the content is found existing.
+	checked map[digest.Digest]time.Time
+}
+
+// newAccessLog creates a new accessLog.
+func newAccessLog() *accessLog {
+	return &accessLog{
+		accessed: make(map[digest.Digest]time.Time),
+		checked:  make(map[digest.Digest]time.Time),
+	}
+}
+
+// touch records the access to the root of the given digest.
+func (l *accessLog) touch(dgst digest.Digest, now time.Time) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.accessed[dgst] = now
+}
+
+// accessedAt returns the last access time of the root of the given digest.
+func (l *accessLog) accessedAt(dgst digest.Digest) (time.Time, bool) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	t, ok := l.accessed[dgst]
+	return t, ok
+}
+
+// check records that the content of the given digest is found existing.
+func (l *accessLog) check(dgst digest.Digest, now time.Time) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.checked[dgst] = now
+}
+
+// checkedSince returns the digests of the content found existing since the
+// given time, and forgets the content checked earlier.
+func (l *accessLog) checkedSince(since time.Time) set.Set[digest.Digest] {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	checked := set.New[digest.Digest]()
+	for dgst, t := range l.checked {
+		if t.Before(since) {
+			delete(l.checked, dgst)
+			continue
+		}
+		checked.Add(dgst)
+	}
+	return checked
+}
+
+// load reads the access times of the roots from the file at the given path.
+// An invalid or missing file is ignored, as the access times only affect the
+// order of eviction.
+func (l *accessLog) load(path string) {
+	accessJSON, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+	var accessed map[digest.Digest]time.Time
+	if err := json.Unmarshal(accessJSON, &accessed); err != nil {
+		return
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	maps.Copy(l.accessed, accessed)
+}
+
+// save writes the access times of the given roots to the file at the given
+// path.
+func (l *accessLog) save(path string, roots set.Set[digest.Digest]) error {
+	l.lock.Lock()
+	accessed := make(map[digest.Digest]time.Time)
+	for dgst, t := range l.accessed {
+		if roots.Contains(dgst) {
+			accessed[dgst] = t
+		}
+	}
+	l.lock.Unlock()
+
+	accessJSON, err := json.Marshal(accessed)
+	if err != nil {
+		return fmt.Errorf("failed to marshal access log: %w", err)
+	}
+	return writeFileAtomic(path, accessJSON)
+}
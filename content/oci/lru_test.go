@@ -0,0 +1,254 @@
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oci
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/errdef"
+)
+
+// lruTestStore wraps a Store for testing the LRU eviction.
+type lruTestStore struct {
+	*Store
+	t   *testing.T
+	ctx context.Context
+}
+
+func (s *lruTestStore) push(mediaType string, blob []byte) ocispec.Descriptor {
+	s.t.Helper()
+	desc := content.NewDescriptorFromBytes(mediaType, blob)
+	if err := s.Push(s.ctx, desc, bytes.NewReader(blob)); err != nil && !errors.Is(err, errdef.ErrAlreadyExists) {
+		s.t.Fatal("Store.Push() error =", err)
+	}
+	return desc
+}
+
+func (s *lruTestStore) pushImage(name string, subject *ocispec.Descriptor) (ocispec.Descriptor, ocispec.Descriptor) {
+	s.t.Helper()
+	s.push(ocispec.MediaTypeEmptyJSON, ocispec.DescriptorEmptyJSON.Data)
+	layer := s.push(ocispec.MediaTypeImageLayer, bytes.Repeat([]byte(name), 100))
+	manifestJSON, err := json.Marshal(ocispec.Manifest{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Config:    ocispec.DescriptorEmptyJSON,
+		Layers:    []ocispec.Descriptor{layer},
+		Subject:   subject,
+	})
+	if err != nil {
+		s.t.Fatal("json.Marshal() error =", err)
+	}
+	return s.push(ocispec.MediaTypeImageManifest, manifestJSON), layer
+}
+
+func (s *lruTestStore) tag(desc ocispec.Descriptor, reference string, accessedAt time.Time) {
+	s.t.Helper()
+	if err := s.Tag(s.ctx, desc, reference); err != nil {
+		s.t.Fatal("Store.Tag() error =", err)
+	}
+	s.access.touch(desc.Digest, accessedAt)
+	if err := s.SaveIndex(); err != nil {
+		s.t.Fatal("Store.SaveIndex() error =", err)
+	}
+}
+
+func (s *lruTestStore) checkExists(desc ocispec.Descriptor, want bool) {
+	s.t.Helper()
+	exists, err := s.Exists(s.ctx, desc)
+	if err != nil {
+		s.t.Fatal("Store.Exists() error =", err)
+	}
+	if exists != want {
+		s.t.Errorf("Store.Exists(%s) = %v, want %v", desc.Digest, exists, want)
+	}
+}
+
+func (s *lruTestStore) checkSize() {
+	s.t.Helper()
+	want, err := blobsSize(os.DirFS(s.root))
+	if err != nil {
+		s.t.Fatal("blobsSize() error =", err)
+	}
+	if got := s.Size(); got != want {
+		s.t.Errorf("Store.Size() = %v, want %v", got, want)
+	}
+}
+
+func newLRUTestStore(t *testing.T, root string, opts StoreOptions) *lruTestStore {
+	t.Helper()
+	ctx := context.Background()
+	s, err := NewWithOptions(ctx, root, opts)
+	if err != nil {
+		t.Fatal("NewWithOptions() error =", err)
+	}
+	return &lruTestStore{
+		Store: s,
+		t:     t,
+		ctx:   ctx,
+	}
+}
+
+func TestStore_MaxSize(t *testing.T) {
+	tempDir := t.TempDir()
+	opts := StoreOptions{
+		MaxSize:    1 << 20,
+		PinnedTags: []string{"a"},
+	}
+	s := newLRUTestStore(t, tempDir, opts)
+	now := time.Now()
+	a, aLayer := s.pushImage("a", nil)
+	s.tag(a, "a", now.Add(-3*time.Hour))
+	b, bLayer := s.pushImage("b", nil)
+	s.tag(b, "b", now.Add(-2*time.Hour))
+	c, cLayer := s.pushImage("c", nil)
+	s.tag(c, "c", now.Add(-1*time.Hour))
+	s.checkSize()
+
+	// b becomes the most recently used on resolve
+	if _, err := s.Resolve(s.ctx, "b"); err != nil {
+		t.Fatal("Store.Resolve() error =", err)
+	}
+	if err := s.SaveIndex(); err != nil {
+		t.Fatal("Store.SaveIndex() error =", err)
+	}
+	blobs, err := listBlobs(os.DirFS(tempDir))
+	if err != nil {
+		t.Fatal("listBlobs() error =", err)
+	}
+	for _, dgst := range blobs {
+		// access times are not recorded on the blobs
+		fi, err := os.Stat(filepath.Join(tempDir, filepath.FromSlash(mustBlobPath(t, dgst))))
+		if err != nil {
+			t.Fatal("os.Stat() error =", err)
+		}
+		if fi.ModTime().Before(now.Add(-time.Minute)) {
+			t.Errorf("modification time of %s = %v, want after %v", dgst, fi.ModTime(), now.Add(-time.Minute))
+		}
+	}
+
+	// reopen the store with a smaller size so that pushing another image
+	// evicts one image
+	opts.MaxSize = s.Size() + (aLayer.Size + a.Size) - 1
+	s = newLRUTestStore(t, tempDir, opts)
+	d, dLayer := s.pushImage("d", nil)
+	if err := s.Tag(s.ctx, d, "d"); err != nil {
+		t.Fatal("Store.Tag() error =", err)
+	}
+	for _, desc := range []ocispec.Descriptor{a, aLayer, b, bLayer, d, dLayer, ocispec.DescriptorEmptyJSON} {
+		s.checkExists(desc, true)
+	}
+	for _, desc := range []ocispec.Descriptor{c, cLayer} {
+		s.checkExists(desc, false)
+	}
+	if _, err := s.Resolve(s.ctx, "c"); !errors.Is(err, errdef.ErrNotFound) {
+		t.Errorf("Store.Resolve(c) error = %v, want %v", err, errdef.ErrNotFound)
+	}
+	s.checkSize()
+	if got := s.Size(); got > opts.MaxSize {
+		t.Errorf("Store.Size() = %v, want <= %v", got, opts.MaxSize)
+	}
+
+	// pinned tags are never evicted
+	opts.MaxSize = 1
+	s = newLRUTestStore(t, tempDir, opts)
+	s.push(ocispec.MediaTypeImageLayer, []byte("e"))
+	for _, desc := range []ocispec.Descriptor{a, aLayer, ocispec.DescriptorEmptyJSON} {
+		s.checkExists(desc, true)
+	}
+	for _, desc := range []ocispec.Descriptor{b, bLayer, d, dLayer} {
+		s.checkExists(desc, false)
+	}
+	if _, err := s.Resolve(s.ctx, "a"); err != nil {
+		t.Errorf("Store.Resolve(a) error = %v", err)
+	}
+	s.checkSize()
+}
+
+func TestStore_MaxSize_IndexAndReferrers(t *testing.T) {
+	tempDir := t.TempDir()
+	s := newLRUTestStore(t, tempDir, StoreOptions{MaxSize: 1 << 20})
+	manifest, layer := s.pushImage("m", nil)
+	referrer, referrerLayer := s.pushImage("r", &manifest)
+	indexJSON, err := json.Marshal(ocispec.Index{
+		MediaType: ocispec.MediaTypeImageIndex,
+		Manifests: []ocispec.Descriptor{manifest},
+	})
+	if err != nil {
+		t.Fatal("json.Marshal() error =", err)
+	}
+	index := s.push(ocispec.MediaTypeImageIndex, indexJSON)
+	s.tag(index, "index", time.Now().Add(-time.Hour))
+
+	// the index is evicted with its children and the referrers of the
+	// children, while the blob not reachable from any root is retained
+	s = newLRUTestStore(t, tempDir, StoreOptions{MaxSize: 1})
+	blob := s.push(ocispec.MediaTypeImageLayer, []byte("blob"))
+	for _, desc := range []ocispec.Descriptor{index, manifest, layer, referrer, referrerLayer, ocispec.DescriptorEmptyJSON} {
+		s.checkExists(desc, false)
+	}
+	s.checkExists(blob, true)
+	if got, want := s.Size(), blob.Size; got != want {
+		t.Errorf("Store.Size() = %v, want %v", got, want)
+	}
+}
+
+func TestStore_MaxSize_CheckedContent(t *testing.T) {
+	tempDir := t.TempDir()
+	s := newLRUTestStore(t, tempDir, StoreOptions{MaxSize: 1 << 20})
+	a, aLayer := s.pushImage("a", nil)
+	s.tag(a, "a", time.Now().Add(-time.Hour))
+
+	// a copy finds the config and the layer of a existing and skips them,
+	// while pushing another layer evicts a
+	s = newLRUTestStore(t, tempDir, StoreOptions{MaxSize: s.Size()})
+	s.checkExists(ocispec.DescriptorEmptyJSON, true)
+	s.checkExists(aLayer, true)
+	bLayer := s.push(ocispec.MediaTypeImageLayer, bytes.Repeat([]byte("b"), 100))
+	s.checkExists(a, false)
+	s.checkExists(aLayer, true)
+
+	// the copy completes with the skipped layer
+	manifestJSON, err := json.Marshal(ocispec.Manifest{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Config:    ocispec.DescriptorEmptyJSON,
+		Layers:    []ocispec.Descriptor{aLayer, bLayer},
+	})
+	if err != nil {
+		t.Fatal("json.Marshal() error =", err)
+	}
+	b := s.push(ocispec.MediaTypeImageManifest, manifestJSON)
+	if err := s.Tag(s.ctx, b, "b"); err != nil {
+		t.Fatal("Store.Tag() error =", err)
+	}
+	for _, desc := range []ocispec.Descriptor{b, aLayer, bLayer, ocispec.DescriptorEmptyJSON} {
+		s.checkExists(desc, true)
+	}
+	s.checkSize()
+
+	// the checked content is no longer protected after the retention
+	s.access.check(aLayer.Digest, time.Now().Add(-2*checkRetention))
+	if got := s.access.checkedSince(time.Now().Add(-checkRetention)); got.Contains(aLayer.Digest) {
+		t.Errorf("accessLog.checkedSince() = %v, want no %v", got, aLayer.Digest)
+	}
+}
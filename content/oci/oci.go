@@ -23,11 +23,17 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"maps"
+	"math/rand/v2"
 	"os"
 	"path"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/opencontainers/go-digest"
 	specs "github.com/opencontainers/image-spec/specs-go"
@@ -36,12 +42,22 @@ import (
 	"oras.land/oras-go/v2/errdef"
 	"oras.land/oras-go/v2/internal/container/set"
 	"oras.land/oras-go/v2/internal/descriptor"
+	"oras.land/oras-go/v2/internal/fs/flock"
 	"oras.land/oras-go/v2/internal/graph"
 	"oras.land/oras-go/v2/internal/manifestutil"
 	"oras.land/oras-go/v2/internal/resolver"
 	"oras.land/oras-go/v2/registry"
 )
 
+// indexLockFile is the name of the lock file guarding `index.json` across
+// processes.
+const indexLockFile = "index.json.lock"
+
+// gcGracePeriod is the minimum age of the unreferenced blobs collected by GC
+// if the store is shared by multiple processes, so that the blobs being pushed
+// by other processes are not collected before they are referenced.
+const gcGracePeriod = time.Hour
+
 // Store implements `oras.Target`, and represents a content store
 // based on file system with the OCI-Image layout.
 // Reference: https://github.com/opencontainers/image-spec/blob/v1.1.1/image-layout.md
@@ -66,10 +82,20 @@ type Store struct {
 
 	root        string
 	indexPath   string
+	lockPath    string
 	index       *ocispec.Index
-	storage     *Storage
+	storage     blobStorage
 	tagResolver *resolver.Memory
 	graph       *graph.Memory
+	// nestedResolver resolves the digests and the reference names of the
+	// manifests nested in the image indexes referenced by `index.json`.
+	nestedResolver *resolver.Memory
+	// invalid records the corrupted or malformed manifests skipped on
+	// loading the index, which are quarantined by Repair. It is nil unless
+	// StoreOptions.SkipInvalidManifests is set.
+	invalid *invalidManifests
+	// encrypted indicates whether the blobs are encrypted at rest.
+	encrypted bool
 
 	// sync ensures that most operations can be done concurrently, while Delete
 	// has the exclusive access to Store if a delete operation is underway.
@@ -78,6 +104,125 @@ type Store struct {
 	sync sync.RWMutex
 	// indexLock ensures that only one go-routine is writing to the index.
 	indexLock sync.Mutex
+
+	// multiProcess indicates whether the store is shared by multiple
+	// processes.
+	multiProcess bool
+	// syncedEntries are the entries of `index.json` at the last
+	// synchronization with the file system, keyed by indexEntryKey. It is used
+	// only if multiProcess is true.
+	syncedEntries map[string]ocispec.Descriptor
+	// graphCachePath is the path to the graph cache file. It is empty if the
+	// graph cache is disabled.
+	graphCachePath string
+	// indexDigest is the digest of `index.json` last read or written. It is
+	// the key of the graph cache, and is used only if graphCachePath is set.
+	indexDigest digest.Digest
+
+	// maxSize is the maximum total size of the blobs. It is 0 if the size is
+	// unbounded.
+	maxSize int64
+	// pinnedTags are the tags never evicted.
+	pinnedTags set.Set[string]
+	// access records the access to the content for the eviction, and is
+	// persisted to accessPath on saving the index.
+	access     *accessLog
+	accessPath string
+	// size is the total size of the blobs, tracked only if maxSize is
+	// positive.
+	size atomic.Int64
+}
+
+// StoreOptions contains parameters for [NewWithOptions].
+type StoreOptions struct {
+	// MultiProcess makes the store safe to be shared with other processes,
+	// which open the same directory with MultiProcess set to true.
+	//   - Updates of `index.json` are guarded by an advisory lock on the
+	//     `index.json.lock` file in the root directory, and `index.json` is
+	//     written to a temporary file which is then renamed to `index.json`,
+	//     so that readers never see a partially written index.
+	//   - On saving the index, `index.json` is re-read and the local changes
+	//     since the last save are merged into it, while the changes made by
+	//     other processes are imported to the store. If the same tag is
+	//     changed by multiple processes, the last save wins.
+	//   - Delete() and GC() hold the lock and merge the index before deleting
+	//     blobs, so that the blobs referenced by other processes are kept.
+	//     GC() does not collect the unreferenced blobs modified within the
+	//     last hour, as they may be pushed by other processes and not yet
+	//     referenced by `index.json`.
+	// MultiProcess is not supported on platforms without file locks, where
+	// NewWithOptions returns an error wrapping errors.ErrUnsupported.
+	MultiProcess bool
+
+	// GraphCache persists the graph of the manifests referenced by
+	// `index.json` to the `index.graph.json` file in the root directory, so
+	// that the store can be opened without fetching every manifest.
+	//   - The cache is keyed by the digest of `index.json`. If `index.json` is
+	//     unchanged since the cache is written, the graph is loaded from the
+	//     cache as is.
+	//   - Otherwise, only the manifests missing from the cache are fetched,
+	//     and the cache is rewritten.
+	//   - The cache is also rewritten on SaveIndex and GC, but not on the
+	//     automatic saves of `index.json`, so that pushes and tags do not
+	//     rewrite the whole cache. A cache outdated by automatic saves is
+	//     updated on the next open.
+	// A missing or corrupted cache is rebuilt instead of failing the store,
+	// and failing to write the cache is ignored.
+	GraphCache bool
+
+	// MaxSize bounds the total size in bytes of the blobs in the store, which
+	// makes the store a cache with least-recently-used (LRU) eviction.
+	//   - When pushing makes the store exceed MaxSize, the least recently
+	//     used roots are evicted along with their referrers and the content
+	//     exclusively referenced by them, until the store is within MaxSize.
+	//     A root is a tagged descriptor, or an untagged manifest not
+	//     referenced by any index in the store.
+	//   - The access to a root is recorded on Fetch(), Resolve() and Tag(),
+	//     and saved to the `index.access.json` file in the root directory
+	//     along with `index.json`, so that the order of eviction is retained
+	//     across reopening. The roots never accessed are ordered by the time
+	//     they are pushed.
+	//   - Content found existing by Exists() or Push() is not evicted for an
+	//     hour, so that the content skipped by an in-progress copy is kept.
+	//   - Untagged content not reachable from any root, such as the blobs
+	//     pushed before their manifest, is never evicted. Use GC() to remove
+	//     it.
+	// The store may exceed MaxSize if all the roots are pinned or recently
+	// found existing, or if the content not yet reachable from any root
+	// exceeds MaxSize.
+	// If zero or negative, the size is unbounded.
+	MaxSize int64
+
+	// PinnedTags are the tags never evicted when MaxSize is positive. The
+	// content reachable from the pinned tags and their referrers are not
+	// evicted either.
+	PinnedTags []string
+
+	// EncryptionKey encrypts the blobs at rest with
+	// [content.EncryptedStorage], so that the layout can be shared without
+	// exposing the content. `index.json` and `oci-layout` are not encrypted.
+	//   - The key must be 16, 24, or 32 bytes long.
+	//   - The blobs are still addressed by the digests of their plaintext,
+	//     and the fetched content is verified against its descriptor.
+	//   - MaxSize bounds the total size of the encrypted blobs.
+	// The store must always be opened with the same key, and fails to open if
+	// none of the manifests referenced by `index.json` can be decrypted.
+	// Use [NewFromFSWithOptions] to open the layout read-only. If nil, the
+	// blobs are stored in plaintext.
+	EncryptionKey []byte
+
+	// SkipInvalidManifests opens the store even if some manifests referenced
+	// by `index.json` are corrupted or malformed. The invalid manifests are
+	// skipped when building the graph, reported by [Store.Verify], and can
+	// be quarantined by [Store.Repair]. If false, the store fails to open
+	// with such manifests.
+	SkipInvalidManifests bool
+}
+
+// blobStorage is the storage of the content in the blobs of the layout.
+type blobStorage interface {
+	content.Storage
+	content.Deleter
 }
 
 // New creates a new OCI store with context.Background().
@@ -87,6 +232,11 @@ func New(root string) (*Store, error) {
 
 // NewWithContext creates a new OCI store.
 func NewWithContext(ctx context.Context, root string) (*Store, error) {
+	return NewWithOptions(ctx, root, StoreOptions{})
+}
+
+// NewWithOptions creates a new OCI store with the given options.
+func NewWithOptions(ctx context.Context, root string, opts StoreOptions) (*Store, error) {
 	rootAbs, err := filepath.Abs(root)
 	if err != nil {
 		return nil, fmt.Errorf("failed to resolve absolute path for %s: %w", root, err)
@@ -97,24 +247,63 @@ func NewWithContext(ctx context.Context, root string) (*Store, error) {
 	}
 
 	store := &Store{
-		AutoSaveIndex: true,
-		AutoGC:        true,
-		root:          rootAbs,
-		indexPath:     filepath.Join(rootAbs, ocispec.ImageIndexFile),
-		storage:       storage,
-		tagResolver:   resolver.NewMemory(),
-		graph:         graph.NewMemory(),
+		AutoSaveIndex:  true,
+		AutoGC:         true,
+		root:           rootAbs,
+		indexPath:      filepath.Join(rootAbs, ocispec.ImageIndexFile),
+		lockPath:       filepath.Join(rootAbs, indexLockFile),
+		storage:        storage,
+		tagResolver:    resolver.NewMemory(),
+		graph:          graph.NewMemory(),
+		nestedResolver: resolver.NewMemory(),
+		multiProcess:   opts.MultiProcess,
+	}
+	if opts.SkipInvalidManifests {
+		store.invalid = newInvalidManifests()
+	}
+	if opts.EncryptionKey != nil {
+		if store.storage, err = content.NewEncryptedStorage(storage, opts.EncryptionKey); err != nil {
+			return nil, fmt.Errorf("failed to create storage: %w", err)
+		}
+		store.encrypted = true
+	}
+	if opts.GraphCache {
+		store.graphCachePath = filepath.Join(rootAbs, graphCacheFile)
+	}
+	if opts.MaxSize > 0 {
+		store.maxSize = opts.MaxSize
+		store.access = newAccessLog()
+		store.accessPath = filepath.Join(rootAbs, accessLogFile)
+		store.access.load(store.accessPath)
+		store.pinnedTags = set.New[string]()
+		for _, tag := range opts.PinnedTags {
+			store.pinnedTags.Add(tag)
+		}
 	}
 
 	if err := ensureDir(filepath.Join(rootAbs, ocispec.ImageBlobsDir)); err != nil {
 		return nil, err
 	}
+	if store.multiProcess {
+		lock, err := flock.Acquire(store.lockPath)
+		if err != nil {
+			return nil, err
+		}
+		defer lock.Release()
+	}
 	if err := store.ensureOCILayoutFile(); err != nil {
 		return nil, fmt.Errorf("invalid OCI Image Layout: %w", err)
 	}
 	if err := store.loadIndexFile(ctx); err != nil {
 		return nil, fmt.Errorf("invalid OCI Image Index: %w", err)
 	}
+	if store.maxSize > 0 {
+		size, err := blobsSize(os.DirFS(rootAbs))
+		if err != nil {
+			return nil, err
+		}
+		store.size.Store(size)
+	}
 
 	return store, nil
 }
@@ -126,21 +315,50 @@ func (s *Store) Fetch(ctx context.Context, target ocispec.Descriptor) (io.ReadCl
 	s.sync.RLock()
 	defer s.sync.RUnlock()
 
-	return s.storage.Fetch(ctx, target)
+	rc, err := s.storage.Fetch(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+	s.touch(target)
+	return rc, nil
 }
 
 // Push pushes the content, matching the expected descriptor.
+// If StoreOptions.MaxSize is positive, the least recently used content may be
+// evicted after the push.
 func (s *Store) Push(ctx context.Context, expected ocispec.Descriptor, reader io.Reader) error {
+	if err := s.push(ctx, expected, reader); err != nil {
+		if errors.Is(err, errdef.ErrAlreadyExists) {
+			s.check(expected)
+		}
+		return err
+	}
+	if err := s.evict(ctx, expected); err != nil {
+		return fmt.Errorf("failed to evict content: %w", err)
+	}
+	return nil
+}
+
+// push pushes the content, matching the expected descriptor.
+func (s *Store) push(ctx context.Context, expected ocispec.Descriptor, reader io.Reader) error {
 	s.sync.RLock()
 	defer s.sync.RUnlock()
 
 	if err := s.storage.Push(ctx, expected, reader); err != nil {
 		return err
 	}
+	if s.maxSize > 0 {
+		s.size.Add(storedSize(expected.Size, s.encrypted))
+	}
 	if err := s.graph.Index(ctx, s.storage, expected); err != nil {
 		return err
 	}
 	if descriptor.IsManifest(expected) {
+		// tag the nested manifests before saving the index, so that they
+		// are not added as entries of the index
+		if err := tagNested(ctx, []ocispec.Descriptor{expected}, s.storage, s.nestedResolver, s.invalid); err != nil {
+			return err
+		}
 		// tag by digest
 		return s.tag(ctx, expected, expected.Digest.String())
 	}
@@ -152,7 +370,14 @@ func (s *Store) Exists(ctx context.Context, target ocispec.Descriptor) (bool, er
 	s.sync.RLock()
 	defer s.sync.RUnlock()
 
-	return s.storage.Exists(ctx, target)
+	exists, err := s.storage.Exists(ctx, target)
+	if err != nil {
+		return false, err
+	}
+	if exists {
+		s.check(target)
+	}
+	return exists, nil
 }
 
 // Delete deletes the content matching the descriptor from the store. Delete may
@@ -162,15 +387,46 @@ func (s *Store) Exists(ctx context.Context, target ocispec.Descriptor) (bool, er
 //     remove the dangling blobs caused by the current delete.
 //   - If s.AutoDeleteReferrers is set to true, Delete will recursively remove
 //     the referrers of the manifests being deleted.
+//
+// If the store is opened with StoreOptions.MultiProcess, Delete holds the file
+// lock and merges the index before deleting, so that the blobs referenced by
+// the manifests of other processes are not deleted as dangling blobs.
 func (s *Store) Delete(ctx context.Context, target ocispec.Descriptor) error {
 	s.sync.Lock()
 	defer s.sync.Unlock()
 
+	if s.multiProcess {
+		lock, err := flock.Acquire(s.lockPath)
+		if err != nil {
+			return err
+		}
+		defer lock.Release()
+		s.indexLock.Lock()
+		err = s.mergeIndex()
+		s.indexLock.Unlock()
+		if err != nil {
+			return fmt.Errorf("unable to merge index: %w", err)
+		}
+	}
+
+	danglings := set.New[digest.Digest]()
 	deleteQueue := []ocispec.Descriptor{target}
 	for len(deleteQueue) > 0 {
 		head := deleteQueue[0]
 		deleteQueue = deleteQueue[1:]
 
+		// a dangling node may have been referenced again by a node deleted
+		// earlier or a merged index
+		if danglings.Contains(head.Digest) {
+			predecessors, err := s.graph.Predecessors(ctx, head)
+			if err != nil {
+				return err
+			}
+			if len(predecessors) > 0 {
+				continue
+			}
+		}
+
 		// get referrers if applicable
 		if s.AutoGC && descriptor.IsManifest(head) {
 			referrers, err := registry.Referrers(ctx, &unsafeStore{s}, head, "")
@@ -181,21 +437,22 @@ func (s *Store) Delete(ctx context.Context, target ocispec.Descriptor) error {
 		}
 
 		// delete the head of queue
-		danglings, err := s.delete(ctx, head)
+		nodes, err := s.delete(ctx, head)
 		if err != nil {
 			return err
 		}
 		if s.AutoGC {
-			for _, d := range danglings {
+			for _, d := range nodes {
 				// do not delete existing tagged manifests
 				if !s.isTagged(d) {
+					danglings.Add(d.Digest)
 					deleteQueue = append(deleteQueue, d)
 				}
 			}
 		}
 	}
 
-	return nil
+	return s.reloadNested(ctx)
 }
 
 // delete deletes one node and returns the dangling nodes caused by the delete.
@@ -210,7 +467,10 @@ func (s *Store) delete(ctx context.Context, target ocispec.Descriptor) ([]ocispe
 	}
 	danglings := s.graph.Remove(target)
 	if untagged && s.AutoSaveIndex {
-		err := s.saveIndex()
+		// the file lock, if any, is held by Delete
+		s.indexLock.Lock()
+		err := s.flushIndex()
+		s.indexLock.Unlock()
 		if err != nil {
 			return nil, err
 		}
@@ -218,6 +478,9 @@ func (s *Store) delete(ctx context.Context, target ocispec.Descriptor) ([]ocispe
 	if err := s.storage.Delete(ctx, target); err != nil {
 		return nil, err
 	}
+	if s.maxSize > 0 {
+		s.size.Add(-storedSize(target.Size, s.encrypted))
+	}
 	return danglings, nil
 }
 
@@ -248,8 +511,11 @@ func (s *Store) Tag(ctx context.Context, desc ocispec.Descriptor, reference stri
 	if !exists {
 		return fmt.Errorf("%s: %s: %w", desc.Digest, desc.MediaType, errdef.ErrNotFound)
 	}
-
-	return s.tag(ctx, desc, reference)
+	if err := s.tag(ctx, desc, reference); err != nil {
+		return err
+	}
+	s.touch(desc)
+	return nil
 }
 
 // tag tags a descriptor with a reference string.
@@ -275,6 +541,10 @@ func (s *Store) tag(ctx context.Context, desc ocispec.Descriptor, reference stri
 //     a full descriptor declared by github.com/opencontainers/image-spec/specs-go/v1.
 //   - If the reference is a digest, the returned descriptor will be a
 //     plain descriptor (containing only the digest, media type and size).
+//
+// Tags can be the reference names of the entries of `index.json`, or of the
+// manifests nested in the image indexes referenced by `index.json`, at any
+// nesting level. The entries of `index.json` take precedence.
 func (s *Store) Resolve(ctx context.Context, reference string) (ocispec.Descriptor, error) {
 	s.sync.RLock()
 	defer s.sync.RUnlock()
@@ -287,11 +557,27 @@ func (s *Store) Resolve(ctx context.Context, reference string) (ocispec.Descript
 	desc, err := s.tagResolver.Resolve(ctx, reference)
 	if err != nil {
 		if errors.Is(err, errdef.ErrNotFound) {
+			// attempt resolving nested manifest
+			if desc, err := s.nestedResolver.Resolve(ctx, reference); err == nil {
+				s.touch(desc)
+				if reference == desc.Digest.String() {
+					return descriptor.Plain(desc), nil
+				}
+				return desc, nil
+			}
 			// attempt resolving blob
-			return resolveBlob(os.DirFS(s.root), reference)
+			desc, err := resolveBlob(os.DirFS(s.root), reference)
+			if err != nil {
+				return ocispec.Descriptor{}, err
+			}
+			if desc.Size, err = contentSize(desc.Size, s.encrypted); err != nil {
+				return ocispec.Descriptor{}, fmt.Errorf("%s: %w", desc.Digest, err)
+			}
+			return desc, nil
 		}
 		return ocispec.Descriptor{}, err
 	}
+	s.touch(desc)
 
 	if reference == desc.Digest.String() {
 		return descriptor.Plain(desc), nil
@@ -305,6 +591,8 @@ func (s *Store) Resolve(ctx context.Context, reference string) (ocispec.Descript
 // "org.opencontainers.image.ref.name" annotation is removed from the
 // `index.json` file.
 // The actual content identified by the descriptor is NOT deleted.
+// The reference names of the nested manifests are part of the content of their
+// image indexes, and cannot be untagged.
 //
 // Reference: https://github.com/opencontainers/image-spec/blob/v1.1.1/image-layout.md#indexjson-file
 func (s *Store) Untag(ctx context.Context, reference string) error {
@@ -317,6 +605,12 @@ func (s *Store) Untag(ctx context.Context, reference string) error {
 
 	desc, err := s.tagResolver.Resolve(ctx, reference)
 	if err != nil {
+		if desc, nestedErr := s.nestedResolver.Resolve(ctx, reference); nestedErr == nil {
+			if reference == desc.Digest.String() {
+				return fmt.Errorf("reference %q is a digest and not a tag: %w", reference, errdef.ErrInvalidReference)
+			}
+			return fmt.Errorf("reference %q is a nested reference name: %w", reference, errdef.ErrUnsupported)
+		}
 		return fmt.Errorf("resolving reference %q: %w", reference, err)
 	}
 	if reference == desc.Digest.String() {
@@ -341,7 +635,8 @@ func (s *Store) Predecessors(ctx context.Context, node ocispec.Descriptor) ([]oc
 }
 
 // Tags lists the tags presented in the `index.json` file of the OCI layout,
-// returned in ascending order.
+// including the reference names of the nested manifests, returned in ascending
+// order.
 // If `last` is NOT empty, the entries in the response start after the tag
 // specified by `last`. Otherwise, the response starts from the top of the tags
 // list.
@@ -351,7 +646,7 @@ func (s *Store) Tags(ctx context.Context, last string, fn func(tags []string) er
 	s.sync.RLock()
 	defer s.sync.RUnlock()
 
-	return listTags(s.tagResolver, last, fn)
+	return listTags(s.tagResolver, s.nestedResolver, last, fn)
 }
 
 // ensureOCILayoutFile ensures the `oci-layout` file.
@@ -403,12 +698,43 @@ func (s *Store) loadIndexFile(ctx context.Context) error {
 	}
 	defer indexFile.Close()
 
+	indexJSON, err := io.ReadAll(indexFile)
+	if err != nil {
+		return fmt.Errorf("failed to read index file: %w", err)
+	}
 	var index ocispec.Index
-	if err := json.NewDecoder(indexFile).Decode(&index); err != nil {
+	if err := json.Unmarshal(indexJSON, &index); err != nil {
 		return fmt.Errorf("failed to decode index file: %w", err)
 	}
 	s.index = &index
-	return loadIndex(ctx, s.index, s.storage, s.tagResolver, s.graph)
+	if s.encrypted {
+		if err := verifyKey(ctx, s.storage, s.index.Manifests); err != nil {
+			return err
+		}
+	}
+	if s.multiProcess {
+		s.syncedEntries = indexEntries(index.Manifests)
+	}
+	if s.graphCachePath == "" {
+		return loadIndex(ctx, s.index, s.storage, s.tagResolver, s.nestedResolver, s.graph, s.invalid)
+	}
+
+	if err := tagIndex(ctx, s.index, s.tagResolver); err != nil {
+		return err
+	}
+	if err := tagNested(ctx, s.index.Manifests, s.storage, s.nestedResolver, s.invalid); err != nil {
+		return err
+	}
+	s.indexDigest = digest.FromBytes(indexJSON)
+	upToDate, err := loadGraph(ctx, s.index, s.indexDigest, s.storage, s.graph, s.invalid, readGraphCache(s.graphCachePath))
+	if err != nil {
+		return err
+	}
+	if !upToDate {
+		// the cache is optional, so failing to write it is not fatal
+		_ = writeGraphCache(s.graphCachePath, s.indexDigest, s.graph)
+	}
+	return nil
 }
 
 // SaveIndex writes the `index.json` file to the file system.
@@ -417,18 +743,71 @@ func (s *Store) loadIndexFile(ctx context.Context) error {
 //     on Tag() and Delete() calls, and when pushing a manifest.
 //   - If AutoSaveIndex is set to false, it's the caller's responsibility
 //     to manually call this method when needed.
+//
+// If the store is opened with StoreOptions.MultiProcess, SaveIndex also
+// imports the changes of `index.json` made by other processes.
 func (s *Store) SaveIndex() error {
 	s.sync.RLock()
 	defer s.sync.RUnlock()
 
-	return s.saveIndex()
+	if err := s.saveIndex(); err != nil {
+		return err
+	}
+	s.saveGraphCache()
+	return nil
 }
 
 func (s *Store) saveIndex() error {
 	s.indexLock.Lock()
 	defer s.indexLock.Unlock()
 
-	var manifests []ocispec.Descriptor
+	if s.multiProcess {
+		lock, err := flock.Acquire(s.lockPath)
+		if err != nil {
+			return err
+		}
+		defer lock.Release()
+	}
+	return s.flushIndex()
+}
+
+// flushIndex writes the index, merging it with `index.json` if s.multiProcess
+// is true. The caller must hold s.indexLock, and the file lock if
+// s.multiProcess is true.
+func (s *Store) flushIndex() error {
+	if s.multiProcess {
+		return s.mergeIndex()
+	}
+	s.index.Manifests = s.indexManifests()
+	return s.writeIndexFile()
+}
+
+// saveGraphCache writes the graph cache for the last written `index.json` if
+// the graph cache is enabled.
+func (s *Store) saveGraphCache() {
+	if s.graphCachePath == "" {
+		return
+	}
+	s.indexLock.Lock()
+	defer s.indexLock.Unlock()
+
+	// the cache is optional, so failing to write it is not fatal
+	_ = writeGraphCache(s.graphCachePath, s.indexDigest, s.graph)
+}
+
+// indexManifests returns the manifests of the index generated from the tag
+// resolver. The existing entries of the index keep their order.
+// The untagged manifests nested in the image indexes of the index are
+// reachable from the index, and are not added as entries.
+func (s *Store) indexManifests() []ocispec.Descriptor {
+	return s.tagResolverManifests(true)
+}
+
+// tagResolverManifests returns the manifests resolvable by the tag resolver
+// as the entries of the index, skipping the untagged nested manifests if
+// skipNested is true. The existing entries of the index keep their order.
+func (s *Store) tagResolverManifests(skipNested bool) []ocispec.Descriptor {
+	entries := make(map[string]ocispec.Descriptor)
 	tagged := set.New[digest.Digest]()
 	refMap := s.tagResolver.Map()
 
@@ -440,7 +819,7 @@ func (s *Store) saveIndex() error {
 			maps.Copy(annotations, desc.Annotations)
 			annotations[ocispec.AnnotationRefName] = ref
 			desc.Annotations = annotations
-			manifests = append(manifests, desc)
+			entries[ref] = desc
 			// mark the digest as tagged for deduplication in step 2
 			tagged.Add(desc.Digest)
 		}
@@ -449,12 +828,94 @@ func (s *Store) saveIndex() error {
 	for ref, desc := range refMap {
 		if ref == desc.Digest.String() && !tagged.Contains(desc.Digest) {
 			// skip tagged ones since they have been added in step 1
-			manifests = append(manifests, deleteAnnotationRefName(desc))
+			if skipNested {
+				if _, err := s.nestedResolver.Resolve(context.Background(), ref); err == nil {
+					// reachable from an image index in the index
+					continue
+				}
+			}
+			entries[ref] = deleteAnnotationRefName(desc)
 		}
 	}
+	return orderIndexEntries(s.index.Manifests, entries)
+}
 
-	s.index.Manifests = manifests
-	return s.writeIndexFile()
+// mergeIndex re-reads `index.json`, merges the local changes since the last
+// synchronization into it, imports the changes made by other processes, and
+// writes the merged index. The caller must hold s.indexLock and the file
+// lock.
+func (s *Store) mergeIndex() error {
+	index, err := readIndexFile(s.indexPath)
+	if err != nil {
+		return err
+	}
+
+	// apply the local changes since the last synchronization
+	local := indexEntries(s.indexManifests())
+	merged := indexEntries(index.Manifests)
+	for key, desc := range local {
+		if synced, ok := s.syncedEntries[key]; !ok || !equalIndexEntry(synced, desc) {
+			merged[key] = desc
+		}
+	}
+	for key := range s.syncedEntries {
+		if _, ok := local[key]; !ok {
+			delete(merged, key)
+		}
+	}
+	// remove the untagged entries of the tagged manifests
+	tagged := set.New[digest.Digest]()
+	for key, desc := range merged {
+		if key != desc.Digest.String() {
+			tagged.Add(desc.Digest)
+		}
+	}
+	for key, desc := range merged {
+		if key == desc.Digest.String() && tagged.Contains(desc.Digest) {
+			delete(merged, key)
+		}
+	}
+
+	// import the changes made by other processes
+	ctx := context.Background()
+	for key, desc := range merged {
+		if current, ok := local[key]; ok && equalIndexEntry(current, desc) {
+			continue
+		}
+		if err := s.tagResolver.Tag(ctx, deleteAnnotationRefName(desc), desc.Digest.String()); err != nil {
+			return err
+		}
+		if key != desc.Digest.String() {
+			if err := s.tagResolver.Tag(ctx, desc, key); err != nil {
+				return err
+			}
+		}
+		if err := s.graph.IndexAllSkip(ctx, s.storage, descriptor.Plain(desc), s.invalid.skip); err != nil && !errors.Is(err, errdef.ErrNotFound) {
+			return err
+		}
+		if err := tagNested(ctx, []ocispec.Descriptor{desc}, s.storage, s.nestedResolver, s.invalid); err != nil {
+			return err
+		}
+	}
+	for key, desc := range local {
+		if _, ok := merged[key]; ok {
+			continue
+		}
+		if key != desc.Digest.String() {
+			s.tagResolver.Untag(key)
+		} else if !tagged.Contains(desc.Digest) {
+			s.tagResolver.Untag(key)
+		}
+	}
+
+	// write the merged index
+	index.Manifests = orderIndexEntries(index.Manifests, merged)
+	s.index = index
+	if err := s.writeIndexFile(); err != nil {
+		return err
+	}
+	s.syncedEntries = merged
+	return nil
 }
 
 // writeIndexFile writes the `index.json` file.
@@ -463,7 +924,28 @@ func (s *Store) writeIndexFile() error {
 	if err != nil {
 		return fmt.Errorf("failed to marshal index file: %w", err)
 	}
-	return os.WriteFile(s.indexPath, indexJSON, 0666)
+	if s.multiProcess {
+		err = writeFileAtomic(s.indexPath, indexJSON)
+	} else {
+		err = os.WriteFile(s.indexPath, indexJSON, 0666)
+	}
+	if err != nil {
+		return err
+	}
+	if s.graphCachePath != "" {
+		// the graph cache is written on SaveIndex and GC, and is detected
+		// as stale by the digest on open
+		s.indexDigest = digest.FromBytes(indexJSON)
+	}
+	if s.maxSize > 0 {
+		// the access times only affect the order of eviction
+		roots := set.New[digest.Digest]()
+		for _, desc := range s.tagResolver.Map() {
+			roots.Add(desc.Digest)
+		}
+		_ = s.access.save(s.accessPath, roots)
+	}
+	return nil
 }
 
 // GC removes garbage from Store. Unsaved index will be lost. To prevent unexpected
@@ -475,6 +957,22 @@ func (s *Store) GC(ctx context.Context) error {
 	s.sync.Lock()
 	defer s.sync.Unlock()
 
+	if s.multiProcess {
+		// hold the file lock so that other processes do not update the index
+		// during GC
+		lock, err := flock.Acquire(s.lockPath)
+		if err != nil {
+			return err
+		}
+		defer lock.Release()
+		s.indexLock.Lock()
+		err = s.mergeIndex()
+		s.indexLock.Unlock()
+		if err != nil {
+			return fmt.Errorf("unable to merge index: %w", err)
+		}
+	}
+
 	// get reachable nodes by reloading the index
 	err := s.gcIndex(ctx)
 	if err != nil {
@@ -513,6 +1011,19 @@ func (s *Store) GC(ctx context.Context) error {
 				continue
 			}
 			if !reachableNodes.Contains(blobDigest) {
+				if s.multiProcess {
+					// skip the blobs that may be in use by other processes
+					info, err := digestEntry.Info()
+					if err != nil {
+						if errors.Is(err, fs.ErrNotExist) {
+							continue
+						}
+						return err
+					}
+					if time.Since(info.ModTime()) < gcGracePeriod {
+						continue
+					}
+				}
 				// remove the blob from storage if it does not exist in Store
 				err = os.Remove(path.Join(algPath, dgst))
 				if err != nil {
@@ -521,6 +1032,14 @@ func (s *Store) GC(ctx context.Context) error {
 			}
 		}
 	}
+	if s.maxSize > 0 {
+		size, err := blobsSize(os.DirFS(s.root))
+		if err != nil {
+			return err
+		}
+		s.size.Store(size)
+	}
+	s.saveGraphCache()
 	return nil
 }
 
@@ -550,6 +1069,28 @@ func (s *Store) gcIndex(ctx context.Context) error {
 		tagged.Add(desc.Digest)
 	}
 
+	// index untagged image indexes with nested reference names
+	for ref, desc := range refMap {
+		if ref != desc.Digest.String() || tagged.Contains(desc.Digest) || !descriptor.IsIndex(desc) {
+			continue
+		}
+		nestedResolver := resolver.NewMemory()
+		if err := tagNested(ctx, []ocispec.Descriptor{desc}, s.storage, nestedResolver, s.invalid); err != nil {
+			return err
+		}
+		if !hasReferenceNames(nestedResolver) {
+			continue
+		}
+		if err := tagResolver.Tag(ctx, deleteAnnotationRefName(desc), desc.Digest.String()); err != nil {
+			return err
+		}
+		plain := descriptor.Plain(desc)
+		if err := graph.IndexAll(ctx, s.storage, plain); err != nil {
+			return err
+		}
+		tagged.Add(desc.Digest)
+	}
+
 	// index referrer manifests
 	for ref, desc := range refMap {
 		if ref != desc.Digest.String() || tagged.Contains(desc.Digest) {
@@ -579,9 +1120,35 @@ func (s *Store) gcIndex(ctx context.Context) error {
 	}
 	s.tagResolver = tagResolver
 	s.graph = graph
+	return s.reloadNested(ctx)
+}
+
+// reloadNested reloads the reference names of the nested manifests from the
+// entries of the index after content is deleted. The caller must hold s.sync.
+func (s *Store) reloadNested(ctx context.Context) error {
+	if len(s.nestedResolver.Map()) == 0 {
+		// deleting content never adds reference names
+		return nil
+	}
+	nestedResolver := resolver.NewMemory()
+	if err := tagNested(ctx, s.tagResolverManifests(false), s.storage, nestedResolver, s.invalid); err != nil {
+		return err
+	}
+	s.nestedResolver = nestedResolver
 	return nil
 }
 
+// hasReferenceNames returns true if the resolver resolves any reference other
+// than digests.
+func hasReferenceNames(r *resolver.Memory) bool {
+	for ref, desc := range r.Map() {
+		if ref != desc.Digest.String() {
+			return true
+		}
+	}
+	return false
+}
+
 // isTagged checks if the blob given by the descriptor is tagged.
 func (s *Store) isTagged(desc ocispec.Descriptor) bool {
 	tagSet := s.tagResolver.TagSet(desc)
@@ -634,3 +1201,103 @@ func isKnownAlgorithm(alg string) bool {
 		return false
 	}
 }
+
+// indexEntries returns the manifests of an index keyed by indexEntryKey.
+func indexEntries(manifests []ocispec.Descriptor) map[string]ocispec.Descriptor {
+	entries := make(map[string]ocispec.Descriptor, len(manifests))
+	for _, desc := range manifests {
+		entries[indexEntryKey(desc)] = desc
+	}
+	return entries
+}
+
+// indexEntryKey returns the reference name of a manifest of an index, or its
+// digest if untagged.
+func indexEntryKey(desc ocispec.Descriptor) string {
+	if key := desc.Annotations[ocispec.AnnotationRefName]; key != "" {
+		return key
+	}
+	return desc.Digest.String()
+}
+
+// orderIndexEntries returns the entries keyed by indexEntryKey in the order of
+// manifests, followed by the entries not in manifests in ascending order of
+// their keys.
+func orderIndexEntries(manifests []ocispec.Descriptor, entries map[string]ocispec.Descriptor) []ocispec.Descriptor {
+	ordered := make([]ocispec.Descriptor, 0, len(entries))
+	added := set.New[string]()
+	for _, desc := range manifests {
+		key := indexEntryKey(desc)
+		if entry, ok := entries[key]; ok && !added.Contains(key) {
+			ordered = append(ordered, entry)
+			added.Add(key)
+		}
+	}
+	for _, key := range slices.Sorted(maps.Keys(entries)) {
+		if !added.Contains(key) {
+			ordered = append(ordered, entries[key])
+		}
+	}
+	return ordered
+}
+
+// equalIndexEntry checks if two manifests of an index are identical.
+func equalIndexEntry(a, b ocispec.Descriptor) bool {
+	return content.Equal(a, b) && maps.Equal(a.Annotations, b.Annotations)
+}
+
+// readIndexFile reads the index file at the given path. An empty index is
+// returned if the file does not exist.
+func readIndexFile(path string) (*ocispec.Index, error) {
+	indexJSON, err := os.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to read index file: %w", err)
+		}
+		return &ocispec.Index{
+			Versioned: specs.Versioned{
+				SchemaVersion: 2, // historical value
+			},
+			MediaType: ocispec.MediaTypeImageIndex,
+			Manifests: []ocispec.Descriptor{},
+		}, nil
+	}
+	var index ocispec.Index
+	if err := json.Unmarshal(indexJSON, &index); err != nil {
+		return nil, fmt.Errorf("failed to decode index file: %w", err)
+	}
+	return &index, nil
+}
+
+// writeFileAtomic writes data to a temporary file in the directory of the
+// given path, and renames the temporary file to the path, so that readers
+// never see a partially written file.
+func writeFileAtomic(path string, data []byte) error {
+	var fp *os.File
+	var err error
+	for range 10 {
+		// open with the same permission as os.WriteFile, which is subject to
+		// umask, unlike os.CreateTemp
+		tmpPath := path + "." + strconv.FormatUint(rand.Uint64(), 36) + ".tmp"
+		fp, err = os.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0666)
+		if !os.IsExist(err) {
+			break
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	tmpPath := fp.Name()
+	_, err = fp.Write(data)
+	if closeErr := fp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, path)
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
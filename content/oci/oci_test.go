@@ -24,6 +24,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
 	"path"
 	"path/filepath"
@@ -32,14 +33,18 @@ import (
 	"strings"
 	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/opencontainers/go-digest"
+	specs "github.com/opencontainers/image-spec/specs-go"
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 	"golang.org/x/sync/errgroup"
 	"oras.land/oras-go/v2"
 	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/content/file"
 	"oras.land/oras-go/v2/content/memory"
 	"oras.land/oras-go/v2/errdef"
+	"oras.land/oras-go/v2/internal/aesgcm"
 	"oras.land/oras-go/v2/internal/cas"
 	"oras.land/oras-go/v2/internal/descriptor"
 	"oras.land/oras-go/v2/internal/spec"
@@ -2947,3 +2952,1019 @@ func TestStore_BadDigest(t *testing.T) {
 		}
 	})
 }
+
+func TestStore_MultiProcess(t *testing.T) {
+	tempDir := t.TempDir()
+	ctx := context.Background()
+	opts := StoreOptions{MultiProcess: true}
+	s1, err := NewWithOptions(ctx, tempDir, opts)
+	if err != nil {
+		t.Fatal("NewWithOptions() error =", err)
+	}
+	s2, err := NewWithOptions(ctx, tempDir, opts)
+	if err != nil {
+		t.Fatal("NewWithOptions() error =", err)
+	}
+
+	pushManifest := func(s *Store, data string) ocispec.Descriptor {
+		blob := []byte(data)
+		desc := content.NewDescriptorFromBytes(ocispec.MediaTypeImageManifest, blob)
+		if err := s.Push(ctx, desc, bytes.NewReader(blob)); err != nil && !errors.Is(err, errdef.ErrAlreadyExists) {
+			t.Fatal("Store.Push() error =", err)
+		}
+		return desc
+	}
+	foo := pushManifest(s1, `{"layers":[],"annotations":{"name":"foo"}}`)
+	bar := pushManifest(s2, `{"layers":[],"annotations":{"name":"bar"}}`)
+
+	// concurrent tags by different stores are merged
+	if err := s1.Tag(ctx, foo, "foo"); err != nil {
+		t.Fatal("Store.Tag() error =", err)
+	}
+	if err := s2.Tag(ctx, bar, "bar"); err != nil {
+		t.Fatal("Store.Tag() error =", err)
+	}
+	if err := s1.Tag(ctx, foo, "shared"); err != nil {
+		t.Fatal("Store.Tag() error =", err)
+	}
+	if err := s2.Tag(ctx, bar, "shared"); err != nil {
+		t.Fatal("Store.Tag() error =", err)
+	}
+	s3, err := NewWithOptions(ctx, tempDir, opts)
+	if err != nil {
+		t.Fatal("NewWithOptions() error =", err)
+	}
+	want := map[string]ocispec.Descriptor{
+		"foo":    foo,
+		"bar":    bar,
+		"shared": bar,
+	}
+	for ref, wantDesc := range want {
+		got, err := s3.Resolve(ctx, ref)
+		if err != nil {
+			t.Fatalf("Store.Resolve(%q) error = %v", ref, err)
+		}
+		if !content.Equal(got, wantDesc) {
+			t.Errorf("Store.Resolve(%q) = %v, want %v", ref, got, wantDesc)
+		}
+	}
+
+	// changes made by other stores are imported on save
+	if err := s1.SaveIndex(); err != nil {
+		t.Fatal("Store.SaveIndex() error =", err)
+	}
+	for ref, wantDesc := range want {
+		got, err := s1.Resolve(ctx, ref)
+		if err != nil {
+			t.Fatalf("Store.Resolve(%q) error = %v", ref, err)
+		}
+		if !content.Equal(got, wantDesc) {
+			t.Errorf("Store.Resolve(%q) = %v, want %v", ref, got, wantDesc)
+		}
+	}
+	// untag by one store is merged
+	if err := s3.Untag(ctx, "foo"); err != nil {
+		t.Fatal("Store.Untag() error =", err)
+	}
+	if err := s2.SaveIndex(); err != nil {
+		t.Fatal("Store.SaveIndex() error =", err)
+	}
+	if _, err := s2.Resolve(ctx, "foo"); !errors.Is(err, errdef.ErrNotFound) {
+		t.Errorf("Store.Resolve() error = %v, wantErr %v", err, errdef.ErrNotFound)
+	}
+	if _, err := s2.Resolve(ctx, foo.Digest.String()); err != nil {
+		t.Errorf("Store.Resolve() error = %v", err)
+	}
+
+	// no temporary files are left
+	entries, err := os.ReadDir(tempDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	wantNames := []string{"blobs", ocispec.ImageIndexFile, indexLockFile, "ingest", ocispec.ImageLayoutFile}
+	if !reflect.DeepEqual(names, wantNames) {
+		t.Errorf("entries = %v, want %v", names, wantNames)
+	}
+}
+
+func TestStore_MultiProcess_ConcurrentTag(t *testing.T) {
+	tempDir := t.TempDir()
+	ctx := context.Background()
+	opts := StoreOptions{MultiProcess: true}
+	blob := []byte(`{"layers":[]}`)
+	desc := content.NewDescriptorFromBytes(ocispec.MediaTypeImageManifest, blob)
+
+	// simulate processes by stores opened separately
+	const numStores, numTags = 4, 10
+	eg, egCtx := errgroup.WithContext(ctx)
+	for i := range numStores {
+		eg.Go(func() error {
+			s, err := NewWithOptions(egCtx, tempDir, opts)
+			if err != nil {
+				return err
+			}
+			if err := s.Push(egCtx, desc, bytes.NewReader(blob)); err != nil && !errors.Is(err, errdef.ErrAlreadyExists) {
+				return err
+			}
+			for j := range numTags {
+				if err := s.Tag(egCtx, desc, fmt.Sprintf("tag-%d-%d", i, j)); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	}
+	if err := eg.Wait(); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := NewWithOptions(ctx, tempDir, opts)
+	if err != nil {
+		t.Fatal("NewWithOptions() error =", err)
+	}
+	tags, err := registry.Tags(ctx, s)
+	if err != nil {
+		t.Fatal("registry.Tags() error =", err)
+	}
+	if got, want := len(tags), numStores*numTags; got != want {
+		t.Errorf("len(tags) = %v, want %v", got, want)
+	}
+}
+
+func TestStore_MultiProcess_GC(t *testing.T) {
+	tempDir := t.TempDir()
+	ctx := context.Background()
+	opts := StoreOptions{MultiProcess: true}
+	s1, err := NewWithOptions(ctx, tempDir, opts)
+	if err != nil {
+		t.Fatal("NewWithOptions() error =", err)
+	}
+	s2, err := NewWithOptions(ctx, tempDir, opts)
+	if err != nil {
+		t.Fatal("NewWithOptions() error =", err)
+	}
+
+	// tag a manifest via s2 after s1 is opened
+	blob := []byte(`{"layers":[]}`)
+	desc := content.NewDescriptorFromBytes(ocispec.MediaTypeImageManifest, blob)
+	if err := s2.Push(ctx, desc, bytes.NewReader(blob)); err != nil {
+		t.Fatal("Store.Push() error =", err)
+	}
+	if err := s2.Tag(ctx, desc, "latest"); err != nil {
+		t.Fatal("Store.Tag() error =", err)
+	}
+
+	// GC by s1 keeps the manifest tagged by s2
+	if err := s1.GC(ctx); err != nil {
+		t.Fatal("Store.GC() error =", err)
+	}
+	exists, err := s1.Exists(ctx, desc)
+	if err != nil {
+		t.Fatal("Store.Exists() error =", err)
+	}
+	if !exists {
+		t.Errorf("Store.Exists() = %v, want %v", exists, true)
+	}
+	got, err := s1.Resolve(ctx, "latest")
+	if err != nil {
+		t.Fatal("Store.Resolve() error =", err)
+	}
+	if !content.Equal(got, desc) {
+		t.Errorf("Store.Resolve() = %v, want %v", got, desc)
+	}
+
+	// recently pushed unreferenced blobs are kept by GC
+	garbage := []byte("garbage")
+	garbageDesc := content.NewDescriptorFromBytes(ocispec.MediaTypeImageLayer, garbage)
+	if err := s2.Push(ctx, garbageDesc, bytes.NewReader(garbage)); err != nil {
+		t.Fatal("Store.Push() error =", err)
+	}
+	if err := s1.GC(ctx); err != nil {
+		t.Fatal("Store.GC() error =", err)
+	}
+	garbagePath := filepath.Join(tempDir, "blobs", "sha256", garbageDesc.Digest.Encoded())
+	if _, err := os.Stat(garbagePath); err != nil {
+		t.Errorf("os.Stat() error = %v, want blob kept within the grace period", err)
+	}
+
+	// unreferenced blobs older than the grace period are collected
+	old := time.Now().Add(-2 * gcGracePeriod)
+	if err := os.Chtimes(garbagePath, old, old); err != nil {
+		t.Fatal("os.Chtimes() error =", err)
+	}
+	if err := s1.GC(ctx); err != nil {
+		t.Fatal("Store.GC() error =", err)
+	}
+	if _, err := os.Stat(garbagePath); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("os.Stat() error = %v, wantErr %v", err, fs.ErrNotExist)
+	}
+	exists, err = s1.Exists(ctx, desc)
+	if err != nil {
+		t.Fatal("Store.Exists() error =", err)
+	}
+	if !exists {
+		t.Errorf("Store.Exists() = %v, want %v", exists, true)
+	}
+}
+
+func TestStore_MultiProcess_Delete(t *testing.T) {
+	tempDir := t.TempDir()
+	ctx := context.Background()
+	opts := StoreOptions{MultiProcess: true}
+	s1, err := NewWithOptions(ctx, tempDir, opts)
+	if err != nil {
+		t.Fatal("NewWithOptions() error =", err)
+	}
+
+	push := func(s *Store, mediaType string, blob []byte) ocispec.Descriptor {
+		desc := content.NewDescriptorFromBytes(mediaType, blob)
+		if err := s.Push(ctx, desc, bytes.NewReader(blob)); err != nil && !errors.Is(err, errdef.ErrAlreadyExists) {
+			t.Fatal("Store.Push() error =", err)
+		}
+		return desc
+	}
+	pushManifest := func(s *Store, layer ocispec.Descriptor, name string) ocispec.Descriptor {
+		manifest := ocispec.Manifest{
+			MediaType:   ocispec.MediaTypeImageManifest,
+			Config:      ocispec.DescriptorEmptyJSON,
+			Layers:      []ocispec.Descriptor{layer},
+			Annotations: map[string]string{"name": name},
+		}
+		manifestJSON, err := json.Marshal(manifest)
+		if err != nil {
+			t.Fatal("json.Marshal() error =", err)
+		}
+		return push(s, ocispec.MediaTypeImageManifest, manifestJSON)
+	}
+
+	push(s1, ocispec.MediaTypeEmptyJSON, ocispec.DescriptorEmptyJSON.Data)
+	layer := push(s1, ocispec.MediaTypeImageLayer, []byte("layer"))
+	foo := pushManifest(s1, layer, "foo")
+	if err := s1.Tag(ctx, foo, "foo"); err != nil {
+		t.Fatal("Store.Tag() error =", err)
+	}
+
+	// s2 references the layer after s1 has loaded its graph
+	s2, err := NewWithOptions(ctx, tempDir, opts)
+	if err != nil {
+		t.Fatal("NewWithOptions() error =", err)
+	}
+	bar := pushManifest(s2, layer, "bar")
+	if err := s2.Tag(ctx, bar, "bar"); err != nil {
+		t.Fatal("Store.Tag() error =", err)
+	}
+
+	// deleting foo by s1 keeps the layer referenced by bar
+	if err := s1.Delete(ctx, foo); err != nil {
+		t.Fatal("Store.Delete() error =", err)
+	}
+	for _, desc := range []ocispec.Descriptor{layer, ocispec.DescriptorEmptyJSON, bar} {
+		exists, err := s1.Exists(ctx, desc)
+		if err != nil {
+			t.Fatal("Store.Exists() error =", err)
+		}
+		if !exists {
+			t.Errorf("Store.Exists(%v) = %v, want %v", desc.Digest, exists, true)
+		}
+	}
+	exists, err := s1.Exists(ctx, foo)
+	if err != nil {
+		t.Fatal("Store.Exists() error =", err)
+	}
+	if exists {
+		t.Errorf("Store.Exists(%v) = %v, want %v", foo.Digest, exists, false)
+	}
+
+	s3, err := NewWithOptions(ctx, tempDir, opts)
+	if err != nil {
+		t.Fatal("NewWithOptions() error =", err)
+	}
+	if _, err := s3.Resolve(ctx, "foo"); !errors.Is(err, errdef.ErrNotFound) {
+		t.Errorf("Store.Resolve() error = %v, wantErr %v", err, errdef.ErrNotFound)
+	}
+	got, err := s3.Resolve(ctx, "bar")
+	if err != nil {
+		t.Fatal("Store.Resolve() error =", err)
+	}
+	if !content.Equal(got, bar) {
+		t.Errorf("Store.Resolve() = %v, want %v", got, bar)
+	}
+}
+
+func TestStore_GraphCache(t *testing.T) {
+	tempDir := t.TempDir()
+	ctx := context.Background()
+	opts := StoreOptions{GraphCache: true}
+	s, err := NewWithOptions(ctx, tempDir, opts)
+	if err != nil {
+		t.Fatal("NewWithOptions() error =", err)
+	}
+
+	push := func(s *Store, mediaType string, blob []byte) ocispec.Descriptor {
+		desc := content.NewDescriptorFromBytes(mediaType, blob)
+		if err := s.Push(ctx, desc, bytes.NewReader(blob)); err != nil {
+			t.Fatal("Store.Push() error =", err)
+		}
+		return desc
+	}
+	pushManifest := func(s *Store, layer ocispec.Descriptor, name string) ocispec.Descriptor {
+		manifest := ocispec.Manifest{
+			MediaType:   ocispec.MediaTypeImageManifest,
+			Config:      ocispec.DescriptorEmptyJSON,
+			Layers:      []ocispec.Descriptor{layer},
+			Annotations: map[string]string{"name": name},
+		}
+		manifestJSON, err := json.Marshal(manifest)
+		if err != nil {
+			t.Fatal("json.Marshal() error =", err)
+		}
+		return push(s, ocispec.MediaTypeImageManifest, manifestJSON)
+	}
+	checkPredecessors := func(s *Store, node ocispec.Descriptor, want []ocispec.Descriptor) {
+		t.Helper()
+		got, err := s.Predecessors(ctx, node)
+		if err != nil {
+			t.Fatal("Store.Predecessors() error =", err)
+		}
+		if !equalDescriptorSet(got, want) {
+			t.Errorf("Store.Predecessors() = %v, want %v", got, want)
+		}
+	}
+	readCache := func() *graphCache {
+		t.Helper()
+		cache := readGraphCache(filepath.Join(tempDir, graphCacheFile))
+		if cache == nil {
+			t.Fatal("graph cache is missing or invalid")
+		}
+		indexJSON, err := os.ReadFile(filepath.Join(tempDir, ocispec.ImageIndexFile))
+		if err != nil {
+			t.Fatal("os.ReadFile() error =", err)
+		}
+		if want := digest.FromBytes(indexJSON); cache.IndexDigest != want {
+			t.Errorf("graphCache.IndexDigest = %v, want %v", cache.IndexDigest, want)
+		}
+		return cache
+	}
+
+	push(s, ocispec.MediaTypeEmptyJSON, ocispec.DescriptorEmptyJSON.Data)
+	layer := push(s, ocispec.MediaTypeImageLayer, []byte("layer"))
+	foo := pushManifest(s, layer, "foo")
+	if err := s.Tag(ctx, foo, "foo"); err != nil {
+		t.Fatal("Store.Tag() error =", err)
+	}
+
+	// the cache is built on open
+	s, err = NewWithOptions(ctx, tempDir, opts)
+	if err != nil {
+		t.Fatal("NewWithOptions() error =", err)
+	}
+	checkPredecessors(s, layer, []ocispec.Descriptor{foo})
+	if got, want := len(readCache().Nodes), 3; got != want {
+		t.Errorf("len(graphCache.Nodes) = %v, want %v", got, want)
+	}
+
+	// the manifests are not fetched if the cache is up to date
+	fooPath := filepath.Join(tempDir, filepath.FromSlash(mustBlobPath(t, foo.Digest)))
+	fooJSON, err := os.ReadFile(fooPath)
+	if err != nil {
+		t.Fatal("os.ReadFile() error =", err)
+	}
+	if err := os.Remove(fooPath); err != nil {
+		t.Fatal("os.Remove() error =", err)
+	}
+	s, err = New(tempDir)
+	if err != nil {
+		t.Fatal("New() error =", err)
+	}
+	checkPredecessors(s, layer, nil)
+	s, err = NewWithOptions(ctx, tempDir, opts)
+	if err != nil {
+		t.Fatal("NewWithOptions() error =", err)
+	}
+	checkPredecessors(s, layer, []ocispec.Descriptor{foo})
+
+	// the cache is not rewritten on the automatic saves of the index, but
+	// on SaveIndex
+	bar := pushManifest(s, layer, "bar")
+	if err := s.Tag(ctx, bar, "bar"); err != nil {
+		t.Fatal("Store.Tag() error =", err)
+	}
+	cache := readGraphCache(filepath.Join(tempDir, graphCacheFile))
+	if cache == nil {
+		t.Fatal("graph cache is missing or invalid")
+	}
+	if got, want := len(cache.Nodes), 3; got != want {
+		t.Errorf("len(graphCache.Nodes) = %v, want %v", got, want)
+	}
+	if err := s.SaveIndex(); err != nil {
+		t.Fatal("Store.SaveIndex() error =", err)
+	}
+	if got, want := len(readCache().Nodes), 4; got != want {
+		t.Errorf("len(graphCache.Nodes) = %v, want %v", got, want)
+	}
+
+	// only the manifests missing from a stale cache are fetched
+	s, err = New(tempDir)
+	if err != nil {
+		t.Fatal("New() error =", err)
+	}
+	baz := pushManifest(s, layer, "baz")
+	if err := s.Tag(ctx, baz, "baz"); err != nil {
+		t.Fatal("Store.Tag() error =", err)
+	}
+	s, err = NewWithOptions(ctx, tempDir, opts)
+	if err != nil {
+		t.Fatal("NewWithOptions() error =", err)
+	}
+	checkPredecessors(s, layer, []ocispec.Descriptor{foo, bar, baz})
+	if got, want := len(readCache().Nodes), 5; got != want {
+		t.Errorf("len(graphCache.Nodes) = %v, want %v", got, want)
+	}
+
+	// a corrupted cache is rebuilt
+	if err := os.WriteFile(fooPath, fooJSON, 0666); err != nil {
+		t.Fatal("os.WriteFile() error =", err)
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, graphCacheFile), []byte("{"), 0666); err != nil {
+		t.Fatal("os.WriteFile() error =", err)
+	}
+	s, err = NewWithOptions(ctx, tempDir, opts)
+	if err != nil {
+		t.Fatal("NewWithOptions() error =", err)
+	}
+	checkPredecessors(s, layer, []ocispec.Descriptor{foo, bar, baz})
+	if got, want := len(readCache().Nodes), 5; got != want {
+		t.Errorf("len(graphCache.Nodes) = %v, want %v", got, want)
+	}
+
+	// the cache is rewritten on GC
+	if err := s.Untag(ctx, "bar"); err != nil {
+		t.Fatal("Store.Untag() error =", err)
+	}
+	if err := s.GC(ctx); err != nil {
+		t.Fatal("Store.GC() error =", err)
+	}
+	if got, want := len(readCache().Nodes), 4; got != want {
+		t.Errorf("len(graphCache.Nodes) = %v, want %v", got, want)
+	}
+}
+
+func TestStore_GraphCache_WriteFailed(t *testing.T) {
+	tempDir := t.TempDir()
+	ctx := context.Background()
+
+	// a non-empty directory cannot be replaced by the cache file
+	cachePath := filepath.Join(tempDir, graphCacheFile)
+	if err := os.MkdirAll(filepath.Join(cachePath, "dir"), 0777); err != nil {
+		t.Fatal("os.MkdirAll() error =", err)
+	}
+	s, err := NewWithOptions(ctx, tempDir, StoreOptions{GraphCache: true})
+	if err != nil {
+		t.Fatal("NewWithOptions() error =", err)
+	}
+
+	blob := []byte("foo")
+	desc := content.NewDescriptorFromBytes("test", blob)
+	if err := s.Push(ctx, desc, bytes.NewReader(blob)); err != nil {
+		t.Fatal("Store.Push() error =", err)
+	}
+	if err := s.Tag(ctx, desc, "foo"); err != nil {
+		t.Fatal("Store.Tag() error =", err)
+	}
+	if err := s.SaveIndex(); err != nil {
+		t.Fatal("Store.SaveIndex() error =", err)
+	}
+	if _, err := NewWithOptions(ctx, tempDir, StoreOptions{GraphCache: true}); err != nil {
+		t.Fatal("NewWithOptions() error =", err)
+	}
+}
+
+func TestStore_NestedIndex(t *testing.T) {
+	tempDir := t.TempDir()
+	writeBlob := func(mediaType string, blob []byte) ocispec.Descriptor {
+		desc := content.NewDescriptorFromBytes(mediaType, blob)
+		path := filepath.Join(tempDir, filepath.FromSlash(mustBlobPath(t, desc.Digest)))
+		if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+			t.Fatal("os.MkdirAll() error =", err)
+		}
+		if err := os.WriteFile(path, blob, 0666); err != nil {
+			t.Fatal("os.WriteFile() error =", err)
+		}
+		return desc
+	}
+	writeJSON := func(mediaType string, v any) ocispec.Descriptor {
+		blob, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal("json.Marshal() error =", err)
+		}
+		return writeBlob(mediaType, blob)
+	}
+	withRefName := func(desc ocispec.Descriptor, ref string) ocispec.Descriptor {
+		desc.Annotations = map[string]string{ocispec.AnnotationRefName: ref}
+		return desc
+	}
+	newManifest := func(name string) ocispec.Descriptor {
+		config := writeBlob(ocispec.MediaTypeImageConfig, []byte(name))
+		return writeJSON(ocispec.MediaTypeImageManifest, ocispec.Manifest{
+			Versioned: specs.Versioned{SchemaVersion: 2},
+			MediaType: ocispec.MediaTypeImageManifest,
+			Config:    config,
+			Layers:    []ocispec.Descriptor{},
+		})
+	}
+	newIndex := func(manifests ...ocispec.Descriptor) ocispec.Descriptor {
+		return writeJSON(ocispec.MediaTypeImageIndex, ocispec.Index{
+			Versioned: specs.Versioned{SchemaVersion: 2},
+			MediaType: ocispec.MediaTypeImageIndex,
+			Manifests: manifests,
+		})
+	}
+
+	// index.json -> outer -> inner -> amd64
+	//                     -> arm64
+	amd64 := newManifest("amd64")
+	arm64 := newManifest("arm64")
+	inner := newIndex(withRefName(amd64, "amd64"))
+	outer := newIndex(withRefName(inner, "inner"), withRefName(arm64, "arm64"))
+	standalone := newManifest("standalone")
+	indexJSON, err := json.Marshal(ocispec.Index{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+		MediaType: ocispec.MediaTypeImageIndex,
+		Manifests: []ocispec.Descriptor{
+			withRefName(standalone, "standalone"),
+			outer,
+			withRefName(standalone, "arm64"),
+		},
+	})
+	if err != nil {
+		t.Fatal("json.Marshal() error =", err)
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, ocispec.ImageIndexFile), indexJSON, 0666); err != nil {
+		t.Fatal("os.WriteFile() error =", err)
+	}
+
+	ctx := context.Background()
+	s, err := New(tempDir)
+	if err != nil {
+		t.Fatal("New() error =", err)
+	}
+
+	// resolve the reference names at any nesting level
+	tests := []struct {
+		reference string
+		want      ocispec.Descriptor
+	}{
+		{reference: "inner", want: withRefName(inner, "inner")},
+		{reference: "amd64", want: withRefName(amd64, "amd64")},
+		// the entries of index.json take precedence
+		{reference: "arm64", want: withRefName(standalone, "arm64")},
+	}
+	for _, tt := range tests {
+		got, err := s.Resolve(ctx, tt.reference)
+		if err != nil {
+			t.Fatalf("Store.Resolve(%s) error = %v", tt.reference, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Store.Resolve(%s) = %v, want %v", tt.reference, got, tt.want)
+		}
+	}
+	if err := s.Tags(ctx, "", func(got []string) error {
+		want := []string{"amd64", "arm64", "inner", "standalone"}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Store.Tags() = %v, want %v", got, want)
+		}
+		return nil
+	}); err != nil {
+		t.Fatal("Store.Tags() error =", err)
+	}
+	if err := s.Untag(ctx, "amd64"); !errors.Is(err, errdef.ErrUnsupported) {
+		t.Errorf("Store.Untag() error = %v, want %v", err, errdef.ErrUnsupported)
+	}
+
+	// the structure of index.json is preserved on save
+	if err := s.Tag(ctx, amd64, "latest"); err != nil {
+		t.Fatal("Store.Tag() error =", err)
+	}
+	readIndex := func() []ocispec.Descriptor {
+		index, err := readIndexFile(filepath.Join(tempDir, ocispec.ImageIndexFile))
+		if err != nil {
+			t.Fatal("readIndexFile() error =", err)
+		}
+		return index.Manifests
+	}
+	want := []ocispec.Descriptor{
+		withRefName(standalone, "standalone"),
+		outer,
+		withRefName(standalone, "arm64"),
+		withRefName(amd64, "latest"),
+	}
+	if got := readIndex(); !reflect.DeepEqual(got, want) {
+		t.Errorf("index.json manifests = %v, want %v", got, want)
+	}
+
+	// GC keeps the untagged index with nested reference names
+	if err := s.GC(ctx); err != nil {
+		t.Fatal("Store.GC() error =", err)
+	}
+	if got, err := s.Resolve(ctx, "inner"); err != nil || !content.Equal(got, inner) {
+		t.Errorf("Store.Resolve(inner) = %v, %v, want %v", got, err, inner)
+	}
+	if err := s.SaveIndex(); err != nil {
+		t.Fatal("Store.SaveIndex() error =", err)
+	}
+	if got := readIndex(); !reflect.DeepEqual(got, want) {
+		t.Errorf("index.json manifests = %v, want %v", got, want)
+	}
+
+	// reopen the store
+	s, err = New(tempDir)
+	if err != nil {
+		t.Fatal("New() error =", err)
+	}
+	if got, err := s.Resolve(ctx, "amd64"); err != nil || !content.Equal(got, amd64) {
+		t.Errorf("Store.Resolve(amd64) = %v, %v, want %v", got, err, amd64)
+	}
+
+	// the nested reference names are removed along with their index
+	if err := s.Delete(ctx, outer); err != nil {
+		t.Fatal("Store.Delete() error =", err)
+	}
+	if _, err := s.Resolve(ctx, "inner"); !errors.Is(err, errdef.ErrNotFound) {
+		t.Errorf("Store.Resolve(inner) error = %v, want %v", err, errdef.ErrNotFound)
+	}
+
+	// the nested reference names of pushed indexes are resolvable
+	pushedJSON, err := json.Marshal(ocispec.Index{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+		MediaType: ocispec.MediaTypeImageIndex,
+		Manifests: []ocispec.Descriptor{withRefName(standalone, "nested")},
+	})
+	if err != nil {
+		t.Fatal("json.Marshal() error =", err)
+	}
+	pushed := content.NewDescriptorFromBytes(ocispec.MediaTypeImageIndex, pushedJSON)
+	if err := s.Push(ctx, pushed, bytes.NewReader(pushedJSON)); err != nil {
+		t.Fatal("Store.Push() error =", err)
+	}
+	if got, err := s.Resolve(ctx, "nested"); err != nil || !content.Equal(got, standalone) {
+		t.Errorf("Store.Resolve(nested) = %v, %v, want %v", got, err, standalone)
+	}
+}
+
+func TestStore_PushIndex_NestedManifests(t *testing.T) {
+	tempDir := t.TempDir()
+	ctx := context.Background()
+	s, err := New(tempDir)
+	if err != nil {
+		t.Fatal("New() error =", err)
+	}
+	push := func(mediaType string, blob []byte) ocispec.Descriptor {
+		desc := content.NewDescriptorFromBytes(mediaType, blob)
+		if err := s.Push(ctx, desc, bytes.NewReader(blob)); err != nil {
+			t.Fatal("Store.Push() error =", err)
+		}
+		return desc
+	}
+	pushJSON := func(mediaType string, v any) ocispec.Descriptor {
+		blob, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal("json.Marshal() error =", err)
+		}
+		return push(mediaType, blob)
+	}
+	newManifest := func(name string) ocispec.Descriptor {
+		config := push(ocispec.MediaTypeImageConfig, []byte(name))
+		return pushJSON(ocispec.MediaTypeImageManifest, ocispec.Manifest{
+			Versioned: specs.Versioned{SchemaVersion: 2},
+			MediaType: ocispec.MediaTypeImageManifest,
+			Config:    config,
+			Layers:    []ocispec.Descriptor{},
+		})
+	}
+
+	// the manifests are pushed before their index as Copy does
+	amd64 := newManifest("amd64")
+	arm64 := newManifest("arm64")
+	index := pushJSON(ocispec.MediaTypeImageIndex, ocispec.Index{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+		MediaType: ocispec.MediaTypeImageIndex,
+		Manifests: []ocispec.Descriptor{amd64, arm64},
+	})
+	if err := s.Tag(ctx, index, "latest"); err != nil {
+		t.Fatal("Store.Tag() error =", err)
+	}
+
+	// the nested manifests are not the entries of index.json
+	checkIndex := func() {
+		t.Helper()
+		got, err := readIndexFile(filepath.Join(tempDir, ocispec.ImageIndexFile))
+		if err != nil {
+			t.Fatal("readIndexFile() error =", err)
+		}
+		want := []ocispec.Descriptor{index}
+		want[0].Annotations = map[string]string{ocispec.AnnotationRefName: "latest"}
+		if !reflect.DeepEqual(got.Manifests, want) {
+			t.Errorf("index.json manifests = %v, want %v", got.Manifests, want)
+		}
+	}
+	checkIndex()
+
+	// the nested manifests are resolvable by their digests
+	checkResolve := func() {
+		t.Helper()
+		for _, want := range []ocispec.Descriptor{amd64, arm64} {
+			got, err := s.Resolve(ctx, want.Digest.String())
+			if err != nil {
+				t.Fatal("Store.Resolve() error =", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Store.Resolve(%s) = %v, want %v", want.Digest, got, want)
+			}
+		}
+		if err := s.Tags(ctx, "", func(got []string) error {
+			if want := []string{"latest"}; !reflect.DeepEqual(got, want) {
+				t.Errorf("Store.Tags() = %v, want %v", got, want)
+			}
+			return nil
+		}); err != nil {
+			t.Fatal("Store.Tags() error =", err)
+		}
+	}
+	checkResolve()
+	if err := s.Untag(ctx, amd64.Digest.String()); !errors.Is(err, errdef.ErrInvalidReference) {
+		t.Errorf("Store.Untag() error = %v, want %v", err, errdef.ErrInvalidReference)
+	}
+	s, err = New(tempDir)
+	if err != nil {
+		t.Fatal("New() error =", err)
+	}
+	checkResolve()
+
+	// malformed nested indexes are skipped, and recorded if the invalid
+	// manifests are skipped
+	s, err = NewWithOptions(ctx, tempDir, StoreOptions{SkipInvalidManifests: true})
+	if err != nil {
+		t.Fatal("NewWithOptions() error =", err)
+	}
+	malformedJSON := []byte("{")
+	malformed := content.NewDescriptorFromBytes(ocispec.MediaTypeImageIndex, malformedJSON)
+	path := filepath.Join(tempDir, filepath.FromSlash(mustBlobPath(t, malformed.Digest)))
+	if err := os.WriteFile(path, malformedJSON, 0666); err != nil {
+		t.Fatal("os.WriteFile() error =", err)
+	}
+	outer := pushJSON(ocispec.MediaTypeImageIndex, ocispec.Index{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+		MediaType: ocispec.MediaTypeImageIndex,
+		Manifests: []ocispec.Descriptor{malformed},
+	})
+	if got, want := s.invalid.list(), []ocispec.Descriptor{malformed}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Store.invalid = %v, want %v", got, want)
+	}
+	if err := s.Tag(ctx, outer, "outer"); err != nil {
+		t.Fatal("Store.Tag() error =", err)
+	}
+	if _, err := New(tempDir); err == nil {
+		t.Error("New() error = nil, wantErr true")
+	}
+	s, err = NewWithOptions(ctx, tempDir, StoreOptions{SkipInvalidManifests: true})
+	if err != nil {
+		t.Fatal("NewWithOptions() error =", err)
+	}
+	if got, want := s.invalid.list(), []ocispec.Descriptor{malformed}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Store.invalid = %v, want %v", got, want)
+	}
+}
+
+func TestStore_PushIndex_MalformedNestedIndex(t *testing.T) {
+	tempDir := t.TempDir()
+	ctx := context.Background()
+	s, err := New(tempDir)
+	if err != nil {
+		t.Fatal("New() error =", err)
+	}
+	push := func(mediaType string, blob []byte) ocispec.Descriptor {
+		desc := content.NewDescriptorFromBytes(mediaType, blob)
+		if err := s.Push(ctx, desc, bytes.NewReader(blob)); err != nil {
+			t.Fatal("Store.Push() error =", err)
+		}
+		return desc
+	}
+	pushJSON := func(mediaType string, v any) ocispec.Descriptor {
+		blob, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal("json.Marshal() error =", err)
+		}
+		return push(mediaType, blob)
+	}
+	config := push(ocispec.MediaTypeImageConfig, []byte("amd64"))
+	amd64 := pushJSON(ocispec.MediaTypeImageManifest, ocispec.Manifest{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+		MediaType: ocispec.MediaTypeImageManifest,
+		Config:    config,
+		Layers:    []ocispec.Descriptor{},
+	})
+	amd64.Annotations = map[string]string{ocispec.AnnotationRefName: "amd64"}
+	malformedJSON := []byte("{")
+	malformed := content.NewDescriptorFromBytes(ocispec.MediaTypeImageIndex, malformedJSON)
+	path := filepath.Join(tempDir, filepath.FromSlash(mustBlobPath(t, malformed.Digest)))
+	if err := os.WriteFile(path, malformedJSON, 0666); err != nil {
+		t.Fatal("os.WriteFile() error =", err)
+	}
+
+	// a malformed nested index is skipped without SkipInvalidManifests, so
+	// that it does not fail pushing and tagging its valid parent
+	outer := pushJSON(ocispec.MediaTypeImageIndex, ocispec.Index{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+		MediaType: ocispec.MediaTypeImageIndex,
+		Manifests: []ocispec.Descriptor{malformed, amd64},
+	})
+	if err := s.Tag(ctx, outer, "outer"); err != nil {
+		t.Fatal("Store.Tag() error =", err)
+	}
+	if got := s.invalid.list(); len(got) != 0 {
+		t.Errorf("Store.invalid = %v, want none", got)
+	}
+
+	// the reference names of its valid siblings are still resolvable
+	got, err := s.Resolve(ctx, "amd64")
+	if err != nil {
+		t.Fatal("Store.Resolve() error =", err)
+	}
+	if !reflect.DeepEqual(got, amd64) {
+		t.Errorf("Store.Resolve() = %v, want %v", got, amd64)
+	}
+}
+
+func TestStore_Encryption(t *testing.T) {
+	tempDir := t.TempDir()
+	key := bytes.Repeat([]byte("k"), 32)
+	ctx := context.Background()
+	s, err := NewWithOptions(ctx, tempDir, StoreOptions{EncryptionKey: key})
+	if err != nil {
+		t.Fatal("NewWithOptions() error =", err)
+	}
+
+	layerJSON := []byte("proprietary")
+	layer := content.NewDescriptorFromBytes(ocispec.MediaTypeImageLayer, layerJSON)
+	manifestJSON, err := json.Marshal(ocispec.Manifest{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+		MediaType: ocispec.MediaTypeImageManifest,
+		Config:    ocispec.DescriptorEmptyJSON,
+		Layers:    []ocispec.Descriptor{layer},
+	})
+	if err != nil {
+		t.Fatal("json.Marshal() error =", err)
+	}
+	manifest := content.NewDescriptorFromBytes(ocispec.MediaTypeImageManifest, manifestJSON)
+	for _, blob := range []struct {
+		desc    ocispec.Descriptor
+		content []byte
+	}{
+		{ocispec.DescriptorEmptyJSON, ocispec.DescriptorEmptyJSON.Data},
+		{layer, layerJSON},
+		{manifest, manifestJSON},
+	} {
+		if err := s.Push(ctx, blob.desc, bytes.NewReader(blob.content)); err != nil {
+			t.Fatal("Store.Push() error =", err)
+		}
+	}
+	if err := s.Tag(ctx, manifest, "latest"); err != nil {
+		t.Fatal("Store.Tag() error =", err)
+	}
+
+	// the blobs are resolved by the plaintext digests and sizes
+	got, err := s.Resolve(ctx, layer.Digest.String())
+	if err != nil {
+		t.Fatal("Store.Resolve() error =", err)
+	}
+	if got.Size != layer.Size {
+		t.Errorf("Store.Resolve().Size = %v, want %v", got.Size, layer.Size)
+	}
+	report, err := s.Verify(ctx, VerifyOptions{})
+	if err != nil {
+		t.Fatal("Store.Verify() error =", err)
+	}
+	if !report.Healthy() {
+		t.Errorf("Store.Verify() = %+v, want healthy", report)
+	}
+
+	// reopen the store with the key
+	s, err = NewWithOptions(ctx, tempDir, StoreOptions{EncryptionKey: key})
+	if err != nil {
+		t.Fatal("NewWithOptions() error =", err)
+	}
+	desc, err := s.Resolve(ctx, "latest")
+	if err != nil {
+		t.Fatal("Store.Resolve() error =", err)
+	}
+	successors, err := content.Successors(ctx, s, desc)
+	if err != nil {
+		t.Fatal("content.Successors() error =", err)
+	}
+	if want := []ocispec.Descriptor{ocispec.DescriptorEmptyJSON, layer}; !reflect.DeepEqual(successors, want) {
+		t.Errorf("content.Successors() = %v, want %v", successors, want)
+	}
+	got, err = s.Resolve(ctx, layer.Digest.String())
+	if err != nil {
+		t.Fatal("Store.Resolve() error =", err)
+	}
+	fetched, err := content.FetchAll(ctx, s, got)
+	if err != nil {
+		t.Fatal("content.FetchAll() error =", err)
+	}
+	if !bytes.Equal(fetched, layerJSON) {
+		t.Errorf("content.FetchAll() = %s, want %s", fetched, layerJSON)
+	}
+
+	// the layout cannot be opened with another key
+	otherKey := bytes.Repeat([]byte("x"), 32)
+	if _, err := NewWithOptions(ctx, tempDir, StoreOptions{EncryptionKey: otherKey}); !errors.Is(err, aesgcm.ErrInvalidCiphertext) {
+		t.Errorf("NewWithOptions() error = %v, want %v", err, aesgcm.ErrInvalidCiphertext)
+	}
+	if _, err := NewFromFSWithOptions(ctx, os.DirFS(tempDir), ReadOnlyStoreOptions{EncryptionKey: otherKey}); !errors.Is(err, aesgcm.ErrInvalidCiphertext) {
+		t.Errorf("NewFromFSWithOptions() error = %v, want %v", err, aesgcm.ErrInvalidCiphertext)
+	}
+
+	// the layout can be opened read-only with the key
+	ros, err := NewFromFSWithOptions(ctx, os.DirFS(tempDir), ReadOnlyStoreOptions{EncryptionKey: key})
+	if err != nil {
+		t.Fatal("NewFromFSWithOptions() error =", err)
+	}
+	got, err = ros.Resolve(ctx, layer.Digest.String())
+	if err != nil {
+		t.Fatal("ReadOnlyStore.Resolve() error =", err)
+	}
+	fetched, err = content.FetchAll(ctx, ros, got)
+	if err != nil {
+		t.Fatal("content.FetchAll() error =", err)
+	}
+	if !bytes.Equal(fetched, layerJSON) {
+		t.Errorf("content.FetchAll() = %s, want %s", fetched, layerJSON)
+	}
+	report, err = ros.Verify(ctx, VerifyOptions{})
+	if err != nil {
+		t.Fatal("ReadOnlyStore.Verify() error =", err)
+	}
+	if !report.Healthy() {
+		t.Errorf("ReadOnlyStore.Verify() = %+v, want healthy", report)
+	}
+
+	// tampered blobs are reported as corrupted
+	layerPath := filepath.Join(tempDir, mustBlobPath(t, layer.Digest))
+	if err := os.Chmod(layerPath, 0644); err != nil {
+		t.Fatal("os.Chmod() error =", err)
+	}
+	stored, err := os.ReadFile(layerPath)
+	if err != nil {
+		t.Fatal("os.ReadFile() error =", err)
+	}
+	stored[len(stored)-1] ^= 1
+	if err := os.WriteFile(layerPath, stored, 0644); err != nil {
+		t.Fatal("os.WriteFile() error =", err)
+	}
+	report, err = ros.Verify(ctx, VerifyOptions{})
+	if err != nil {
+		t.Fatal("ReadOnlyStore.Verify() error =", err)
+	}
+	if want := []digest.Digest{layer.Digest}; !reflect.DeepEqual(report.CorruptBlobs, want) {
+		t.Errorf("ReadOnlyStore.Verify().CorruptBlobs = %v, want %v", report.CorruptBlobs, want)
+	}
+
+	// the encrypted storage works as the fallback storage of a file store
+	ociStorage, err := NewStorage(t.TempDir())
+	if err != nil {
+		t.Fatal("NewStorage() error =", err)
+	}
+	storage, err := content.NewEncryptedStorage(ociStorage, key)
+	if err != nil {
+		t.Fatal("content.NewEncryptedStorage() error =", err)
+	}
+	fileStore, err := file.NewWithFallbackStorage(t.TempDir(), storage)
+	if err != nil {
+		t.Fatal("file.NewWithFallbackStorage() error =", err)
+	}
+	defer fileStore.Close()
+	if err := fileStore.Push(ctx, manifest, bytes.NewReader(manifestJSON)); err != nil {
+		t.Fatal("file.Store.Push() error =", err)
+	}
+	fetched, err = content.FetchAll(ctx, fileStore, manifest)
+	if err != nil {
+		t.Fatal("content.FetchAll() error =", err)
+	}
+	if !bytes.Equal(fetched, manifestJSON) {
+		t.Errorf("content.FetchAll() = %s, want %s", fetched, manifestJSON)
+	}
+}
+
+func mustBlobPath(t *testing.T, dgst digest.Digest) string {
+	t.Helper()
+	path, err := blobPath(dgst)
+	if err != nil {
+		t.Fatal("blobPath() error =", err)
+	}
+	return path
+}
@@ -28,6 +28,7 @@ import (
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 	"oras.land/oras-go/v2/content"
 	"oras.land/oras-go/v2/errdef"
+	"oras.land/oras-go/v2/internal/container/set"
 	"oras.land/oras-go/v2/internal/descriptor"
 	"oras.land/oras-go/v2/internal/fs/tarfs"
 	"oras.land/oras-go/v2/internal/graph"
@@ -42,15 +43,58 @@ type ReadOnlyStore struct {
 	storage     content.ReadOnlyStorage
 	tagResolver *resolver.Memory
 	graph       *graph.Memory
+
+	// nestedResolver resolves the digests and the reference names of the
+	// manifests nested in the image indexes referenced by `index.json`.
+	nestedResolver *resolver.Memory
+	// invalid records the corrupted or malformed manifests skipped on
+	// loading the index. It is nil unless
+	// ReadOnlyStoreOptions.SkipInvalidManifests is set.
+	invalid *invalidManifests
+	// encrypted indicates whether the blobs are encrypted at rest.
+	encrypted bool
+}
+
+// ReadOnlyStoreOptions contains parameters for [NewFromFSWithOptions].
+type ReadOnlyStoreOptions struct {
+	// EncryptionKey decrypts the blobs encrypted at rest by a store opened
+	// with the same StoreOptions.EncryptionKey.
+	// If nil, the blobs are read in plaintext.
+	EncryptionKey []byte
+
+	// SkipInvalidManifests opens the store even if some manifests referenced
+	// by `index.json` are corrupted or malformed. The invalid manifests are
+	// skipped when building the graph, and reported by
+	// [ReadOnlyStore.Verify]. If false, the store fails to open with such
+	// manifests.
+	SkipInvalidManifests bool
 }
 
 // NewFromFS creates a new read-only OCI store from fsys.
 func NewFromFS(ctx context.Context, fsys fs.FS) (*ReadOnlyStore, error) {
+	return NewFromFSWithOptions(ctx, fsys, ReadOnlyStoreOptions{})
+}
+
+// NewFromFSWithOptions creates a new read-only OCI store from fsys with the
+// given options.
+func NewFromFSWithOptions(ctx context.Context, fsys fs.FS, opts ReadOnlyStoreOptions) (*ReadOnlyStore, error) {
 	store := &ReadOnlyStore{
-		fsys:        fsys,
-		storage:     NewStorageFromFS(fsys),
-		tagResolver: resolver.NewMemory(),
-		graph:       graph.NewMemory(),
+		fsys:           fsys,
+		storage:        NewStorageFromFS(fsys),
+		tagResolver:    resolver.NewMemory(),
+		graph:          graph.NewMemory(),
+		nestedResolver: resolver.NewMemory(),
+	}
+	if opts.SkipInvalidManifests {
+		store.invalid = newInvalidManifests()
+	}
+	if opts.EncryptionKey != nil {
+		storage, err := content.NewEncryptedStorage(store.storage, opts.EncryptionKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create storage: %w", err)
+		}
+		store.storage = storage
+		store.encrypted = true
 	}
 
 	if err := store.validateOCILayoutFile(); err != nil {
@@ -88,6 +132,10 @@ func (s *ReadOnlyStore) Exists(ctx context.Context, target ocispec.Descriptor) (
 //     a full descriptor declared by github.com/opencontainers/image-spec/specs-go/v1.
 //   - If the reference is a digest, the returned descriptor will be a
 //     plain descriptor (containing only the digest, media type and size).
+//
+// Tags can be the reference names of the entries of `index.json`, or of the
+// manifests nested in the image indexes referenced by `index.json`, at any
+// nesting level. The entries of `index.json` take precedence.
 func (s *ReadOnlyStore) Resolve(ctx context.Context, reference string) (ocispec.Descriptor, error) {
 	if reference == "" {
 		return ocispec.Descriptor{}, errdef.ErrMissingReference
@@ -97,8 +145,22 @@ func (s *ReadOnlyStore) Resolve(ctx context.Context, reference string) (ocispec.
 	desc, err := s.tagResolver.Resolve(ctx, reference)
 	if err != nil {
 		if errors.Is(err, errdef.ErrNotFound) {
+			// attempt resolving nested manifest
+			if desc, err := s.nestedResolver.Resolve(ctx, reference); err == nil {
+				if reference == desc.Digest.String() {
+					return descriptor.Plain(desc), nil
+				}
+				return desc, nil
+			}
 			// attempt resolving blob
-			return resolveBlob(s.fsys, reference)
+			desc, err := resolveBlob(s.fsys, reference)
+			if err != nil {
+				return ocispec.Descriptor{}, err
+			}
+			if desc.Size, err = contentSize(desc.Size, s.encrypted); err != nil {
+				return ocispec.Descriptor{}, fmt.Errorf("%s: %w", desc.Digest, err)
+			}
+			return desc, nil
 		}
 		return ocispec.Descriptor{}, err
 	}
@@ -118,14 +180,15 @@ func (s *ReadOnlyStore) Predecessors(ctx context.Context, node ocispec.Descripto
 }
 
 // Tags lists the tags presented in the `index.json` file of the OCI layout,
-// returned in ascending order.
+// including the reference names of the nested manifests, returned in ascending
+// order.
 // If `last` is NOT empty, the entries in the response start after the tag
 // specified by `last`. Otherwise, the response starts from the top of the tags
 // list.
 //
 // See also `Tags()` in the package `registry`.
 func (s *ReadOnlyStore) Tags(ctx context.Context, last string, fn func(tags []string) error) error {
-	return listTags(s.tagResolver, last, fn)
+	return listTags(s.tagResolver, s.nestedResolver, last, fn)
 }
 
 // validateOCILayoutFile validates the `oci-layout` file.
@@ -164,11 +227,29 @@ func (s *ReadOnlyStore) loadIndexFile(ctx context.Context) error {
 	if err := json.NewDecoder(indexFile).Decode(&index); err != nil {
 		return fmt.Errorf("failed to decode index file: %w", err)
 	}
-	return loadIndex(ctx, &index, s.storage, s.tagResolver, s.graph)
+	if s.encrypted {
+		if err := verifyKey(ctx, s.storage, index.Manifests); err != nil {
+			return err
+		}
+	}
+	return loadIndex(ctx, &index, s.storage, s.tagResolver, s.nestedResolver, s.graph, s.invalid)
+}
+
+// loadIndex loads index into memory. Corrupted or malformed manifests are
+// skipped and recorded in invalid, if invalid is not nil.
+func loadIndex(ctx context.Context, index *ocispec.Index, fetcher content.Fetcher, tagger content.Tagger, nestedResolver *resolver.Memory, graph *graph.Memory, invalid *invalidManifests) error {
+	if err := tagIndex(ctx, index, tagger); err != nil {
+		return err
+	}
+	if err := tagNested(ctx, index.Manifests, fetcher, nestedResolver, invalid); err != nil {
+		return err
+	}
+	return indexGraph(ctx, index, fetcher, graph, invalid)
 }
 
-// loadIndex loads index into memory.
-func loadIndex(ctx context.Context, index *ocispec.Index, fetcher content.Fetcher, tagger content.Tagger, graph *graph.Memory) error {
+// tagIndex tags the manifests referenced by the index by their digests and
+// reference names.
+func tagIndex(ctx context.Context, index *ocispec.Index, tagger content.Tagger) error {
 	for _, desc := range index.Manifests {
 		if err := tagger.Tag(ctx, deleteAnnotationRefName(desc), desc.Digest.String()); err != nil {
 			return err
@@ -178,8 +259,71 @@ func loadIndex(ctx context.Context, index *ocispec.Index, fetcher content.Fetche
 				return err
 			}
 		}
+	}
+	return nil
+}
+
+// tagNested tags the manifests nested in the image indexes described by roots
+// by their digests and reference names, at any nesting level. The indexes are
+// walked in breadth-first order, and the first manifest found for a reference
+// name wins.
+// Indexes not present in fetcher, and corrupted or malformed indexes are
+// skipped. The latter are recorded in invalid, if invalid is not nil.
+func tagNested(ctx context.Context, roots []ocispec.Descriptor, fetcher content.Fetcher, nestedResolver *resolver.Memory, invalid *invalidManifests) error {
+	visited := set.New[digest.Digest]()
+	queue := slices.Clone(roots)
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		if !descriptor.IsIndex(node) || visited.Contains(node.Digest) {
+			continue
+		}
+		visited.Add(node.Digest)
+
+		indexJSON, err := content.FetchAll(ctx, fetcher, node)
+		if err != nil {
+			if errors.Is(err, errdef.ErrNotFound) {
+				continue
+			}
+			if isInvalidContent(err) {
+				invalid.add(node)
+				continue
+			}
+			return err
+		}
+		var index ocispec.Index
+		if err := json.Unmarshal(indexJSON, &index); err != nil {
+			if isInvalidContent(err) {
+				invalid.add(node)
+				continue
+			}
+			return fmt.Errorf("failed to decode index %s: %w", node.Digest, err)
+		}
+		for _, desc := range index.Manifests {
+			for _, ref := range []string{desc.Digest.String(), desc.Annotations[ocispec.AnnotationRefName]} {
+				if ref == "" {
+					continue
+				}
+				if _, err := nestedResolver.Resolve(ctx, ref); err == nil {
+					continue
+				}
+				if err := nestedResolver.Tag(ctx, desc, ref); err != nil {
+					return err
+				}
+			}
+		}
+		queue = append(queue, index.Manifests...)
+	}
+	return nil
+}
+
+// indexGraph indexes the graph of the manifests referenced by the index.
+// Corrupted or malformed manifests are skipped and recorded in invalid, if
+// invalid is not nil.
+func indexGraph(ctx context.Context, index *ocispec.Index, fetcher content.Fetcher, graph *graph.Memory, invalid *invalidManifests) error {
+	for _, desc := range index.Manifests {
 		plain := descriptor.Plain(desc)
-		if err := graph.IndexAll(ctx, fetcher, plain); err != nil {
+		if err := graph.IndexAllSkip(ctx, fetcher, plain, invalid.skip); err != nil {
 			return err
 		}
 	}
@@ -216,14 +360,23 @@ func resolveBlob(fsys fs.FS, dgst string) (ocispec.Descriptor, error) {
 // list.
 //
 // See also `Tags()` in the package `registry`.
-func listTags(tagResolver *resolver.Memory, last string, fn func(tags []string) error) error {
-	var tags []string
-
-	tagMap := tagResolver.Map()
-	for tag, desc := range tagMap {
+func listTags(tagResolver, nestedResolver *resolver.Memory, last string, fn func(tags []string) error) error {
+	tagSet := set.New[string]()
+	for tag, desc := range tagResolver.Map() {
+		if tag == desc.Digest.String() {
+			continue
+		}
+		tagSet.Add(tag)
+	}
+	for tag, desc := range nestedResolver.Map() {
 		if tag == desc.Digest.String() {
 			continue
 		}
+		tagSet.Add(tag)
+	}
+
+	var tags []string
+	for tag := range tagSet {
 		if last != "" && tag <= last {
 			continue
 		}
@@ -798,6 +798,87 @@ func TestReadOnlyStore_Tags(t *testing.T) {
 	}
 }
 
+func TestReadOnlyStore_NestedIndex(t *testing.T) {
+	fsys := fstest.MapFS{}
+	writeJSON := func(mediaType string, v any) ocispec.Descriptor {
+		blob, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		desc := content.NewDescriptorFromBytes(mediaType, blob)
+		path := strings.Join([]string{"blobs", desc.Digest.Algorithm().String(), desc.Digest.Encoded()}, "/")
+		fsys[path] = &fstest.MapFile{Data: blob}
+		return desc
+	}
+	withRefName := func(desc ocispec.Descriptor, ref string) ocispec.Descriptor {
+		desc.Annotations = map[string]string{ocispec.AnnotationRefName: ref}
+		return desc
+	}
+	newIndex := func(manifests ...ocispec.Descriptor) ocispec.Descriptor {
+		return writeJSON(ocispec.MediaTypeImageIndex, ocispec.Index{
+			Versioned: specs.Versioned{SchemaVersion: 2},
+			MediaType: ocispec.MediaTypeImageIndex,
+			Manifests: manifests,
+		})
+	}
+
+	config := writeJSON(ocispec.MediaTypeImageConfig, map[string]string{})
+	manifest := writeJSON(ocispec.MediaTypeImageManifest, ocispec.Manifest{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+		MediaType: ocispec.MediaTypeImageManifest,
+		Config:    config,
+		Layers:    []ocispec.Descriptor{},
+	})
+	inner := newIndex(withRefName(manifest, "v1"))
+	outer := newIndex(withRefName(inner, "inner"))
+	root := newIndex(outer)
+	layoutJSON, err := json.Marshal(ocispec.ImageLayout{Version: ocispec.ImageLayoutVersion})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fsys[ocispec.ImageLayoutFile] = &fstest.MapFile{Data: layoutJSON}
+	indexJSON, err := json.Marshal(ocispec.Index{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+		Manifests: []ocispec.Descriptor{withRefName(root, "root")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fsys[ocispec.ImageIndexFile] = &fstest.MapFile{Data: indexJSON}
+
+	ctx := context.Background()
+	s, err := NewFromFS(ctx, fsys)
+	if err != nil {
+		t.Fatal("NewFromFS() error =", err)
+	}
+	tests := []struct {
+		reference string
+		want      ocispec.Descriptor
+	}{
+		{reference: "root", want: withRefName(root, "root")},
+		{reference: "inner", want: withRefName(inner, "inner")},
+		{reference: "v1", want: withRefName(manifest, "v1")},
+	}
+	for _, tt := range tests {
+		got, err := s.Resolve(ctx, tt.reference)
+		if err != nil {
+			t.Fatalf("ReadOnlyStore.Resolve(%s) error = %v", tt.reference, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ReadOnlyStore.Resolve(%s) = %v, want %v", tt.reference, got, tt.want)
+		}
+	}
+	if err := s.Tags(ctx, "", func(got []string) error {
+		want := []string{"inner", "root", "v1"}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("ReadOnlyStore.Tags() = %v, want %v", got, want)
+		}
+		return nil
+	}); err != nil {
+		t.Fatal("ReadOnlyStore.Tags() error =", err)
+	}
+}
+
 func Test_deleteAnnotationRefName(t *testing.T) {
 	tests := []struct {
 		name string
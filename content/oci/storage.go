@@ -26,10 +26,15 @@ import (
 	"sync"
 
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	contentpkg "oras.land/oras-go/v2/content"
 	"oras.land/oras-go/v2/errdef"
 	"oras.land/oras-go/v2/internal/ioutil"
 )
 
+// ingestDir is the directory of the temporary ingest files in the root
+// directory of the OCI layout.
+const ingestDir = "ingest"
+
 // bufPool is a pool of byte buffers that can be reused for copying content
 // between files.
 var bufPool = sync.Pool{
@@ -62,12 +67,24 @@ func NewStorage(root string) (*Storage, error) {
 	return &Storage{
 		ReadOnlyStorage: NewStorageFromFS(os.DirFS(rootAbs)),
 		root:            rootAbs,
-		ingestRoot:      filepath.Join(rootAbs, "ingest"),
+		ingestRoot:      filepath.Join(rootAbs, ingestDir),
 	}, nil
 }
 
 // Push pushes the content, matching the expected descriptor.
 func (s *Storage) Push(_ context.Context, expected ocispec.Descriptor, content io.Reader) error {
+	return s.push(expected, content, ioutil.CopyBuffer)
+}
+
+// PushRaw pushes the content, matching only the size of the expected
+// descriptor, so that the content can be stored transformed by the digest of
+// its original form, such as by [contentpkg.EncryptedStorage].
+func (s *Storage) PushRaw(_ context.Context, expected ocispec.Descriptor, content io.Reader) error {
+	return s.push(expected, content, copyRaw)
+}
+
+// push pushes the content, copying it with copyFn.
+func (s *Storage) push(expected ocispec.Descriptor, content io.Reader, copyFn copyFunc) error {
 	path, err := blobPath(expected.Digest)
 	if err != nil {
 		return fmt.Errorf("%s: %s: %w", expected.Digest, expected.MediaType, errdef.ErrInvalidDigest)
@@ -86,7 +103,7 @@ func (s *Storage) Push(_ context.Context, expected ocispec.Descriptor, content i
 	}
 
 	// write the content to a temporary ingest file.
-	ingest, err := s.ingest(expected, content)
+	ingest, err := s.ingest(expected, content, copyFn)
 	if err != nil {
 		return err
 	}
@@ -124,8 +141,8 @@ func (s *Storage) Delete(ctx context.Context, target ocispec.Descriptor) error {
 	return nil
 }
 
-// ingest write the content into a temporary ingest file.
-func (s *Storage) ingest(expected ocispec.Descriptor, content io.Reader) (path string, ingestErr error) {
+// ingest write the content into a temporary ingest file with copyFn.
+func (s *Storage) ingest(expected ocispec.Descriptor, content io.Reader, copyFn copyFunc) (path string, ingestErr error) {
 	if err := ensureDir(s.ingestRoot); err != nil {
 		return "", fmt.Errorf("failed to ensure ingest dir: %w", err)
 	}
@@ -154,7 +171,7 @@ func (s *Storage) ingest(expected ocispec.Descriptor, content io.Reader) (path s
 
 	buf := bufPool.Get().(*[]byte)
 	defer bufPool.Put(buf)
-	if err := ioutil.CopyBuffer(fp, content, *buf, expected); err != nil {
+	if err := copyFn(fp, content, *buf, expected); err != nil {
 		return "", fmt.Errorf("failed to ingest: %w", err)
 	}
 
@@ -166,6 +183,29 @@ func (s *Storage) ingest(expected ocispec.Descriptor, content io.Reader) (path s
 	return
 }
 
+// copyFunc copies from src to dst through buf, verifying the content against
+// desc.
+type copyFunc func(dst io.Writer, src io.Reader, buf []byte, desc ocispec.Descriptor) error
+
+// copyRaw copies from src to dst through buf, verifying the size of the
+// content against desc but not its digest.
+func copyRaw(dst io.Writer, src io.Reader, buf []byte, desc ocispec.Descriptor) error {
+	n, err := io.CopyBuffer(dst, io.LimitReader(src, desc.Size), buf)
+	if err != nil {
+		return fmt.Errorf("copy failed: %w", err)
+	}
+	if n != desc.Size {
+		return io.ErrUnexpectedEOF
+	}
+	var p [1]byte
+	if _, err := io.ReadFull(src, p[:]); err == nil {
+		return contentpkg.ErrTrailingData
+	} else if err != io.EOF {
+		return err
+	}
+	return nil
+}
+
 // ensureDir ensures the directories of the path exists.
 func ensureDir(path string) error {
 	return os.MkdirAll(path, 0777)
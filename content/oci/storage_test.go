@@ -29,6 +29,7 @@ import (
 	"github.com/opencontainers/go-digest"
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 	"golang.org/x/sync/errgroup"
+	contentpkg "oras.land/oras-go/v2/content"
 	"oras.land/oras-go/v2/errdef"
 )
 
@@ -417,3 +418,76 @@ func TestStorage_Delete(t *testing.T) {
 		t.Fatalf("got error = %v, want %v", err, errdef.ErrNotFound)
 	}
 }
+
+func TestStorage_PushRaw(t *testing.T) {
+	content := []byte("hello world")
+	desc := ocispec.Descriptor{
+		MediaType: "test",
+		Digest:    digest.FromBytes([]byte("foo")),
+		Size:      int64(len(content)),
+	}
+
+	tempDir := t.TempDir()
+	s, err := NewStorage(tempDir)
+	if err != nil {
+		t.Fatal("NewStorage() error =", err)
+	}
+	ctx := context.Background()
+
+	// the content is stored without verifying the digest
+	if err := s.PushRaw(ctx, desc, bytes.NewReader(content)); err != nil {
+		t.Fatal("Storage.PushRaw() error =", err)
+	}
+	rc, err := s.Fetch(ctx, desc)
+	if err != nil {
+		t.Fatal("Storage.Fetch() error =", err)
+	}
+	got, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatal("Storage.Fetch().Read() error =", err)
+	}
+	rc.Close()
+	if !bytes.Equal(got, content) {
+		t.Errorf("Storage.Fetch() = %v, want %v", got, content)
+	}
+	if err := s.PushRaw(ctx, desc, bytes.NewReader(content)); !errors.Is(err, errdef.ErrAlreadyExists) {
+		t.Errorf("Storage.PushRaw() error = %v, want %v", err, errdef.ErrAlreadyExists)
+	}
+
+	// the size is verified
+	tests := []struct {
+		name    string
+		content []byte
+		wantErr error
+	}{
+		{
+			name:    "short content",
+			content: content[:5],
+			wantErr: io.ErrUnexpectedEOF,
+		},
+		{
+			name:    "trailing data",
+			content: append([]byte("bar"), content...),
+			wantErr: contentpkg.ErrTrailingData,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			desc := ocispec.Descriptor{
+				MediaType: "test",
+				Digest:    digest.FromBytes([]byte(tt.name)),
+				Size:      int64(len(content)),
+			}
+			if err := s.PushRaw(ctx, desc, bytes.NewReader(tt.content)); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Storage.PushRaw() error = %v, want %v", err, tt.wantErr)
+			}
+			exists, err := s.Exists(ctx, desc)
+			if err != nil {
+				t.Fatal("Storage.Exists() error =", err)
+			}
+			if exists {
+				t.Errorf("Storage.Exists() = %v, want %v", exists, false)
+			}
+		})
+	}
+}
@@ -0,0 +1,599 @@
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oci
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/internal/aesgcm"
+	"oras.land/oras-go/v2/internal/container/set"
+	"oras.land/oras-go/v2/internal/descriptor"
+	"oras.land/oras-go/v2/internal/resolver"
+	"oras.land/oras-go/v2/internal/syncutil"
+)
+
+// Default parameters of VerifyOptions.
+const (
+	defaultVerifyConcurrency = 3
+	defaultStaleIngestAge    = 24 * time.Hour
+)
+
+// quarantineDir is the directory in the root directory where corrupted blobs
+// are moved to by Store.Repair.
+const quarantineDir = "quarantine"
+
+// VerifyOptions contains parameters for [Store.Verify], [Store.Repair] and
+// [ReadOnlyStore.Verify].
+type VerifyOptions struct {
+	// Concurrency limits the maximum number of blobs hashed concurrently.
+	// If less than or equal to 0, a default (currently 3) is used.
+	Concurrency int
+
+	// StaleIngestAge is the minimum age of a temporary ingest file to be
+	// considered stale. Younger ingest files may belong to pushes in
+	// progress.
+	// If less than or equal to 0, a default (currently 24 hours) is used.
+	StaleIngestAge time.Duration
+}
+
+// VerifyReport reports the integrity issues found in an OCI layout.
+// Blobs are reported by their digests, and the slices are sorted.
+type VerifyReport struct {
+	// CorruptBlobs are the blobs whose content does not match the digests
+	// in their paths.
+	CorruptBlobs []digest.Digest
+
+	// MissingNodes are the nodes reachable from the index whose blobs do not
+	// exist. Foreign layers are not reported since they are not required to
+	// exist in the layout.
+	MissingNodes []ocispec.Descriptor
+
+	// SizeMismatches are the nodes reachable from the index whose sizes
+	// differ from the sizes of their blobs.
+	SizeMismatches []ocispec.Descriptor
+
+	// InvalidManifests are the manifests reachable from the index whose
+	// blobs are intact but cannot be decoded.
+	InvalidManifests []ocispec.Descriptor
+
+	// DanglingBlobs are the intact blobs not reachable from the index, which
+	// are removable by garbage collection.
+	DanglingBlobs []digest.Digest
+
+	// StaleIngestFiles are the paths, relative to the root directory, of the
+	// temporary ingest files older than VerifyOptions.StaleIngestAge.
+	// It is reported only by [Store].
+	StaleIngestFiles []string
+}
+
+// Healthy returns true if no blob is corrupted or missing, no node has a
+// mismatched size, and no manifest is malformed.
+// Dangling blobs and stale ingest files waste space but do not harm the
+// integrity of the layout.
+func (r *VerifyReport) Healthy() bool {
+	return len(r.CorruptBlobs) == 0 &&
+		len(r.MissingNodes) == 0 &&
+		len(r.SizeMismatches) == 0 &&
+		len(r.InvalidManifests) == 0
+}
+
+// Verify checks the integrity of the store without modifying it.
+//   - Every blob is re-hashed against the digest in its path.
+//   - Every node reachable from the index, including the tagged and the
+//     untagged manifests that are not yet saved, is checked to exist with
+//     the right size.
+//   - Blobs not reachable from the index are reported as dangling.
+//   - Temporary ingest files older than VerifyOptions.StaleIngestAge are
+//     reported as stale.
+func (s *Store) Verify(ctx context.Context, opts VerifyOptions) (*VerifyReport, error) {
+	s.sync.RLock()
+	defer s.sync.RUnlock()
+
+	return s.verify(ctx, opts)
+}
+
+// Repair verifies the store as Verify does, and then repairs the store:
+//   - Corrupted blobs and malformed manifests are moved to the `quarantine`
+//     directory in the root directory, keeping their blob paths. The
+//     quarantined content is then missing from the store, and can be pushed
+//     again. The untagged entries of the quarantined manifests are removed
+//     from the index, while the tagged ones are kept.
+//   - Stale ingest files are removed.
+//
+// Missing nodes and size mismatches are not repaired as
+// they require the content to be pushed again. Dangling blobs are left to
+// GC.
+// The blobs are re-hashed without blocking the other operations on the
+// store, and the reported corrupted blobs are re-hashed again before they are
+// quarantined.
+// Repair returns the report of the verification prior to the repair.
+func (s *Store) Repair(ctx context.Context, opts VerifyOptions) (*VerifyReport, error) {
+	report, err := s.Verify(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	s.sync.Lock()
+	defer s.sync.Unlock()
+
+	quarantined := set.New[digest.Digest]()
+	for _, dgst := range report.CorruptBlobs {
+		// the blob may be replaced since the verification
+		_, intact, err := hashBlob(ctx, s.blobLayout(), dgst)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return nil, err
+		}
+		if intact {
+			continue
+		}
+		if err := s.quarantine(dgst); err != nil {
+			return nil, err
+		}
+		quarantined.Add(dgst)
+	}
+	for _, desc := range report.InvalidManifests {
+		if err := s.quarantine(desc.Digest); err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return nil, err
+		}
+		quarantined.Add(desc.Digest)
+	}
+	if len(quarantined) > 0 {
+		if err := s.removeQuarantined(ctx, quarantined); err != nil {
+			return nil, err
+		}
+	}
+	for _, ingest := range report.StaleIngestFiles {
+		if err := os.Remove(filepath.Join(s.root, filepath.FromSlash(ingest))); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("failed to remove stale ingest file %s: %w", ingest, err)
+		}
+	}
+	return report, nil
+}
+
+// quarantine moves the blob of the given digest to the quarantine directory.
+// The caller must hold s.sync.
+func (s *Store) quarantine(dgst digest.Digest) error {
+	blob, err := blobPath(dgst)
+	if err != nil {
+		return err
+	}
+	src := filepath.Join(s.root, filepath.FromSlash(blob))
+	dst := filepath.Join(s.root, quarantineDir, dgst.Algorithm().String(), dgst.Encoded())
+	if err := ensureDir(filepath.Dir(dst)); err != nil {
+		return err
+	}
+	if err := os.Rename(src, dst); err != nil {
+		return fmt.Errorf("failed to quarantine blob %s: %w", dgst, err)
+	}
+	return nil
+}
+
+// removeQuarantined removes the quarantined content from the metadata of the
+// store. The caller must hold s.sync.
+func (s *Store) removeQuarantined(ctx context.Context, quarantined set.Set[digest.Digest]) error {
+	untagged := false
+	for ref, desc := range s.tagResolver.Map() {
+		if quarantined.Contains(desc.Digest) && ref == desc.Digest.String() && !s.isTagged(desc) {
+			s.tagResolver.Untag(ref)
+			untagged = true
+		}
+	}
+	for _, node := range s.graph.Nodes() {
+		if quarantined.Contains(node.Descriptor.Digest) {
+			s.graph.Remove(node.Descriptor)
+		}
+	}
+	for dgst := range quarantined {
+		s.invalid.remove(dgst)
+	}
+	if untagged && s.AutoSaveIndex {
+		if err := s.saveIndex(); err != nil {
+			return err
+		}
+	}
+	if s.maxSize > 0 {
+		size, err := blobsSize(os.DirFS(s.root))
+		if err != nil {
+			return err
+		}
+		s.size.Store(size)
+	}
+	return s.reloadNested(ctx)
+}
+
+// verify checks the integrity of the store. The caller must hold s.sync.
+func (s *Store) verify(ctx context.Context, opts VerifyOptions) (*VerifyReport, error) {
+	report, err := verifyLayout(ctx, s.blobLayout(), s.tagResolver, s.invalid, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	staleIngestAge := opts.StaleIngestAge
+	if staleIngestAge <= 0 {
+		staleIngestAge = defaultStaleIngestAge
+	}
+	entries, err := os.ReadDir(filepath.Join(s.root, ingestDir))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read ingest dir: %w", err)
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				// the ingest file is committed or removed
+				continue
+			}
+			return nil, err
+		}
+		if time.Since(info.ModTime()) >= staleIngestAge {
+			report.StaleIngestFiles = append(report.StaleIngestFiles, path.Join(ingestDir, entry.Name()))
+		}
+	}
+	return report, nil
+}
+
+// Verify checks the integrity of the store.
+//   - Every blob is re-hashed against the digest in its path.
+//   - Every node reachable from the index is checked to exist with the right
+//     size.
+//   - Blobs not reachable from the index are reported as dangling.
+//
+// Verify requires the file system of the store to implement fs.ReadDirFS or
+// to support reading directories via Open.
+func (s *ReadOnlyStore) Verify(ctx context.Context, opts VerifyOptions) (*VerifyReport, error) {
+	return verifyLayout(ctx, blobLayout{
+		fsys:      s.fsys,
+		storage:   s.storage,
+		encrypted: s.encrypted,
+	}, s.tagResolver, s.invalid, opts)
+}
+
+// blobLayout provides access to the blobs of an OCI layout.
+type blobLayout struct {
+	// fsys is the file system of the layout.
+	fsys fs.FS
+	// storage fetches the content of the blobs, decrypting it if the blobs
+	// are encrypted.
+	storage content.ReadOnlyStorage
+	// encrypted indicates whether the blobs are encrypted at rest.
+	encrypted bool
+}
+
+// blobLayout returns the blobLayout of the store.
+func (s *Store) blobLayout() blobLayout {
+	return blobLayout{
+		fsys:      os.DirFS(s.root),
+		storage:   s.storage,
+		encrypted: s.encrypted,
+	}
+}
+
+// verifyLayout checks the integrity of the blobs in layout, and the graph
+// reachable from the descriptors resolvable by tagResolver. The manifests
+// recorded in invalid are reported as well.
+func verifyLayout(ctx context.Context, layout blobLayout, tagResolver *resolver.Memory, invalid *invalidManifests, opts VerifyOptions) (*VerifyReport, error) {
+	storage := layout.storage
+	blobs, err := listBlobs(layout.fsys)
+	if err != nil {
+		return nil, err
+	}
+	sizes, corrupted, err := hashBlobs(ctx, layout, blobs, opts.Concurrency)
+	if err != nil {
+		return nil, err
+	}
+	report := &VerifyReport{
+		CorruptBlobs: slices.Collect(maps.Keys(corrupted)),
+	}
+
+	// walk the graph from the index
+	visited := set.New[descriptor.Descriptor]()
+	reachable := set.New[digest.Digest]()
+	var stack []ocispec.Descriptor
+	for _, desc := range tagResolver.Map() {
+		stack = append(stack, descriptor.Plain(desc))
+	}
+	for len(stack) > 0 {
+		if err := isContextDone(ctx); err != nil {
+			return nil, err
+		}
+		desc := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		key := descriptor.FromOCI(desc)
+		if visited.Contains(key) {
+			continue
+		}
+		visited.Add(key)
+		reachable.Add(desc.Digest)
+
+		size, exists := sizes[desc.Digest]
+		switch {
+		case corrupted.Contains(desc.Digest):
+			// already reported
+			continue
+		case !exists:
+			if !descriptor.IsForeignLayer(desc) {
+				report.MissingNodes = append(report.MissingNodes, desc)
+			}
+			continue
+		case size != desc.Size:
+			report.SizeMismatches = append(report.SizeMismatches, desc)
+			continue
+		}
+		successors, err := content.Successors(ctx, storage, desc)
+		if err != nil {
+			if isInvalidContent(err) {
+				report.InvalidManifests = append(report.InvalidManifests, desc)
+				continue
+			}
+			return nil, err
+		}
+		for _, successor := range successors {
+			stack = append(stack, descriptor.Plain(successor))
+		}
+	}
+	for _, desc := range invalid.list() {
+		// report the manifests no longer reachable from the index
+		if _, exists := sizes[desc.Digest]; exists && !reachable.Contains(desc.Digest) && !corrupted.Contains(desc.Digest) {
+			report.InvalidManifests = append(report.InvalidManifests, desc)
+			reachable.Add(desc.Digest)
+		}
+	}
+	for dgst := range sizes {
+		if !reachable.Contains(dgst) && !corrupted.Contains(dgst) {
+			report.DanglingBlobs = append(report.DanglingBlobs, dgst)
+		}
+	}
+
+	slices.Sort(report.CorruptBlobs)
+	slices.Sort(report.DanglingBlobs)
+	for _, descs := range [][]ocispec.Descriptor{report.MissingNodes, report.SizeMismatches, report.InvalidManifests} {
+		slices.SortFunc(descs, func(a, b ocispec.Descriptor) int {
+			return strings.Compare(a.Digest.String(), b.Digest.String())
+		})
+	}
+	return report, nil
+}
+
+// listBlobs lists the digests of the blobs in fsys. Irrelevant files in the
+// blobs directory are skipped.
+func listBlobs(fsys fs.FS) ([]digest.Digest, error) {
+	algDirs, err := fs.ReadDir(fsys, ocispec.ImageBlobsDir)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read blobs dir: %w", err)
+	}
+	var blobs []digest.Digest
+	for _, algDir := range algDirs {
+		alg := algDir.Name()
+		if !algDir.IsDir() || !isKnownAlgorithm(alg) {
+			continue
+		}
+		entries, err := fs.ReadDir(fsys, path.Join(ocispec.ImageBlobsDir, alg))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read blobs dir: %w", err)
+		}
+		for _, entry := range entries {
+			dgst := digest.NewDigestFromEncoded(digest.Algorithm(alg), entry.Name())
+			if entry.IsDir() || dgst.Validate() != nil {
+				// skip irrelevant content
+				continue
+			}
+			blobs = append(blobs, dgst)
+		}
+	}
+	return blobs, nil
+}
+
+// hashBlobs re-hashes the blobs in layout concurrently, and returns the
+// sizes of their content and the set of the corrupted blobs.
+func hashBlobs(ctx context.Context, layout blobLayout, blobs []digest.Digest, concurrency int) (map[digest.Digest]int64, set.Set[digest.Digest], error) {
+	if concurrency <= 0 {
+		concurrency = defaultVerifyConcurrency
+	}
+	var lock sync.Mutex
+	sizes := make(map[digest.Digest]int64, len(blobs))
+	corrupted := set.New[digest.Digest]()
+
+	eg, egCtx := syncutil.LimitGroup(ctx, concurrency)
+	for _, dgst := range blobs {
+		eg.Go(func() error {
+			size, intact, err := hashBlob(egCtx, layout, dgst)
+			if err != nil {
+				return err
+			}
+			lock.Lock()
+			defer lock.Unlock()
+			sizes[dgst] = size
+			if !intact {
+				corrupted.Add(dgst)
+			}
+			return nil
+		})
+	}
+	if err := eg.Wait(); err != nil {
+		return nil, nil, err
+	}
+	return sizes, corrupted, nil
+}
+
+// hashBlob re-hashes the blob of the given digest in layout, and returns the
+// size of its content and whether the content matches the digest.
+// Blobs of unavailable algorithms are considered intact, and encrypted blobs
+// failing the decryption are considered corrupted.
+func hashBlob(ctx context.Context, layout blobLayout, dgst digest.Digest) (int64, bool, error) {
+	blob, err := blobPath(dgst)
+	if err != nil {
+		return 0, false, err
+	}
+	fp, err := layout.fsys.Open(blob)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to open blob %s: %w", dgst, err)
+	}
+	defer fp.Close()
+
+	if !dgst.Algorithm().Available() || layout.encrypted {
+		fi, err := fp.Stat()
+		if err != nil {
+			return 0, false, fmt.Errorf("failed to stat blob %s: %w", dgst, err)
+		}
+		size, err := contentSize(fi.Size(), layout.encrypted)
+		if err != nil {
+			return 0, false, nil
+		}
+		if !layout.encrypted {
+			return size, true, nil
+		}
+		return hashEncryptedBlob(ctx, layout.storage, dgst, size)
+	}
+	verifier := dgst.Verifier()
+	size, err := io.Copy(verifier, &contextReader{ctx: ctx, r: fp})
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to read blob %s: %w", dgst, err)
+	}
+	return size, verifier.Verified(), nil
+}
+
+// hashEncryptedBlob decrypts the blob of the given digest and content size
+// from storage, and returns whether the content matches the digest.
+func hashEncryptedBlob(ctx context.Context, storage content.ReadOnlyStorage, dgst digest.Digest, size int64) (int64, bool, error) {
+	rc, err := storage.Fetch(ctx, ocispec.Descriptor{
+		MediaType: descriptor.DefaultMediaType,
+		Digest:    dgst,
+		Size:      size,
+	})
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to open blob %s: %w", dgst, err)
+	}
+	defer rc.Close()
+	if _, err := io.Copy(io.Discard, &contextReader{ctx: ctx, r: rc}); err != nil {
+		if isInvalidContent(err) {
+			return size, false, nil
+		}
+		return 0, false, fmt.Errorf("failed to read blob %s: %w", dgst, err)
+	}
+	return size, true, nil
+}
+
+// contextReader is an io.Reader which stops reading on context cancellation.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+// Read reads from the underlying reader unless the context is done.
+func (r *contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
+
+// invalidManifests records the corrupted or malformed manifests skipped on
+// loading the index. It is safe for concurrent use. A nil invalidManifests
+// skips and records nothing.
+type invalidManifests struct {
+	lock  sync.Mutex
+	descs map[digest.Digest]ocispec.Descriptor
+}
+
+// newInvalidManifests creates a new invalidManifests.
+func newInvalidManifests() *invalidManifests {
+	return &invalidManifests{
+		descs: make(map[digest.Digest]ocispec.Descriptor),
+	}
+}
+
+// skip records desc and returns true if err indicates that the content of
+// desc is corrupted or malformed. It returns false if m is nil, so that the
+// errors are not skipped.
+func (m *invalidManifests) skip(desc ocispec.Descriptor, err error) bool {
+	if m == nil || !isInvalidContent(err) {
+		return false
+	}
+	m.add(desc)
+	return true
+}
+
+// add records desc as a corrupted or malformed manifest.
+func (m *invalidManifests) add(desc ocispec.Descriptor) {
+	if m == nil {
+		return
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.descs[desc.Digest] = descriptor.Plain(desc)
+}
+
+// remove removes the record of the manifest of the given digest.
+func (m *invalidManifests) remove(dgst digest.Digest) {
+	if m == nil {
+		return
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	delete(m.descs, dgst)
+}
+
+// list returns the recorded manifests.
+func (m *invalidManifests) list() []ocispec.Descriptor {
+	if m == nil {
+		return nil
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return slices.Collect(maps.Values(m.descs))
+}
+
+// isInvalidContent returns true if err indicates that the fetched content
+// does not match its descriptor or cannot be decoded.
+func isInvalidContent(err error) bool {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	return errors.Is(err, content.ErrMismatchedDigest) ||
+		errors.Is(err, content.ErrTrailingData) ||
+		errors.Is(err, content.ErrInvalidDescriptorSize) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, aesgcm.ErrInvalidCiphertext) ||
+		errors.As(err, &syntaxErr) ||
+		errors.As(err, &typeErr)
+}
@@ -0,0 +1,411 @@
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oci
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/errdef"
+	"oras.land/oras-go/v2/internal/descriptor"
+)
+
+func TestStore_VerifyAndRepair(t *testing.T) {
+	tempDir := t.TempDir()
+	ctx := context.Background()
+	s, err := New(tempDir)
+	if err != nil {
+		t.Fatal("New() error =", err)
+	}
+
+	push := func(mediaType string, blob []byte) ocispec.Descriptor {
+		desc := content.NewDescriptorFromBytes(mediaType, blob)
+		if err := s.Push(ctx, desc, bytes.NewReader(blob)); err != nil {
+			t.Fatal("Store.Push() error =", err)
+		}
+		return desc
+	}
+	pushManifest := func(layers ...ocispec.Descriptor) ocispec.Descriptor {
+		manifestJSON, err := json.Marshal(ocispec.Manifest{
+			MediaType: ocispec.MediaTypeImageManifest,
+			Config:    ocispec.DescriptorEmptyJSON,
+			Layers:    layers,
+		})
+		if err != nil {
+			t.Fatal("json.Marshal() error =", err)
+		}
+		return push(ocispec.MediaTypeImageManifest, manifestJSON)
+	}
+	blobFile := func(dgst digest.Digest) string {
+		return filepath.Join(tempDir, filepath.FromSlash(mustBlobPath(t, dgst)))
+	}
+
+	push(ocispec.MediaTypeEmptyJSON, ocispec.DescriptorEmptyJSON.Data)
+	corrupted := push(ocispec.MediaTypeImageLayer, []byte("corrupted"))
+	missing := push(ocispec.MediaTypeImageLayer, []byte("missing"))
+	mismatched := push(ocispec.MediaTypeImageLayer, []byte("mismatched"))
+	dangling := push(ocispec.MediaTypeImageLayer, []byte("dangling"))
+	// dangling blobs do not harm the integrity
+	report, err := s.Verify(ctx, VerifyOptions{})
+	if err != nil {
+		t.Fatal("Store.Verify() error =", err)
+	}
+	if !report.Healthy() {
+		t.Errorf("VerifyReport.Healthy() = false, want true: %+v", report)
+	}
+
+	foreign := ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageLayerNonDistributableGzip, //nolint:staticcheck
+		Digest:    digest.FromString("foreign"),
+		Size:      7,
+		URLs:      []string{"https://example.com/foreign"},
+	}
+	wrongSize := mismatched
+	wrongSize.Size++
+	manifest := pushManifest(corrupted, missing, wrongSize, foreign)
+	if err := s.Tag(ctx, manifest, "latest"); err != nil {
+		t.Fatal("Store.Tag() error =", err)
+	}
+
+	// damage the store
+	if err := os.WriteFile(blobFile(corrupted.Digest), []byte("corrupteD"), 0666); err != nil {
+		t.Fatal("os.WriteFile() error =", err)
+	}
+	if err := os.Remove(blobFile(missing.Digest)); err != nil {
+		t.Fatal("os.Remove() error =", err)
+	}
+	staleIngest := filepath.Join(tempDir, "ingest", "stale")
+	freshIngest := filepath.Join(tempDir, "ingest", "fresh")
+	for _, name := range []string{staleIngest, freshIngest} {
+		if err := os.WriteFile(name, []byte("partial"), 0666); err != nil {
+			t.Fatal("os.WriteFile() error =", err)
+		}
+	}
+	past := time.Now().Add(-2 * time.Hour)
+	if err := os.Chtimes(staleIngest, past, past); err != nil {
+		t.Fatal("os.Chtimes() error =", err)
+	}
+
+	opts := VerifyOptions{StaleIngestAge: time.Hour}
+	want := &VerifyReport{
+		CorruptBlobs:     []digest.Digest{corrupted.Digest},
+		MissingNodes:     []ocispec.Descriptor{missing},
+		SizeMismatches:   []ocispec.Descriptor{wrongSize},
+		DanglingBlobs:    []digest.Digest{dangling.Digest},
+		StaleIngestFiles: []string{"ingest/stale"},
+	}
+	report, err = s.Verify(ctx, opts)
+	if err != nil {
+		t.Fatal("Store.Verify() error =", err)
+	}
+	if !reflect.DeepEqual(report, want) {
+		t.Errorf("Store.Verify() = %+v, want %+v", report, want)
+	}
+	if report.Healthy() {
+		t.Error("VerifyReport.Healthy() = true, want false")
+	}
+
+	// repair the store
+	report, err = s.Repair(ctx, opts)
+	if err != nil {
+		t.Fatal("Store.Repair() error =", err)
+	}
+	if !reflect.DeepEqual(report, want) {
+		t.Errorf("Store.Repair() = %+v, want %+v", report, want)
+	}
+	quarantined := filepath.Join(tempDir, quarantineDir, corrupted.Digest.Algorithm().String(), corrupted.Digest.Encoded())
+	if got, err := os.ReadFile(quarantined); err != nil || string(got) != "corrupteD" {
+		t.Errorf("quarantined blob = %q, %v, want %q", got, err, "corrupteD")
+	}
+	if _, err := os.Stat(blobFile(corrupted.Digest)); !os.IsNotExist(err) {
+		t.Errorf("os.Stat(corrupted blob) error = %v, want not exist", err)
+	}
+	if _, err := os.Stat(staleIngest); !os.IsNotExist(err) {
+		t.Errorf("os.Stat(stale ingest) error = %v, want not exist", err)
+	}
+	if _, err := os.Stat(freshIngest); err != nil {
+		t.Errorf("os.Stat(fresh ingest) error = %v, want nil", err)
+	}
+
+	// the quarantined blob is missing and can be pushed again
+	want = &VerifyReport{
+		MissingNodes:   []ocispec.Descriptor{corrupted, missing},
+		SizeMismatches: []ocispec.Descriptor{wrongSize},
+		DanglingBlobs:  []digest.Digest{dangling.Digest},
+	}
+	if corrupted.Digest > missing.Digest {
+		want.MissingNodes = []ocispec.Descriptor{missing, corrupted}
+	}
+	report, err = s.Verify(ctx, opts)
+	if err != nil {
+		t.Fatal("Store.Verify() error =", err)
+	}
+	if !reflect.DeepEqual(report, want) {
+		t.Errorf("Store.Verify() = %+v, want %+v", report, want)
+	}
+	push(corrupted.MediaType, []byte("corrupted"))
+	if exists, err := s.Exists(ctx, corrupted); err != nil || !exists {
+		t.Errorf("Store.Exists() = %v, %v, want %v", exists, err, true)
+	}
+}
+
+func TestStore_Repair_InvalidManifests(t *testing.T) {
+	tempDir := t.TempDir()
+	ctx := context.Background()
+	s, err := New(tempDir)
+	if err != nil {
+		t.Fatal("New() error =", err)
+	}
+
+	push := func(mediaType string, blob []byte) ocispec.Descriptor {
+		desc := content.NewDescriptorFromBytes(mediaType, blob)
+		if err := s.Push(ctx, desc, bytes.NewReader(blob)); err != nil {
+			t.Fatal("Store.Push() error =", err)
+		}
+		return desc
+	}
+	pushManifest := func(layer ocispec.Descriptor, name string) ocispec.Descriptor {
+		manifestJSON, err := json.Marshal(ocispec.Manifest{
+			MediaType:   ocispec.MediaTypeImageManifest,
+			Config:      ocispec.DescriptorEmptyJSON,
+			Layers:      []ocispec.Descriptor{layer},
+			Annotations: map[string]string{"name": name},
+		})
+		if err != nil {
+			t.Fatal("json.Marshal() error =", err)
+		}
+		desc := push(ocispec.MediaTypeImageManifest, manifestJSON)
+		if err := s.Tag(ctx, desc, name); err != nil {
+			t.Fatal("Store.Tag() error =", err)
+		}
+		return desc
+	}
+	blobFile := func(dgst digest.Digest) string {
+		return filepath.Join(tempDir, filepath.FromSlash(mustBlobPath(t, dgst)))
+	}
+	corrupt := func(desc ocispec.Descriptor) {
+		if err := os.WriteFile(blobFile(desc.Digest), bytes.Repeat([]byte("x"), int(desc.Size)), 0666); err != nil {
+			t.Fatal("os.WriteFile() error =", err)
+		}
+	}
+	checkPredecessors := func(s *Store, node ocispec.Descriptor, want []ocispec.Descriptor) {
+		t.Helper()
+		got, err := s.Predecessors(ctx, node)
+		if err != nil {
+			t.Fatal("Store.Predecessors() error =", err)
+		}
+		if !equalDescriptorSet(got, want) {
+			t.Errorf("Store.Predecessors() = %v, want %v", got, want)
+		}
+	}
+	sortDigests := func(descs ...ocispec.Descriptor) []ocispec.Descriptor {
+		slices.SortFunc(descs, func(a, b ocispec.Descriptor) int {
+			return strings.Compare(a.Digest.String(), b.Digest.String())
+		})
+		return descs
+	}
+
+	push(ocispec.MediaTypeEmptyJSON, ocispec.DescriptorEmptyJSON.Data)
+	layer := push(ocispec.MediaTypeImageLayer, []byte("layer"))
+	foo := pushManifest(layer, "foo")
+	bar := pushManifest(layer, "bar")
+	baz := pushManifest(layer, "baz")
+
+	// add a corrupted manifest and an untagged malformed manifest
+	corrupt(bar)
+	malformedJSON := []byte("{")
+	malformed := content.NewDescriptorFromBytes(ocispec.MediaTypeImageManifest, malformedJSON)
+	if err := os.WriteFile(blobFile(malformed.Digest), malformedJSON, 0666); err != nil {
+		t.Fatal("os.WriteFile() error =", err)
+	}
+	index, err := readIndexFile(filepath.Join(tempDir, ocispec.ImageIndexFile))
+	if err != nil {
+		t.Fatal("readIndexFile() error =", err)
+	}
+	index.Manifests = append(index.Manifests, malformed)
+	indexJSON, err := json.Marshal(index)
+	if err != nil {
+		t.Fatal("json.Marshal() error =", err)
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, ocispec.ImageIndexFile), indexJSON, 0666); err != nil {
+		t.Fatal("os.WriteFile() error =", err)
+	}
+
+	// the store fails to open with the invalid manifests by default
+	if _, err := New(tempDir); !errors.Is(err, content.ErrMismatchedDigest) {
+		t.Errorf("New() error = %v, want %v", err, content.ErrMismatchedDigest)
+	}
+
+	// the store opens with the invalid manifests skipped if requested
+	s, err = NewWithOptions(ctx, tempDir, StoreOptions{SkipInvalidManifests: true})
+	if err != nil {
+		t.Fatal("NewWithOptions() error =", err)
+	}
+	checkPredecessors(s, layer, []ocispec.Descriptor{foo, baz})
+	if got, want := sortDigests(s.invalid.list()...), sortDigests(bar, malformed); !reflect.DeepEqual(got, want) {
+		t.Errorf("Store.invalid = %v, want %v", got, want)
+	}
+
+	// corrupt a manifest indexed on open
+	corrupt(baz)
+	want := &VerifyReport{
+		CorruptBlobs:     []digest.Digest{bar.Digest, baz.Digest},
+		InvalidManifests: []ocispec.Descriptor{malformed},
+	}
+	slices.Sort(want.CorruptBlobs)
+	report, err := s.Repair(ctx, VerifyOptions{})
+	if err != nil {
+		t.Fatal("Store.Repair() error =", err)
+	}
+	if !reflect.DeepEqual(report, want) {
+		t.Errorf("Store.Repair() = %+v, want %+v", report, want)
+	}
+
+	// the quarantined manifests are removed from the graph and the index
+	checkPredecessors(s, layer, []ocispec.Descriptor{foo})
+	if got := s.invalid.list(); len(got) != 0 {
+		t.Errorf("Store.invalid = %v, want empty", got)
+	}
+	if _, err := s.Resolve(ctx, malformed.Digest.String()); !errors.Is(err, errdef.ErrNotFound) {
+		t.Errorf("Store.Resolve() error = %v, want %v", err, errdef.ErrNotFound)
+	}
+	index, err = readIndexFile(filepath.Join(tempDir, ocispec.ImageIndexFile))
+	if err != nil {
+		t.Fatal("readIndexFile() error =", err)
+	}
+	if got, want := len(index.Manifests), 3; got != want {
+		t.Errorf("len(index.Manifests) = %v, want %v", got, want)
+	}
+	want = &VerifyReport{
+		MissingNodes: sortDigests(descriptor.Plain(bar), descriptor.Plain(baz)),
+	}
+	report, err = s.Verify(ctx, VerifyOptions{})
+	if err != nil {
+		t.Fatal("Store.Verify() error =", err)
+	}
+	if !reflect.DeepEqual(report, want) {
+		t.Errorf("Store.Verify() = %+v, want %+v", report, want)
+	}
+}
+
+func TestReadOnlyStore_Verify(t *testing.T) {
+	layer := []byte("layer")
+	layerDesc := content.NewDescriptorFromBytes(ocispec.MediaTypeImageLayer, layer)
+	dangling := []byte("dangling")
+	danglingDesc := content.NewDescriptorFromBytes(ocispec.MediaTypeImageLayer, dangling)
+	manifestJSON, err := json.Marshal(ocispec.Manifest{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Config:    ocispec.DescriptorEmptyJSON,
+		Layers:    []ocispec.Descriptor{layerDesc},
+	})
+	if err != nil {
+		t.Fatal("json.Marshal() error =", err)
+	}
+	manifestDesc := content.NewDescriptorFromBytes(ocispec.MediaTypeImageManifest, manifestJSON)
+	indexJSON, err := json.Marshal(ocispec.Index{
+		MediaType: ocispec.MediaTypeImageIndex,
+		Manifests: []ocispec.Descriptor{manifestDesc},
+	})
+	if err != nil {
+		t.Fatal("json.Marshal() error =", err)
+	}
+	layoutJSON, err := json.Marshal(ocispec.ImageLayout{Version: ocispec.ImageLayoutVersion})
+	if err != nil {
+		t.Fatal("json.Marshal() error =", err)
+	}
+	fsys := fstest.MapFS{
+		ocispec.ImageLayoutFile:                             {Data: layoutJSON},
+		ocispec.ImageIndexFile:                              {Data: indexJSON},
+		mustBlobPath(t, manifestDesc.Digest):                {Data: manifestJSON},
+		mustBlobPath(t, ocispec.DescriptorEmptyJSON.Digest): {Data: ocispec.DescriptorEmptyJSON.Data},
+		mustBlobPath(t, layerDesc.Digest):                   {Data: []byte("LAYER")},
+		mustBlobPath(t, danglingDesc.Digest):                {Data: dangling},
+		"blobs/sha256/irrelevant":                           {Data: []byte("irrelevant")},
+		"blobs/unknown/" + danglingDesc.Digest.Encoded():    {Data: dangling},
+	}
+	s, err := NewFromFS(context.Background(), fsys)
+	if err != nil {
+		t.Fatal("NewFromFS() error =", err)
+	}
+
+	report, err := s.Verify(context.Background(), VerifyOptions{})
+	if err != nil {
+		t.Fatal("ReadOnlyStore.Verify() error =", err)
+	}
+	want := &VerifyReport{
+		CorruptBlobs:  []digest.Digest{layerDesc.Digest},
+		DanglingBlobs: []digest.Digest{danglingDesc.Digest},
+	}
+	if !reflect.DeepEqual(report, want) {
+		t.Errorf("ReadOnlyStore.Verify() = %+v, want %+v", report, want)
+	}
+}
+
+func TestReadOnlyStore_SkipInvalidManifests(t *testing.T) {
+	malformedJSON := []byte("{")
+	malformed := content.NewDescriptorFromBytes(ocispec.MediaTypeImageManifest, malformedJSON)
+	indexJSON, err := json.Marshal(ocispec.Index{
+		MediaType: ocispec.MediaTypeImageIndex,
+		Manifests: []ocispec.Descriptor{malformed},
+	})
+	if err != nil {
+		t.Fatal("json.Marshal() error =", err)
+	}
+	layoutJSON, err := json.Marshal(ocispec.ImageLayout{Version: ocispec.ImageLayoutVersion})
+	if err != nil {
+		t.Fatal("json.Marshal() error =", err)
+	}
+	fsys := fstest.MapFS{
+		ocispec.ImageLayoutFile:           {Data: layoutJSON},
+		ocispec.ImageIndexFile:            {Data: indexJSON},
+		mustBlobPath(t, malformed.Digest): {Data: malformedJSON},
+	}
+	ctx := context.Background()
+
+	// the store fails to open by default
+	if _, err := NewFromFS(ctx, fsys); err == nil {
+		t.Error("NewFromFS() error = nil, wantErr true")
+	}
+
+	// the invalid manifests are skipped and reported if requested
+	s, err := NewFromFSWithOptions(ctx, fsys, ReadOnlyStoreOptions{SkipInvalidManifests: true})
+	if err != nil {
+		t.Fatal("NewFromFSWithOptions() error =", err)
+	}
+	report, err := s.Verify(ctx, VerifyOptions{})
+	if err != nil {
+		t.Fatal("ReadOnlyStore.Verify() error =", err)
+	}
+	want := &VerifyReport{
+		InvalidManifests: []ocispec.Descriptor{malformed},
+	}
+	if !reflect.DeepEqual(report, want) {
+		t.Errorf("ReadOnlyStore.Verify() = %+v, want %+v", report, want)
+	}
+}
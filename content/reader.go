@@ -16,6 +16,7 @@ limitations under the License.
 package content
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"io"
@@ -137,6 +138,23 @@ func ReadAll(r io.Reader, desc ocispec.Descriptor) ([]byte, error) {
 	return buf, nil
 }
 
+// EmbeddedData returns the content embedded in the Data field of the
+// descriptor. The embedded content is verified against the size and the
+// digest of the descriptor.
+// It returns false if the descriptor does not embed any content.
+//
+// Reference: https://github.com/opencontainers/image-spec/blob/v1.1.1/descriptor.md#embedded-content
+func EmbeddedData(desc ocispec.Descriptor) ([]byte, bool, error) {
+	if desc.Data == nil {
+		return nil, false, nil
+	}
+	data, err := ReadAll(bytes.NewReader(desc.Data), desc)
+	if err != nil {
+		return nil, true, fmt.Errorf("invalid embedded data of %s: %w", desc.Digest, err)
+	}
+	return data, true, nil
+}
+
 // ensureEOF ensures the read operation ends with an EOF and no
 // trailing data is present.
 func ensureEOF(r io.Reader) error {
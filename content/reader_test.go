@@ -260,3 +260,35 @@ func TestReadAll_InvalidDescriptorSize(t *testing.T) {
 		t.Errorf("ReadAll() error = %v, want %v", err, ErrInvalidDescriptorSize)
 	}
 }
+
+func TestEmbeddedData(t *testing.T) {
+	content := []byte("example content")
+	desc := NewDescriptorFromBytes("test", content)
+
+	// test no embedded data
+	if _, ok, err := EmbeddedData(desc); ok || err != nil {
+		t.Errorf("EmbeddedData() = %v, %v, want %v, %v", ok, err, false, nil)
+	}
+
+	// test valid embedded data
+	desc.Data = content
+	got, ok, err := EmbeddedData(desc)
+	if !ok || err != nil {
+		t.Fatalf("EmbeddedData() = %v, %v, want %v, %v", ok, err, true, nil)
+	}
+	if !bytes.Equal(got, content) {
+		t.Errorf("EmbeddedData() = %v, want %v", got, content)
+	}
+
+	// test mismatched embedded data
+	desc.Data = []byte("example contenT")
+	if _, ok, err := EmbeddedData(desc); !ok || !errors.Is(err, ErrMismatchedDigest) {
+		t.Errorf("EmbeddedData() = %v, %v, want %v, %v", ok, err, true, ErrMismatchedDigest)
+	}
+
+	// test embedded data of a wrong size
+	desc.Data = content[:5]
+	if _, ok, err := EmbeddedData(desc); !ok || !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("EmbeddedData() = %v, %v, want %v, %v", ok, err, true, io.ErrUnexpectedEOF)
+	}
+}
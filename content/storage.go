@@ -60,9 +60,23 @@ type Deleter interface {
 	Delete(ctx context.Context, target ocispec.Descriptor) error
 }
 
+// RawPusher pushes content without verifying its digest.
+// RawPusher is an extension of Storage, which is required by EncryptedStorage
+// to store the encrypted content by the digest of its plaintext.
+type RawPusher interface {
+	// PushRaw pushes the content, matching only the size of the expected
+	// descriptor.
+	PushRaw(ctx context.Context, expected ocispec.Descriptor, content io.Reader) error
+}
+
 // FetchAll safely fetches the content described by the descriptor.
 // The fetched content is verified against the size and the digest.
+// If desc embeds the content in its Data field, the embedded content is
+// returned after verification without fetching.
 func FetchAll(ctx context.Context, fetcher Fetcher, desc ocispec.Descriptor) ([]byte, error) {
+	if data, ok, err := EmbeddedData(desc); ok {
+		return data, err
+	}
 	rc, err := fetcher.Fetch(ctx, desc)
 	if err != nil {
 		return nil, err
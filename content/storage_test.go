@@ -56,3 +56,31 @@ func TestFetcherFunc_Fetch(t *testing.T) {
 		t.Errorf("FetcherFunc.Fetch() = %v, want %v", got, data)
 	}
 }
+
+func TestFetchAll_EmbeddedData(t *testing.T) {
+	data := []byte("test content")
+	desc := ocispec.Descriptor{
+		MediaType: "test",
+		Digest:    digest.FromBytes(data),
+		Size:      int64(len(data)),
+		Data:      data,
+	}
+	fetcher := FetcherFunc(func(ctx context.Context, target ocispec.Descriptor) (io.ReadCloser, error) {
+		t.Errorf("unexpected fetch: %v", target)
+		return nil, errors.New("unexpected fetch")
+	})
+
+	ctx := context.Background()
+	got, err := FetchAll(ctx, fetcher, desc)
+	if err != nil {
+		t.Fatal("FetchAll() error =", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Errorf("FetchAll() = %v, want %v", got, data)
+	}
+
+	desc.Data = []byte("bad content!")
+	if _, err := FetchAll(ctx, fetcher, desc); !errors.Is(err, ErrMismatchedDigest) {
+		t.Errorf("FetchAll() error = %v, wantErr %v", err, ErrMismatchedDigest)
+	}
+}
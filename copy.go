@@ -16,14 +16,18 @@ limitations under the License.
 package oras
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
 	"io"
+	"net/http"
 
+	"github.com/opencontainers/go-digest"
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 	"golang.org/x/sync/semaphore"
 	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/encryption"
 	"oras.land/oras-go/v2/errdef"
 	"oras.land/oras-go/v2/internal/cas"
 	"oras.land/oras-go/v2/internal/descriptor"
@@ -54,6 +58,31 @@ type CopyOptions struct {
 	// reference will be passed to MapRoot, and the mapped descriptor will be
 	// used as the root node for copy.
 	MapRoot func(ctx context.Context, src content.ReadOnlyStorage, root ocispec.Descriptor) (ocispec.Descriptor, error)
+	// RewriteForeignLayers rewrites the media types of foreign layers to their
+	// distributable equivalents and removes their URLs, so that the foreign
+	// layers are hosted by the destination. Since the manifests referencing
+	// foreign layers are rewritten, the returned root node differs from the
+	// source root node if there is any foreign layer. The rewritten manifests
+	// are re-encoded, which drops the fields unknown to image-spec.
+	// RewriteForeignLayers takes effect only if ForeignLayerClient is not nil.
+	RewriteForeignLayers bool
+	// DecryptLayers decrypts the encrypted layers with the key wrappers, and
+	// rewrites the manifests referencing them accordingly. Copy fails if any
+	// encrypted layer cannot be decrypted with the key wrappers.
+	// See also [encryption.DecryptLayer].
+	DecryptLayers []encryption.KeyWrapper
+	// EncryptLayers encrypts the layers for the recipients of the key
+	// wrappers, and rewrites the manifests referencing them accordingly.
+	// Foreign layers, encrypted layers and empty layers are not encrypted.
+	// If DecryptLayers is also set, the layers are decrypted before being
+	// encrypted, so that the encrypted layers are re-encrypted for the
+	// recipients.
+	// Since the manifests are rewritten, the returned root node differs from
+	// the source root node if any layer is decrypted or encrypted. The
+	// rewritten manifests are re-encoded, which drops the fields unknown to
+	// image-spec.
+	// See also [encryption.EncryptLayer].
+	EncryptLayers []encryption.KeyWrapper
 }
 
 // WithTargetPlatform configures opts.MapRoot to select the manifest whose
@@ -118,6 +147,13 @@ type CopyGraphOptions struct {
 	// source storage to fetch large blobs.
 	// If FindSuccessors is nil, content.Successors will be used.
 	FindSuccessors func(ctx context.Context, fetcher content.Fetcher, desc ocispec.Descriptor) ([]ocispec.Descriptor, error)
+	// ForeignLayerClient is the HTTP client used to download foreign layers
+	// from their URLs.
+	// If not nil, foreign layers are copied instead of being skipped, and
+	// any content that is not found in the source is downloaded from the URLs
+	// of its descriptor with verification. See also [FetchFromURLs].
+	// If nil, foreign layers are skipped.
+	ForeignLayerClient *http.Client
 }
 
 // Copy copies a rooted directed acyclic graph (DAG), such as an artifact,
@@ -159,11 +195,39 @@ func Copy(ctx context.Context, src ReadOnlyTarget, srcRef string, dst Target, ds
 		proxy.StopCaching = false
 	}
 
+	var storage content.ReadOnlyStorage = src
+	if opts.RewriteForeignLayers && opts.ForeignLayerClient != nil {
+		var urls map[digest.Digest][]string
+		root, urls, err = rewriteForeignLayers(ctx, proxy, root)
+		if err != nil {
+			return ocispec.Descriptor{}, newCopyError("RewriteForeignLayers", CopyErrorOriginSource, err)
+		}
+		// keep downloading the rewritten layers from their removed URLs
+		storage = &urlFallbackStorage{
+			ReadOnlyStorage: src,
+			client:          opts.ForeignLayerClient,
+			urls:            urls,
+		}
+	}
+
+	if len(opts.DecryptLayers) != 0 {
+		storage, root, err = decryptLayers(ctx, storage, proxy, root, opts.DecryptLayers)
+		if err != nil {
+			return ocispec.Descriptor{}, newCopyError("DecryptLayers", CopyErrorOriginSource, err)
+		}
+	}
+	if len(opts.EncryptLayers) != 0 {
+		storage, root, err = encryptLayers(ctx, storage, proxy, root, opts.EncryptLayers)
+		if err != nil {
+			return ocispec.Descriptor{}, newCopyError("EncryptLayers", CopyErrorOriginSource, err)
+		}
+	}
+
 	if err := prepareCopy(ctx, dst, dstRef, proxy, root, &opts); err != nil {
 		return ocispec.Descriptor{}, err
 	}
 
-	if err := copyGraph(ctx, src, dst, root, proxy, nil, nil, opts.CopyGraphOptions); err != nil {
+	if err := copyGraph(ctx, storage, dst, root, proxy, nil, nil, opts.CopyGraphOptions); err != nil {
 		return ocispec.Descriptor{}, err
 	}
 
@@ -209,6 +273,8 @@ func copyGraph(ctx context.Context, src content.ReadOnlyStorage, dst content.Sto
 	if opts.FindSuccessors == nil {
 		opts.FindSuccessors = content.Successors
 	}
+	// download the content not found in the source from its URLs if enabled
+	src = withURLFallback(src, opts.ForeignLayerClient)
 
 	// traverse the graph
 	var fn syncutil.GoFunc[ocispec.Descriptor]
@@ -244,7 +310,9 @@ func copyGraph(ctx context.Context, src content.ReadOnlyStorage, dst content.Sto
 		if err != nil {
 			return newCopyError("FindSuccessors", CopyErrorOriginSource, err)
 		}
-		successors = removeForeignLayers(successors)
+		if opts.ForeignLayerClient == nil {
+			successors = removeForeignLayers(successors)
+		}
 
 		if len(successors) != 0 {
 			// for non-leaf nodes, process successors and wait for them to complete
@@ -325,7 +393,7 @@ func mountOrCopyNode(ctx context.Context, src content.ReadOnlyStorage, dst conte
 					return nil, err
 				}
 			}
-			return src.Fetch(ctx, desc)
+			return fetchNode(ctx, src, desc)
 		}
 
 		// Mount or copy
@@ -356,7 +424,7 @@ func mountOrCopyNode(ctx context.Context, src content.ReadOnlyStorage, dst conte
 
 // doCopyNode copies a single content from the source CAS to the destination CAS.
 func doCopyNode(ctx context.Context, src content.ReadOnlyStorage, dst content.Storage, desc ocispec.Descriptor) error {
-	rc, err := src.Fetch(ctx, desc)
+	rc, err := fetchNode(ctx, src, desc)
 	if err != nil {
 		return newCopyError("Fetch", CopyErrorOriginSource, err)
 	}
@@ -368,6 +436,18 @@ func doCopyNode(ctx context.Context, src content.ReadOnlyStorage, dst content.St
 	return nil
 }
 
+// fetchNode fetches the content described by desc from the embedded data of
+// desc if present, or from the fetcher otherwise.
+func fetchNode(ctx context.Context, fetcher content.Fetcher, desc ocispec.Descriptor) (io.ReadCloser, error) {
+	if data, ok, err := content.EmbeddedData(desc); ok {
+		if err != nil {
+			return nil, err
+		}
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}
+	return fetcher.Fetch(ctx, desc)
+}
+
 // copyNode copies a single content from the source CAS to the destination CAS,
 // and apply the given options.
 func copyNode(ctx context.Context, src content.ReadOnlyStorage, dst content.Storage, desc ocispec.Descriptor, opts CopyGraphOptions) error {
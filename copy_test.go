@@ -23,11 +23,14 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net/http"
+	"net/http/httptest"
 	"reflect"
 	"sync/atomic"
 	"testing"
 
 	"github.com/opencontainers/go-digest"
+	specs "github.com/opencontainers/image-spec/specs-go"
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 	"oras.land/oras-go/v2"
 	"oras.land/oras-go/v2/content"
@@ -2022,6 +2025,91 @@ func TestCopyGraph_WithConcurrencyLimit(t *testing.T) {
 	}
 }
 
+func TestCopyGraph_EmbeddedData(t *testing.T) {
+	src := cas.NewMemory()
+	dst := cas.NewMemory()
+
+	// generate test content, where the layers are embedded in the manifest
+	// and do not exist in the source
+	var blobs [][]byte
+	var descs []ocispec.Descriptor
+	appendBlob := func(mediaType string, blob []byte) {
+		blobs = append(blobs, blob)
+		descs = append(descs, ocispec.Descriptor{
+			MediaType: mediaType,
+			Digest:    digest.FromBytes(blob),
+			Size:      int64(len(blob)),
+		})
+	}
+	appendBlob(ocispec.MediaTypeImageConfig, []byte("config")) // Blob 0
+	appendBlob(ocispec.MediaTypeImageLayer, []byte("foo"))     // Blob 1
+	appendBlob(ocispec.MediaTypeImageLayer, []byte("bar"))     // Blob 2
+	layers := make([]ocispec.Descriptor, 2)
+	for i, desc := range descs[1:3] {
+		desc.Data = blobs[i+1]
+		layers[i] = desc
+	}
+	manifestJSON, err := json.Marshal(ocispec.Manifest{
+		Config: descs[0],
+		Layers: layers,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	appendBlob(ocispec.MediaTypeImageManifest, manifestJSON) // Blob 3
+
+	ctx := context.Background()
+	for _, i := range []int{0, 3} {
+		err := src.Push(ctx, descs[i], bytes.NewReader(blobs[i]))
+		if err != nil {
+			t.Fatalf("failed to push test content to src: %d: %v", i, err)
+		}
+	}
+
+	// test copy
+	srcTracker := &storageTracker{Storage: src}
+	root := descs[3]
+	if err := oras.CopyGraph(ctx, srcTracker, dst, root, oras.CopyGraphOptions{}); err != nil {
+		t.Fatalf("CopyGraph() error = %v, wantErr %v", err, false)
+	}
+
+	// verify contents
+	for i := range blobs {
+		got, err := content.FetchAll(ctx, dst, descs[i])
+		if err != nil {
+			t.Errorf("content[%d] error = %v, wantErr %v", i, err, false)
+			continue
+		}
+		if want := blobs[i]; !bytes.Equal(got, want) {
+			t.Errorf("content[%d] = %v, want %v", i, got, want)
+		}
+	}
+
+	// verify API counts
+	if got, want := srcTracker.fetch, int64(2); got != want {
+		t.Errorf("count(src.Fetch()) = %v, want %v", got, want)
+	}
+
+	// test copy with mismatched embedded data
+	layers[0].Data = []byte("baz")
+	manifestJSON, err = json.Marshal(ocispec.Manifest{
+		Config: descs[0],
+		Layers: layers,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	root = content.NewDescriptorFromBytes(ocispec.MediaTypeImageManifest, manifestJSON)
+	if err := src.Push(ctx, root, bytes.NewReader(manifestJSON)); err != nil {
+		t.Fatalf("failed to push test content to src: %v", err)
+	}
+	dst = cas.NewMemory()
+	err = oras.CopyGraph(ctx, src, dst, root, oras.CopyGraphOptions{})
+	if !errors.Is(err, content.ErrMismatchedDigest) {
+		t.Errorf("CopyGraph() error = %v, wantErr %v", err, content.ErrMismatchedDigest)
+	}
+}
+
 func TestCopyGraph_ForeignLayers(t *testing.T) {
 	src := cas.NewMemory()
 	dst := cas.NewMemory()
@@ -2219,6 +2307,256 @@ func TestCopyGraph_ForeignLayers_Mixed(t *testing.T) {
 	}
 }
 
+func TestCopyGraph_ForeignLayers_ForeignLayerClient(t *testing.T) {
+	foreignLayer := []byte("hello")
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/layer" {
+			t.Errorf("unexpected access: %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write(foreignLayer)
+	}))
+	defer ts.Close()
+
+	src := cas.NewMemory()
+	dst := cas.NewMemory()
+
+	// generate test content
+	var blobs [][]byte
+	var descs []ocispec.Descriptor
+	appendBlob := func(mediaType string, blob []byte) {
+		desc := ocispec.Descriptor{
+			MediaType: mediaType,
+			Digest:    digest.FromBytes(blob),
+			Size:      int64(len(blob)),
+		}
+		if mediaType == ocispec.MediaTypeImageLayerNonDistributable {
+			desc.URLs = append(desc.URLs, ts.URL+"/layer")
+		}
+		descs = append(descs, desc)
+		blobs = append(blobs, blob)
+	}
+	generateManifest := func(config ocispec.Descriptor, layers ...ocispec.Descriptor) {
+		manifest := ocispec.Manifest{
+			Config: config,
+			Layers: layers,
+		}
+		manifestJSON, err := json.Marshal(manifest)
+		if err != nil {
+			t.Fatal(err)
+		}
+		appendBlob(ocispec.MediaTypeImageManifest, manifestJSON)
+	}
+
+	appendBlob(ocispec.MediaTypeImageConfig, []byte("config"))            // Blob 0
+	appendBlob(ocispec.MediaTypeImageLayerNonDistributable, foreignLayer) // Blob 1
+	appendBlob(ocispec.MediaTypeImageLayer, []byte("foo"))                // Blob 2
+	generateManifest(descs[0], descs[1:3]...)                             // Blob 3
+
+	ctx := context.Background()
+	for i := range blobs {
+		if i == 1 {
+			// the foreign layer is not in the source
+			continue
+		}
+		err := src.Push(ctx, descs[i], bytes.NewReader(blobs[i]))
+		if err != nil {
+			t.Fatalf("failed to push test content to src: %d: %v", i, err)
+		}
+	}
+
+	// test copy
+	root := descs[len(descs)-1]
+	if err := oras.CopyGraph(ctx, src, dst, root, oras.CopyGraphOptions{
+		ForeignLayerClient: ts.Client(),
+	}); err != nil {
+		t.Fatalf("CopyGraph() error = %v, wantErr %v", err, false)
+	}
+
+	// verify contents
+	contents := dst.Map()
+	if got, want := len(contents), len(blobs); got != want {
+		t.Errorf("len(dst) = %v, wantErr %v", got, want)
+	}
+	for i := range blobs {
+		got, err := content.FetchAll(ctx, dst, descs[i])
+		if err != nil {
+			t.Errorf("content[%d] error = %v, wantErr %v", i, err, false)
+			continue
+		}
+		if want := blobs[i]; !bytes.Equal(got, want) {
+			t.Errorf("content[%d] = %v, want %v", i, got, want)
+		}
+	}
+}
+
+func TestCopyGraph_ForeignLayers_ForeignLayerClient_MismatchedContent(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("world"))
+	}))
+	defer ts.Close()
+
+	src := cas.NewMemory()
+	dst := cas.NewMemory()
+	ctx := context.Background()
+
+	config := []byte("config")
+	configDesc := content.NewDescriptorFromBytes(ocispec.MediaTypeImageConfig, config)
+	layerDesc := content.NewDescriptorFromBytes(ocispec.MediaTypeImageLayerNonDistributable, []byte("hello"))
+	layerDesc.URLs = []string{ts.URL}
+	manifestJSON, err := json.Marshal(ocispec.Manifest{
+		Config: configDesc,
+		Layers: []ocispec.Descriptor{layerDesc},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := content.NewDescriptorFromBytes(ocispec.MediaTypeImageManifest, manifestJSON)
+	if err := src.Push(ctx, configDesc, bytes.NewReader(config)); err != nil {
+		t.Fatal(err)
+	}
+	if err := src.Push(ctx, root, bytes.NewReader(manifestJSON)); err != nil {
+		t.Fatal(err)
+	}
+
+	err = oras.CopyGraph(ctx, src, dst, root, oras.CopyGraphOptions{
+		ForeignLayerClient: ts.Client(),
+	})
+	if !errors.Is(err, content.ErrMismatchedDigest) {
+		t.Fatalf("CopyGraph() error = %v, wantErr %v", err, content.ErrMismatchedDigest)
+	}
+	exists, err := dst.Exists(ctx, layerDesc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exists {
+		t.Errorf("dst.Exists(%v) = %v, want %v", layerDesc.Digest, exists, false)
+	}
+}
+
+func TestCopy_RewriteForeignLayers(t *testing.T) {
+	foreignLayer := []byte("hello")
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(foreignLayer)
+	}))
+	defer ts.Close()
+
+	src := memory.New()
+	dst := memory.New()
+	ctx := context.Background()
+
+	// generate test content: an index referencing a manifest with a docker
+	// foreign layer
+	config := []byte("config")
+	configDesc := content.NewDescriptorFromBytes(docker.MediaTypeConfig, config)
+	layerDesc := content.NewDescriptorFromBytes(docker.MediaTypeForeignLayer, foreignLayer)
+	layerDesc.URLs = []string{ts.URL}
+	manifestJSON, err := json.Marshal(ocispec.Manifest{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+		MediaType: docker.MediaTypeManifest,
+		Config:    configDesc,
+		Layers:    []ocispec.Descriptor{layerDesc},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	manifestDesc := content.NewDescriptorFromBytes(docker.MediaTypeManifest, manifestJSON)
+	manifestDesc.Platform = &ocispec.Platform{OS: "windows", Architecture: "amd64"}
+	indexJSON, err := json.Marshal(ocispec.Index{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+		MediaType: docker.MediaTypeManifestList,
+		Manifests: []ocispec.Descriptor{manifestDesc},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	indexDesc := content.NewDescriptorFromBytes(docker.MediaTypeManifestList, indexJSON)
+	for _, blob := range []struct {
+		desc ocispec.Descriptor
+		data []byte
+	}{
+		{configDesc, config},
+		{manifestDesc, manifestJSON},
+		{indexDesc, indexJSON},
+	} {
+		if err := src.Push(ctx, blob.desc, bytes.NewReader(blob.data)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ref := "foobar"
+	if err := src.Tag(ctx, indexDesc, ref); err != nil {
+		t.Fatal(err)
+	}
+
+	// test copy
+	root, err := oras.Copy(ctx, src, ref, dst, "", oras.CopyOptions{
+		CopyGraphOptions: oras.CopyGraphOptions{
+			ForeignLayerClient: ts.Client(),
+		},
+		RewriteForeignLayers: true,
+	})
+	if err != nil {
+		t.Fatalf("Copy() error = %v, wantErr %v", err, false)
+	}
+	if content.Equal(root, indexDesc) {
+		t.Fatalf("Copy() = %v, want rewritten root", root)
+	}
+	if got, want := root.MediaType, docker.MediaTypeManifestList; got != want {
+		t.Errorf("Copy() media type = %v, want %v", got, want)
+	}
+	gotDesc, err := dst.Resolve(ctx, ref)
+	if err != nil {
+		t.Fatal("dst.Resolve() error =", err)
+	}
+	if !reflect.DeepEqual(gotDesc, root) {
+		t.Errorf("dst.Resolve() = %v, want %v", gotDesc, root)
+	}
+
+	// verify rewritten manifests
+	gotIndexJSON, err := content.FetchAll(ctx, dst, root)
+	if err != nil {
+		t.Fatal("content.FetchAll() error =", err)
+	}
+	var gotIndex ocispec.Index
+	if err := json.Unmarshal(gotIndexJSON, &gotIndex); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(gotIndex.Manifests), 1; got != want {
+		t.Fatalf("len(index.Manifests) = %v, want %v", got, want)
+	}
+	gotManifestDesc := gotIndex.Manifests[0]
+	if !reflect.DeepEqual(gotManifestDesc.Platform, manifestDesc.Platform) {
+		t.Errorf("index.Manifests[0].Platform = %v, want %v", gotManifestDesc.Platform, manifestDesc.Platform)
+	}
+	gotManifestJSON, err := content.FetchAll(ctx, dst, gotManifestDesc)
+	if err != nil {
+		t.Fatal("content.FetchAll() error =", err)
+	}
+	var gotManifest ocispec.Manifest
+	if err := json.Unmarshal(gotManifestJSON, &gotManifest); err != nil {
+		t.Fatal(err)
+	}
+	wantLayerDesc := layerDesc
+	wantLayerDesc.MediaType = docker.MediaTypeLayer
+	wantLayerDesc.URLs = nil
+	if want := []ocispec.Descriptor{wantLayerDesc}; !reflect.DeepEqual(gotManifest.Layers, want) {
+		t.Errorf("manifest.Layers = %v, want %v", gotManifest.Layers, want)
+	}
+	if got, want := gotManifest.MediaType, docker.MediaTypeManifest; got != want {
+		t.Errorf("manifest.MediaType = %v, want %v", got, want)
+	}
+
+	// verify the layer is hosted by dst
+	got, err := content.FetchAll(ctx, dst, wantLayerDesc)
+	if err != nil {
+		t.Fatal("content.FetchAll() error =", err)
+	}
+	if !bytes.Equal(got, foreignLayer) {
+		t.Errorf("content.FetchAll() = %v, want %v", got, foreignLayer)
+	}
+}
+
 func TestCopy_ReferencePusher(t *testing.T) {
 	ctx := context.Background()
 	src := memory.New()
@@ -0,0 +1,173 @@
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oras
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/encryption"
+	"oras.land/oras-go/v2/errdef"
+	"oras.land/oras-go/v2/internal/cas"
+	"oras.land/oras-go/v2/internal/descriptor"
+	"oras.land/oras-go/v2/internal/verifyutil"
+)
+
+// layerTransformStorage serves the transformed layers by transforming the
+// content of their sources fetched from the underlying storage.
+type layerTransformStorage struct {
+	content.ReadOnlyStorage
+	// transforms maps the digests of the transformed layers to their
+	// transforms.
+	transforms map[digest.Digest]*encryption.LayerTransform
+}
+
+// Fetch fetches the content identified by the descriptor.
+func (s *layerTransformStorage) Fetch(ctx context.Context, target ocispec.Descriptor) (io.ReadCloser, error) {
+	transform, ok := s.transforms[target.Digest]
+	if !ok {
+		return s.ReadOnlyStorage.Fetch(ctx, target)
+	}
+	rc, err := fetchNode(ctx, s.ReadOnlyStorage, transform.Source)
+	if err != nil {
+		return nil, err
+	}
+	return verifyutil.NewReadCloser(content.NewVerifyReader(transform.NewReader(rc), target), rc), nil
+}
+
+// Exists returns true if the described content exists.
+func (s *layerTransformStorage) Exists(ctx context.Context, target ocispec.Descriptor) (bool, error) {
+	if transform, ok := s.transforms[target.Digest]; ok {
+		return s.ReadOnlyStorage.Exists(ctx, transform.Source)
+	}
+	return s.ReadOnlyStorage.Exists(ctx, target)
+}
+
+// transformLayers transforms the layers referenced by the manifests in the
+// graph rooted by root with the transform function, which returns nil for
+// the layers to be kept as is. The rewritten manifests are pushed to the
+// cache of the proxy.
+// The returned storage serves the transformed layers from the given storage,
+// along with the descriptor of the rewritten root.
+func transformLayers(ctx context.Context, storage content.ReadOnlyStorage, proxy *cas.Proxy, root ocispec.Descriptor,
+	transform func(ctx context.Context, layer ocispec.Descriptor) (*encryption.LayerTransform, error)) (content.ReadOnlyStorage, ocispec.Descriptor, error) {
+	transformed := &layerTransformStorage{
+		ReadOnlyStorage: storage,
+		transforms:      make(map[digest.Digest]*encryption.LayerTransform),
+	}
+	root, err := rewriteLayers(ctx, proxy, root, func(ctx context.Context, layer ocispec.Descriptor) (ocispec.Descriptor, error) {
+		t, err := transform(ctx, layer)
+		if err != nil || t == nil {
+			return layer, err
+		}
+		transformed.transforms[t.Target.Digest] = t
+		return t.Target, nil
+	})
+	if err != nil {
+		return nil, ocispec.Descriptor{}, err
+	}
+	if len(transformed.transforms) == 0 {
+		return storage, root, nil
+	}
+	return transformed, root, nil
+}
+
+// decryptLayers decrypts the encrypted layers in the graph rooted by root
+// with the key wrappers. See transformLayers for the returned values.
+func decryptLayers(ctx context.Context, storage content.ReadOnlyStorage, proxy *cas.Proxy, root ocispec.Descriptor, wrappers []encryption.KeyWrapper) (content.ReadOnlyStorage, ocispec.Descriptor, error) {
+	return transformLayers(ctx, storage, proxy, root, func(_ context.Context, layer ocispec.Descriptor) (*encryption.LayerTransform, error) {
+		if !encryption.IsEncrypted(layer) {
+			return nil, nil
+		}
+		return encryption.DecryptLayer(layer, wrappers...)
+	})
+}
+
+// encryptLayers encrypts the layers in the graph rooted by root for the
+// recipients of the key wrappers. See transformLayers for the returned
+// values.
+func encryptLayers(ctx context.Context, storage content.ReadOnlyStorage, proxy *cas.Proxy, root ocispec.Descriptor, wrappers []encryption.KeyWrapper) (content.ReadOnlyStorage, ocispec.Descriptor, error) {
+	return transformLayers(ctx, storage, proxy, root, func(ctx context.Context, layer ocispec.Descriptor) (*encryption.LayerTransform, error) {
+		if !isEncryptable(layer) {
+			return nil, nil
+		}
+		return encryptLayer(ctx, storage, layer, wrappers)
+	})
+}
+
+// encryptLayer encrypts the layer fetched from the fetcher for the recipients
+// of the key wrappers.
+func encryptLayer(ctx context.Context, fetcher content.Fetcher, layer ocispec.Descriptor, wrappers []encryption.KeyWrapper) (*encryption.LayerTransform, error) {
+	rc, err := fetchNode(ctx, fetcher, layer)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return encryption.EncryptLayer(rc, layer, wrappers...)
+}
+
+// isEncryptable checks if the layer should be encrypted. Foreign layers,
+// encrypted layers and empty layers are not encrypted.
+func isEncryptable(layer ocispec.Descriptor) bool {
+	return !descriptor.IsForeignLayer(layer) &&
+		!encryption.IsEncrypted(layer) &&
+		layer.MediaType != ocispec.MediaTypeEmptyJSON
+}
+
+// packEncryptedLayers encrypts the layers fetched from the fetcher for the
+// recipients of the key wrappers, pushes the encrypted layers, and returns
+// their descriptors. The plaintext layers are never pushed.
+func packEncryptedLayers(ctx context.Context, fetcher content.Fetcher, pusher content.Pusher, layers []ocispec.Descriptor, wrappers []encryption.KeyWrapper) ([]ocispec.Descriptor, error) {
+	if fetcher == nil {
+		return nil, errors.New("missing layer source")
+	}
+	// copy the layers to avoid modifying the caller's slice
+	encrypted := make([]ocispec.Descriptor, len(layers))
+	for i, layer := range layers {
+		if !isEncryptable(layer) {
+			encrypted[i] = layer
+			continue
+		}
+		transform, err := encryptLayer(ctx, fetcher, layer, wrappers)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s: %w", layer.Digest, layer.MediaType, err)
+		}
+		if err := pushTransformedLayer(ctx, fetcher, pusher, transform); err != nil {
+			return nil, err
+		}
+		encrypted[i] = transform.Target
+	}
+	return encrypted, nil
+}
+
+// pushTransformedLayer pushes the transformed content of the layer fetched
+// from the fetcher.
+func pushTransformedLayer(ctx context.Context, fetcher content.Fetcher, pusher content.Pusher, transform *encryption.LayerTransform) error {
+	rc, err := fetchNode(ctx, fetcher, transform.Source)
+	if err != nil {
+		return fmt.Errorf("%s: %s: %w", transform.Source.Digest, transform.Source.MediaType, err)
+	}
+	defer rc.Close()
+	if err := pusher.Push(ctx, transform.Target, transform.NewReader(rc)); err != nil && !errors.Is(err, errdef.ErrAlreadyExists) {
+		return fmt.Errorf("failed to push: %s: %s: %w", transform.Target.Digest, transform.Target.MediaType, err)
+	}
+	return nil
+}
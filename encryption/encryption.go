@@ -0,0 +1,84 @@
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package encryption provides encryption of image layers compatible with
+// the `+encrypted` layer media types and the `org.opencontainers.image.enc.*`
+// annotations of ocicrypt.
+//
+// A layer is encrypted with a random symmetric key, which is wrapped for the
+// recipients by the key wrappers and recorded in the annotations of the
+// encrypted layer. The JWE and PKCS #7 schemes of ocicrypt are provided by
+// [JWE] and [PKCS7] with local keys, and other schemes can be plugged in by
+// implementing [KeyWrapper].
+//
+// Reference: https://github.com/containers/ocicrypt
+package encryption
+
+import (
+	"errors"
+	"strings"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// MediaTypeSuffix is the suffix appended to the media types of the encrypted
+// layers.
+const MediaTypeSuffix = "+encrypted"
+
+const (
+	// AnnotationKeysPrefix is the prefix of the annotations recording the
+	// wrapped keys of an encrypted layer, followed by the scheme of the key
+	// wrapper, e.g. "org.opencontainers.image.enc.keys.jwe".
+	AnnotationKeysPrefix = "org.opencontainers.image.enc.keys."
+
+	// AnnotationPublicOptions is the annotation recording the public options
+	// of the cipher of an encrypted layer.
+	AnnotationPublicOptions = "org.opencontainers.image.enc.pubopts"
+
+	// annotationPrefix is the prefix of all the encryption annotations.
+	annotationPrefix = "org.opencontainers.image.enc."
+)
+
+var (
+	// ErrNoMatchingKey is returned by KeyWrapper.UnwrapKey when none of the
+	// keys of the wrapper can unwrap the key, and by DecryptLayer when none
+	// of the key wrappers can.
+	ErrNoMatchingKey = errors.New("no matching key")
+
+	// ErrInvalidHMAC is returned when reading the decrypted content of a
+	// layer whose ciphertext does not match its HMAC.
+	ErrInvalidHMAC = errors.New("invalid hmac")
+)
+
+// KeyWrapper wraps the symmetric keys of the layers for the recipients, and
+// unwraps them with the keys of the recipients.
+type KeyWrapper interface {
+	// Scheme returns the name of the key wrapping scheme, which is the suffix
+	// of the annotation key recording the wrapped keys, e.g. "jwe".
+	Scheme() string
+
+	// WrapKey wraps the private options of a layer, which contain the
+	// symmetric key.
+	WrapKey(opts []byte) ([]byte, error)
+
+	// UnwrapKey unwraps the private options of a layer. ErrNoMatchingKey is
+	// returned if none of the keys of the wrapper can unwrap them.
+	UnwrapKey(wrapped []byte) ([]byte, error)
+}
+
+// IsEncrypted checks if desc describes an encrypted layer.
+func IsEncrypted(desc ocispec.Descriptor) bool {
+	return strings.HasSuffix(desc.MediaType, MediaTypeSuffix)
+}
@@ -0,0 +1,503 @@
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package encryption
+
+import (
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"math/big"
+
+	"oras.land/oras-go/v2/errdef"
+)
+
+// JWE algorithms.
+// Reference: https://www.rfc-editor.org/rfc/rfc7518
+const (
+	jweAlgRSAOAEP           = "RSA-OAEP"
+	jweAlgRSAOAEP256        = "RSA-OAEP-256"
+	jweAlgECDHESA128KW      = "ECDH-ES+A128KW"
+	jweAlgECDHESA192KW      = "ECDH-ES+A192KW"
+	jweAlgECDHESA256KW      = "ECDH-ES+A256KW"
+	jweEncA128GCM           = "A128GCM"
+	jweEncA192GCM           = "A192GCM"
+	jweEncA256GCM           = "A256GCM"
+	jweContentEncryptionAlg = jweEncA256GCM
+)
+
+// JWE wraps the keys of the layers in JSON Web Encryption (JWE) messages in
+// the JSON serialization, as ocicrypt does.
+//   - Keys are wrapped for RSA recipients with RSA-OAEP, and for ECDSA
+//     recipients with ECDH-ES+A256KW.
+//   - The content of the messages is encrypted with A256GCM.
+//
+// Reference: https://www.rfc-editor.org/rfc/rfc7516
+type JWE struct {
+	// PublicKeys are the keys of the recipients to wrap the keys for, of the
+	// type *rsa.PublicKey or *ecdsa.PublicKey.
+	PublicKeys []crypto.PublicKey
+
+	// PrivateKeys are the keys to unwrap the keys with, of the type
+	// *rsa.PrivateKey or *ecdsa.PrivateKey.
+	PrivateKeys []crypto.PrivateKey
+}
+
+// Scheme returns "jwe".
+func (w *JWE) Scheme() string {
+	return "jwe"
+}
+
+// jweMessage is a JWE message in the general or the flattened JSON
+// serialization.
+type jweMessage struct {
+	Protected    string         `json:"protected,omitempty"`
+	Unprotected  *jweHeader     `json:"unprotected,omitempty"`
+	Header       *jweHeader     `json:"header,omitempty"`
+	EncryptedKey string         `json:"encrypted_key,omitempty"`
+	Recipients   []jweRecipient `json:"recipients,omitempty"`
+	AAD          string         `json:"aad,omitempty"`
+	IV           string         `json:"iv"`
+	Ciphertext   string         `json:"ciphertext"`
+	Tag          string         `json:"tag"`
+}
+
+// jweRecipient is a recipient of a JWE message.
+type jweRecipient struct {
+	Header       *jweHeader `json:"header,omitempty"`
+	EncryptedKey string     `json:"encrypted_key,omitempty"`
+}
+
+// jweHeader contains the JWE header parameters in use.
+type jweHeader struct {
+	Alg string  `json:"alg,omitempty"`
+	Enc string  `json:"enc,omitempty"`
+	EPK *jwkKey `json:"epk,omitempty"`
+	APU string  `json:"apu,omitempty"`
+	APV string  `json:"apv,omitempty"`
+}
+
+// merge returns the header with the parameters of other filled in.
+func (h jweHeader) merge(other *jweHeader) jweHeader {
+	if other == nil {
+		return h
+	}
+	if h.Alg == "" {
+		h.Alg = other.Alg
+	}
+	if h.Enc == "" {
+		h.Enc = other.Enc
+	}
+	if h.EPK == nil {
+		h.EPK = other.EPK
+	}
+	if h.APU == "" {
+		h.APU = other.APU
+	}
+	if h.APV == "" {
+		h.APV = other.APV
+	}
+	return h
+}
+
+// jwkKey is an elliptic curve public key in the JSON Web Key (JWK) format.
+type jwkKey struct {
+	Kty string `json:"kty"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// WrapKey wraps opts in a JWE message for all the public keys.
+func (w *JWE) WrapKey(opts []byte) ([]byte, error) {
+	if len(w.PublicKeys) == 0 {
+		return nil, errors.New("jwe: no public keys")
+	}
+	cek := make([]byte, 32)
+	if _, err := rand.Read(cek); err != nil {
+		return nil, err
+	}
+	protected, err := json.Marshal(jweHeader{Enc: jweContentEncryptionAlg})
+	if err != nil {
+		return nil, err
+	}
+	message := jweMessage{
+		Protected: b64(protected),
+	}
+	for _, key := range w.PublicKeys {
+		recipient, err := wrapJWEKey(cek, key)
+		if err != nil {
+			return nil, err
+		}
+		message.Recipients = append(message.Recipients, recipient)
+	}
+
+	gcm, err := newGCM(cek)
+	if err != nil {
+		return nil, err
+	}
+	iv := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(iv); err != nil {
+		return nil, err
+	}
+	sealed := gcm.Seal(nil, iv, opts, []byte(message.Protected))
+	tagStart := len(sealed) - gcm.Overhead()
+	message.IV = b64(iv)
+	message.Ciphertext = b64(sealed[:tagStart])
+	message.Tag = b64(sealed[tagStart:])
+	return json.Marshal(message)
+}
+
+// UnwrapKey decrypts the JWE message wrapped with any of the private keys.
+func (w *JWE) UnwrapKey(wrapped []byte) ([]byte, error) {
+	var message jweMessage
+	if err := json.Unmarshal(wrapped, &message); err != nil {
+		return nil, fmt.Errorf("jwe: invalid message: %w", err)
+	}
+	var protected jweHeader
+	if message.Protected != "" {
+		protectedJSON, err := unb64(message.Protected)
+		if err != nil {
+			return nil, fmt.Errorf("jwe: invalid protected header: %w", err)
+		}
+		if err := json.Unmarshal(protectedJSON, &protected); err != nil {
+			return nil, fmt.Errorf("jwe: invalid protected header: %w", err)
+		}
+	}
+	recipients := message.Recipients
+	if len(recipients) == 0 {
+		// flattened serialization
+		recipients = []jweRecipient{{Header: message.Header, EncryptedKey: message.EncryptedKey}}
+	}
+	iv, err := unb64(message.IV)
+	if err != nil {
+		return nil, fmt.Errorf("jwe: invalid iv: %w", err)
+	}
+	ciphertext, err := unb64(message.Ciphertext)
+	if err != nil {
+		return nil, fmt.Errorf("jwe: invalid ciphertext: %w", err)
+	}
+	tag, err := unb64(message.Tag)
+	if err != nil {
+		return nil, fmt.Errorf("jwe: invalid tag: %w", err)
+	}
+	aad := message.Protected
+	if message.AAD != "" {
+		aad += "." + message.AAD
+	}
+
+	for _, recipient := range recipients {
+		header := protected.merge(message.Unprotected).merge(recipient.Header)
+		for _, key := range w.PrivateKeys {
+			cek, err := unwrapJWEKey(header, recipient.EncryptedKey, key)
+			if err != nil {
+				// the key does not belong to the recipient
+				continue
+			}
+			if keySize, ok := jweEncKeySizes[header.Enc]; !ok {
+				return nil, fmt.Errorf("jwe: content encryption %q: %w", header.Enc, errdef.ErrUnsupported)
+			} else if len(cek) != keySize {
+				continue
+			}
+			gcm, err := newGCM(cek)
+			if err != nil {
+				return nil, err
+			}
+			if len(iv) != gcm.NonceSize() {
+				return nil, errors.New("jwe: invalid iv")
+			}
+			plaintext, err := gcm.Open(nil, iv, append(ciphertext, tag...), []byte(aad))
+			if err != nil {
+				continue
+			}
+			return plaintext, nil
+		}
+	}
+	return nil, ErrNoMatchingKey
+}
+
+// jweEncKeySizes are the key sizes of the supported content encryption
+// algorithms.
+var jweEncKeySizes = map[string]int{
+	jweEncA128GCM: 16,
+	jweEncA192GCM: 24,
+	jweEncA256GCM: 32,
+}
+
+// wrapJWEKey wraps the content encryption key for the recipient of the public
+// key.
+func wrapJWEKey(cek []byte, key crypto.PublicKey) (jweRecipient, error) {
+	switch key := key.(type) {
+	case *rsa.PublicKey:
+		encryptedKey, err := rsa.EncryptOAEP(sha1.New(), rand.Reader, key, cek, nil)
+		if err != nil {
+			return jweRecipient{}, fmt.Errorf("jwe: failed to wrap key: %w", err)
+		}
+		return jweRecipient{
+			Header:       &jweHeader{Alg: jweAlgRSAOAEP},
+			EncryptedKey: b64(encryptedKey),
+		}, nil
+	case *ecdsa.PublicKey:
+		pub, err := key.ECDH()
+		if err != nil {
+			return jweRecipient{}, fmt.Errorf("jwe: invalid public key: %w", err)
+		}
+		ephemeral, err := pub.Curve().GenerateKey(rand.Reader)
+		if err != nil {
+			return jweRecipient{}, err
+		}
+		z, err := ephemeral.ECDH(pub)
+		if err != nil {
+			return jweRecipient{}, fmt.Errorf("jwe: failed to agree on key: %w", err)
+		}
+		epk, err := newJWK(key.Curve, ephemeral.PublicKey())
+		if err != nil {
+			return jweRecipient{}, err
+		}
+		header := &jweHeader{Alg: jweAlgECDHESA256KW, EPK: epk}
+		kek := concatKDF(z, header.Alg, nil, nil, 32)
+		encryptedKey, err := aesKeyWrap(kek, cek)
+		if err != nil {
+			return jweRecipient{}, err
+		}
+		return jweRecipient{
+			Header:       header,
+			EncryptedKey: b64(encryptedKey),
+		}, nil
+	default:
+		return jweRecipient{}, fmt.Errorf("jwe: public key type %T: %w", key, errdef.ErrUnsupported)
+	}
+}
+
+// unwrapJWEKey unwraps the content encryption key of a recipient with the
+// private key.
+func unwrapJWEKey(header jweHeader, encodedKey string, key crypto.PrivateKey) ([]byte, error) {
+	encryptedKey, err := unb64(encodedKey)
+	if err != nil {
+		return nil, err
+	}
+	switch key := key.(type) {
+	case *rsa.PrivateKey:
+		var h hash.Hash
+		switch header.Alg {
+		case jweAlgRSAOAEP:
+			h = sha1.New()
+		case jweAlgRSAOAEP256:
+			h = sha256.New()
+		default:
+			return nil, fmt.Errorf("jwe: algorithm %q: %w", header.Alg, errdef.ErrUnsupported)
+		}
+		return rsa.DecryptOAEP(h, nil, key, encryptedKey, nil)
+	case *ecdsa.PrivateKey:
+		var kekSize int
+		switch header.Alg {
+		case jweAlgECDHESA128KW:
+			kekSize = 16
+		case jweAlgECDHESA192KW:
+			kekSize = 24
+		case jweAlgECDHESA256KW:
+			kekSize = 32
+		default:
+			return nil, fmt.Errorf("jwe: algorithm %q: %w", header.Alg, errdef.ErrUnsupported)
+		}
+		if header.EPK == nil {
+			return nil, errors.New("jwe: missing epk")
+		}
+		epk, err := header.EPK.publicKey()
+		if err != nil {
+			return nil, err
+		}
+		priv, err := key.ECDH()
+		if err != nil {
+			return nil, err
+		}
+		z, err := priv.ECDH(epk)
+		if err != nil {
+			return nil, err
+		}
+		apu, err := unb64(header.APU)
+		if err != nil {
+			return nil, err
+		}
+		apv, err := unb64(header.APV)
+		if err != nil {
+			return nil, err
+		}
+		kek := concatKDF(z, header.Alg, apu, apv, kekSize)
+		return aesKeyUnwrap(kek, encryptedKey)
+	default:
+		return nil, fmt.Errorf("jwe: private key type %T: %w", key, errdef.ErrUnsupported)
+	}
+}
+
+// newJWK returns the JWK of an ECDH public key on the curve.
+func newJWK(curve elliptic.Curve, key *ecdh.PublicKey) (*jwkKey, error) {
+	// the uncompressed form: 0x04 || X || Y
+	point := key.Bytes()
+	size := (len(point) - 1) / 2
+	return &jwkKey{
+		Kty: "EC",
+		Crv: curve.Params().Name,
+		X:   b64(point[1 : 1+size]),
+		Y:   b64(point[1+size:]),
+	}, nil
+}
+
+// publicKey returns the ECDH public key of the JWK.
+func (k *jwkKey) publicKey() (*ecdh.PublicKey, error) {
+	if k.Kty != "EC" {
+		return nil, fmt.Errorf("jwe: key type %q: %w", k.Kty, errdef.ErrUnsupported)
+	}
+	var curve ecdh.Curve
+	var size int
+	switch k.Crv {
+	case "P-256":
+		curve, size = ecdh.P256(), 32
+	case "P-384":
+		curve, size = ecdh.P384(), 48
+	case "P-521":
+		curve, size = ecdh.P521(), 66
+	default:
+		return nil, fmt.Errorf("jwe: curve %q: %w", k.Crv, errdef.ErrUnsupported)
+	}
+	x, err := unb64(k.X)
+	if err != nil {
+		return nil, err
+	}
+	y, err := unb64(k.Y)
+	if err != nil {
+		return nil, err
+	}
+	point := make([]byte, 1+2*size)
+	point[0] = 4
+	new(big.Int).SetBytes(x).FillBytes(point[1 : 1+size])
+	new(big.Int).SetBytes(y).FillBytes(point[1+size:])
+	return curve.NewPublicKey(point)
+}
+
+// concatKDF derives a key of keySize bytes from the shared secret z with the
+// Concat KDF of NIST SP 800-56A, as specified for ECDH-ES.
+// Reference: https://www.rfc-editor.org/rfc/rfc7518#section-4.6.2
+func concatKDF(z []byte, alg string, apu, apv []byte, keySize int) []byte {
+	var otherInfo []byte
+	for _, field := range [][]byte{[]byte(alg), apu, apv} {
+		otherInfo = binary.BigEndian.AppendUint32(otherInfo, uint32(len(field)))
+		otherInfo = append(otherInfo, field...)
+	}
+	otherInfo = binary.BigEndian.AppendUint32(otherInfo, uint32(keySize*8))
+
+	var key []byte
+	for counter := uint32(1); len(key) < keySize; counter++ {
+		h := sha256.New()
+		binary.Write(h, binary.BigEndian, counter)
+		h.Write(z)
+		h.Write(otherInfo)
+		key = h.Sum(key)
+	}
+	return key[:keySize]
+}
+
+// aesKeyWrapIV is the default initial value of the AES key wrap.
+var aesKeyWrapIV = []byte{0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6}
+
+// aesKeyWrap wraps the key with the key encryption key kek.
+// Reference: https://www.rfc-editor.org/rfc/rfc3394
+func aesKeyWrap(kek, key []byte) ([]byte, error) {
+	if len(key)%8 != 0 || len(key) < 16 {
+		return nil, errors.New("invalid key size to wrap")
+	}
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		return nil, err
+	}
+	n := len(key) / 8
+	wrapped := make([]byte, 8+len(key))
+	copy(wrapped, aesKeyWrapIV)
+	copy(wrapped[8:], key)
+	buf := make([]byte, 16)
+	for j := 0; j < 6; j++ {
+		for i := 1; i <= n; i++ {
+			copy(buf, wrapped[:8])
+			copy(buf[8:], wrapped[8*i:8*i+8])
+			block.Encrypt(buf, buf)
+			t := uint64(n*j + i)
+			binary.BigEndian.PutUint64(wrapped[:8], binary.BigEndian.Uint64(buf[:8])^t)
+			copy(wrapped[8*i:], buf[8:])
+		}
+	}
+	return wrapped, nil
+}
+
+// aesKeyUnwrap unwraps the key wrapped with the key encryption key kek.
+func aesKeyUnwrap(kek, wrapped []byte) ([]byte, error) {
+	if len(wrapped)%8 != 0 || len(wrapped) < 24 {
+		return nil, errors.New("invalid wrapped key size")
+	}
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		return nil, err
+	}
+	n := len(wrapped)/8 - 1
+	key := make([]byte, len(wrapped))
+	copy(key, wrapped)
+	buf := make([]byte, 16)
+	for j := 5; j >= 0; j-- {
+		for i := n; i >= 1; i-- {
+			t := uint64(n*j + i)
+			binary.BigEndian.PutUint64(buf[:8], binary.BigEndian.Uint64(key[:8])^t)
+			copy(buf[8:], key[8*i:8*i+8])
+			block.Decrypt(buf, buf)
+			copy(key[:8], buf[:8])
+			copy(key[8*i:], buf[8:])
+		}
+	}
+	if subtle.ConstantTimeCompare(key[:8], aesKeyWrapIV) != 1 {
+		return nil, errors.New("failed to unwrap key")
+	}
+	return key[8:], nil
+}
+
+// newGCM returns the AES-GCM cipher of the key.
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// b64 encodes data in the unpadded base64url encoding.
+func b64(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// unb64 decodes data in the unpadded base64url encoding.
+func unb64(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(s)
+}
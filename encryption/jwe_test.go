@@ -0,0 +1,138 @@
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package encryption
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestAESKeyWrap(t *testing.T) {
+	// Reference: https://www.rfc-editor.org/rfc/rfc3394#section-4.1
+	kek, _ := hex.DecodeString("000102030405060708090A0B0C0D0E0F")
+	key, _ := hex.DecodeString("00112233445566778899AABBCCDDEEFF")
+	want, _ := hex.DecodeString("1FA68B0A8112B447AEF34BD8FB5A7B829D3E862371D2CFE5")
+
+	got, err := aesKeyWrap(kek, key)
+	if err != nil {
+		t.Fatal("aesKeyWrap() error =", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("aesKeyWrap() = %x, want %x", got, want)
+	}
+	unwrapped, err := aesKeyUnwrap(kek, got)
+	if err != nil {
+		t.Fatal("aesKeyUnwrap() error =", err)
+	}
+	if !bytes.Equal(unwrapped, key) {
+		t.Errorf("aesKeyUnwrap() = %x, want %x", unwrapped, key)
+	}
+	got[0] ^= 1
+	if _, err := aesKeyUnwrap(kek, got); err == nil {
+		t.Error("aesKeyUnwrap() error = nil, wantErr true")
+	}
+}
+
+func TestConcatKDF(t *testing.T) {
+	// Reference: https://www.rfc-editor.org/rfc/rfc7518#appendix-C
+	z := []byte{158, 86, 217, 29, 129, 113, 53, 211, 114, 131, 66, 131, 191, 132,
+		38, 156, 251, 49, 110, 163, 218, 128, 106, 72, 246, 218, 167, 121, 140,
+		254, 144, 196}
+	got := concatKDF(z, "A128GCM", []byte("Alice"), []byte("Bob"), 16)
+	if want := "VqqN6vgjbSBcIijNcacQGg"; b64(got) != want {
+		t.Errorf("concatKDF() = %v, want %v", b64(got), want)
+	}
+}
+
+func TestJWE(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal("failed to generate key:", err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatal("failed to generate key:", err)
+	}
+	opts := []byte(`{"symkey":"c2VjcmV0"}`)
+
+	wrapper := &JWE{PublicKeys: []crypto.PublicKey{&rsaKey.PublicKey, &ecKey.PublicKey}}
+	if got := wrapper.Scheme(); got != "jwe" {
+		t.Errorf("JWE.Scheme() = %v, want %v", got, "jwe")
+	}
+	wrapped, err := wrapper.WrapKey(opts)
+	if err != nil {
+		t.Fatal("JWE.WrapKey() error =", err)
+	}
+	var message jweMessage
+	if err := json.Unmarshal(wrapped, &message); err != nil {
+		t.Fatal("invalid message:", err)
+	}
+	if got := len(message.Recipients); got != 2 {
+		t.Fatalf("JWE.WrapKey() recipients = %v, want %v", got, 2)
+	}
+	if got := message.Recipients[1].Header.EPK.Crv; got != "P-384" {
+		t.Errorf("JWE.WrapKey() epk crv = %v, want %v", got, "P-384")
+	}
+
+	for _, key := range []crypto.PrivateKey{rsaKey, ecKey} {
+		got, err := (&JWE{PrivateKeys: []crypto.PrivateKey{key}}).UnwrapKey(wrapped)
+		if err != nil {
+			t.Fatalf("JWE.UnwrapKey() error = %v", err)
+		}
+		if !bytes.Equal(got, opts) {
+			t.Errorf("JWE.UnwrapKey() = %s, want %s", got, opts)
+		}
+	}
+
+	// flattened serialization
+	flattened, err := json.Marshal(map[string]any{
+		"protected":     message.Protected,
+		"header":        message.Recipients[1].Header,
+		"encrypted_key": message.Recipients[1].EncryptedKey,
+		"iv":            message.IV,
+		"ciphertext":    message.Ciphertext,
+		"tag":           message.Tag,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := (&JWE{PrivateKeys: []crypto.PrivateKey{ecKey}}).UnwrapKey(flattened)
+	if err != nil {
+		t.Fatal("JWE.UnwrapKey() error =", err)
+	}
+	if !bytes.Equal(got, opts) {
+		t.Errorf("JWE.UnwrapKey() = %s, want %s", got, opts)
+	}
+
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal("failed to generate key:", err)
+	}
+	if _, err := (&JWE{PrivateKeys: []crypto.PrivateKey{otherKey}}).UnwrapKey(wrapped); !errors.Is(err, ErrNoMatchingKey) {
+		t.Errorf("JWE.UnwrapKey() error = %v, wantErr %v", err, ErrNoMatchingKey)
+	}
+	if _, err := (&JWE{}).WrapKey(opts); err == nil {
+		t.Error("JWE.WrapKey() error = nil, wantErr true")
+	}
+}
@@ -0,0 +1,322 @@
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"maps"
+	"strings"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/errdef"
+)
+
+// cipherAES256CTRHMACSHA256 is the cipher of the layers: AES-256 in CTR mode,
+// authenticated by HMAC-SHA256 over the ciphertext.
+const cipherAES256CTRHMACSHA256 = "AES_256_CTR_HMAC_SHA256"
+
+// symmetricKeySize is the size of the symmetric key of a layer.
+const symmetricKeySize = 32
+
+// privateOptions are the options of the cipher of a layer wrapped by the key
+// wrappers.
+type privateOptions struct {
+	SymmetricKey  []byte            `json:"symkey"`
+	Digest        digest.Digest     `json:"digest"`
+	CipherOptions map[string][]byte `json:"cipheroptions"`
+}
+
+// publicOptions are the options of the cipher of a layer recorded in plain.
+type publicOptions struct {
+	CipherType    string            `json:"cipher"`
+	HMAC          []byte            `json:"hmac"`
+	CipherOptions map[string][]byte `json:"cipheroptions"`
+}
+
+// LayerTransform transforms the content of a layer between its plaintext and
+// its ciphertext.
+type LayerTransform struct {
+	// Source describes the content read by the transform.
+	Source ocispec.Descriptor
+	// Target describes the transformed content.
+	Target ocispec.Descriptor
+
+	decrypt bool
+	key     []byte
+	nonce   []byte
+	// hmac is the expected HMAC of the ciphertext on decryption.
+	hmac []byte
+}
+
+// NewReader returns a reader of the transformed content of r, which reads
+// the content described by t.Source. The content is not verified against
+// the descriptors. On decryption, ErrInvalidHMAC is returned instead of
+// io.EOF if the ciphertext does not match its HMAC.
+func (t *LayerTransform) NewReader(r io.Reader) io.Reader {
+	cr, err := newCTRReader(r, t.key, t.nonce, t.decrypt)
+	if err != nil {
+		// the key and the nonce are validated on creating t
+		return errReader{err}
+	}
+	cr.expectedHMAC = t.hmac
+	return cr
+}
+
+// EncryptLayer encrypts the layer described by desc for the recipients of
+// the key wrappers, with a random symmetric key. The plaintext is read from r
+// and verified against desc, in order to compute the digest and the HMAC of
+// the ciphertext. The returned transform produces the same ciphertext from
+// the plaintext.
+func EncryptLayer(r io.Reader, desc ocispec.Descriptor, wrappers ...KeyWrapper) (*LayerTransform, error) {
+	if IsEncrypted(desc) {
+		return nil, fmt.Errorf("%s: %s: layer is already encrypted: %w", desc.Digest, desc.MediaType, errdef.ErrUnsupported)
+	}
+	if len(wrappers) == 0 {
+		return nil, errors.New("no key wrappers")
+	}
+	key := make([]byte, symmetricKeySize)
+	nonce := make([]byte, aes.BlockSize)
+	for _, b := range [][]byte{key, nonce} {
+		if _, err := rand.Read(b); err != nil {
+			return nil, fmt.Errorf("failed to generate key: %w", err)
+		}
+	}
+
+	// compute the digest and the HMAC of the ciphertext
+	vr := content.NewVerifyReader(r, desc)
+	cr, err := newCTRReader(vr, key, nonce, false)
+	if err != nil {
+		return nil, err
+	}
+	digester := digest.Canonical.Digester()
+	size, err := io.Copy(digester.Hash(), cr)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to read layer: %w", desc.Digest, err)
+	}
+	if err := vr.Verify(); err != nil {
+		return nil, fmt.Errorf("%s: failed to read layer: %w", desc.Digest, err)
+	}
+
+	// wrap the private options
+	privateJSON, err := json.Marshal(privateOptions{
+		SymmetricKey:  key,
+		Digest:        desc.Digest,
+		CipherOptions: map[string][]byte{"nonce": nonce},
+	})
+	if err != nil {
+		return nil, err
+	}
+	publicJSON, err := json.Marshal(publicOptions{
+		CipherType:    cipherAES256CTRHMACSHA256,
+		HMAC:          cr.mac.Sum(nil),
+		CipherOptions: map[string][]byte{},
+	})
+	if err != nil {
+		return nil, err
+	}
+	annotations := make(map[string]string, len(desc.Annotations)+len(wrappers)+1)
+	maps.Copy(annotations, desc.Annotations)
+	for _, wrapper := range wrappers {
+		wrapped, err := wrapper.WrapKey(privateJSON)
+		if err != nil {
+			return nil, fmt.Errorf("failed to wrap key with %s: %w", wrapper.Scheme(), err)
+		}
+		key := AnnotationKeysPrefix + wrapper.Scheme()
+		value := base64.StdEncoding.EncodeToString(wrapped)
+		if existing := annotations[key]; existing != "" {
+			value = existing + "," + value
+		}
+		annotations[key] = value
+	}
+	annotations[AnnotationPublicOptions] = base64.StdEncoding.EncodeToString(publicJSON)
+
+	return &LayerTransform{
+		Source: desc,
+		Target: ocispec.Descriptor{
+			MediaType:   desc.MediaType + MediaTypeSuffix,
+			Digest:      digester.Digest(),
+			Size:        size,
+			Annotations: annotations,
+		},
+		key:   key,
+		nonce: nonce,
+	}, nil
+}
+
+// DecryptLayer unwraps the symmetric key of the encrypted layer described by
+// desc with the first key wrapper able to. The descriptor of the decrypted
+// layer is restored from the options of the cipher, without the encryption
+// annotations.
+func DecryptLayer(desc ocispec.Descriptor, wrappers ...KeyWrapper) (*LayerTransform, error) {
+	if !IsEncrypted(desc) {
+		return nil, fmt.Errorf("%s: %s: layer is not encrypted: %w", desc.Digest, desc.MediaType, errdef.ErrUnsupported)
+	}
+	var public publicOptions
+	if err := decodeAnnotation(desc.Annotations[AnnotationPublicOptions], &public); err != nil {
+		return nil, fmt.Errorf("%s: invalid public options: %w", desc.Digest, err)
+	}
+	if public.CipherType != cipherAES256CTRHMACSHA256 {
+		return nil, fmt.Errorf("%s: cipher %q: %w", desc.Digest, public.CipherType, errdef.ErrUnsupported)
+	}
+	private, err := unwrapKey(desc, wrappers)
+	if err != nil {
+		return nil, err
+	}
+	nonce := private.CipherOptions["nonce"]
+	if len(private.SymmetricKey) != symmetricKeySize || len(nonce) != aes.BlockSize {
+		return nil, fmt.Errorf("%s: invalid private options", desc.Digest)
+	}
+	if err := private.Digest.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: invalid private options: %w", desc.Digest, err)
+	}
+
+	var annotations map[string]string
+	for k, v := range desc.Annotations {
+		if strings.HasPrefix(k, annotationPrefix) {
+			continue
+		}
+		if annotations == nil {
+			annotations = make(map[string]string)
+		}
+		annotations[k] = v
+	}
+	return &LayerTransform{
+		Source: desc,
+		Target: ocispec.Descriptor{
+			MediaType:   strings.TrimSuffix(desc.MediaType, MediaTypeSuffix),
+			Digest:      private.Digest,
+			Size:        desc.Size,
+			Annotations: annotations,
+		},
+		decrypt: true,
+		key:     private.SymmetricKey,
+		nonce:   nonce,
+		hmac:    public.HMAC,
+	}, nil
+}
+
+// unwrapKey unwraps the private options of the encrypted layer described by
+// desc with the first key wrapper able to.
+func unwrapKey(desc ocispec.Descriptor, wrappers []KeyWrapper) (*privateOptions, error) {
+	for _, wrapper := range wrappers {
+		value := desc.Annotations[AnnotationKeysPrefix+wrapper.Scheme()]
+		if value == "" {
+			continue
+		}
+		for _, encoded := range strings.Split(value, ",") {
+			wrapped, err := base64.StdEncoding.DecodeString(encoded)
+			if err != nil {
+				return nil, fmt.Errorf("%s: invalid %s key: %w", desc.Digest, wrapper.Scheme(), err)
+			}
+			privateJSON, err := wrapper.UnwrapKey(wrapped)
+			if err != nil {
+				if errors.Is(err, ErrNoMatchingKey) {
+					continue
+				}
+				return nil, fmt.Errorf("%s: failed to unwrap %s key: %w", desc.Digest, wrapper.Scheme(), err)
+			}
+			var private privateOptions
+			if err := json.Unmarshal(privateJSON, &private); err != nil {
+				return nil, fmt.Errorf("%s: invalid private options: %w", desc.Digest, err)
+			}
+			return &private, nil
+		}
+	}
+	return nil, fmt.Errorf("%s: %w", desc.Digest, ErrNoMatchingKey)
+}
+
+// decodeAnnotation decodes the base64 encoded JSON value of an annotation.
+func decodeAnnotation(value string, v any) error {
+	if value == "" {
+		return errors.New("missing annotation")
+	}
+	data, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// ctrReader encrypts or decrypts the content of the underlying reader with
+// AES in CTR mode, and computes the HMAC of the ciphertext.
+type ctrReader struct {
+	r       io.Reader
+	stream  cipher.Stream
+	mac     hash.Hash
+	decrypt bool
+	// expectedHMAC is verified on reaching the end of the ciphertext if
+	// decrypting.
+	expectedHMAC []byte
+	err          error
+}
+
+// newCTRReader creates a ctrReader with the key and the nonce.
+func newCTRReader(r io.Reader, key, nonce []byte, decrypt bool) (*ctrReader, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return &ctrReader{
+		r:       r,
+		stream:  cipher.NewCTR(block, nonce),
+		mac:     hmac.New(sha256.New, key),
+		decrypt: decrypt,
+	}, nil
+}
+
+// Read reads up to len(p) bytes of the transformed content into p.
+func (cr *ctrReader) Read(p []byte) (int, error) {
+	if cr.err != nil {
+		return 0, cr.err
+	}
+	n, err := cr.r.Read(p)
+	if cr.decrypt {
+		cr.mac.Write(p[:n])
+	}
+	cr.stream.XORKeyStream(p[:n], p[:n])
+	if !cr.decrypt {
+		cr.mac.Write(p[:n])
+	}
+	if err != nil {
+		if err == io.EOF && cr.decrypt && !hmac.Equal(cr.mac.Sum(nil), cr.expectedHMAC) {
+			err = ErrInvalidHMAC
+		}
+		cr.err = err
+	}
+	return n, err
+}
+
+// errReader returns the error on every read.
+type errReader struct {
+	err error
+}
+
+// Read returns the error.
+func (r errReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
@@ -0,0 +1,219 @@
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package encryption
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+)
+
+// testKeyWrapper wraps the keys with AES-GCM under a symmetric key shared by
+// the senders and the recipients.
+type testKeyWrapper struct {
+	scheme string
+	key    []byte
+}
+
+// newTestKeyWrapper creates a testKeyWrapper of the scheme with a random key.
+func newTestKeyWrapper(t *testing.T, scheme string) *testKeyWrapper {
+	t.Helper()
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatal("failed to generate key:", err)
+	}
+	return &testKeyWrapper{scheme: scheme, key: key}
+}
+
+func (w *testKeyWrapper) Scheme() string {
+	return w.scheme
+}
+
+func (w *testKeyWrapper) WrapKey(opts []byte) ([]byte, error) {
+	aead, err := w.aead()
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, opts, nil), nil
+}
+
+func (w *testKeyWrapper) UnwrapKey(wrapped []byte) ([]byte, error) {
+	aead, err := w.aead()
+	if err != nil {
+		return nil, err
+	}
+	if len(wrapped) < aead.NonceSize() {
+		return nil, ErrNoMatchingKey
+	}
+	opts, err := aead.Open(nil, wrapped[:aead.NonceSize()], wrapped[aead.NonceSize():], nil)
+	if err != nil {
+		return nil, ErrNoMatchingKey
+	}
+	return opts, nil
+}
+
+func (w *testKeyWrapper) aead() (cipher.AEAD, error) {
+	block, err := aes.NewCipher(w.key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func TestEncryptLayer_DecryptLayer(t *testing.T) {
+	foo1 := newTestKeyWrapper(t, "foo")
+	foo2 := newTestKeyWrapper(t, "foo")
+	bar := newTestKeyWrapper(t, "bar")
+
+	blob := bytes.Repeat([]byte("hello world "), 1000)
+	desc := content.NewDescriptorFromBytes(ocispec.MediaTypeImageLayerGzip, blob)
+	desc.Annotations = map[string]string{ocispec.AnnotationTitle: "hello.txt"}
+
+	encryptor := []KeyWrapper{foo1, foo2, bar}
+	enc, err := EncryptLayer(bytes.NewReader(blob), desc, encryptor...)
+	if err != nil {
+		t.Fatal("EncryptLayer() error =", err)
+	}
+	if want := ocispec.MediaTypeImageLayerGzip + MediaTypeSuffix; enc.Target.MediaType != want {
+		t.Errorf("EncryptLayer() media type = %v, want %v", enc.Target.MediaType, want)
+	}
+	if !IsEncrypted(enc.Target) {
+		t.Errorf("IsEncrypted() = %v, want %v", false, true)
+	}
+	for _, key := range []string{AnnotationKeysPrefix + "foo", AnnotationKeysPrefix + "bar", AnnotationPublicOptions, ocispec.AnnotationTitle} {
+		if _, ok := enc.Target.Annotations[key]; !ok {
+			t.Errorf("EncryptLayer() annotations missing %q", key)
+		}
+	}
+	if got := len(strings.Split(enc.Target.Annotations[AnnotationKeysPrefix+"foo"], ",")); got != 2 {
+		t.Errorf("EncryptLayer() foo keys = %v, want %v", got, 2)
+	}
+	ciphertext, err := io.ReadAll(enc.NewReader(bytes.NewReader(blob)))
+	if err != nil {
+		t.Fatal("LayerTransform.NewReader() error =", err)
+	}
+	if got := content.NewDescriptorFromBytes(enc.Target.MediaType, ciphertext); got.Digest != enc.Target.Digest || got.Size != enc.Target.Size {
+		t.Errorf("encrypted content = %v, want %v", got, enc.Target)
+	}
+	if bytes.Equal(ciphertext, blob) {
+		t.Error("encrypted content equals the plaintext")
+	}
+
+	decryptors := map[string]KeyWrapper{
+		"foo 1": foo1,
+		"foo 2": foo2,
+		"bar":   bar,
+	}
+	for name, wrapper := range decryptors {
+		t.Run(name, func(t *testing.T) {
+			dec, err := DecryptLayer(enc.Target, wrapper)
+			if err != nil {
+				t.Fatal("DecryptLayer() error =", err)
+			}
+			if !content.Equal(dec.Target, desc) {
+				t.Errorf("DecryptLayer() = %v, want %v", dec.Target, desc)
+			}
+			plaintext, err := io.ReadAll(dec.NewReader(bytes.NewReader(ciphertext)))
+			if err != nil {
+				t.Fatal("LayerTransform.NewReader() error =", err)
+			}
+			if !bytes.Equal(plaintext, blob) {
+				t.Error("decrypted content does not match the plaintext")
+			}
+		})
+	}
+
+	// wrong keys
+	if _, err := DecryptLayer(enc.Target, newTestKeyWrapper(t, "foo")); !errors.Is(err, ErrNoMatchingKey) {
+		t.Errorf("DecryptLayer() error = %v, wantErr %v", err, ErrNoMatchingKey)
+	}
+
+	// tampered ciphertext
+	dec, err := DecryptLayer(enc.Target, foo1)
+	if err != nil {
+		t.Fatal("DecryptLayer() error =", err)
+	}
+	tampered := bytes.Clone(ciphertext)
+	tampered[0] ^= 1
+	if _, err := io.ReadAll(dec.NewReader(bytes.NewReader(tampered))); !errors.Is(err, ErrInvalidHMAC) {
+		t.Errorf("LayerTransform.NewReader() error = %v, wantErr %v", err, ErrInvalidHMAC)
+	}
+}
+
+func TestEncryptLayer_Invalid(t *testing.T) {
+	wrapper := newTestKeyWrapper(t, "test")
+	blob := []byte("hello world")
+	desc := content.NewDescriptorFromBytes(ocispec.MediaTypeImageLayer, blob)
+
+	if _, err := EncryptLayer(bytes.NewReader([]byte("hello worlD")), desc, wrapper); !errors.Is(err, content.ErrMismatchedDigest) {
+		t.Errorf("EncryptLayer() error = %v, wantErr %v", err, content.ErrMismatchedDigest)
+	}
+	if _, err := EncryptLayer(bytes.NewReader(blob), desc); err == nil {
+		t.Error("EncryptLayer() error = nil, wantErr true")
+	}
+
+	enc, err := EncryptLayer(bytes.NewReader(blob), desc, wrapper)
+	if err != nil {
+		t.Fatal("EncryptLayer() error =", err)
+	}
+	if _, err := EncryptLayer(bytes.NewReader(blob), enc.Target, wrapper); err == nil {
+		t.Error("EncryptLayer() error = nil, wantErr true")
+	}
+	if _, err := DecryptLayer(desc, wrapper); err == nil {
+		t.Error("DecryptLayer() error = nil, wantErr true")
+	}
+}
+
+func TestDecryptLayer_PublicOptions(t *testing.T) {
+	wrapper := newTestKeyWrapper(t, "test")
+	blob := []byte("hello world")
+	desc := content.NewDescriptorFromBytes(ocispec.MediaTypeImageLayer, blob)
+	enc, err := EncryptLayer(bytes.NewReader(blob), desc, wrapper)
+	if err != nil {
+		t.Fatal("EncryptLayer() error =", err)
+	}
+	var public map[string]any
+	if err := decodeAnnotation(enc.Target.Annotations[AnnotationPublicOptions], &public); err != nil {
+		t.Fatal("invalid public options:", err)
+	}
+	if got := public["cipher"]; got != "AES_256_CTR_HMAC_SHA256" {
+		t.Errorf("public options cipher = %v, want %v", got, "AES_256_CTR_HMAC_SHA256")
+	}
+
+	public["cipher"] = "AES_128_CBC"
+	publicJSON, err := json.Marshal(public)
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc.Target.Annotations[AnnotationPublicOptions] = base64.StdEncoding.EncodeToString(publicJSON)
+	if _, err := DecryptLayer(enc.Target, wrapper); err == nil {
+		t.Error("DecryptLayer() error = nil, wantErr true")
+	}
+}
@@ -0,0 +1,229 @@
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package encryption
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+)
+
+// The fixture in testdata/ocicrypt is a layer encrypted by the block cipher of
+// ocicrypt, whose keys are wrapped by go-jose and smallstep/pkcs7 with the
+// parameters of the JWE and PKCS #7 key wrappers of ocicrypt:
+//   - layer: the plaintext of the layer.
+//   - layer.enc: the ciphertext of the layer.
+//   - layer.json: the descriptor of the encrypted layer, whose keys are
+//     wrapped for rsa.pem and ec.pem with JWE, and for pkcs7.crt with PKCS #7.
+//   - ec.jwe: the private options of the layer wrapped for ec.pem only, where
+//     the header of the recipient is in the protected header.
+
+// readFixture reads the file of the ocicrypt fixture.
+func readFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", "ocicrypt", name))
+	if err != nil {
+		t.Fatal("failed to read fixture:", err)
+	}
+	return data
+}
+
+// readFixturePEM reads the PEM encoded DER of the ocicrypt fixture.
+func readFixturePEM(t *testing.T, name string) []byte {
+	t.Helper()
+	block, _ := pem.Decode(readFixture(t, name))
+	if block == nil {
+		t.Fatalf("invalid PEM file %s", name)
+	}
+	return block.Bytes
+}
+
+// ocicryptFixture is the ocicrypt fixture.
+type ocicryptFixture struct {
+	plaintext  []byte
+	ciphertext []byte
+	desc       ocispec.Descriptor
+	jwe        *JWE
+	pkcs7      *PKCS7
+}
+
+// loadOcicryptFixture loads the ocicrypt fixture.
+func loadOcicryptFixture(t *testing.T) *ocicryptFixture {
+	t.Helper()
+	rsaKey, err := x509.ParsePKCS1PrivateKey(readFixturePEM(t, "rsa.pem"))
+	if err != nil {
+		t.Fatal("failed to parse key:", err)
+	}
+	ecKey, err := x509.ParseECPrivateKey(readFixturePEM(t, "ec.pem"))
+	if err != nil {
+		t.Fatal("failed to parse key:", err)
+	}
+	pkcs7Key, err := x509.ParsePKCS1PrivateKey(readFixturePEM(t, "pkcs7.pem"))
+	if err != nil {
+		t.Fatal("failed to parse key:", err)
+	}
+	cert, err := x509.ParseCertificate(readFixturePEM(t, "pkcs7.crt"))
+	if err != nil {
+		t.Fatal("failed to parse certificate:", err)
+	}
+	f := &ocicryptFixture{
+		plaintext:  readFixture(t, "layer"),
+		ciphertext: readFixture(t, "layer.enc"),
+		jwe: &JWE{
+			PublicKeys:  []crypto.PublicKey{&rsaKey.PublicKey, &ecKey.PublicKey},
+			PrivateKeys: []crypto.PrivateKey{rsaKey, ecKey},
+		},
+		pkcs7: &PKCS7{
+			Certificates: []*x509.Certificate{cert},
+			PrivateKeys:  []crypto.PrivateKey{pkcs7Key},
+		},
+	}
+	if err := json.Unmarshal(readFixture(t, "layer.json"), &f.desc); err != nil {
+		t.Fatal("failed to parse descriptor:", err)
+	}
+	return f
+}
+
+// decryptFixtureLayer decrypts the ciphertext of the layer described by desc.
+func decryptFixtureLayer(t *testing.T, desc ocispec.Descriptor, ciphertext []byte, wrapper KeyWrapper) []byte {
+	t.Helper()
+	dec, err := DecryptLayer(desc, wrapper)
+	if err != nil {
+		t.Fatal("DecryptLayer() error =", err)
+	}
+	plaintext, err := io.ReadAll(dec.NewReader(bytes.NewReader(ciphertext)))
+	if err != nil {
+		t.Fatal("LayerTransform.NewReader() error =", err)
+	}
+	return plaintext
+}
+
+func TestDecryptLayer_Ocicrypt(t *testing.T) {
+	f := loadOcicryptFixture(t)
+	wrappers := map[string]KeyWrapper{
+		"jwe rsa": &JWE{PrivateKeys: f.jwe.PrivateKeys[:1]},
+		"jwe ec":  &JWE{PrivateKeys: f.jwe.PrivateKeys[1:]},
+		"pkcs7":   &PKCS7{PrivateKeys: f.pkcs7.PrivateKeys},
+	}
+	for name, wrapper := range wrappers {
+		t.Run(name, func(t *testing.T) {
+			if got := decryptFixtureLayer(t, f.desc, f.ciphertext, wrapper); !bytes.Equal(got, f.plaintext) {
+				t.Errorf("decrypted content = %q, want %q", got, f.plaintext)
+			}
+		})
+	}
+
+	// a single recipient in the protected header
+	wrapped, err := base64.StdEncoding.DecodeString(f.desc.Annotations[AnnotationKeysPrefix+"jwe"])
+	if err != nil {
+		t.Fatal("invalid annotation:", err)
+	}
+	want, err := f.jwe.UnwrapKey(wrapped)
+	if err != nil {
+		t.Fatal("JWE.UnwrapKey() error =", err)
+	}
+	got, err := (&JWE{PrivateKeys: f.jwe.PrivateKeys[1:]}).UnwrapKey(readFixture(t, "ec.jwe"))
+	if err != nil {
+		t.Fatal("JWE.UnwrapKey() error =", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("JWE.UnwrapKey() = %s, want %s", got, want)
+	}
+}
+
+func TestEncryptLayer_Ocicrypt(t *testing.T) {
+	f := loadOcicryptFixture(t)
+	desc := content.NewDescriptorFromBytes(ocispec.MediaTypeImageLayerGzip, f.plaintext)
+	enc, err := EncryptLayer(bytes.NewReader(f.plaintext), desc, f.jwe, f.pkcs7)
+	if err != nil {
+		t.Fatal("EncryptLayer() error =", err)
+	}
+	ciphertext, err := io.ReadAll(enc.NewReader(bytes.NewReader(f.plaintext)))
+	if err != nil {
+		t.Fatal("LayerTransform.NewReader() error =", err)
+	}
+	if got, want := enc.Target.MediaType, f.desc.MediaType; got != want {
+		t.Errorf("EncryptLayer() media type = %v, want %v", got, want)
+	}
+	if got, want := slices.Sorted(maps.Keys(enc.Target.Annotations)), slices.Sorted(maps.Keys(f.desc.Annotations)); !slices.Equal(got, want) {
+		t.Errorf("EncryptLayer() annotations = %v, want %v", got, want)
+	}
+
+	// the wrapped keys are laid out as ocicrypt does
+	decodeAnnotation := func(desc ocispec.Descriptor, scheme string) []byte {
+		t.Helper()
+		wrapped, err := base64.StdEncoding.DecodeString(desc.Annotations[AnnotationKeysPrefix+scheme])
+		if err != nil {
+			t.Fatal("invalid annotation:", err)
+		}
+		return wrapped
+	}
+	var gotJWE, wantJWE jweMessage
+	if err := json.Unmarshal(decodeAnnotation(enc.Target, "jwe"), &gotJWE); err != nil {
+		t.Fatal("invalid message:", err)
+	}
+	if err := json.Unmarshal(decodeAnnotation(f.desc, "jwe"), &wantJWE); err != nil {
+		t.Fatal("invalid message:", err)
+	}
+	if got, want := gotJWE.Protected, wantJWE.Protected; got != want {
+		t.Errorf("JWE protected header = %v, want %v", got, want)
+	}
+	for i, recipient := range gotJWE.Recipients {
+		if got, want := recipient.Header.Alg, wantJWE.Recipients[i].Header.Alg; got != want {
+			t.Errorf("JWE recipient %d alg = %v, want %v", i, got, want)
+		}
+	}
+	var gotInfo, wantInfo pkcs7ContentInfo
+	if _, err := asn1.Unmarshal(decodeAnnotation(enc.Target, "pkcs7"), &gotInfo); err != nil {
+		t.Fatal("invalid content info:", err)
+	}
+	if _, err := asn1.Unmarshal(decodeAnnotation(f.desc, "pkcs7"), &wantInfo); err != nil {
+		t.Fatal("invalid content info:", err)
+	}
+	var gotEnvelope, wantEnvelope pkcs7EnvelopedData
+	if _, err := asn1.Unmarshal(gotInfo.Content.Bytes, &gotEnvelope); err != nil {
+		t.Fatal("invalid enveloped data:", err)
+	}
+	if _, err := asn1.Unmarshal(wantInfo.Content.Bytes, &wantEnvelope); err != nil {
+		t.Fatal("invalid enveloped data:", err)
+	}
+	if got, want := gotEnvelope.EncryptedContentInfo.ContentEncryptionAlgorithm.Algorithm, wantEnvelope.EncryptedContentInfo.ContentEncryptionAlgorithm.Algorithm; !got.Equal(want) {
+		t.Errorf("PKCS7 content encryption = %v, want %v", got, want)
+	}
+	if got, want := gotEnvelope.RecipientInfos[0].IssuerAndSerialNumber.SerialNumber, wantEnvelope.RecipientInfos[0].IssuerAndSerialNumber.SerialNumber; got.Cmp(want) != 0 {
+		t.Errorf("PKCS7 serial number = %v, want %v", got, want)
+	}
+
+	// the layer decrypts with the keys of the fixture
+	for _, wrapper := range []KeyWrapper{f.jwe, f.pkcs7} {
+		if got := decryptFixtureLayer(t, enc.Target, ciphertext, wrapper); !bytes.Equal(got, f.plaintext) {
+			t.Errorf("decrypted content = %q, want %q", got, f.plaintext)
+		}
+	}
+}
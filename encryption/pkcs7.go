@@ -0,0 +1,274 @@
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package encryption
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"oras.land/oras-go/v2/errdef"
+)
+
+// PKCS #7 object identifiers.
+var (
+	oidData                = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}
+	oidEnvelopedData       = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 3}
+	oidEncryptionRSA       = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}
+	oidEncryptionAES128GCM = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 6}
+	oidEncryptionAES256GCM = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 46}
+)
+
+// PKCS7 wraps the keys of the layers in PKCS #7 enveloped data, as ocicrypt
+// does.
+//   - Keys are wrapped for the RSA public keys of the certificates with
+//     PKCS #1 v1.5.
+//   - The content of the enveloped data is encrypted with AES-128-GCM.
+//     Only the enveloped data encrypted with AES-GCM can be unwrapped.
+//
+// Reference: https://www.rfc-editor.org/rfc/rfc2315
+type PKCS7 struct {
+	// Certificates are the certificates of the recipients to wrap the keys
+	// for.
+	Certificates []*x509.Certificate
+
+	// PrivateKeys are the keys to unwrap the keys with, of the type
+	// *rsa.PrivateKey.
+	PrivateKeys []crypto.PrivateKey
+}
+
+// Scheme returns "pkcs7".
+func (w *PKCS7) Scheme() string {
+	return "pkcs7"
+}
+
+// pkcs7ContentInfo is the PKCS #7 ContentInfo.
+type pkcs7ContentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"explicit,optional,tag:0"`
+}
+
+// pkcs7EnvelopedData is the PKCS #7 EnvelopedData.
+type pkcs7EnvelopedData struct {
+	Version              int
+	RecipientInfos       []pkcs7RecipientInfo `asn1:"set"`
+	EncryptedContentInfo pkcs7EncryptedContentInfo
+}
+
+// pkcs7RecipientInfo is the PKCS #7 RecipientInfo.
+type pkcs7RecipientInfo struct {
+	Version                int
+	IssuerAndSerialNumber  pkcs7IssuerAndSerial
+	KeyEncryptionAlgorithm pkix.AlgorithmIdentifier
+	EncryptedKey           []byte
+}
+
+// pkcs7IssuerAndSerial is the PKCS #7 IssuerAndSerialNumber.
+type pkcs7IssuerAndSerial struct {
+	IssuerName   asn1.RawValue
+	SerialNumber *big.Int
+}
+
+// pkcs7EncryptedContentInfo is the PKCS #7 EncryptedContentInfo.
+type pkcs7EncryptedContentInfo struct {
+	ContentType                asn1.ObjectIdentifier
+	ContentEncryptionAlgorithm pkix.AlgorithmIdentifier
+	EncryptedContent           asn1.RawValue `asn1:"tag:0,optional"`
+}
+
+// aesGCMParameters are the parameters of AES-GCM.
+// Reference: https://www.rfc-editor.org/rfc/rfc5084
+type aesGCMParameters struct {
+	Nonce  []byte `asn1:"tag:4"`
+	ICVLen int
+}
+
+// WrapKey wraps opts in PKCS #7 enveloped data for all the certificates.
+func (w *PKCS7) WrapKey(opts []byte) ([]byte, error) {
+	if len(w.Certificates) == 0 {
+		return nil, errors.New("pkcs7: no certificates")
+	}
+	key := make([]byte, 16)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	ciphertext := gcm.Seal(nil, nonce, opts, nil)
+	paramsBytes, err := asn1.Marshal(aesGCMParameters{
+		Nonce:  nonce,
+		ICVLen: gcm.Overhead(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	encryptedContent, err := asn1.Marshal(ciphertext)
+	if err != nil {
+		return nil, err
+	}
+
+	envelope := pkcs7EnvelopedData{
+		EncryptedContentInfo: pkcs7EncryptedContentInfo{
+			ContentType: oidData,
+			ContentEncryptionAlgorithm: pkix.AlgorithmIdentifier{
+				Algorithm:  oidEncryptionAES128GCM,
+				Parameters: asn1.RawValue{Tag: asn1.TagSequence, Bytes: paramsBytes},
+			},
+			EncryptedContent: asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: encryptedContent},
+		},
+	}
+	for _, cert := range w.Certificates {
+		pub, ok := cert.PublicKey.(*rsa.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("pkcs7: public key type %T: %w", cert.PublicKey, errdef.ErrUnsupported)
+		}
+		encryptedKey, err := rsa.EncryptPKCS1v15(rand.Reader, pub, key)
+		if err != nil {
+			return nil, fmt.Errorf("pkcs7: failed to wrap key: %w", err)
+		}
+		envelope.RecipientInfos = append(envelope.RecipientInfos, pkcs7RecipientInfo{
+			IssuerAndSerialNumber: pkcs7IssuerAndSerial{
+				IssuerName:   asn1.RawValue{FullBytes: cert.RawIssuer},
+				SerialNumber: cert.SerialNumber,
+			},
+			KeyEncryptionAlgorithm: pkix.AlgorithmIdentifier{Algorithm: oidEncryptionRSA},
+			EncryptedKey:           encryptedKey,
+		})
+	}
+	envelopeBytes, err := asn1.Marshal(envelope)
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(pkcs7ContentInfo{
+		ContentType: oidEnvelopedData,
+		Content:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: envelopeBytes},
+	})
+}
+
+// UnwrapKey decrypts the PKCS #7 enveloped data wrapped with any of the
+// private keys.
+func (w *PKCS7) UnwrapKey(wrapped []byte) ([]byte, error) {
+	var info pkcs7ContentInfo
+	if _, err := asn1.Unmarshal(wrapped, &info); err != nil {
+		return nil, fmt.Errorf("pkcs7: invalid content info: %w", err)
+	}
+	if !info.ContentType.Equal(oidEnvelopedData) {
+		return nil, fmt.Errorf("pkcs7: content type %v: %w", info.ContentType, errdef.ErrUnsupported)
+	}
+	var envelope pkcs7EnvelopedData
+	if _, err := asn1.Unmarshal(info.Content.Bytes, &envelope); err != nil {
+		return nil, fmt.Errorf("pkcs7: invalid enveloped data: %w", err)
+	}
+
+	keySize, err := envelope.EncryptedContentInfo.keySize()
+	if err != nil {
+		return nil, err
+	}
+	for _, recipient := range envelope.RecipientInfos {
+		if !recipient.KeyEncryptionAlgorithm.Algorithm.Equal(oidEncryptionRSA) {
+			continue
+		}
+		for _, key := range w.PrivateKeys {
+			priv, ok := key.(*rsa.PrivateKey)
+			if !ok {
+				continue
+			}
+			// the content key is left random if the key does not belong to
+			// the recipient, so that the padding oracle is not exposed and
+			// the decryption of the content fails.
+			contentKey := make([]byte, keySize)
+			if _, err := rand.Read(contentKey); err != nil {
+				return nil, err
+			}
+			if err := rsa.DecryptPKCS1v15SessionKey(nil, priv, recipient.EncryptedKey, contentKey); err != nil {
+				continue
+			}
+			plaintext, err := envelope.EncryptedContentInfo.decrypt(contentKey)
+			if err != nil {
+				continue
+			}
+			return plaintext, nil
+		}
+	}
+	return nil, ErrNoMatchingKey
+}
+
+// keySize returns the size of the key of the content encryption algorithm.
+func (eci pkcs7EncryptedContentInfo) keySize() (int, error) {
+	alg := eci.ContentEncryptionAlgorithm.Algorithm
+	switch {
+	case alg.Equal(oidEncryptionAES128GCM):
+		return 16, nil
+	case alg.Equal(oidEncryptionAES256GCM):
+		return 32, nil
+	default:
+		return 0, fmt.Errorf("pkcs7: content encryption %v: %w", alg, errdef.ErrUnsupported)
+	}
+}
+
+// decrypt decrypts the encrypted content with the key, using AES-GCM.
+func (eci pkcs7EncryptedContentInfo) decrypt(key []byte) ([]byte, error) {
+	// the encrypted content is either a tagged octet string, or constructed
+	// of multiple octet strings.
+	var ciphertext []byte
+	if eci.EncryptedContent.IsCompound {
+		var buf bytes.Buffer
+		rest := eci.EncryptedContent.Bytes
+		for len(rest) > 0 {
+			var part []byte
+			var err error
+			if rest, err = asn1.Unmarshal(rest, &part); err != nil {
+				return nil, err
+			}
+			buf.Write(part)
+		}
+		ciphertext = buf.Bytes()
+	} else {
+		ciphertext = eci.EncryptedContent.Bytes
+	}
+
+	var params aesGCMParameters
+	algParams := eci.ContentEncryptionAlgorithm.Parameters
+	if _, err := asn1.Unmarshal(algParams.Bytes, &params); err != nil {
+		if _, err := asn1.Unmarshal(algParams.FullBytes, &params); err != nil {
+			return nil, fmt.Errorf("invalid aes-gcm parameters: %w", err)
+		}
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCMWithNonceSize(block, len(params.Nonce))
+	if err != nil {
+		return nil, err
+	}
+	return gcm.Open(nil, params.Nonce, ciphertext, nil)
+}
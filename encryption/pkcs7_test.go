@@ -0,0 +1,106 @@
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package encryption
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+)
+
+// newTestCertificate creates a self-signed certificate of the key.
+func newTestCertificate(t *testing.T, key *rsa.PrivateKey) *x509.Certificate {
+	t.Helper()
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(42),
+		Subject:      pkix.Name{CommonName: "test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageKeyEncipherment,
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal("failed to create certificate:", err)
+	}
+	cert, err := x509.ParseCertificate(certDER)
+	if err != nil {
+		t.Fatal("failed to parse certificate:", err)
+	}
+	return cert
+}
+
+func TestPKCS7(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal("failed to generate key:", err)
+	}
+	cert := newTestCertificate(t, key)
+	opts := []byte(`{"symkey":"c2VjcmV0"}`)
+
+	wrapper := &PKCS7{Certificates: []*x509.Certificate{cert}}
+	if got := wrapper.Scheme(); got != "pkcs7" {
+		t.Errorf("PKCS7.Scheme() = %v, want %v", got, "pkcs7")
+	}
+	wrapped, err := wrapper.WrapKey(opts)
+	if err != nil {
+		t.Fatal("PKCS7.WrapKey() error =", err)
+	}
+
+	var info pkcs7ContentInfo
+	if _, err := asn1.Unmarshal(wrapped, &info); err != nil {
+		t.Fatal("invalid content info:", err)
+	}
+	var envelope pkcs7EnvelopedData
+	if _, err := asn1.Unmarshal(info.Content.Bytes, &envelope); err != nil {
+		t.Fatal("invalid enveloped data:", err)
+	}
+	if got := len(envelope.RecipientInfos); got != 1 {
+		t.Fatalf("PKCS7.WrapKey() recipients = %v, want %v", got, 1)
+	}
+	if got := envelope.RecipientInfos[0].IssuerAndSerialNumber.SerialNumber; got.Cmp(cert.SerialNumber) != 0 {
+		t.Errorf("PKCS7.WrapKey() serial number = %v, want %v", got, cert.SerialNumber)
+	}
+	if got := envelope.EncryptedContentInfo.ContentEncryptionAlgorithm.Algorithm; !got.Equal(oidEncryptionAES128GCM) {
+		t.Errorf("PKCS7.WrapKey() content encryption = %v, want %v", got, oidEncryptionAES128GCM)
+	}
+
+	got, err := (&PKCS7{PrivateKeys: []crypto.PrivateKey{key}}).UnwrapKey(wrapped)
+	if err != nil {
+		t.Fatal("PKCS7.UnwrapKey() error =", err)
+	}
+	if !bytes.Equal(got, opts) {
+		t.Errorf("PKCS7.UnwrapKey() = %s, want %s", got, opts)
+	}
+
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal("failed to generate key:", err)
+	}
+	if _, err := (&PKCS7{PrivateKeys: []crypto.PrivateKey{otherKey}}).UnwrapKey(wrapped); !errors.Is(err, ErrNoMatchingKey) {
+		t.Errorf("PKCS7.UnwrapKey() error = %v, wantErr %v", err, ErrNoMatchingKey)
+	}
+	if _, err := (&PKCS7{}).WrapKey(opts); err == nil {
+		t.Error("PKCS7.WrapKey() error = nil, wantErr true")
+	}
+}
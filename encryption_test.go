@@ -0,0 +1,350 @@
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oras_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	specs "github.com/opencontainers/image-spec/specs-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/content/memory"
+	"oras.land/oras-go/v2/encryption"
+)
+
+// testKeyWrapper wraps the keys with AES-GCM under a symmetric key shared by
+// the senders and the recipients.
+type testKeyWrapper struct {
+	key []byte
+}
+
+// newTestKeyWrapper creates a testKeyWrapper with a random key.
+func newTestKeyWrapper(t *testing.T) *testKeyWrapper {
+	t.Helper()
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatal("failed to generate key:", err)
+	}
+	return &testKeyWrapper{key: key}
+}
+
+func (w *testKeyWrapper) Scheme() string {
+	return "test"
+}
+
+func (w *testKeyWrapper) WrapKey(opts []byte) ([]byte, error) {
+	aead, err := w.aead()
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, opts, nil), nil
+}
+
+func (w *testKeyWrapper) UnwrapKey(wrapped []byte) ([]byte, error) {
+	aead, err := w.aead()
+	if err != nil {
+		return nil, err
+	}
+	if len(wrapped) < aead.NonceSize() {
+		return nil, encryption.ErrNoMatchingKey
+	}
+	opts, err := aead.Open(nil, wrapped[:aead.NonceSize()], wrapped[aead.NonceSize():], nil)
+	if err != nil {
+		return nil, encryption.ErrNoMatchingKey
+	}
+	return opts, nil
+}
+
+func (w *testKeyWrapper) aead() (cipher.AEAD, error) {
+	block, err := aes.NewCipher(w.key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// fetchManifest fetches and decodes the manifest described by desc.
+func fetchManifest(t *testing.T, fetcher content.Fetcher, desc ocispec.Descriptor) ocispec.Manifest {
+	t.Helper()
+	manifestJSON, err := content.FetchAll(context.Background(), fetcher, desc)
+	if err != nil {
+		t.Fatalf("content.FetchAll() error = %v", err)
+	}
+	var manifest ocispec.Manifest
+	if err := json.Unmarshal(manifestJSON, &manifest); err != nil {
+		t.Fatalf("failed to decode manifest: %v", err)
+	}
+	return manifest
+}
+
+// decryptBlob fetches and decrypts the encrypted layer described by desc.
+func decryptBlob(t *testing.T, fetcher content.Fetcher, desc ocispec.Descriptor, wrapper encryption.KeyWrapper) []byte {
+	t.Helper()
+	transform, err := encryption.DecryptLayer(desc, wrapper)
+	if err != nil {
+		t.Fatalf("encryption.DecryptLayer() error = %v", err)
+	}
+	rc, err := fetcher.Fetch(context.Background(), desc)
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	defer rc.Close()
+	got, err := io.ReadAll(transform.NewReader(rc))
+	if err != nil {
+		t.Fatalf("io.ReadAll() error = %v", err)
+	}
+	return got
+}
+
+func TestCopy_EncryptDecryptLayers(t *testing.T) {
+	wrapper := newTestKeyWrapper(t)
+	encryptor, decryptor := wrapper, wrapper
+
+	ctx := context.Background()
+	src := memory.New()
+	blob := []byte("hello world")
+	layer := content.NewDescriptorFromBytes(ocispec.MediaTypeImageLayer, blob)
+	if err := src.Push(ctx, layer, bytes.NewReader(blob)); err != nil {
+		t.Fatalf("Push() error = %v", err)
+	}
+	if err := src.Push(ctx, ocispec.DescriptorEmptyJSON, bytes.NewReader(ocispec.DescriptorEmptyJSON.Data)); err != nil {
+		t.Fatalf("Push() error = %v", err)
+	}
+	root, err := oras.PackManifest(ctx, src, oras.PackManifestVersion1_1, "application/vnd.test", oras.PackManifestOptions{
+		Layers:              []ocispec.Descriptor{layer, ocispec.DescriptorEmptyJSON},
+		ManifestAnnotations: map[string]string{ocispec.AnnotationCreated: "2000-01-01T00:00:00Z"},
+	})
+	if err != nil {
+		t.Fatalf("PackManifest() error = %v", err)
+	}
+	ref := "foobar"
+	if err := src.Tag(ctx, root, ref); err != nil {
+		t.Fatalf("Tag() error = %v", err)
+	}
+
+	// encrypt on copy
+	encrypted := memory.New()
+	encryptedRoot, err := oras.Copy(ctx, src, ref, encrypted, "", oras.CopyOptions{
+		EncryptLayers: []encryption.KeyWrapper{encryptor},
+	})
+	if err != nil {
+		t.Fatalf("Copy() error = %v", err)
+	}
+	if content.Equal(encryptedRoot, root) {
+		t.Fatalf("Copy() = %v, want rewritten root", encryptedRoot)
+	}
+	manifest := fetchManifest(t, encrypted, encryptedRoot)
+	if got := manifest.Layers[0]; !encryption.IsEncrypted(got) {
+		t.Errorf("layer media type = %v, want encrypted", got.MediaType)
+	}
+	if got := manifest.Layers[1]; !content.Equal(got, ocispec.DescriptorEmptyJSON) {
+		t.Errorf("empty layer = %v, want %v", got, ocispec.DescriptorEmptyJSON)
+	}
+	if got := decryptBlob(t, encrypted, manifest.Layers[0], decryptor); !bytes.Equal(got, blob) {
+		t.Errorf("decrypted layer = %s, want %s", got, blob)
+	}
+	if exists, err := encrypted.Exists(ctx, layer); err != nil || exists {
+		t.Errorf("Exists() = %v, %v, want %v, nil", exists, err, false)
+	}
+	gotDesc, err := encrypted.Resolve(ctx, ref)
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	if !content.Equal(gotDesc, encryptedRoot) {
+		t.Errorf("Resolve() = %v, want %v", gotDesc, encryptedRoot)
+	}
+
+	// decrypt on copy
+	decrypted := memory.New()
+	decryptedRoot, err := oras.Copy(ctx, encrypted, ref, decrypted, "", oras.CopyOptions{
+		DecryptLayers: []encryption.KeyWrapper{decryptor},
+	})
+	if err != nil {
+		t.Fatalf("Copy() error = %v", err)
+	}
+	if !content.Equal(decryptedRoot, root) {
+		t.Errorf("Copy() = %v, want %v", decryptedRoot, root)
+	}
+	got, err := content.FetchAll(ctx, decrypted, layer)
+	if err != nil {
+		t.Fatalf("content.FetchAll() error = %v", err)
+	}
+	if !bytes.Equal(got, blob) {
+		t.Errorf("decrypted layer = %s, want %s", got, blob)
+	}
+
+	// re-encrypt on copy
+	otherWrapper := newTestKeyWrapper(t)
+	reencrypted := memory.New()
+	reencryptedRoot, err := oras.Copy(ctx, encrypted, ref, reencrypted, "", oras.CopyOptions{
+		DecryptLayers: []encryption.KeyWrapper{decryptor},
+		EncryptLayers: []encryption.KeyWrapper{otherWrapper},
+	})
+	if err != nil {
+		t.Fatalf("Copy() error = %v", err)
+	}
+	manifest = fetchManifest(t, reencrypted, reencryptedRoot)
+	if got := decryptBlob(t, reencrypted, manifest.Layers[0], otherWrapper); !bytes.Equal(got, blob) {
+		t.Errorf("decrypted layer = %s, want %s", got, blob)
+	}
+
+	// decrypt with a wrong key
+	_, err = oras.Copy(ctx, encrypted, ref, memory.New(), "", oras.CopyOptions{
+		DecryptLayers: []encryption.KeyWrapper{otherWrapper},
+	})
+	var copyErr *oras.CopyError
+	if !errors.As(err, &copyErr) || copyErr.Op != "DecryptLayers" {
+		t.Errorf("Copy() error = %v, want CopyError of DecryptLayers", err)
+	}
+	if !errors.Is(err, encryption.ErrNoMatchingKey) {
+		t.Errorf("Copy() error = %v, wantErr %v", err, encryption.ErrNoMatchingKey)
+	}
+}
+
+func TestCopy_RewriteForeignLayers_EncryptLayers(t *testing.T) {
+	foreignLayer := []byte("hello")
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(foreignLayer)
+	}))
+	defer ts.Close()
+
+	ctx := context.Background()
+	src := memory.New()
+	layer := content.NewDescriptorFromBytes(ocispec.MediaTypeImageLayerNonDistributable, foreignLayer)
+	layer.URLs = []string{ts.URL}
+	manifestJSON, err := json.Marshal(ocispec.Manifest{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+		MediaType: ocispec.MediaTypeImageManifest,
+		Config:    ocispec.DescriptorEmptyJSON,
+		Layers:    []ocispec.Descriptor{layer},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := content.NewDescriptorFromBytes(ocispec.MediaTypeImageManifest, manifestJSON)
+	for _, blob := range []struct {
+		desc ocispec.Descriptor
+		data []byte
+	}{
+		{ocispec.DescriptorEmptyJSON, ocispec.DescriptorEmptyJSON.Data},
+		{root, manifestJSON},
+	} {
+		if err := src.Push(ctx, blob.desc, bytes.NewReader(blob.data)); err != nil {
+			t.Fatalf("Push() error = %v", err)
+		}
+	}
+	ref := "foobar"
+	if err := src.Tag(ctx, root, ref); err != nil {
+		t.Fatalf("Tag() error = %v", err)
+	}
+	wrapper := newTestKeyWrapper(t)
+
+	// foreign layers are not encrypted
+	dst := memory.New()
+	gotRoot, err := oras.Copy(ctx, src, ref, dst, "", oras.CopyOptions{
+		EncryptLayers: []encryption.KeyWrapper{wrapper},
+	})
+	if err != nil {
+		t.Fatalf("Copy() error = %v", err)
+	}
+	if got := fetchManifest(t, dst, gotRoot).Layers[0]; !content.Equal(got, layer) {
+		t.Errorf("foreign layer = %v, want %v", got, layer)
+	}
+
+	// rewritten foreign layers are encrypted
+	dst = memory.New()
+	gotRoot, err = oras.Copy(ctx, src, ref, dst, "", oras.CopyOptions{
+		CopyGraphOptions: oras.CopyGraphOptions{
+			ForeignLayerClient: ts.Client(),
+		},
+		RewriteForeignLayers: true,
+		EncryptLayers:        []encryption.KeyWrapper{wrapper},
+	})
+	if err != nil {
+		t.Fatalf("Copy() error = %v", err)
+	}
+	got := fetchManifest(t, dst, gotRoot).Layers[0]
+	if !encryption.IsEncrypted(got) {
+		t.Errorf("layer media type = %v, want encrypted", got.MediaType)
+	}
+	if len(got.URLs) != 0 {
+		t.Errorf("layer URLs = %v, want none", got.URLs)
+	}
+	if plaintext := decryptBlob(t, dst, got, wrapper); !bytes.Equal(plaintext, foreignLayer) {
+		t.Errorf("decrypted layer = %s, want %s", plaintext, foreignLayer)
+	}
+}
+
+func TestPackManifest_EncryptLayers(t *testing.T) {
+	wrapper := newTestKeyWrapper(t)
+	ctx := context.Background()
+	source := memory.New()
+	blob := []byte("hello world")
+	layer := content.NewDescriptorFromBytes(ocispec.MediaTypeImageLayer, blob)
+	if err := source.Push(ctx, layer, bytes.NewReader(blob)); err != nil {
+		t.Fatalf("Push() error = %v", err)
+	}
+
+	for _, version := range []oras.PackManifestVersion{oras.PackManifestVersion1_0, oras.PackManifestVersion1_1} {
+		s := memory.New()
+		layers := []ocispec.Descriptor{layer}
+		root, err := oras.PackManifest(ctx, s, version, "application/vnd.test", oras.PackManifestOptions{
+			Layers:        layers,
+			EncryptLayers: []encryption.KeyWrapper{wrapper},
+			LayerSource:   source,
+		})
+		if err != nil {
+			t.Fatalf("PackManifest() error = %v", err)
+		}
+		if !content.Equal(layers[0], layer) {
+			t.Errorf("PackManifest() modified layers = %v, want %v", layers[0], layer)
+		}
+		manifest := fetchManifest(t, s, root)
+		if got := manifest.Layers[0]; !encryption.IsEncrypted(got) {
+			t.Errorf("layer media type = %v, want encrypted", got.MediaType)
+		}
+		if got := decryptBlob(t, s, manifest.Layers[0], wrapper); !bytes.Equal(got, blob) {
+			t.Errorf("decrypted layer = %s, want %s", got, blob)
+		}
+		// the plaintext is not pushed
+		if exists, err := s.Exists(ctx, layer); err != nil || exists {
+			t.Errorf("Exists() = %v, %v, want %v, nil", exists, err, false)
+		}
+	}
+
+	// the layer source is required
+	if _, err := oras.PackManifest(ctx, memory.New(), oras.PackManifestVersion1_1, "application/vnd.test", oras.PackManifestOptions{
+		Layers:        []ocispec.Descriptor{layer},
+		EncryptLayers: []encryption.KeyWrapper{wrapper},
+	}); err == nil {
+		t.Error("PackManifest() error = nil, wantErr true")
+	}
+}
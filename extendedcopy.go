@@ -20,6 +20,7 @@ import (
 	"encoding/json"
 	"errors"
 	"regexp"
+	"slices"
 
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 	"golang.org/x/sync/semaphore"
@@ -61,6 +62,10 @@ type ExtendedCopyGraphOptions struct {
 	// FindPredecessors finds the predecessors of the current node.
 	// If FindPredecessors is nil, src.Predecessors will be adapted and used.
 	FindPredecessors func(ctx context.Context, src content.ReadOnlyGraphStorage, desc ocispec.Descriptor) ([]ocispec.Descriptor, error)
+
+	// includeCosignTagReferrers is set by IncludeCosignTagReferrers to
+	// recreate the cosign tags of the copied referrers in the destination.
+	includeCosignTagReferrers bool
 }
 
 // ExtendedCopy copies the directed acyclic graph (DAG) that are reachable from
@@ -114,6 +119,12 @@ func ExtendedCopyGraph(ctx context.Context, src content.ReadOnlyGraphStorage, ds
 		return newCopyError("ExtendedCopyGraph", CopyErrorOriginDestination, errors.New("nil destination target"))
 	}
 
+	// record the referrers discovered by the cosign tags so that the tags can
+	// be recreated in the destination
+	var cosignTagged []ocispec.Descriptor
+	if opts.includeCosignTagReferrers {
+		opts.FindPredecessors = recordCosignTagReferrers(opts.FindPredecessors, &cosignTagged)
+	}
 	roots, err := findRoots(ctx, src, node, opts)
 	if err != nil {
 		return err
@@ -133,7 +144,7 @@ func ExtendedCopyGraph(ctx context.Context, src content.ReadOnlyGraphStorage, ds
 	tracker := status.NewTracker()
 
 	// copy the sub-DAGs rooted by the root nodes
-	return syncutil.Go(ctx, limiter, func(ctx context.Context, region *syncutil.LimitedRegion, root ocispec.Descriptor) error {
+	if err := syncutil.Go(ctx, limiter, func(ctx context.Context, region *syncutil.LimitedRegion, root ocispec.Descriptor) error {
 		// As a root can be a predecessor of other roots, release the limit here
 		// for dispatching, to avoid dead locks where predecessor roots are
 		// handled first and are waiting for its successors to complete.
@@ -142,7 +153,51 @@ func ExtendedCopyGraph(ctx context.Context, src content.ReadOnlyGraphStorage, ds
 			return err
 		}
 		return region.Start()
-	}, roots...)
+	}, roots...); err != nil {
+		return err
+	}
+
+	// recreate the cosign tags in the destination
+	if tagger, ok := dst.(content.Tagger); ok {
+		for _, desc := range cosignTagged {
+			tag := desc.Annotations[ocispec.AnnotationRefName]
+			target := ocispec.Descriptor{
+				MediaType: desc.MediaType,
+				Digest:    desc.Digest,
+				Size:      desc.Size,
+			}
+			if err := tagger.Tag(ctx, target, tag); err != nil {
+				return newCopyError("Tag", CopyErrorOriginDestination, err)
+			}
+		}
+	}
+	return nil
+}
+
+// recordCosignTagReferrers wraps findPredecessors to record the predecessors
+// discovered by the cosign tags.
+func recordCosignTagReferrers(findPredecessors func(ctx context.Context, src content.ReadOnlyGraphStorage, desc ocispec.Descriptor) ([]ocispec.Descriptor, error), recorded *[]ocispec.Descriptor) func(ctx context.Context, src content.ReadOnlyGraphStorage, desc ocispec.Descriptor) ([]ocispec.Descriptor, error) {
+	return func(ctx context.Context, src content.ReadOnlyGraphStorage, desc ocispec.Descriptor) ([]ocispec.Descriptor, error) {
+		predecessors, err := findPredecessorsWith(ctx, findPredecessors, src, desc)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range predecessors {
+			if _, ok := registry.IsCosignTagReferrer(desc, p); ok {
+				*recorded = append(*recorded, p)
+			}
+		}
+		return predecessors, nil
+	}
+}
+
+// findPredecessorsWith finds the predecessors of desc with findPredecessors,
+// or with src.Predecessors if findPredecessors is nil.
+func findPredecessorsWith(ctx context.Context, findPredecessors func(ctx context.Context, src content.ReadOnlyGraphStorage, desc ocispec.Descriptor) ([]ocispec.Descriptor, error), src content.ReadOnlyGraphStorage, desc ocispec.Descriptor) ([]ocispec.Descriptor, error) {
+	if findPredecessors == nil {
+		return src.Predecessors(ctx, desc)
+	}
+	return findPredecessors(ctx, src, desc)
 }
 
 // findRoots finds the root nodes reachable from the given node through a
@@ -217,6 +272,44 @@ func findRoots(ctx context.Context, storage content.ReadOnlyGraphStorage, node o
 	return roots, nil
 }
 
+// IncludeCosignTagReferrers configures opts.FindPredecessors to also discover
+// the signatures, attestations and SBOMs stored under the conventional tags
+// used by cosign, such as "sha256-<hex>.sig", if the source is a
+// content.Resolver. The discovered referrers are copied along with the other
+// predecessors, and their tags are recreated in the destination if the
+// destination is a content.Tagger.
+//
+// To filter the discovered referrers as well, call IncludeCosignTagReferrers
+// before FilterArtifactType and FilterAnnotation.
+// See also: registry.CosignTagReferrers()
+func (opts *ExtendedCopyGraphOptions) IncludeCosignTagReferrers() {
+	opts.includeCosignTagReferrers = true
+	fp := opts.FindPredecessors
+	opts.FindPredecessors = func(ctx context.Context, src content.ReadOnlyGraphStorage, desc ocispec.Descriptor) ([]ocispec.Descriptor, error) {
+		predecessors, err := findPredecessorsWith(ctx, fp, src, desc)
+		if err != nil {
+			return nil, err
+		}
+
+		resolver, ok := src.(content.Resolver)
+		if !ok || !descriptor.IsManifest(desc) {
+			return predecessors, nil
+		}
+		tagReferrers, err := registry.CosignTagReferrers(ctx, resolver, desc, "")
+		if err != nil {
+			return nil, err
+		}
+		for _, referrer := range tagReferrers {
+			if !slices.ContainsFunc(predecessors, func(p ocispec.Descriptor) bool {
+				return content.Equal(p, referrer)
+			}) {
+				predecessors = append(predecessors, referrer)
+			}
+		}
+		return predecessors, nil
+	}
+}
+
 // FilterAnnotation configures opts.FindPredecessors to filter the predecessors
 // whose annotation matches a given regex pattern.
 //
@@ -38,6 +38,7 @@ import (
 	"oras.land/oras-go/v2/content/memory"
 	"oras.land/oras-go/v2/errdef"
 	"oras.land/oras-go/v2/internal/spec"
+	"oras.land/oras-go/v2/registry"
 	"oras.land/oras-go/v2/registry/remote"
 )
 
@@ -723,6 +724,126 @@ func TestExtendedCopy_NotFound(t *testing.T) {
 	}
 }
 
+func TestExtendedCopy_IncludeCosignTagReferrers(t *testing.T) {
+	src := memory.New()
+
+	// generate test content
+	var blobs [][]byte
+	var descs []ocispec.Descriptor
+	appendBlob := func(mediaType string, blob []byte) {
+		blobs = append(blobs, blob)
+		descs = append(descs, ocispec.Descriptor{
+			MediaType: mediaType,
+			Digest:    digest.FromBytes(blob),
+			Size:      int64(len(blob)),
+		})
+	}
+	generateManifest := func(subject *ocispec.Descriptor, config ocispec.Descriptor, layers ...ocispec.Descriptor) {
+		manifest := ocispec.Manifest{
+			Config:  config,
+			Layers:  layers,
+			Subject: subject,
+		}
+		manifestJSON, err := json.Marshal(manifest)
+		if err != nil {
+			t.Fatal(err)
+		}
+		appendBlob(ocispec.MediaTypeImageManifest, manifestJSON)
+	}
+
+	appendBlob(ocispec.MediaTypeImageConfig, []byte("config"))   // Blob 0
+	appendBlob(ocispec.MediaTypeImageLayer, []byte("foo"))       // Blob 1
+	generateManifest(nil, descs[0], descs[1])                    // Blob 2
+	appendBlob(ocispec.MediaTypeImageLayer, []byte("signature")) // Blob 3
+	generateManifest(nil, descs[0], descs[3])                    // Blob 4
+	appendBlob(ocispec.MediaTypeImageLayer, []byte("sbom"))      // Blob 5
+	generateManifest(&descs[2], descs[0], descs[5])              // Blob 6
+
+	ctx := context.Background()
+	for i := range blobs {
+		err := src.Push(ctx, descs[i], bytes.NewReader(blobs[i]))
+		if err != nil {
+			t.Fatalf("failed to push test content to src: %d: %v", i, err)
+		}
+	}
+
+	manifest := descs[2]
+	ref := "foobar"
+	if err := src.Tag(ctx, manifest, ref); err != nil {
+		t.Fatal("fail to tag root node", err)
+	}
+	sigTag := strings.Replace(manifest.Digest.String(), ":", "-", 1) + ".sig"
+	if err := src.Tag(ctx, descs[4], sigTag); err != nil {
+		t.Fatal("fail to tag signature", err)
+	}
+
+	// test extended copy without cosign tags
+	dst := memory.New()
+	if _, err := oras.ExtendedCopy(ctx, src, ref, dst, "", oras.ExtendedCopyOptions{}); err != nil {
+		t.Fatalf("ExtendedCopy() error = %v, wantErr %v", err, false)
+	}
+	if exists, err := dst.Exists(ctx, descs[4]); err != nil || exists {
+		t.Errorf("dst.Exists(4) = %v, %v, want %v", exists, err, false)
+	}
+
+	// test extended copy with custom FindPredecessors discovering cosign tags,
+	// which does not recreate the tags
+	dst = memory.New()
+	customOpts := oras.ExtendedCopyOptions{}
+	customOpts.FindPredecessors = func(ctx context.Context, src content.ReadOnlyGraphStorage, desc ocispec.Descriptor) ([]ocispec.Descriptor, error) {
+		predecessors, err := src.Predecessors(ctx, desc)
+		if err != nil {
+			return nil, err
+		}
+		tagReferrers, err := registry.CosignTagReferrers(ctx, src.(content.Resolver), desc, "")
+		if err != nil {
+			return nil, err
+		}
+		return append(predecessors, tagReferrers...), nil
+	}
+	if _, err := oras.ExtendedCopy(ctx, src, ref, dst, "", customOpts); err != nil {
+		t.Fatalf("ExtendedCopy() error = %v, wantErr %v", err, false)
+	}
+	if exists, err := dst.Exists(ctx, descs[4]); err != nil || !exists {
+		t.Errorf("dst.Exists(4) = %v, %v, want %v", exists, err, true)
+	}
+	if _, err := dst.Resolve(ctx, sigTag); !errors.Is(err, errdef.ErrNotFound) {
+		t.Errorf("dst.Resolve() error = %v, wantErr %v", err, errdef.ErrNotFound)
+	}
+
+	// test extended copy with cosign tags
+	dst = memory.New()
+	opts := oras.ExtendedCopyOptions{}
+	opts.IncludeCosignTagReferrers()
+	gotDesc, err := oras.ExtendedCopy(ctx, src, ref, dst, "", opts)
+	if err != nil {
+		t.Fatalf("ExtendedCopy() error = %v, wantErr %v", err, false)
+	}
+	if !reflect.DeepEqual(gotDesc, manifest) {
+		t.Errorf("ExtendedCopy() = %v, want %v", gotDesc, manifest)
+	}
+
+	// verify contents
+	for i, desc := range descs {
+		exists, err := dst.Exists(ctx, desc)
+		if err != nil {
+			t.Fatalf("dst.Exists(%d) error = %v", i, err)
+		}
+		if !exists {
+			t.Errorf("dst.Exists(%d) = %v, want %v", i, exists, true)
+		}
+	}
+
+	// verify tags
+	gotDesc, err = dst.Resolve(ctx, sigTag)
+	if err != nil {
+		t.Fatal("dst.Resolve() error =", err)
+	}
+	if !reflect.DeepEqual(gotDesc, descs[4]) {
+		t.Errorf("dst.Resolve() = %v, want %v", gotDesc, descs[4])
+	}
+}
+
 func TestExtendedCopyGraph_FilterAnnotationWithRegex(t *testing.T) {
 	// generate test content
 	var blobs [][]byte
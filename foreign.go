@@ -0,0 +1,237 @@
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oras
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/errdef"
+	"oras.land/oras-go/v2/internal/cas"
+	"oras.land/oras-go/v2/internal/descriptor"
+	"oras.land/oras-go/v2/internal/docker"
+	"oras.land/oras-go/v2/internal/verifyutil"
+)
+
+// FetchFromURLs downloads the content described by desc from the URLs of
+// desc, such as a foreign layer, using the given HTTP client. The URLs are
+// tried in turn until one succeeds. Only "http" and "https" URLs are
+// supported.
+// If client is nil, http.DefaultClient is used.
+//
+// The returned reader verifies the content against the size and the digest of
+// desc, and returns an error instead of io.EOF if the verification fails.
+func FetchFromURLs(ctx context.Context, client *http.Client, desc ocispec.Descriptor) (io.ReadCloser, error) {
+	if len(desc.URLs) == 0 {
+		return nil, fmt.Errorf("%s: no urls to fetch from: %w", desc.Digest, errdef.ErrNotFound)
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	var errs []error
+	for _, rawURL := range desc.URLs {
+		rc, err := fetchFromURL(ctx, client, rawURL, desc)
+		if err == nil {
+			return rc, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		errs = append(errs, err)
+	}
+	return nil, fmt.Errorf("%s: failed to fetch from urls: %w", desc.Digest, errors.Join(errs...))
+}
+
+// fetchFromURL downloads the content described by desc from the given URL.
+func fetchFromURL(ctx context.Context, client *http.Client, rawURL string, desc ocispec.Descriptor) (io.ReadCloser, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("%s: unsupported url scheme %q", rawURL, u.Scheme)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s %q: unexpected status code %d", req.Method, rawURL, resp.StatusCode)
+	}
+	if size := resp.ContentLength; size != -1 && size != desc.Size {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s %q: mismatch Content-Length %d: %w", req.Method, rawURL, size, content.ErrInvalidDescriptorSize)
+	}
+	return verifyutil.NewReadCloser(content.NewVerifyReader(resp.Body, desc), resp.Body), nil
+}
+
+// urlFallbackStorage falls back to fetching content from the URLs of the
+// descriptors if the content is not found in the underlying storage.
+type urlFallbackStorage struct {
+	content.ReadOnlyStorage
+	client *http.Client
+	// urls maps the digests of the rewritten foreign layers to their URLs,
+	// which are removed from the rewritten descriptors.
+	urls map[digest.Digest][]string
+}
+
+// withURLFallback wraps the storage to fall back to fetching content from
+// the URLs of the descriptors using the given client. The storage is returned
+// as is if client is nil.
+func withURLFallback(storage content.ReadOnlyStorage, client *http.Client) content.ReadOnlyStorage {
+	if client == nil {
+		return storage
+	}
+	return &urlFallbackStorage{
+		ReadOnlyStorage: storage,
+		client:          client,
+	}
+}
+
+// Fetch fetches the content identified by the descriptor.
+func (s *urlFallbackStorage) Fetch(ctx context.Context, target ocispec.Descriptor) (io.ReadCloser, error) {
+	rc, err := s.ReadOnlyStorage.Fetch(ctx, target)
+	if err != nil && errors.Is(err, errdef.ErrNotFound) {
+		if len(target.URLs) == 0 {
+			target.URLs = s.urls[target.Digest]
+		}
+		if len(target.URLs) != 0 {
+			return FetchFromURLs(ctx, s.client, target)
+		}
+	}
+	return rc, err
+}
+
+// rewriteForeignLayers rewrites the media types of the foreign layers
+// referenced by the manifests in the graph rooted by root to their
+// distributable equivalents, and removes their URLs. The rewritten manifests
+// are pushed to the cache of the proxy, and the descriptor of the rewritten
+// root is returned along with the removed URLs of the rewritten layers.
+// root is returned as is if the graph has no foreign layers.
+func rewriteForeignLayers(ctx context.Context, proxy *cas.Proxy, root ocispec.Descriptor) (ocispec.Descriptor, map[digest.Digest][]string, error) {
+	urls := make(map[digest.Digest][]string)
+	root, err := rewriteLayers(ctx, proxy, root, func(_ context.Context, layer ocispec.Descriptor) (ocispec.Descriptor, error) {
+		if mediaType, ok := descriptor.DistributableMediaType(layer.MediaType); ok {
+			if len(layer.URLs) != 0 {
+				urls[layer.Digest] = layer.URLs
+			}
+			layer.MediaType = mediaType
+			layer.URLs = nil
+		}
+		return layer, nil
+	})
+	if err != nil {
+		return ocispec.Descriptor{}, nil, err
+	}
+	return root, urls, nil
+}
+
+// rewriteLayers rewrites the layers referenced by the manifests in the graph
+// rooted by root with the rewrite function. The rewritten manifests are
+// pushed to the cache of the proxy, and the descriptor of the rewritten root
+// is returned. root is returned as is if no layer is rewritten.
+//
+// The rewritten manifests are re-encoded through ocispec.Manifest and
+// ocispec.Index, so the fields unknown to them are dropped.
+func rewriteLayers(ctx context.Context, proxy *cas.Proxy, root ocispec.Descriptor,
+	rewrite func(ctx context.Context, layer ocispec.Descriptor) (ocispec.Descriptor, error)) (ocispec.Descriptor, error) {
+	switch root.MediaType {
+	case docker.MediaTypeManifest, ocispec.MediaTypeImageManifest:
+		manifestJSON, err := content.FetchAll(ctx, proxy, root)
+		if err != nil {
+			return ocispec.Descriptor{}, err
+		}
+		var manifest ocispec.Manifest
+		if err := json.Unmarshal(manifestJSON, &manifest); err != nil {
+			return ocispec.Descriptor{}, fmt.Errorf("failed to decode manifest %s: %w", root.Digest, err)
+		}
+		var rewritten bool
+		for i, layer := range manifest.Layers {
+			desc, err := rewrite(ctx, layer)
+			if err != nil {
+				return ocispec.Descriptor{}, err
+			}
+			if !content.Equal(desc, layer) {
+				manifest.Layers[i] = desc
+				rewritten = true
+			}
+		}
+		if !rewritten {
+			return root, nil
+		}
+		return pushRewrittenManifest(ctx, proxy.Cache, root, manifest)
+	case docker.MediaTypeManifestList, ocispec.MediaTypeImageIndex:
+		indexJSON, err := content.FetchAll(ctx, proxy, root)
+		if err != nil {
+			return ocispec.Descriptor{}, err
+		}
+		var index ocispec.Index
+		if err := json.Unmarshal(indexJSON, &index); err != nil {
+			return ocispec.Descriptor{}, fmt.Errorf("failed to decode index %s: %w", root.Digest, err)
+		}
+		var rewritten bool
+		for i, manifest := range index.Manifests {
+			desc, err := rewriteLayers(ctx, proxy, manifest, rewrite)
+			if err != nil {
+				return ocispec.Descriptor{}, err
+			}
+			if !content.Equal(desc, manifest) {
+				index.Manifests[i] = desc
+				rewritten = true
+			}
+		}
+		if !rewritten {
+			return root, nil
+		}
+		return pushRewrittenManifest(ctx, proxy.Cache, root, index)
+	default:
+		return root, nil
+	}
+}
+
+// pushRewrittenManifest pushes the rewritten manifest of the original
+// descriptor to the cache, and returns the descriptor of the rewritten
+// manifest, which keeps the properties of the original descriptor except the
+// digest and the size.
+func pushRewrittenManifest(ctx context.Context, cache content.Storage, original ocispec.Descriptor, manifest any) (ocispec.Descriptor, error) {
+	manifestJSON, err := json.Marshal(manifest)
+	if err != nil {
+		return ocispec.Descriptor{}, fmt.Errorf("failed to encode rewritten manifest of %s: %w", original.Digest, err)
+	}
+	desc := content.NewDescriptorFromBytes(original.MediaType, manifestJSON)
+	desc.ArtifactType = original.ArtifactType
+	desc.Annotations = original.Annotations
+	desc.Platform = original.Platform
+	if err := cache.Push(ctx, desc, bytes.NewReader(manifestJSON)); err != nil && !errors.Is(err, errdef.ErrAlreadyExists) {
+		return ocispec.Descriptor{}, err
+	}
+	return desc, nil
+}
@@ -0,0 +1,180 @@
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oras_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/content/memory"
+	"oras.land/oras-go/v2/errdef"
+)
+
+func TestFetchFromURLs(t *testing.T) {
+	blob := []byte("hello world")
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/blob":
+			w.Write(blob)
+		case "/trailing":
+			w.Write(append(blob, "!"...))
+		case "/short":
+			w.Header().Set("Content-Length", "5")
+			w.Write(blob[:5])
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+	desc := content.NewDescriptorFromBytes(ocispec.MediaTypeImageLayerNonDistributable, blob)
+
+	tests := []struct {
+		name    string
+		urls    []string
+		wantErr error
+	}{
+		{
+			name: "single url",
+			urls: []string{ts.URL + "/blob"},
+		},
+		{
+			name: "fall back to next url",
+			urls: []string{"ftp://example.com/blob", ts.URL + "/missing", ts.URL + "/blob"},
+		},
+		{
+			name:    "no urls",
+			wantErr: errdef.ErrNotFound,
+		},
+		{
+			name:    "mismatched size",
+			urls:    []string{ts.URL + "/short"},
+			wantErr: content.ErrInvalidDescriptorSize,
+		},
+		{
+			name:    "trailing data",
+			urls:    []string{ts.URL + "/trailing"},
+			wantErr: content.ErrInvalidDescriptorSize,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			desc := desc
+			desc.URLs = tt.urls
+			rc, err := oras.FetchFromURLs(context.Background(), ts.Client(), desc)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("FetchFromURLs() error = %v, wantErr %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("FetchFromURLs() error = %v", err)
+			}
+			defer rc.Close()
+			got, err := io.ReadAll(rc)
+			if err != nil {
+				t.Fatalf("io.ReadAll() error = %v", err)
+			}
+			if !bytes.Equal(got, blob) {
+				t.Errorf("FetchFromURLs() = %v, want %v", got, blob)
+			}
+		})
+	}
+}
+
+func TestFetchFromURLs_MismatchedDigest(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("world"))
+	}))
+	defer ts.Close()
+	desc := content.NewDescriptorFromBytes(ocispec.MediaTypeImageLayerNonDistributable, []byte("hello"))
+	desc.URLs = []string{ts.URL}
+
+	rc, err := oras.FetchFromURLs(context.Background(), ts.Client(), desc)
+	if err != nil {
+		t.Fatalf("FetchFromURLs() error = %v", err)
+	}
+	defer rc.Close()
+	if _, err := io.ReadAll(rc); !errors.Is(err, content.ErrMismatchedDigest) {
+		t.Errorf("io.ReadAll() error = %v, wantErr %v", err, content.ErrMismatchedDigest)
+	}
+}
+
+func TestFetch_ForeignLayer(t *testing.T) {
+	blob := []byte("hello world")
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(blob)
+	}))
+	defer ts.Close()
+	layer := content.NewDescriptorFromBytes(ocispec.MediaTypeImageLayerNonDistributable, blob)
+	layer.URLs = []string{ts.URL}
+
+	// push a manifest referencing the foreign layer without its content
+	ctx := context.Background()
+	target := memory.New()
+	config := ocispec.DescriptorEmptyJSON
+	if err := target.Push(ctx, config, bytes.NewReader(config.Data)); err != nil {
+		t.Fatalf("Push() error = %v", err)
+	}
+	manifest, err := oras.PackManifest(ctx, target, oras.PackManifestVersion1_1, "application/vnd.test", oras.PackManifestOptions{
+		Layers:           []ocispec.Descriptor{layer},
+		ConfigDescriptor: &config,
+	})
+	if err != nil {
+		t.Fatalf("PackManifest() error = %v", err)
+	}
+	ref := "foreign"
+	if err := target.Tag(ctx, manifest, ref); err != nil {
+		t.Fatalf("Tag() error = %v", err)
+	}
+
+	// fetch the manifest, and then the foreign layer from its URLs
+	gotDesc, manifestJSON, err := oras.FetchBytes(ctx, target, ref, oras.DefaultFetchBytesOptions)
+	if err != nil {
+		t.Fatalf("FetchBytes() error = %v", err)
+	}
+	if !content.Equal(gotDesc, manifest) {
+		t.Errorf("FetchBytes() = %v, want %v", gotDesc, manifest)
+	}
+	var got ocispec.Manifest
+	if err := json.Unmarshal(manifestJSON, &got); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	if _, err := target.Fetch(ctx, got.Layers[0]); !errors.Is(err, errdef.ErrNotFound) {
+		t.Fatalf("Fetch() error = %v, wantErr %v", err, errdef.ErrNotFound)
+	}
+	rc, err := oras.FetchFromURLs(ctx, ts.Client(), got.Layers[0])
+	if err != nil {
+		t.Fatalf("FetchFromURLs() error = %v", err)
+	}
+	defer rc.Close()
+	gotBlob, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatalf("io.ReadAll() error = %v", err)
+	}
+	if !bytes.Equal(gotBlob, blob) {
+		t.Errorf("FetchFromURLs() = %v, want %v", gotBlob, blob)
+	}
+}
@@ -0,0 +1,310 @@
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package aesgcm implements streaming authenticated encryption with AES-GCM.
+//
+// The plaintext is split into chunks of ChunkSize bytes, which are sealed
+// individually. The ciphertext starts with a header containing a random salt,
+// from which the key of the content is derived, followed by the sealed chunks.
+// The nonce of each chunk is composed of the chunk counter and a flag marking
+// the last chunk, so that reordered, truncated or extended ciphertexts are
+// rejected.
+package aesgcm
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ChunkSize is the maximum size of a plaintext chunk.
+const ChunkSize = 64 * 1024
+
+const (
+	// saltSize is the size of the random salt in the header.
+	saltSize = 32
+	// tagSize is the size of the authentication tag of a chunk.
+	tagSize = 16
+	// recordSize is the maximum size of a sealed chunk.
+	recordSize = ChunkSize + tagSize
+)
+
+// magic identifies the format and its version.
+var magic = []byte("ORASGCM\x01")
+
+// headerSize is the size of the header preceding the sealed chunks.
+var headerSize = len(magic) + saltSize
+
+// ErrInvalidCiphertext is returned when the ciphertext is malformed, or fails
+// the authentication.
+var ErrInvalidCiphertext = errors.New("invalid ciphertext")
+
+// errClosed is returned when writing to a closed writer.
+var errClosed = errors.New("write to closed writer")
+
+// Cipher encrypts and decrypts content with a key.
+type Cipher struct {
+	key []byte
+}
+
+// New creates a cipher with the key, which must be 16, 24, or 32 bytes long.
+func New(key []byte) (*Cipher, error) {
+	switch len(key) {
+	case 16, 24, 32:
+		return &Cipher{key: bytes.Clone(key)}, nil
+	default:
+		return nil, aes.KeySizeError(len(key))
+	}
+}
+
+// NewWriter returns a writer encrypting the content written to it into w.
+// The additional data ad is authenticated but not encrypted, and must be
+// provided again on decryption. The writer must be closed to write the last
+// chunk.
+func (c *Cipher) NewWriter(w io.Writer, ad []byte) (io.WriteCloser, error) {
+	header := make([]byte, headerSize)
+	copy(header, magic)
+	salt := header[len(magic):]
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("failed to generate salt: %w", err)
+	}
+	aead, err := c.aead(salt)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(header); err != nil {
+		return nil, err
+	}
+	return &writer{
+		w:    w,
+		aead: aead,
+		ad:   ad,
+		buf:  make([]byte, 0, recordSize),
+	}, nil
+}
+
+// NewReader returns a reader decrypting the content read from r, which must
+// be encrypted with the same additional data ad.
+// ErrInvalidCiphertext is returned if the content is tampered.
+func (c *Cipher) NewReader(r io.Reader, ad []byte) io.Reader {
+	return &reader{
+		r:      r,
+		cipher: c,
+		ad:     ad,
+		buf:    make([]byte, recordSize+1),
+	}
+}
+
+// aead derives the key of the content from the salt, and returns the AEAD
+// cipher of the key.
+func (c *Cipher) aead(salt []byte) (cipher.AEAD, error) {
+	mac := hmac.New(sha256.New, c.key)
+	mac.Write(salt)
+	block, err := aes.NewCipher(mac.Sum(nil))
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// CiphertextSize returns the size of the ciphertext of a plaintext of the
+// given size.
+func CiphertextSize(size int64) int64 {
+	chunks := max(1, (size+ChunkSize-1)/ChunkSize)
+	return int64(headerSize) + size + chunks*tagSize
+}
+
+// PlaintextSize returns the size of the plaintext of a ciphertext of the
+// given size.
+func PlaintextSize(size int64) (int64, error) {
+	body := size - int64(headerSize)
+	if body < tagSize {
+		return 0, fmt.Errorf("ciphertext size %d: %w", size, ErrInvalidCiphertext)
+	}
+	chunks := (body + recordSize - 1) / recordSize
+	plaintextSize := body - chunks*tagSize
+	if CiphertextSize(plaintextSize) != size {
+		return 0, fmt.Errorf("ciphertext size %d: %w", size, ErrInvalidCiphertext)
+	}
+	return plaintextSize, nil
+}
+
+// nonce returns the nonce of the chunk of the given counter.
+func nonce(counter uint64, last bool) []byte {
+	n := make([]byte, 12)
+	binary.BigEndian.PutUint64(n[3:11], counter)
+	if last {
+		n[11] = 1
+	}
+	return n
+}
+
+// writer seals the written content in chunks.
+type writer struct {
+	w       io.Writer
+	aead    cipher.AEAD
+	ad      []byte
+	buf     []byte
+	counter uint64
+	err     error
+}
+
+// Write buffers p, and seals the buffered chunks which are not the last.
+func (w *writer) Write(p []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+	var written int
+	for len(p) > 0 {
+		if len(w.buf) == ChunkSize {
+			// more content follows the full chunk
+			if err := w.seal(false); err != nil {
+				w.err = err
+				return written, err
+			}
+		}
+		n := min(ChunkSize-len(w.buf), len(p))
+		w.buf = append(w.buf, p[:n]...)
+		p = p[n:]
+		written += n
+	}
+	return written, nil
+}
+
+// Close seals the buffered content as the last chunk.
+func (w *writer) Close() error {
+	if w.err != nil {
+		if w.err == errClosed {
+			return nil
+		}
+		return w.err
+	}
+	if err := w.seal(true); err != nil {
+		w.err = err
+		return err
+	}
+	w.err = errClosed
+	return nil
+}
+
+// seal seals the buffered content in place and writes it.
+func (w *writer) seal(last bool) error {
+	record := w.aead.Seal(w.buf[:0], nonce(w.counter, last), w.buf, w.ad)
+	if _, err := w.w.Write(record); err != nil {
+		return err
+	}
+	w.buf = w.buf[:0]
+	w.counter++
+	return nil
+}
+
+// reader opens the sealed chunks read from the underlying reader.
+type reader struct {
+	r      io.Reader
+	cipher *Cipher
+	ad     []byte
+	aead   cipher.AEAD
+	// buf holds a sealed chunk and one byte ahead to detect the last chunk.
+	buf          []byte
+	lookahead    byte
+	hasLookahead bool
+	plaintext    []byte
+	counter      uint64
+	err          error
+}
+
+// Read reads up to len(p) bytes of the decrypted content into p.
+func (r *reader) Read(p []byte) (int, error) {
+	for len(r.plaintext) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+		r.err = r.next()
+	}
+	n := copy(p, r.plaintext)
+	r.plaintext = r.plaintext[n:]
+	return n, nil
+}
+
+// next opens the next chunk. It returns io.EOF after the last chunk.
+func (r *reader) next() error {
+	if r.aead == nil {
+		if err := r.readHeader(); err != nil {
+			return err
+		}
+	} else if !r.hasLookahead {
+		// the last chunk has been opened
+		return io.EOF
+	}
+
+	var pending int
+	if r.hasLookahead {
+		r.buf[0] = r.lookahead
+		pending = 1
+	}
+	n, err := io.ReadFull(r.r, r.buf[pending:])
+	n += pending
+	var last bool
+	switch err {
+	case nil:
+		// one byte ahead belongs to the next chunk
+		n--
+		r.lookahead = r.buf[n]
+		r.hasLookahead = true
+	case io.EOF, io.ErrUnexpectedEOF:
+		last = true
+		r.hasLookahead = false
+	default:
+		return err
+	}
+	record := r.buf[:n]
+	if len(record) < tagSize {
+		return fmt.Errorf("truncated chunk %d: %w", r.counter, ErrInvalidCiphertext)
+	}
+	plaintext, err := r.aead.Open(record[:0], nonce(r.counter, last), record, r.ad)
+	if err != nil {
+		return fmt.Errorf("chunk %d: %w", r.counter, ErrInvalidCiphertext)
+	}
+	r.plaintext = plaintext
+	r.counter++
+	return nil
+}
+
+// readHeader reads the header and derives the key of the content.
+func (r *reader) readHeader() error {
+	header := make([]byte, headerSize)
+	if _, err := io.ReadFull(r.r, header); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return fmt.Errorf("truncated header: %w", ErrInvalidCiphertext)
+		}
+		return err
+	}
+	if !bytes.Equal(header[:len(magic)], magic) {
+		return fmt.Errorf("unknown format: %w", ErrInvalidCiphertext)
+	}
+	aead, err := r.cipher.aead(header[len(magic):])
+	if err != nil {
+		return err
+	}
+	r.aead = aead
+	return nil
+}
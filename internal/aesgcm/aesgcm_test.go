@@ -0,0 +1,128 @@
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aesgcm
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"io"
+	"testing"
+)
+
+func encrypt(t *testing.T, c *Cipher, plaintext, ad []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := c.NewWriter(&buf, ad)
+	if err != nil {
+		t.Fatal("Cipher.NewWriter() error =", err)
+	}
+	// write in uneven pieces to exercise the chunk buffering
+	for len(plaintext) > 0 {
+		n := min(1000, len(plaintext))
+		if _, err := w.Write(plaintext[:n]); err != nil {
+			t.Fatal("writer.Write() error =", err)
+		}
+		plaintext = plaintext[n:]
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal("writer.Close() error =", err)
+	}
+	return buf.Bytes()
+}
+
+func TestCipher_RoundTrip(t *testing.T) {
+	c, err := New(bytes.Repeat([]byte("k"), 32))
+	if err != nil {
+		t.Fatal("New() error =", err)
+	}
+	ad := []byte("sha256:foo")
+	for _, size := range []int{0, 1, ChunkSize - 1, ChunkSize, ChunkSize + 1, 3*ChunkSize + 42} {
+		plaintext := make([]byte, size)
+		if _, err := rand.Read(plaintext); err != nil {
+			t.Fatal(err)
+		}
+		ciphertext := encrypt(t, c, plaintext, ad)
+		if got, want := int64(len(ciphertext)), CiphertextSize(int64(size)); got != want {
+			t.Errorf("len(ciphertext) = %v, want CiphertextSize() = %v", got, want)
+		}
+		if got, err := PlaintextSize(int64(len(ciphertext))); err != nil || got != int64(size) {
+			t.Errorf("PlaintextSize() = %v, %v, want %v", got, err, size)
+		}
+		got, err := io.ReadAll(c.NewReader(bytes.NewReader(ciphertext), ad))
+		if err != nil {
+			t.Fatalf("size %d: io.ReadAll() error = %v", size, err)
+		}
+		if !bytes.Equal(got, plaintext) {
+			t.Errorf("size %d: decrypted content does not match the plaintext", size)
+		}
+	}
+}
+
+func TestCipher_Tampered(t *testing.T) {
+	key := bytes.Repeat([]byte("k"), 16)
+	c, err := New(key)
+	if err != nil {
+		t.Fatal("New() error =", err)
+	}
+	ad := []byte("sha256:foo")
+	plaintext := bytes.Repeat([]byte("foobar"), ChunkSize/3)
+	ciphertext := encrypt(t, c, plaintext, ad)
+
+	otherKey, err := New(bytes.Repeat([]byte("x"), 16))
+	if err != nil {
+		t.Fatal("New() error =", err)
+	}
+	flipped := bytes.Clone(ciphertext)
+	flipped[len(flipped)/2] ^= 1
+	lastChunk := headerSize + recordSize
+	tests := []struct {
+		name       string
+		cipher     *Cipher
+		ciphertext []byte
+		ad         []byte
+	}{
+		{name: "flipped bit", cipher: c, ciphertext: flipped, ad: ad},
+		{name: "truncated at chunk boundary", cipher: c, ciphertext: ciphertext[:lastChunk], ad: ad},
+		{name: "truncated header", cipher: c, ciphertext: ciphertext[:headerSize-1], ad: ad},
+		{name: "extended", cipher: c, ciphertext: append(bytes.Clone(ciphertext), ciphertext[headerSize:]...), ad: ad},
+		{name: "wrong additional data", cipher: c, ciphertext: ciphertext, ad: []byte("sha256:bar")},
+		{name: "wrong key", cipher: otherKey, ciphertext: ciphertext, ad: ad},
+		{name: "plaintext", cipher: c, ciphertext: plaintext, ad: ad},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := io.ReadAll(tt.cipher.NewReader(bytes.NewReader(tt.ciphertext), tt.ad))
+			if !errors.Is(err, ErrInvalidCiphertext) {
+				t.Errorf("io.ReadAll() error = %v, want %v", err, ErrInvalidCiphertext)
+			}
+		})
+	}
+}
+
+func TestNew_InvalidKey(t *testing.T) {
+	if _, err := New([]byte("short")); err == nil {
+		t.Error("New() error = nil, want error")
+	}
+}
+
+func TestPlaintextSize_Invalid(t *testing.T) {
+	for _, size := range []int64{0, int64(headerSize) + tagSize - 1, int64(headerSize) + recordSize + tagSize} {
+		if _, err := PlaintextSize(size); !errors.Is(err, ErrInvalidCiphertext) {
+			t.Errorf("PlaintextSize(%d) error = %v, want %v", size, err, ErrInvalidCiphertext)
+		}
+	}
+}
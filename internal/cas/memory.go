@@ -50,6 +50,18 @@ func (m *Memory) Fetch(_ context.Context, target ocispec.Descriptor) (io.ReadClo
 
 // Push pushes the content, matching the expected descriptor.
 func (m *Memory) Push(_ context.Context, expected ocispec.Descriptor, content io.Reader) error {
+	return m.push(expected, content, contentpkg.ReadAll)
+}
+
+// PushRaw pushes the content, matching only the size of the expected
+// descriptor, so that the content can be stored transformed by the digest of
+// its original form, such as by [contentpkg.EncryptedStorage].
+func (m *Memory) PushRaw(_ context.Context, expected ocispec.Descriptor, content io.Reader) error {
+	return m.push(expected, content, readRaw)
+}
+
+// push pushes the content, reading it with readFn.
+func (m *Memory) push(expected ocispec.Descriptor, content io.Reader, readFn func(io.Reader, ocispec.Descriptor) ([]byte, error)) error {
 	key := descriptor.FromOCI(expected)
 
 	// check if the content exists in advance to avoid reading from the content.
@@ -58,7 +70,7 @@ func (m *Memory) Push(_ context.Context, expected ocispec.Descriptor, content io
 	}
 
 	// read and try to store the content.
-	value, err := contentpkg.ReadAll(content, expected)
+	value, err := readFn(content, expected)
 	if err != nil {
 		return err
 	}
@@ -75,6 +87,15 @@ func (m *Memory) Exists(_ context.Context, target ocispec.Descriptor) (bool, err
 	return exists, nil
 }
 
+// Delete removes the content identified by the descriptor.
+func (m *Memory) Delete(_ context.Context, target ocispec.Descriptor) error {
+	key := descriptor.FromOCI(target)
+	if _, exists := m.content.LoadAndDelete(key); !exists {
+		return fmt.Errorf("%s: %s: %w", key.Digest, key.MediaType, errdef.ErrNotFound)
+	}
+	return nil
+}
+
 // Map dumps the memory into a built-in map structure.
 // Like other operations, calling Map() is go-routine safe. However, it does not
 // necessarily correspond to any consistent snapshot of the storage contents.
@@ -86,3 +107,24 @@ func (m *Memory) Map() map[descriptor.Descriptor][]byte {
 	})
 	return res
 }
+
+// readRaw reads the content of the size of desc without verifying its digest.
+func readRaw(r io.Reader, desc ocispec.Descriptor) ([]byte, error) {
+	if desc.Size < 0 {
+		return nil, contentpkg.ErrInvalidDescriptorSize
+	}
+	buf := make([]byte, desc.Size)
+	if n, err := io.ReadFull(r, buf); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil, fmt.Errorf("read failed: expected content size of %d, got %d, for digest %s: %w", desc.Size, n, desc.Digest.String(), io.ErrUnexpectedEOF)
+		}
+		return nil, fmt.Errorf("read failed: %w", err)
+	}
+	var p [1]byte
+	if _, err := io.ReadFull(r, p[:]); err == nil {
+		return nil, contentpkg.ErrTrailingData
+	} else if err != io.EOF {
+		return nil, err
+	}
+	return buf, nil
+}
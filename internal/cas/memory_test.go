@@ -26,6 +26,7 @@ import (
 
 	"github.com/opencontainers/go-digest"
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	contentpkg "oras.land/oras-go/v2/content"
 	"oras.land/oras-go/v2/errdef"
 )
 
@@ -73,6 +74,35 @@ func TestMemorySuccess(t *testing.T) {
 	}
 }
 
+func TestMemoryDelete(t *testing.T) {
+	content := []byte("hello world")
+	desc := ocispec.Descriptor{
+		MediaType: "test",
+		Digest:    digest.FromBytes(content),
+		Size:      int64(len(content)),
+	}
+
+	s := NewMemory()
+	ctx := context.Background()
+
+	if err := s.Push(ctx, desc, bytes.NewReader(content)); err != nil {
+		t.Fatal("Memory.Push() error =", err)
+	}
+	if err := s.Delete(ctx, desc); err != nil {
+		t.Fatal("Memory.Delete() error =", err)
+	}
+	exists, err := s.Exists(ctx, desc)
+	if err != nil {
+		t.Fatal("Memory.Exists() error =", err)
+	}
+	if exists {
+		t.Errorf("Memory.Exists() = %v, want %v", exists, false)
+	}
+	if err := s.Delete(ctx, desc); !errors.Is(err, errdef.ErrNotFound) {
+		t.Errorf("Memory.Delete() error = %v, want %v", err, errdef.ErrNotFound)
+	}
+}
+
 func TestMemoryNotFound(t *testing.T) {
 	content := []byte("hello world")
 	desc := ocispec.Descriptor{
@@ -136,3 +166,50 @@ func TestMemoryBadPush(t *testing.T) {
 		t.Errorf("Memory.Push() error = %v, wantErr %v", err, true)
 	}
 }
+
+func TestMemoryPushRaw(t *testing.T) {
+	content := []byte("hello world")
+	desc := ocispec.Descriptor{
+		MediaType: "test",
+		Digest:    digest.FromBytes(content),
+		Size:      int64(len(content)),
+	}
+	raw := []byte("HELLO WORLD")
+
+	s := NewMemory()
+	ctx := context.Background()
+
+	// the raw content is stored by the digest of the content
+	if err := s.PushRaw(ctx, desc, bytes.NewReader(raw)); err != nil {
+		t.Fatal("Memory.PushRaw() error =", err)
+	}
+	rc, err := s.Fetch(ctx, desc)
+	if err != nil {
+		t.Fatal("Memory.Fetch() error =", err)
+	}
+	got, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatal("Memory.Fetch().Read() error =", err)
+	}
+	if err := rc.Close(); err != nil {
+		t.Error("Memory.Fetch().Close() error =", err)
+	}
+	if !bytes.Equal(got, raw) {
+		t.Errorf("Memory.Fetch() = %v, want %v", got, raw)
+	}
+	if err := s.PushRaw(ctx, desc, bytes.NewReader(raw)); !errors.Is(err, errdef.ErrAlreadyExists) {
+		t.Errorf("Memory.PushRaw() error = %v, want %v", err, errdef.ErrAlreadyExists)
+	}
+
+	// the size is still verified
+	s = NewMemory()
+	if err := s.PushRaw(ctx, desc, strings.NewReader("hello")); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("Memory.PushRaw() error = %v, want %v", err, io.ErrUnexpectedEOF)
+	}
+	if err := s.PushRaw(ctx, desc, strings.NewReader("hello world!")); !errors.Is(err, contentpkg.ErrTrailingData) {
+		t.Errorf("Memory.PushRaw() error = %v, want %v", err, contentpkg.ErrTrailingData)
+	}
+	if exists, err := s.Exists(ctx, desc); err != nil || exists {
+		t.Errorf("Memory.Exists() = %v, %v, want %v", exists, err, false)
+	}
+}
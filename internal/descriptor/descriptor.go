@@ -64,6 +64,24 @@ func IsForeignLayer(desc ocispec.Descriptor) bool {
 	}
 }
 
+// DistributableMediaType returns the distributable equivalent of the media
+// type of a foreign layer. It returns false if the media type is not of a
+// foreign layer.
+func DistributableMediaType(mediaType string) (string, bool) {
+	switch mediaType {
+	case ocispec.MediaTypeImageLayerNonDistributable:
+		return ocispec.MediaTypeImageLayer, true
+	case ocispec.MediaTypeImageLayerNonDistributableGzip:
+		return ocispec.MediaTypeImageLayerGzip, true
+	case ocispec.MediaTypeImageLayerNonDistributableZstd:
+		return ocispec.MediaTypeImageLayerZstd, true
+	case docker.MediaTypeForeignLayer:
+		return docker.MediaTypeLayer, true
+	default:
+		return "", false
+	}
+}
+
 // IsManifest checks if a descriptor describes a manifest.
 func IsManifest(desc ocispec.Descriptor) bool {
 	switch desc.MediaType {
@@ -78,6 +96,16 @@ func IsManifest(desc ocispec.Descriptor) bool {
 	}
 }
 
+// IsIndex checks if a descriptor describes an index.
+func IsIndex(desc ocispec.Descriptor) bool {
+	switch desc.MediaType {
+	case docker.MediaTypeManifestList, ocispec.MediaTypeImageIndex:
+		return true
+	default:
+		return false
+	}
+}
+
 // Plain returns a plain descriptor that contains only MediaType, Digest and
 // Size.
 func Plain(desc ocispec.Descriptor) ocispec.Descriptor {
@@ -20,5 +20,6 @@ const (
 	MediaTypeConfig       = "application/vnd.docker.container.image.v1+json"
 	MediaTypeManifestList = "application/vnd.docker.distribution.manifest.list.v2+json"
 	MediaTypeManifest     = "application/vnd.docker.distribution.manifest.v2+json"
+	MediaTypeLayer        = "application/vnd.docker.image.rootfs.diff.tar.gzip"
 	MediaTypeForeignLayer = "application/vnd.docker.image.rootfs.foreign.diff.tar.gzip"
 )
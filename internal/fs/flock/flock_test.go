@@ -0,0 +1,60 @@
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flock
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAcquire(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.lock")
+	lock, err := Acquire(path)
+	if err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+
+	// the second lock blocks until the first lock is released
+	acquired := make(chan *Lock)
+	go func() {
+		lock, err := Acquire(path)
+		if err != nil {
+			t.Errorf("Acquire() error = %v", err)
+		}
+		acquired <- lock
+	}()
+	select {
+	case <-acquired:
+		t.Fatal("Acquire() returned while the lock is held")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	if err := lock.Release(); err != nil {
+		t.Fatalf("Lock.Release() error = %v", err)
+	}
+	select {
+	case lock := <-acquired:
+		if lock == nil {
+			return
+		}
+		if err := lock.Release(); err != nil {
+			t.Fatalf("Lock.Release() error = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Acquire() did not return after the lock is released")
+	}
+}
@@ -0,0 +1,54 @@
+//go:build windows
+
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flock
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	modkernel32      = syscall.NewLazyDLL("kernel32.dll")
+	procLockFileEx   = modkernel32.NewProc("LockFileEx")
+	procUnlockFileEx = modkernel32.NewProc("UnlockFileEx")
+)
+
+// lockfileExclusiveLock is the LOCKFILE_EXCLUSIVE_LOCK flag of LockFileEx.
+// Reference: https://learn.microsoft.com/windows/win32/api/fileapi/nf-fileapi-lockfileex
+const lockfileExclusiveLock = 0x00000002
+
+// lock acquires an exclusive lock on the first byte of the file.
+func lock(file *os.File) error {
+	var overlapped syscall.Overlapped
+	r1, _, err := procLockFileEx.Call(file.Fd(), lockfileExclusiveLock, 0, 1, 0, uintptr(unsafe.Pointer(&overlapped)))
+	if r1 == 0 {
+		return err
+	}
+	return nil
+}
+
+// unlock releases the lock on the first byte of the file.
+func unlock(file *os.File) error {
+	var overlapped syscall.Overlapped
+	r1, _, err := procUnlockFileEx.Call(file.Fd(), 0, 1, 0, uintptr(unsafe.Pointer(&overlapped)))
+	if r1 == 0 {
+		return err
+	}
+	return nil
+}
@@ -24,6 +24,9 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"slices"
+	"strings"
+	"time"
 
 	"oras.land/oras-go/v2/errdef"
 )
@@ -106,6 +109,51 @@ func (tfs *TarFS) Stat(name string) (fs.FileInfo, error) {
 	return entry.header.FileInfo(), nil
 }
 
+// ReadDir reads the named directory and returns a list of directory entries
+// sorted by filename. Directories without their own entries in the tar archive
+// are implied by the paths of the files in them.
+func (tfs *TarFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrInvalid}
+	}
+	prefix := ""
+	if name != "." {
+		prefix = name + "/"
+	}
+	children := make(map[string]fs.DirEntry)
+	for entryPath, entry := range tfs.entries {
+		rel, ok := strings.CutPrefix(entryPath, prefix)
+		if !ok || rel == "" || rel == "." {
+			continue
+		}
+		if child, _, nested := strings.Cut(rel, "/"); nested {
+			if _, exists := children[child]; !exists {
+				children[child] = fs.FileInfoToDirEntry(dirInfo(child))
+			}
+			continue
+		}
+		children[rel] = fs.FileInfoToDirEntry(entry.header.FileInfo())
+	}
+	if len(children) == 0 && name != "." {
+		entry, ok := tfs.entries[name]
+		if !ok {
+			return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+		}
+		if entry.header.Typeflag != tar.TypeDir {
+			return nil, &fs.PathError{Op: "readdir", Path: name, Err: errors.New("not a directory")}
+		}
+	}
+
+	entries := make([]fs.DirEntry, 0, len(children))
+	for _, child := range children {
+		entries = append(entries, child)
+	}
+	slices.SortFunc(entries, func(a, b fs.DirEntry) int {
+		return strings.Compare(a.Name(), b.Name())
+	})
+	return entries, nil
+}
+
 // getEntry returns the named entry.
 func (tfs *TarFS) getEntry(operation string, path string) (*entry, error) {
 	if !fs.ValidPath(path) {
@@ -165,3 +213,14 @@ type entryFile struct {
 func (e *entryFile) Stat() (fs.FileInfo, error) {
 	return e.header.FileInfo(), nil
 }
+
+// dirInfo describes a directory implied by the paths of the entries in a tar
+// archive, and implements `fs.FileInfo`.
+type dirInfo string
+
+func (d dirInfo) Name() string       { return string(d) }
+func (d dirInfo) Size() int64        { return 0 }
+func (d dirInfo) Mode() fs.FileMode  { return fs.ModeDir | 0555 }
+func (d dirInfo) ModTime() time.Time { return time.Time{} }
+func (d dirInfo) IsDir() bool        { return true }
+func (d dirInfo) Sys() any           { return nil }
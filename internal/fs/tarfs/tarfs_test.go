@@ -21,6 +21,7 @@ import (
 	"io"
 	"io/fs"
 	"path/filepath"
+	"reflect"
 	"testing"
 
 	"oras.land/oras-go/v2/errdef"
@@ -406,6 +407,63 @@ func TestTarFS_Stat_Unsupported(t *testing.T) {
 	}
 }
 
+func TestTarFS_ReadDir(t *testing.T) {
+	tests := map[string][]string{
+		".":          {"dir", "foobar", "foobar_link", "foobar_symlink"},
+		"dir":        {"hello", "subdir"},
+		"dir/subdir": {"world"},
+	}
+	tarPaths := []string{
+		"testdata/cleaned_path.tar",
+		"testdata/prefixed_path.tar",
+	}
+
+	for _, tarPath := range tarPaths {
+		t.Run(tarPath, func(t *testing.T) {
+			tfs, err := New(tarPath)
+			if err != nil {
+				t.Fatalf("New() error = %v, wantErr %v", err, nil)
+			}
+			for name, want := range tests {
+				t.Run(name, func(t *testing.T) {
+					entries, err := tfs.ReadDir(name)
+					if err != nil {
+						t.Fatalf("TarFS.ReadDir(%s) error = %v, wantErr %v", name, err, nil)
+					}
+					var got []string
+					for _, entry := range entries {
+						got = append(got, entry.Name())
+					}
+					if !reflect.DeepEqual(got, want) {
+						t.Errorf("TarFS.ReadDir(%s) = %v, want %v", name, got, want)
+					}
+				})
+			}
+
+		})
+	}
+}
+
+func TestTarFS_ReadDir_Error(t *testing.T) {
+	tarPath := "testdata/cleaned_path.tar"
+	tfs, err := New(tarPath)
+	if err != nil {
+		t.Fatalf("New() error = %v, wantErr %v", err, nil)
+	}
+	tests := map[string]error{
+		"nonexistence": fs.ErrNotExist,
+		"../dir":       fs.ErrInvalid,
+	}
+	for name, wantErr := range tests {
+		if _, err := tfs.ReadDir(name); !errors.Is(err, wantErr) {
+			t.Errorf("TarFS.ReadDir(%s) error = %v, wantErr %v", name, err, wantErr)
+		}
+	}
+	if _, err := tfs.ReadDir("foobar"); err == nil {
+		t.Errorf("TarFS.ReadDir(foobar) error = %v, wantErr %v", err, true)
+	}
+}
+
 func TestTarFs_New_Error(t *testing.T) {
 	t.Run("not existing path", func(t *testing.T) {
 		_, err := New("testdata/ghost.tar")
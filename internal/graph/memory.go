@@ -30,6 +30,15 @@ import (
 	"oras.land/oras-go/v2/internal/syncutil"
 )
 
+// Node is a node of the graph with its direct successors.
+type Node struct {
+	// Descriptor is the descriptor of the node.
+	Descriptor ocispec.Descriptor `json:"descriptor"`
+
+	// Successors are the direct successors of the node.
+	Successors []descriptor.Descriptor `json:"successors,omitempty"`
+}
+
 // Memory is a memory based PredecessorFinder.
 type Memory struct {
 	// nodes has the following properties and behaviors:
@@ -73,6 +82,14 @@ func (m *Memory) Index(ctx context.Context, fetcher content.Fetcher, node ocispe
 
 // Index indexes predecessors for all the successors of the given node.
 func (m *Memory) IndexAll(ctx context.Context, fetcher content.Fetcher, node ocispec.Descriptor) error {
+	return m.IndexAllSkip(ctx, fetcher, node, nil)
+}
+
+// IndexAllSkip indexes predecessors for all the successors of the given node
+// as IndexAll does. If a node fails to be indexed, skip is called with the
+// node and the error: the node is skipped if skip returns true, and the
+// indexing fails otherwise. Nodes not found are always skipped.
+func (m *Memory) IndexAllSkip(ctx context.Context, fetcher content.Fetcher, node ocispec.Descriptor, skip func(ocispec.Descriptor, error) bool) error {
 	// track content status
 	tracker := status.NewTracker()
 	var fn syncutil.GoFunc[ocispec.Descriptor]
@@ -88,6 +105,9 @@ func (m *Memory) IndexAll(ctx context.Context, fetcher content.Fetcher, node oci
 				// skip the node if it does not exist
 				return nil
 			}
+			if skip != nil && skip(desc, err) {
+				return nil
+			}
 			return err
 		}
 		if len(successors) > 0 {
@@ -166,9 +186,30 @@ func (m *Memory) index(ctx context.Context, fetcher content.Fetcher, node ocispe
 	if err != nil {
 		return nil, err
 	}
+	successorKeys := make([]descriptor.Descriptor, len(successors))
+	for i, successor := range successors {
+		successorKeys[i] = descriptor.FromOCI(successor)
+	}
+
 	m.lock.Lock()
 	defer m.lock.Unlock()
 
+	m.add(node, successorKeys)
+	return successors, nil
+}
+
+// Add indexes the node with its known direct successors without fetching
+// the node.
+func (m *Memory) Add(node ocispec.Descriptor, successors []descriptor.Descriptor) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.add(node, successors)
+}
+
+// add indexes the node with its direct successors. The caller must hold
+// m.lock.
+func (m *Memory) add(node ocispec.Descriptor, successors []descriptor.Descriptor) {
 	// index the node
 	nodeKey := descriptor.FromOCI(node)
 	m.nodes[nodeKey] = node
@@ -177,8 +218,7 @@ func (m *Memory) index(ctx context.Context, fetcher content.Fetcher, node ocispe
 	// put node into the succeesor's predecessors list
 	successorSet := set.New[descriptor.Descriptor]()
 	m.successors[nodeKey] = successorSet
-	for _, successor := range successors {
-		successorKey := descriptor.FromOCI(successor)
+	for _, successorKey := range successors {
 		successorSet.Add(successorKey)
 		predecessorSet, exists := m.predecessors[successorKey]
 		if !exists {
@@ -187,7 +227,22 @@ func (m *Memory) index(ctx context.Context, fetcher content.Fetcher, node ocispe
 		}
 		predecessorSet.Add(nodeKey)
 	}
-	return successors, nil
+}
+
+// Nodes returns the nodes in the memory with their direct successors.
+func (m *Memory) Nodes() []Node {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	nodes := make([]Node, 0, len(m.nodes))
+	for key, desc := range m.nodes {
+		node := Node{Descriptor: desc}
+		for successorKey := range m.successors[key] {
+			node.Successors = append(node.Successors, successorKey)
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes
 }
 
 // Exists checks if the node exists in the graph
@@ -765,3 +765,123 @@ func TestMemory_Exists(t *testing.T) {
 		}
 	}
 }
+
+func TestMemory_NodesAndAdd(t *testing.T) {
+	testFetcher := cas.NewMemory()
+	ctx := context.Background()
+
+	push := func(mediaType string, blob []byte) ocispec.Descriptor {
+		desc := ocispec.Descriptor{
+			MediaType: mediaType,
+			Digest:    digest.FromBytes(blob),
+			Size:      int64(len(blob)),
+		}
+		if err := testFetcher.Push(ctx, desc, bytes.NewReader(blob)); err != nil {
+			t.Fatal(err)
+		}
+		return desc
+	}
+	descB := push("layer node B", []byte("Node B is a layer"))
+	descC := push("layer node C", []byte("Node C is a layer"))
+	manifestJSON, err := json.Marshal(ocispec.Manifest{
+		Config: descB,
+		Layers: []ocispec.Descriptor{descC},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	descA := push(ocispec.MediaTypeImageManifest, manifestJSON)
+
+	indexed := NewMemory()
+	if err := indexed.IndexAll(ctx, testFetcher, descA); err != nil {
+		t.Fatalf("Memory.IndexAll() error = %v", err)
+	}
+	nodes := indexed.Nodes()
+	if got, want := len(nodes), 3; got != want {
+		t.Fatalf("len(Memory.Nodes()) = %v, want %v", got, want)
+	}
+
+	// rebuild the graph from the nodes without fetching
+	added := NewMemory()
+	for _, node := range nodes {
+		added.Add(node.Descriptor, node.Successors)
+	}
+	if !reflect.DeepEqual(added.nodes, indexed.nodes) {
+		t.Errorf("Memory.nodes = %v, want %v", added.nodes, indexed.nodes)
+	}
+	if !reflect.DeepEqual(added.successors, indexed.successors) {
+		t.Errorf("Memory.successors = %v, want %v", added.successors, indexed.successors)
+	}
+	if !reflect.DeepEqual(added.predecessors, indexed.predecessors) {
+		t.Errorf("Memory.predecessors = %v, want %v", added.predecessors, indexed.predecessors)
+	}
+	for _, desc := range []ocispec.Descriptor{descB, descC} {
+		got, err := added.Predecessors(ctx, desc)
+		if err != nil {
+			t.Fatalf("Memory.Predecessors() error = %v", err)
+		}
+		if want := []ocispec.Descriptor{descA}; !reflect.DeepEqual(got, want) {
+			t.Errorf("Memory.Predecessors() = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestMemory_IndexAllSkip(t *testing.T) {
+	testFetcher := cas.NewMemory()
+	ctx := context.Background()
+
+	push := func(mediaType string, blob []byte) ocispec.Descriptor {
+		desc := ocispec.Descriptor{
+			MediaType: mediaType,
+			Digest:    digest.FromBytes(blob),
+			Size:      int64(len(blob)),
+		}
+		if err := testFetcher.Push(ctx, desc, bytes.NewReader(blob)); err != nil {
+			t.Fatal(err)
+		}
+		return desc
+	}
+	descB := push(ocispec.MediaTypeImageManifest, []byte("{"))
+	descC := push("layer node C", []byte("Node C is a layer"))
+	indexJSON, err := json.Marshal(ocispec.Index{
+		Manifests: []ocispec.Descriptor{descB, descC},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	descA := push(ocispec.MediaTypeImageIndex, indexJSON)
+
+	// the malformed node fails the indexing by default
+	if err := NewMemory().IndexAll(ctx, testFetcher, descA); err == nil {
+		t.Fatal("Memory.IndexAll() error = nil, wantErr true")
+	}
+
+	// the malformed node is skipped
+	testMemory := NewMemory()
+	var skipped []ocispec.Descriptor
+	skip := func(desc ocispec.Descriptor, err error) bool {
+		skipped = append(skipped, desc)
+		return true
+	}
+	if err := testMemory.IndexAllSkip(ctx, testFetcher, descA, skip); err != nil {
+		t.Fatalf("Memory.IndexAllSkip() error = %v", err)
+	}
+	if want := []ocispec.Descriptor{descB}; !reflect.DeepEqual(skipped, want) {
+		t.Errorf("skipped nodes = %v, want %v", skipped, want)
+	}
+	for _, desc := range []ocispec.Descriptor{descA, descC} {
+		if !testMemory.Exists(desc) {
+			t.Errorf("Memory.Exists(%v) = false, want true", desc.Digest)
+		}
+	}
+	if testMemory.Exists(descB) {
+		t.Errorf("Memory.Exists(%v) = true, want false", descB.Digest)
+	}
+	got, err := testMemory.Predecessors(ctx, descB)
+	if err != nil {
+		t.Fatalf("Memory.Predecessors() error = %v", err)
+	}
+	if want := []ocispec.Descriptor{descA}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Memory.Predecessors() = %v, want %v", got, want)
+	}
+}
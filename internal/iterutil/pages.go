@@ -0,0 +1,56 @@
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package iterutil
+
+import (
+	"context"
+	"errors"
+	"iter"
+)
+
+// errStop is returned by the page callback to stop the pagination when the
+// consumer of the iterator breaks early.
+var errStop = errors.New("iteration stopped")
+
+// Pages returns an iterator over the items of a paginated list, where list
+// calls fn for each page of the list.
+//
+// Pages are requested lazily: the next page is not requested until all the
+// items of the current page are consumed. If the consumer breaks early, no
+// further pages are requested. If ctx is done, the iteration stops with the
+// error of ctx.
+//
+// Any error returned by list is yielded as the last element of the iterator
+// with the zero value of T.
+func Pages[T any](ctx context.Context, list func(fn func(page []T) error) error) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		err := list(func(page []T) error {
+			for _, item := range page {
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+				if !yield(item, nil) {
+					return errStop
+				}
+			}
+			return nil
+		})
+		if err != nil && !errors.Is(err, errStop) {
+			var zero T
+			yield(zero, err)
+		}
+	}
+}
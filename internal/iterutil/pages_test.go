@@ -0,0 +1,132 @@
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package iterutil
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestPages(t *testing.T) {
+	pages := [][]int{{1, 2}, {3}, {}, {4, 5}}
+	var requested int
+	list := func(fn func(page []int) error) error {
+		for _, page := range pages {
+			requested++
+			if err := fn(page); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	var got []int
+	for v, err := range Pages(context.Background(), list) {
+		if err != nil {
+			t.Fatalf("Pages() error = %v, wantErr false", err)
+		}
+		got = append(got, v)
+	}
+	if want := []int{1, 2, 3, 4, 5}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Pages() = %v, want %v", got, want)
+	}
+	if requested != len(pages) {
+		t.Errorf("requested pages = %d, want %d", requested, len(pages))
+	}
+}
+
+func TestPages_Break(t *testing.T) {
+	var requested int
+	list := func(fn func(page []int) error) error {
+		for i := 0; i < 3; i++ {
+			requested++
+			if err := fn([]int{2 * i, 2*i + 1}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	var got []int
+	for v, err := range Pages(context.Background(), list) {
+		if err != nil {
+			t.Fatalf("Pages() error = %v, wantErr false", err)
+		}
+		got = append(got, v)
+		if v == 2 {
+			break
+		}
+	}
+	if want := []int{0, 1, 2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Pages() = %v, want %v", got, want)
+	}
+	if want := 2; requested != want {
+		t.Errorf("requested pages = %d, want %d", requested, want)
+	}
+}
+
+func TestPages_Error(t *testing.T) {
+	errList := errors.New("list error")
+	list := func(fn func(page []int) error) error {
+		if err := fn([]int{1}); err != nil {
+			return err
+		}
+		return errList
+	}
+
+	var got []int
+	var gotErr error
+	for v, err := range Pages(context.Background(), list) {
+		if err != nil {
+			gotErr = err
+			continue
+		}
+		got = append(got, v)
+	}
+	if want := []int{1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Pages() = %v, want %v", got, want)
+	}
+	if !errors.Is(gotErr, errList) {
+		t.Errorf("Pages() error = %v, wantErr %v", gotErr, errList)
+	}
+}
+
+func TestPages_ContextCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	list := func(fn func(page []int) error) error {
+		return fn([]int{1, 2, 3})
+	}
+
+	var got []int
+	var gotErr error
+	for v, err := range Pages(ctx, list) {
+		if err != nil {
+			gotErr = err
+			break
+		}
+		got = append(got, v)
+		cancel()
+	}
+	if want := []int{1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Pages() = %v, want %v", got, want)
+	}
+	if !errors.Is(gotErr, context.Canceled) {
+		t.Errorf("Pages() error = %v, wantErr %v", gotErr, context.Canceled)
+	}
+}
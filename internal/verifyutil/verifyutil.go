@@ -0,0 +1,54 @@
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package verifyutil
+
+import "io"
+
+// Verifier reads content and verifies it once read, such as
+// *content.VerifyReader.
+type Verifier interface {
+	io.Reader
+	// Verify verifies the content read.
+	Verify() error
+}
+
+// readCloser verifies the content on reaching its end.
+type readCloser struct {
+	Verifier
+	io.Closer
+}
+
+// NewReadCloser returns an io.ReadCloser reading from v and closing c, which
+// returns the verification error of v instead of io.EOF on reaching the end
+// of the content.
+func NewReadCloser(v Verifier, c io.Closer) io.ReadCloser {
+	return &readCloser{
+		Verifier: v,
+		Closer:   c,
+	}
+}
+
+// Read reads up to len(p) bytes into p. On reaching the end of the content,
+// it returns the verification error if any, or io.EOF otherwise.
+func (rc *readCloser) Read(p []byte) (int, error) {
+	n, err := rc.Verifier.Read(p)
+	if err == io.EOF {
+		if verr := rc.Verify(); verr != nil {
+			return n, verr
+		}
+	}
+	return n, err
+}
@@ -0,0 +1,69 @@
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package verifyutil
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+// testVerifier verifies that the content read equals to want.
+type testVerifier struct {
+	io.Reader
+	read bytes.Buffer
+	want string
+}
+
+func (v *testVerifier) Read(p []byte) (int, error) {
+	n, err := v.Reader.Read(p)
+	v.read.Write(p[:n])
+	return n, err
+}
+
+func (v *testVerifier) Verify() error {
+	if v.read.String() != v.want {
+		return errMismatched
+	}
+	return nil
+}
+
+var errMismatched = errors.New("mismatched content")
+
+func TestReadCloser(t *testing.T) {
+	data := "hello world"
+
+	// valid content
+	rc := NewReadCloser(&testVerifier{Reader: strings.NewReader(data), want: data}, io.NopCloser(nil))
+	got, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatal("ReadCloser.Read() error =", err)
+	}
+	if string(got) != data {
+		t.Errorf("ReadCloser.Read() = %s, want %s", got, data)
+	}
+	if err := rc.Close(); err != nil {
+		t.Error("ReadCloser.Close() error =", err)
+	}
+
+	// mismatched content
+	rc = NewReadCloser(&testVerifier{Reader: strings.NewReader(data), want: "foo"}, io.NopCloser(nil))
+	if _, err := io.ReadAll(rc); !errors.Is(err, errMismatched) {
+		t.Errorf("ReadCloser.Read() error = %v, want %v", err, errMismatched)
+	}
+}
@@ -0,0 +1,225 @@
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oras
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/errdef"
+	"oras.land/oras-go/v2/internal/syncutil"
+	"oras.land/oras-go/v2/registry"
+)
+
+// defaultLockConcurrency is the default value of LockOptions.Concurrency.
+const defaultLockConcurrency = 5
+
+// Lockfile pins references to the digests of their content.
+type Lockfile struct {
+	// Entries are the locked references.
+	Entries []LockEntry `json:"entries"`
+}
+
+// LockEntry is a reference locked to the descriptor resolved from it.
+type LockEntry struct {
+	// Reference is the reference as given to [Lock], such as
+	// "registry.example.com/hello:v1".
+	Reference string `json:"reference"`
+
+	// Digest is the digest of the resolved content.
+	Digest digest.Digest `json:"digest"`
+
+	// Size is the size of the resolved content.
+	Size int64 `json:"size"`
+
+	// MediaType is the media type of the resolved content.
+	MediaType string `json:"mediaType"`
+
+	// Platform is the platform that the reference is resolved for.
+	// It is nil if the reference is resolved without platform selection.
+	Platform *ocispec.Platform `json:"platform,omitempty"`
+}
+
+// Descriptor returns the descriptor of the locked content.
+func (e LockEntry) Descriptor() ocispec.Descriptor {
+	return ocispec.Descriptor{
+		MediaType: e.MediaType,
+		Digest:    e.Digest,
+		Size:      e.Size,
+		Platform:  e.Platform,
+	}
+}
+
+// LockTargetFunc returns the target hosting the repository of the given
+// reference. For example, it may return a [remote.Repository] configured with
+// credentials for the registry of the reference.
+//
+// [remote.Repository]: https://pkg.go.dev/oras.land/oras-go/v2/registry/remote#Repository
+type LockTargetFunc func(ctx context.Context, ref registry.Reference) (ReadOnlyTarget, error)
+
+// DefaultLockOptions provides the default LockOptions.
+var DefaultLockOptions LockOptions
+
+// LockOptions contains parameters for [oras.Lock] and [oras.VerifyLock].
+type LockOptions struct {
+	// Platforms are the platforms to resolve each reference for. The
+	// references are resolved once per platform as [oras.Resolve] does with
+	// ResolveOptions.TargetPlatform.
+	// If empty, the references are resolved without platform selection.
+	Platforms []*ocispec.Platform
+
+	// Concurrency limits the maximum number of concurrent resolutions.
+	// If less than or equal to 0, a default (currently 5) is used.
+	Concurrency int
+
+	// MaxMetadataBytes limits the maximum size of metadata that can be cached
+	// in the memory for platform selection.
+	// If less than or equal to 0, a default (currently 4 MiB) is used.
+	MaxMetadataBytes int64
+}
+
+// Lock resolves the given references concurrently and returns a lockfile
+// pinning them to the digests of their content. The references are fully
+// qualified, and may be hosted by different registries. The target hosting
+// each reference is obtained by targetFunc.
+//
+// The entries of the lockfile are in the order of the references, and, for
+// each reference, in the order of opts.Platforms.
+// If any reference fails to resolve, an error is returned without a lockfile.
+func Lock(ctx context.Context, references []string, targetFunc LockTargetFunc, opts LockOptions) (*Lockfile, error) {
+	platforms := opts.Platforms
+	if len(platforms) == 0 {
+		platforms = []*ocispec.Platform{nil}
+	}
+	entries := make([]LockEntry, len(references)*len(platforms))
+	for i, reference := range references {
+		for j, p := range platforms {
+			entries[i*len(platforms)+j] = LockEntry{
+				Reference: reference,
+				Platform:  p,
+			}
+		}
+	}
+
+	descs, err := resolveLockEntries(ctx, entries, targetFunc, false, opts)
+	if err != nil {
+		return nil, err
+	}
+	for i, desc := range descs {
+		entries[i].Digest = desc.Digest
+		entries[i].Size = desc.Size
+		entries[i].MediaType = desc.MediaType
+	}
+	return &Lockfile{Entries: entries}, nil
+}
+
+// LockDrift describes a locked reference that no longer matches its target.
+type LockDrift struct {
+	// Entry is the locked entry.
+	Entry LockEntry
+
+	// Current is the descriptor currently resolved from the reference.
+	// It is empty if the reference is not found.
+	Current ocispec.Descriptor
+
+	// NotFound indicates that the reference is not found.
+	NotFound bool
+}
+
+// VerifyLock resolves the references in the lockfile concurrently, and
+// returns the drifts of the entries whose resolved digests, sizes or media
+// types differ from the locked ones, in the order of the entries. The
+// references not found are reported as drifts as well.
+// opts.Platforms is ignored since each entry is resolved for its locked
+// platform.
+//
+// An empty result indicates that the lockfile matches the targets. An error is
+// returned if any reference cannot be resolved for reasons other than not
+// being found.
+func VerifyLock(ctx context.Context, lockfile *Lockfile, targetFunc LockTargetFunc, opts LockOptions) ([]LockDrift, error) {
+	descs, err := resolveLockEntries(ctx, lockfile.Entries, targetFunc, true, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var drifts []LockDrift
+	for i, entry := range lockfile.Entries {
+		desc := descs[i]
+		switch {
+		case desc.Digest == "":
+			drifts = append(drifts, LockDrift{
+				Entry:    entry,
+				NotFound: true,
+			})
+		case desc.Digest != entry.Digest || desc.Size != entry.Size || desc.MediaType != entry.MediaType:
+			drifts = append(drifts, LockDrift{
+				Entry:   entry,
+				Current: desc,
+			})
+		}
+	}
+	return drifts, nil
+}
+
+// resolveLockEntries resolves the references of the entries concurrently for
+// their platforms. If allowNotFound is true, a reference not found is resolved
+// to an empty descriptor.
+func resolveLockEntries(ctx context.Context, entries []LockEntry, targetFunc LockTargetFunc, allowNotFound bool, opts LockOptions) ([]ocispec.Descriptor, error) {
+	if targetFunc == nil {
+		return nil, errors.New("nil target function")
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = defaultLockConcurrency
+	}
+
+	descs := make([]ocispec.Descriptor, len(entries))
+	eg, egCtx := syncutil.LimitGroup(ctx, opts.Concurrency)
+	for i, entry := range entries {
+		eg.Go(func() error {
+			ref, err := registry.ParseReference(entry.Reference)
+			if err != nil {
+				return err
+			}
+			if ref.Reference == "" {
+				return fmt.Errorf("%s: %w", entry.Reference, errdef.ErrMissingReference)
+			}
+			target, err := targetFunc(egCtx, ref)
+			if err != nil {
+				return fmt.Errorf("failed to get target of %s: %w", entry.Reference, err)
+			}
+			desc, err := Resolve(egCtx, target, ref.Reference, ResolveOptions{
+				TargetPlatform:   entry.Platform,
+				MaxMetadataBytes: opts.MaxMetadataBytes,
+			})
+			if err != nil {
+				if allowNotFound && errors.Is(err, errdef.ErrNotFound) {
+					// the locked reference is gone
+					return nil
+				}
+				return fmt.Errorf("failed to resolve %s: %w", entry.Reference, err)
+			}
+			descs[i] = desc
+			return nil
+		})
+	}
+	if err := eg.Wait(); err != nil {
+		return nil, err
+	}
+	return descs, nil
+}
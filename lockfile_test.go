@@ -0,0 +1,275 @@
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oras_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+
+	specs "github.com/opencontainers/image-spec/specs-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/content/memory"
+	"oras.land/oras-go/v2/errdef"
+	"oras.land/oras-go/v2/registry"
+)
+
+// testLockTargets hosts repositories in memory stores keyed by
+// "registry/repository".
+type testLockTargets struct {
+	lock    sync.Mutex
+	targets map[string]oras.Target
+}
+
+func (tt *testLockTargets) target(ref registry.Reference) oras.Target {
+	tt.lock.Lock()
+	defer tt.lock.Unlock()
+
+	key := ref.Registry + "/" + ref.Repository
+	if tt.targets == nil {
+		tt.targets = make(map[string]oras.Target)
+	}
+	target, ok := tt.targets[key]
+	if !ok {
+		target = memory.New()
+		tt.targets[key] = target
+	}
+	return target
+}
+
+func (tt *testLockTargets) targetFunc(_ context.Context, ref registry.Reference) (oras.ReadOnlyTarget, error) {
+	return tt.target(ref), nil
+}
+
+// pushTagged pushes the content to the repository of the reference, and tags
+// it with the reference.
+func (tt *testLockTargets) pushTagged(t *testing.T, reference string, mediaType string, blob []byte) ocispec.Descriptor {
+	t.Helper()
+	ref, err := registry.ParseReference(reference)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	target := tt.target(ref)
+	desc := content.NewDescriptorFromBytes(mediaType, blob)
+	if err := target.Push(ctx, desc, bytes.NewReader(blob)); err != nil && !errors.Is(err, errdef.ErrAlreadyExists) {
+		t.Fatal(err)
+	}
+	if err := target.Tag(ctx, desc, ref.Reference); err != nil {
+		t.Fatal(err)
+	}
+	return desc
+}
+
+// pushPlatformIndex pushes an index with a manifest per platform to the
+// repository of the reference, and tags the index with the reference.
+func (tt *testLockTargets) pushPlatformIndex(t *testing.T, reference string, platforms ...ocispec.Platform) (ocispec.Descriptor, []ocispec.Descriptor) {
+	t.Helper()
+	ref, err := registry.ParseReference(reference)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	target := tt.target(ref)
+	var manifests []ocispec.Descriptor
+	for _, p := range platforms {
+		config := []byte(`{"architecture":"` + p.Architecture + `","os":"` + p.OS + `"}`)
+		configDesc := content.NewDescriptorFromBytes(ocispec.MediaTypeImageConfig, config)
+		if err := target.Push(ctx, configDesc, bytes.NewReader(config)); err != nil && !errors.Is(err, errdef.ErrAlreadyExists) {
+			t.Fatal(err)
+		}
+		manifestJSON, err := json.Marshal(ocispec.Manifest{
+			Versioned: specs.Versioned{SchemaVersion: 2},
+			MediaType: ocispec.MediaTypeImageManifest,
+			Config:    configDesc,
+			Layers:    []ocispec.Descriptor{},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		manifestDesc := content.NewDescriptorFromBytes(ocispec.MediaTypeImageManifest, manifestJSON)
+		if err := target.Push(ctx, manifestDesc, bytes.NewReader(manifestJSON)); err != nil && !errors.Is(err, errdef.ErrAlreadyExists) {
+			t.Fatal(err)
+		}
+		manifestDesc.Platform = &p
+		manifests = append(manifests, manifestDesc)
+	}
+	indexJSON, err := json.Marshal(ocispec.Index{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+		MediaType: ocispec.MediaTypeImageIndex,
+		Manifests: manifests,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tt.pushTagged(t, reference, ocispec.MediaTypeImageIndex, indexJSON), manifests
+}
+
+func TestLock(t *testing.T) {
+	var targets testLockTargets
+	foo := targets.pushTagged(t, "registry.example.com/foo:v1", ocispec.MediaTypeImageManifest, []byte(`{"foo":1}`))
+	bar := targets.pushTagged(t, "localhost:5000/bar:latest", ocispec.MediaTypeImageManifest, []byte(`{"bar":1}`))
+
+	ctx := context.Background()
+	references := []string{"registry.example.com/foo:v1", "localhost:5000/bar:latest"}
+	got, err := oras.Lock(ctx, references, targets.targetFunc, oras.LockOptions{})
+	if err != nil {
+		t.Fatalf("Lock() error = %v", err)
+	}
+	want := &oras.Lockfile{
+		Entries: []oras.LockEntry{
+			{
+				Reference: references[0],
+				Digest:    foo.Digest,
+				Size:      foo.Size,
+				MediaType: foo.MediaType,
+			},
+			{
+				Reference: references[1],
+				Digest:    bar.Digest,
+				Size:      bar.Size,
+				MediaType: bar.MediaType,
+			},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Lock() = %v, want %v", got, want)
+	}
+	if desc := got.Entries[0].Descriptor(); !content.Equal(desc, foo) {
+		t.Errorf("LockEntry.Descriptor() = %v, want %v", desc, foo)
+	}
+
+	// the lockfile matches the targets
+	drifts, err := oras.VerifyLock(ctx, got, targets.targetFunc, oras.LockOptions{})
+	if err != nil {
+		t.Fatalf("VerifyLock() error = %v", err)
+	}
+	if len(drifts) != 0 {
+		t.Errorf("VerifyLock() = %v, want no drifts", drifts)
+	}
+
+	// failures
+	_, err = oras.Lock(ctx, []string{"registry.example.com/foo:v2"}, targets.targetFunc, oras.LockOptions{})
+	if !errors.Is(err, errdef.ErrNotFound) {
+		t.Errorf("Lock() error = %v, wantErr %v", err, errdef.ErrNotFound)
+	}
+	_, err = oras.Lock(ctx, []string{"registry.example.com/foo"}, targets.targetFunc, oras.LockOptions{})
+	if !errors.Is(err, errdef.ErrMissingReference) {
+		t.Errorf("Lock() error = %v, wantErr %v", err, errdef.ErrMissingReference)
+	}
+	_, err = oras.Lock(ctx, []string{"foo:v1"}, targets.targetFunc, oras.LockOptions{})
+	if !errors.Is(err, errdef.ErrInvalidReference) {
+		t.Errorf("Lock() error = %v, wantErr %v", err, errdef.ErrInvalidReference)
+	}
+}
+
+func TestLock_Platforms(t *testing.T) {
+	var targets testLockTargets
+	linuxAMD64 := ocispec.Platform{OS: "linux", Architecture: "amd64"}
+	linuxARM64 := ocispec.Platform{OS: "linux", Architecture: "arm64"}
+	_, manifests := targets.pushPlatformIndex(t, "registry.example.com/multi:v1", linuxAMD64, linuxARM64)
+
+	ctx := context.Background()
+	opts := oras.LockOptions{
+		Platforms:   []*ocispec.Platform{&linuxARM64, &linuxAMD64},
+		Concurrency: 1,
+	}
+	got, err := oras.Lock(ctx, []string{"registry.example.com/multi:v1"}, targets.targetFunc, opts)
+	if err != nil {
+		t.Fatalf("Lock() error = %v", err)
+	}
+	want := &oras.Lockfile{
+		Entries: []oras.LockEntry{
+			{
+				Reference: "registry.example.com/multi:v1",
+				Digest:    manifests[1].Digest,
+				Size:      manifests[1].Size,
+				MediaType: manifests[1].MediaType,
+				Platform:  &linuxARM64,
+			},
+			{
+				Reference: "registry.example.com/multi:v1",
+				Digest:    manifests[0].Digest,
+				Size:      manifests[0].Size,
+				MediaType: manifests[0].MediaType,
+				Platform:  &linuxAMD64,
+			},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Lock() = %v, want %v", got, want)
+	}
+
+	// platform not found
+	opts.Platforms = []*ocispec.Platform{{OS: "windows", Architecture: "amd64"}}
+	if _, err := oras.Lock(ctx, []string{"registry.example.com/multi:v1"}, targets.targetFunc, opts); !errors.Is(err, errdef.ErrNotFound) {
+		t.Errorf("Lock() error = %v, wantErr %v", err, errdef.ErrNotFound)
+	}
+}
+
+func TestVerifyLock_Drift(t *testing.T) {
+	var targets testLockTargets
+	targets.pushTagged(t, "registry.example.com/foo:v1", ocispec.MediaTypeImageManifest, []byte(`{"foo":1}`))
+	targets.pushTagged(t, "registry.example.com/bar:v1", ocispec.MediaTypeImageManifest, []byte(`{"bar":1}`))
+	baz := targets.pushTagged(t, "registry.example.com/baz:v1", ocispec.MediaTypeImageManifest, []byte(`{"baz":1}`))
+
+	ctx := context.Background()
+	references := []string{"registry.example.com/foo:v1", "registry.example.com/bar:v1", "registry.example.com/baz:v1"}
+	lockfile, err := oras.Lock(ctx, references, targets.targetFunc, oras.LockOptions{})
+	if err != nil {
+		t.Fatalf("Lock() error = %v", err)
+	}
+
+	// move foo:v1 and remove bar:v1
+	newFoo := targets.pushTagged(t, "registry.example.com/foo:v1", ocispec.MediaTypeImageManifest, []byte(`{"foo":2}`))
+	targets.targets["registry.example.com/bar"] = memory.New()
+
+	got, err := oras.VerifyLock(ctx, lockfile, targets.targetFunc, oras.LockOptions{})
+	if err != nil {
+		t.Fatalf("VerifyLock() error = %v", err)
+	}
+	want := []oras.LockDrift{
+		{
+			Entry:   lockfile.Entries[0],
+			Current: newFoo,
+		},
+		{
+			Entry:    lockfile.Entries[1],
+			NotFound: true,
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("VerifyLock() = %v, want %v", got, want)
+	}
+	if entry := lockfile.Entries[2]; entry.Digest != baz.Digest {
+		t.Errorf("Lock() digest = %v, want %v", entry.Digest, baz.Digest)
+	}
+
+	// failure of the target function
+	errTarget := errors.New("no credentials")
+	_, err = oras.VerifyLock(ctx, lockfile, func(ctx context.Context, ref registry.Reference) (oras.ReadOnlyTarget, error) {
+		return nil, errTarget
+	}, oras.LockOptions{})
+	if !errors.Is(err, errTarget) {
+		t.Errorf("VerifyLock() error = %v, wantErr %v", err, errTarget)
+	}
+}
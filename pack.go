@@ -28,6 +28,7 @@ import (
 	specs "github.com/opencontainers/image-spec/specs-go"
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/encryption"
 	"oras.land/oras-go/v2/errdef"
 	"oras.land/oras-go/v2/internal/spec"
 )
@@ -106,6 +107,34 @@ type PackManifestOptions struct {
 	// ConfigAnnotations is the annotation map of the config descriptor.
 	// This option is valid only when ConfigDescriptor is nil.
 	ConfigAnnotations map[string]string
+
+	// MaxEmbeddedDataSize is the maximum size of the config and the layers
+	// to be embedded in the manifest through the data field of their
+	// descriptors, like the empty config.
+	// The content to be embedded is fetched from the pusher, which must also
+	// be a content.Fetcher. Descriptors already embedding content are kept.
+	// If less than or equal to 0, no content is embedded in addition to the
+	// empty descriptor.
+	// This option is valid only when PackManifestVersion is
+	// PackManifestVersion1_1.
+	//
+	// Reference: https://github.com/opencontainers/image-spec/blob/v1.1.1/descriptor.md#embedded-content
+	MaxEmbeddedDataSize int64
+
+	// EncryptLayers encrypts the layers for the recipients of the key
+	// wrappers, and references the encrypted layers in the manifest instead.
+	// The plaintext layers are fetched from LayerSource, which is required,
+	// and only the encrypted layers are pushed to the pusher, so that the
+	// plaintext never reaches the pusher.
+	// Foreign layers, encrypted layers and empty layers are not encrypted,
+	// and are referenced as is.
+	// See also [encryption.EncryptLayer].
+	EncryptLayers []encryption.KeyWrapper
+
+	// LayerSource is the storage of the plaintext layers to be encrypted by
+	// EncryptLayers, which are not expected to be pushed to the pusher.
+	// This option is valid only when EncryptLayers is set.
+	LayerSource content.Fetcher
 }
 
 // mediaTypeRegexp checks the format of media types.
@@ -249,6 +278,12 @@ func packManifestV1_0(ctx context.Context, pusher content.Pusher, artifactType s
 	if opts.Layers == nil {
 		opts.Layers = []ocispec.Descriptor{} // make it an empty array to prevent potential server-side bugs
 	}
+	if len(opts.EncryptLayers) != 0 {
+		opts.Layers, err = packEncryptedLayers(ctx, opts.LayerSource, pusher, opts.Layers, opts.EncryptLayers)
+		if err != nil {
+			return ocispec.Descriptor{}, fmt.Errorf("failed to encrypt layers: %w", err)
+		}
+	}
 	manifest := ocispec.Manifest{
 		Versioned: specs.Versioned{
 			SchemaVersion: 2, // historical value. does not pertain to OCI or docker version
@@ -350,6 +385,30 @@ func packManifestV1_1(ctx context.Context, pusher content.Pusher, artifactType s
 		opts.Layers = []ocispec.Descriptor{layerDesc}
 	}
 
+	if len(opts.EncryptLayers) != 0 {
+		opts.Layers, err = packEncryptedLayers(ctx, opts.LayerSource, pusher, opts.Layers, opts.EncryptLayers)
+		if err != nil {
+			return ocispec.Descriptor{}, fmt.Errorf("failed to encrypt layers: %w", err)
+		}
+	}
+
+	if opts.MaxEmbeddedDataSize > 0 {
+		var err error
+		configDesc, err = embedData(ctx, pusher, configDesc, opts.MaxEmbeddedDataSize)
+		if err != nil {
+			return ocispec.Descriptor{}, fmt.Errorf("failed to embed config: %w", err)
+		}
+		// copy the layers to avoid modifying the caller's slice
+		layers := make([]ocispec.Descriptor, len(opts.Layers))
+		for i, layer := range opts.Layers {
+			layers[i], err = embedData(ctx, pusher, layer, opts.MaxEmbeddedDataSize)
+			if err != nil {
+				return ocispec.Descriptor{}, fmt.Errorf("failed to embed layer: %w", err)
+			}
+		}
+		opts.Layers = layers
+	}
+
 	manifest := ocispec.Manifest{
 		Versioned: specs.Versioned{
 			SchemaVersion: 2, // historical value. does not pertain to OCI or docker version
@@ -364,6 +423,24 @@ func packManifestV1_1(ctx context.Context, pusher content.Pusher, artifactType s
 	return pushManifest(ctx, pusher, manifest, manifest.MediaType, manifest.ArtifactType, manifest.Annotations)
 }
 
+// embedData embeds the content described by desc into the data field of desc
+// if the size of the content does not exceed maxSize.
+func embedData(ctx context.Context, pusher content.Pusher, desc ocispec.Descriptor, maxSize int64) (ocispec.Descriptor, error) {
+	if desc.Size > maxSize || desc.Data != nil {
+		return desc, nil
+	}
+	fetcher, ok := pusher.(content.Fetcher)
+	if !ok {
+		return ocispec.Descriptor{}, fmt.Errorf("%s: %s: pusher is not a fetcher: %w", desc.Digest, desc.MediaType, errdef.ErrUnsupported)
+	}
+	data, err := content.FetchAll(ctx, fetcher, desc)
+	if err != nil {
+		return ocispec.Descriptor{}, fmt.Errorf("%s: %s: %w", desc.Digest, desc.MediaType, err)
+	}
+	desc.Data = data
+	return desc, nil
+}
+
 // pushIfNotExist pushes data described by desc if it does not exist in the
 // target.
 func pushIfNotExist(ctx context.Context, pusher content.Pusher, desc ocispec.Descriptor, data []byte) error {
@@ -1081,6 +1081,72 @@ func Test_PackManifest_ImageV1_1_InvalidDateTimeFormat(t *testing.T) {
 	}
 }
 
+func Test_PackManifest_ImageV1_1_EmbeddedData(t *testing.T) {
+	s := memory.New()
+	ctx := context.Background()
+
+	// push test content
+	pushBlob := func(mediaType string, blob []byte) ocispec.Descriptor {
+		desc := content.NewDescriptorFromBytes(mediaType, blob)
+		if err := s.Push(ctx, desc, bytes.NewReader(blob)); err != nil {
+			t.Fatal("Store.Push() error =", err)
+		}
+		return desc
+	}
+	configDesc := pushBlob("application/vnd.test.config", []byte(`{"test":true}`))
+	smallLayer := pushBlob(ocispec.MediaTypeImageLayer, []byte("foo"))
+	largeLayer := pushBlob(ocispec.MediaTypeImageLayer, []byte("large layer content"))
+	layers := []ocispec.Descriptor{smallLayer, largeLayer}
+
+	// test PackManifest with MaxEmbeddedDataSize
+	opts := PackManifestOptions{
+		ConfigDescriptor:    &configDesc,
+		Layers:              layers,
+		MaxEmbeddedDataSize: 16,
+	}
+	manifestDesc, err := PackManifest(ctx, s, PackManifestVersion1_1, "", opts)
+	if err != nil {
+		t.Fatal("Oras.PackManifest() error =", err)
+	}
+	manifestJSON, err := content.FetchAll(ctx, s, manifestDesc)
+	if err != nil {
+		t.Fatal("content.FetchAll() error =", err)
+	}
+	var manifest ocispec.Manifest
+	if err := json.Unmarshal(manifestJSON, &manifest); err != nil {
+		t.Fatal("error decoding manifest, error =", err)
+	}
+
+	// verify config
+	expectedConfig := configDesc
+	expectedConfig.Data = []byte(`{"test":true}`)
+	if !reflect.DeepEqual(manifest.Config, expectedConfig) {
+		t.Errorf("got config = %v, want %v", manifest.Config, expectedConfig)
+	}
+
+	// verify layers
+	expectedSmallLayer := smallLayer
+	expectedSmallLayer.Data = []byte("foo")
+	expectedLayers := []ocispec.Descriptor{expectedSmallLayer, largeLayer}
+	if !reflect.DeepEqual(manifest.Layers, expectedLayers) {
+		t.Errorf("got layers = %v, want %v", manifest.Layers, expectedLayers)
+	}
+	if layers[0].Data != nil {
+		t.Errorf("PackManifest() modified the given layers")
+	}
+
+	// test PackManifest with a pusher that is not a fetcher
+	_, err = PackManifest(ctx, pushOnlyStorage{s}, PackManifestVersion1_1, "", opts)
+	if !errors.Is(err, errdef.ErrUnsupported) {
+		t.Errorf("Oras.PackManifest() error = %v, wantErr %v", err, errdef.ErrUnsupported)
+	}
+}
+
+// pushOnlyStorage hides all methods of the storage except Push.
+type pushOnlyStorage struct {
+	content.Pusher
+}
+
 func Test_PackManifest_UnsupportedPackManifestVersion(t *testing.T) {
 	s := memory.New()
 
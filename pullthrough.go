@@ -0,0 +1,269 @@
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oras
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/errdef"
+	"oras.land/oras-go/v2/internal/descriptor"
+)
+
+// errIncompleteRead is used to abort caching content which is not read to the
+// end.
+var errIncompleteRead = errors.New("content is not read to the end")
+
+// PullThroughCache is a read-only graph target which serves content from a
+// remote target, such as a remote.Repository, and caches the content in a
+// local target, such as an oci.Store.
+//   - Blobs and manifests are fetched from the remote only if they are not
+//     cached, and are persisted to the cache while being streamed to the
+//     caller. Failures of caching do not fail the fetch.
+//   - Resolved references are tagged in the cache along with their
+//     manifests, and are served from the cache within TTL.
+//   - Predecessors, such as referrers, are fetched into the cache, and are
+//     listed from the cache within TTL.
+//   - Failures of caching resolved manifests, tags and predecessors do not
+//     fail Resolve and Predecessors, which return the result of the remote
+//     and contact the remote again on the next call.
+//
+// If Offline is set to true, the content is served only from the cache.
+type PullThroughCache struct {
+	// TTL is the duration for which resolved references and listed
+	// predecessors are served from the cache without contacting the remote.
+	// If zero or negative, the remote is always contacted for resolving
+	// references and listing predecessors.
+	TTL time.Duration
+
+	// Offline makes the cache serve content only from the local target,
+	// without contacting the remote. Content not cached is reported as not
+	// found.
+	Offline bool
+
+	remote ReadOnlyGraphTarget
+	cache  GraphTarget
+
+	lock         sync.Mutex
+	resolvedAt   map[string]time.Time
+	predecessors map[descriptor.Descriptor]time.Time
+}
+
+// NewPullThroughCache creates a pull-through cache serving content from the
+// remote target and caching content in the cache target.
+func NewPullThroughCache(remote ReadOnlyGraphTarget, cache GraphTarget) *PullThroughCache {
+	return &PullThroughCache{
+		remote:       remote,
+		cache:        cache,
+		resolvedAt:   make(map[string]time.Time),
+		predecessors: make(map[descriptor.Descriptor]time.Time),
+	}
+}
+
+// Fetch fetches the content identified by the descriptor from the cache, or
+// from the remote while caching the content if not cached.
+// The content is cached only if it is read to the end.
+func (c *PullThroughCache) Fetch(ctx context.Context, target ocispec.Descriptor) (io.ReadCloser, error) {
+	rc, err := c.cache.Fetch(ctx, target)
+	if err == nil {
+		return rc, nil
+	}
+	if !errors.Is(err, errdef.ErrNotFound) {
+		return nil, err
+	}
+	if c.Offline {
+		return nil, err
+	}
+
+	rc, err = c.remote.Fetch(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+	pr, pw := io.Pipe()
+	crc := &cachingReadCloser{
+		ReadCloser: rc,
+		pw:         pw,
+		done:       make(chan struct{}),
+	}
+	go func() {
+		defer close(crc.done)
+		err := c.cache.Push(ctx, target, pr)
+		// unblock the pending writes if the push ends early
+		pr.CloseWithError(err)
+	}()
+	return crc, nil
+}
+
+// Exists returns true if the described content exists in the cache or in the
+// remote.
+func (c *PullThroughCache) Exists(ctx context.Context, target ocispec.Descriptor) (bool, error) {
+	exists, err := c.cache.Exists(ctx, target)
+	if err != nil || exists || c.Offline {
+		return exists, err
+	}
+	return c.remote.Exists(ctx, target)
+}
+
+// Resolve resolves a reference to a descriptor.
+// The reference is resolved by the cache if it is resolved by the remote
+// within TTL, or if Offline is true. Otherwise, the reference is resolved by
+// the remote, and the manifest is cached and tagged with the reference in the
+// cache.
+func (c *PullThroughCache) Resolve(ctx context.Context, reference string) (ocispec.Descriptor, error) {
+	if c.Offline {
+		return c.cache.Resolve(ctx, reference)
+	}
+	if fresh(c, c.resolvedAt, reference) {
+		desc, err := c.cache.Resolve(ctx, reference)
+		if err == nil {
+			return desc, nil
+		}
+		if !errors.Is(err, errdef.ErrNotFound) {
+			return ocispec.Descriptor{}, err
+		}
+		// the cached content is removed, fall back to the remote
+	}
+
+	desc, err := c.remote.Resolve(ctx, reference)
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	if err := c.cacheContent(ctx, desc); err != nil {
+		// serve the remote result without caching it
+		return desc, nil
+	}
+	if _, err := digest.Parse(reference); err != nil {
+		// the reference is a tag
+		if err := c.cache.Tag(ctx, desc, reference); err != nil {
+			return desc, nil
+		}
+	}
+	refresh(c, c.resolvedAt, reference)
+	return desc, nil
+}
+
+// Predecessors returns the nodes directly pointing to the current node.
+// The predecessors are listed from the cache if they are listed from the
+// remote within TTL, or if Offline is true. Otherwise, the predecessors are
+// listed from the remote and cached.
+func (c *PullThroughCache) Predecessors(ctx context.Context, node ocispec.Descriptor) ([]ocispec.Descriptor, error) {
+	key := descriptor.FromOCI(node)
+	if c.Offline || fresh(c, c.predecessors, key) {
+		return c.cache.Predecessors(ctx, node)
+	}
+
+	predecessors, err := c.remote.Predecessors(ctx, node)
+	if err != nil {
+		return nil, err
+	}
+	for _, predecessor := range predecessors {
+		if err := c.cacheContent(ctx, predecessor); err != nil {
+			// serve the remote result without listing it from the cache
+			// until all the predecessors are cached
+			return predecessors, nil
+		}
+	}
+	refresh(c, c.predecessors, key)
+	return predecessors, nil
+}
+
+// cacheContent fetches the described content from the remote into the cache
+// if it is not cached.
+func (c *PullThroughCache) cacheContent(ctx context.Context, desc ocispec.Descriptor) error {
+	exists, err := c.cache.Exists(ctx, desc)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return nil
+	}
+	rc, err := c.remote.Fetch(ctx, desc)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	if err := c.cache.Push(ctx, desc, rc); err != nil && !errors.Is(err, errdef.ErrAlreadyExists) {
+		return fmt.Errorf("failed to cache %s: %w", desc.Digest, err)
+	}
+	return nil
+}
+
+// fresh returns true if the key is refreshed within TTL.
+func fresh[K comparable](c *PullThroughCache, refreshedAt map[K]time.Time, key K) bool {
+	if c.TTL <= 0 {
+		return false
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	t, ok := refreshedAt[key]
+	return ok && time.Since(t) < c.TTL
+}
+
+// refresh records the refresh of the key.
+func refresh[K comparable](c *PullThroughCache, refreshedAt map[K]time.Time, key K) {
+	if c.TTL <= 0 {
+		return
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	refreshedAt[key] = time.Now()
+}
+
+// cachingReadCloser is an io.ReadCloser that copies the content read from the
+// underlying io.ReadCloser to a pipe, from which the content is pushed to
+// the cache. Failures of the pipe do not affect the reads.
+type cachingReadCloser struct {
+	io.ReadCloser
+	pw     *io.PipeWriter
+	done   chan struct{}
+	failed bool
+	eof    bool
+}
+
+// Read reads from the underlying io.ReadCloser and copies the content to the
+// pipe.
+func (r *cachingReadCloser) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	if n > 0 && !r.failed {
+		if _, err := r.pw.Write(p[:n]); err != nil {
+			r.failed = true
+		}
+	}
+	if err == io.EOF && !r.eof {
+		r.eof = true
+		r.pw.Close()
+		<-r.done
+	}
+	return n, err
+}
+
+// Close aborts caching if the content is not read to the end, and closes the
+// underlying io.ReadCloser.
+func (r *cachingReadCloser) Close() error {
+	if !r.eof {
+		r.pw.CloseWithError(errIncompleteRead)
+		<-r.done
+	}
+	return r.ReadCloser.Close()
+}
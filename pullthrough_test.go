@@ -0,0 +1,272 @@
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oras_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/content/memory"
+	"oras.land/oras-go/v2/errdef"
+)
+
+// countingTarget counts the calls to the remote.
+type countingTarget struct {
+	*memory.Store
+	fetches      atomic.Int64
+	resolves     atomic.Int64
+	predecessors atomic.Int64
+}
+
+func (t *countingTarget) Fetch(ctx context.Context, target ocispec.Descriptor) (io.ReadCloser, error) {
+	t.fetches.Add(1)
+	return t.Store.Fetch(ctx, target)
+}
+
+func (t *countingTarget) Resolve(ctx context.Context, reference string) (ocispec.Descriptor, error) {
+	t.resolves.Add(1)
+	return t.Store.Resolve(ctx, reference)
+}
+
+func (t *countingTarget) Predecessors(ctx context.Context, node ocispec.Descriptor) ([]ocispec.Descriptor, error) {
+	t.predecessors.Add(1)
+	return t.Store.Predecessors(ctx, node)
+}
+
+func TestPullThroughCache(t *testing.T) {
+	ctx := context.Background()
+	remote := &countingTarget{Store: memory.New()}
+	push := func(mediaType string, blob []byte) ocispec.Descriptor {
+		desc := content.NewDescriptorFromBytes(mediaType, blob)
+		if err := remote.Push(ctx, desc, bytes.NewReader(blob)); err != nil {
+			t.Fatal("Store.Push() error =", err)
+		}
+		return desc
+	}
+	pushManifest := func(subject *ocispec.Descriptor, layers ...ocispec.Descriptor) ocispec.Descriptor {
+		manifestJSON, err := json.Marshal(ocispec.Manifest{
+			MediaType: ocispec.MediaTypeImageManifest,
+			Config:    ocispec.DescriptorEmptyJSON,
+			Layers:    layers,
+			Subject:   subject,
+		})
+		if err != nil {
+			t.Fatal("json.Marshal() error =", err)
+		}
+		return push(ocispec.MediaTypeImageManifest, manifestJSON)
+	}
+	push(ocispec.MediaTypeEmptyJSON, ocispec.DescriptorEmptyJSON.Data)
+	layerBlob := []byte("hello world")
+	layer := push(ocispec.MediaTypeImageLayer, layerBlob)
+	manifest := pushManifest(nil, layer)
+	referrer := pushManifest(&manifest)
+	if err := remote.Tag(ctx, manifest, "latest"); err != nil {
+		t.Fatal("Store.Tag() error =", err)
+	}
+
+	cache := memory.New()
+	ptc := oras.NewPullThroughCache(remote, cache)
+	ptc.TTL = time.Hour
+	checkCached := func(desc ocispec.Descriptor, want bool) {
+		t.Helper()
+		exists, err := cache.Exists(ctx, desc)
+		if err != nil {
+			t.Fatal("Store.Exists() error =", err)
+		}
+		if exists != want {
+			t.Errorf("cached %s = %v, want %v", desc.Digest, exists, want)
+		}
+	}
+	fetch := func(desc ocispec.Descriptor) []byte {
+		t.Helper()
+		got, err := content.FetchAll(ctx, ptc, desc)
+		if err != nil {
+			t.Fatal("PullThroughCache.Fetch() error =", err)
+		}
+		return got
+	}
+
+	// resolves are cached within TTL
+	for range 2 {
+		got, err := ptc.Resolve(ctx, "latest")
+		if err != nil {
+			t.Fatal("PullThroughCache.Resolve() error =", err)
+		}
+		if !content.Equal(got, manifest) {
+			t.Errorf("PullThroughCache.Resolve() = %v, want %v", got, manifest)
+		}
+	}
+	if got, want := remote.resolves.Load(), int64(1); got != want {
+		t.Errorf("remote resolves = %v, want %v", got, want)
+	}
+	checkCached(manifest, true)
+	if got, err := cache.Resolve(ctx, "latest"); err != nil || !content.Equal(got, manifest) {
+		t.Errorf("cache.Resolve() = %v, %v, want %v", got, err, manifest)
+	}
+
+	// blobs are fetched once
+	fetches := remote.fetches.Load()
+	for range 2 {
+		if got := fetch(layer); !bytes.Equal(got, layerBlob) {
+			t.Errorf("PullThroughCache.Fetch() = %v, want %v", got, layerBlob)
+		}
+	}
+	if got, want := remote.fetches.Load(), fetches+1; got != want {
+		t.Errorf("remote fetches = %v, want %v", got, want)
+	}
+	checkCached(layer, true)
+
+	// partially read content is not cached
+	rc, err := ptc.Fetch(ctx, ocispec.DescriptorEmptyJSON)
+	if err != nil {
+		t.Fatal("PullThroughCache.Fetch() error =", err)
+	}
+	if _, err := rc.Read(make([]byte, 1)); err != nil {
+		t.Fatal("Read() error =", err)
+	}
+	if err := rc.Close(); err != nil {
+		t.Fatal("Close() error =", err)
+	}
+	checkCached(ocispec.DescriptorEmptyJSON, false)
+
+	// predecessors are cached within TTL
+	for range 2 {
+		got, err := ptc.Predecessors(ctx, manifest)
+		if err != nil {
+			t.Fatal("PullThroughCache.Predecessors() error =", err)
+		}
+		if want := []ocispec.Descriptor{referrer}; !reflect.DeepEqual(got, want) {
+			t.Errorf("PullThroughCache.Predecessors() = %v, want %v", got, want)
+		}
+	}
+	if got, want := remote.predecessors.Load(), int64(1); got != want {
+		t.Errorf("remote predecessors = %v, want %v", got, want)
+	}
+	checkCached(referrer, true)
+
+	// offline mode serves only from the cache
+	offline := oras.NewPullThroughCache(remote, cache)
+	offline.Offline = true
+	fetches, resolves, predecessors := remote.fetches.Load(), remote.resolves.Load(), remote.predecessors.Load()
+	if got, err := offline.Resolve(ctx, "latest"); err != nil || !content.Equal(got, manifest) {
+		t.Errorf("PullThroughCache.Resolve() = %v, %v, want %v", got, err, manifest)
+	}
+	if got, err := offline.Predecessors(ctx, manifest); err != nil || !reflect.DeepEqual(got, []ocispec.Descriptor{referrer}) {
+		t.Errorf("PullThroughCache.Predecessors() = %v, %v, want %v", got, err, []ocispec.Descriptor{referrer})
+	}
+	if _, err := offline.Fetch(ctx, ocispec.DescriptorEmptyJSON); !errors.Is(err, errdef.ErrNotFound) {
+		t.Errorf("PullThroughCache.Fetch() error = %v, want %v", err, errdef.ErrNotFound)
+	}
+	if exists, err := offline.Exists(ctx, ocispec.DescriptorEmptyJSON); err != nil || exists {
+		t.Errorf("PullThroughCache.Exists() = %v, %v, want %v", exists, err, false)
+	}
+	if got := remote.fetches.Load() + remote.resolves.Load() + remote.predecessors.Load(); got != fetches+resolves+predecessors {
+		t.Errorf("remote calls = %v, want %v", got, fetches+resolves+predecessors)
+	}
+
+	// without TTL, the remote is always contacted
+	noTTL := oras.NewPullThroughCache(remote, cache)
+	for range 2 {
+		if _, err := noTTL.Resolve(ctx, "latest"); err != nil {
+			t.Fatal("PullThroughCache.Resolve() error =", err)
+		}
+	}
+	if got, want := remote.resolves.Load(), resolves+2; got != want {
+		t.Errorf("remote resolves = %v, want %v", got, want)
+	}
+}
+
+// failingCache is a cache failing to store content and tags.
+type failingCache struct {
+	*memory.Store
+}
+
+func (c *failingCache) Push(_ context.Context, _ ocispec.Descriptor, _ io.Reader) error {
+	return errors.New("push failed")
+}
+
+func (c *failingCache) Tag(_ context.Context, _ ocispec.Descriptor, _ string) error {
+	return errors.New("tag failed")
+}
+
+func TestPullThroughCache_CacheFailure(t *testing.T) {
+	ctx := context.Background()
+	remote := &countingTarget{Store: memory.New()}
+	push := func(mediaType string, blob []byte) ocispec.Descriptor {
+		desc := content.NewDescriptorFromBytes(mediaType, blob)
+		if err := remote.Push(ctx, desc, bytes.NewReader(blob)); err != nil {
+			t.Fatal("Store.Push() error =", err)
+		}
+		return desc
+	}
+	pushManifest := func(subject *ocispec.Descriptor) ocispec.Descriptor {
+		manifestJSON, err := json.Marshal(ocispec.Manifest{
+			MediaType: ocispec.MediaTypeImageManifest,
+			Config:    ocispec.DescriptorEmptyJSON,
+			Layers:    []ocispec.Descriptor{},
+			Subject:   subject,
+		})
+		if err != nil {
+			t.Fatal("json.Marshal() error =", err)
+		}
+		return push(ocispec.MediaTypeImageManifest, manifestJSON)
+	}
+	push(ocispec.MediaTypeEmptyJSON, ocispec.DescriptorEmptyJSON.Data)
+	manifest := pushManifest(nil)
+	referrer := pushManifest(&manifest)
+	if err := remote.Tag(ctx, manifest, "latest"); err != nil {
+		t.Fatal("Store.Tag() error =", err)
+	}
+
+	ptc := oras.NewPullThroughCache(remote, &failingCache{Store: memory.New()})
+	ptc.TTL = time.Hour
+
+	// the remote results are returned, and not served from the cache
+	for range 2 {
+		got, err := ptc.Resolve(ctx, "latest")
+		if err != nil {
+			t.Fatal("PullThroughCache.Resolve() error =", err)
+		}
+		if !content.Equal(got, manifest) {
+			t.Errorf("PullThroughCache.Resolve() = %v, want %v", got, manifest)
+		}
+	}
+	if got, want := remote.resolves.Load(), int64(2); got != want {
+		t.Errorf("remote resolves = %v, want %v", got, want)
+	}
+	for range 2 {
+		got, err := ptc.Predecessors(ctx, manifest)
+		if err != nil {
+			t.Fatal("PullThroughCache.Predecessors() error =", err)
+		}
+		if want := []ocispec.Descriptor{referrer}; !reflect.DeepEqual(got, want) {
+			t.Errorf("PullThroughCache.Predecessors() = %v, want %v", got, want)
+		}
+	}
+	if got, want := remote.predecessors.Load(), int64(2); got != want {
+		t.Errorf("remote predecessors = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,114 @@
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/errdef"
+)
+
+// Artifact types of the referrers discovered by [CosignTagReferrers], which
+// are consistent with the artifact types used by cosign.
+const (
+	// ArtifactTypeCosignSignature is the artifact type of the signatures
+	// tagged with the ".sig" suffix.
+	ArtifactTypeCosignSignature = "application/vnd.dev.cosign.artifact.sig.v1+json"
+
+	// ArtifactTypeCosignAttestation is the artifact type of the attestations
+	// tagged with the ".att" suffix.
+	ArtifactTypeCosignAttestation = "application/vnd.dev.cosign.artifact.att.v1+json"
+
+	// ArtifactTypeCosignSBOM is the artifact type of the SBOMs tagged with the
+	// ".sbom" suffix.
+	ArtifactTypeCosignSBOM = "application/vnd.dev.cosign.artifact.sbom.v1+json"
+)
+
+// cosignTagSuffixes maps the suffixes of the cosign tags to the artifact
+// types, in the order of discovery.
+var cosignTagSuffixes = []struct {
+	suffix       string
+	artifactType string
+}{
+	{".sig", ArtifactTypeCosignSignature},
+	{".att", ArtifactTypeCosignAttestation},
+	{".sbom", ArtifactTypeCosignSBOM},
+}
+
+// CosignTagReferrers discovers the signatures, attestations and SBOMs of the
+// subject stored under the conventional tags used by cosign, i.e.
+// "<alg>-<hex>.sig", "<alg>-<hex>.att" and "<alg>-<hex>.sbom", and returns
+// them as referrers of the subject.
+//
+// The returned descriptors have the artifact types ArtifactTypeCosignSignature,
+// ArtifactTypeCosignAttestation and ArtifactTypeCosignSBOM respectively, and
+// carry the tags in the annotation "org.opencontainers.image.ref.name".
+// If artifactType is not empty, only referrers of the same artifact type are
+// returned.
+//
+// Reference: https://github.com/sigstore/cosign/blob/main/specs/SIGNATURE_SPEC.md
+func CosignTagReferrers(ctx context.Context, resolver content.Resolver, subject ocispec.Descriptor, artifactType string) ([]ocispec.Descriptor, error) {
+	if err := subject.Digest.Validate(); err != nil {
+		return nil, fmt.Errorf("failed to build cosign tags for %s: %w", subject.Digest, err)
+	}
+	prefix := subject.Digest.Algorithm().String() + "-" + subject.Digest.Encoded()
+
+	var referrers []ocispec.Descriptor
+	for _, s := range cosignTagSuffixes {
+		if artifactType != "" && artifactType != s.artifactType {
+			continue
+		}
+		tag := prefix + s.suffix
+		desc, err := resolver.Resolve(ctx, tag)
+		if err != nil {
+			if errors.Is(err, errdef.ErrNotFound) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to resolve cosign tag %s: %w", tag, err)
+		}
+		referrers = append(referrers, ocispec.Descriptor{
+			MediaType:    desc.MediaType,
+			Digest:       desc.Digest,
+			Size:         desc.Size,
+			ArtifactType: s.artifactType,
+			Annotations: map[string]string{
+				ocispec.AnnotationRefName: tag,
+			},
+		})
+	}
+	return referrers, nil
+}
+
+// IsCosignTagReferrer returns true if the referrer is discovered by
+// [CosignTagReferrers] for the subject, and returns the cosign tag of the
+// referrer.
+func IsCosignTagReferrer(subject, referrer ocispec.Descriptor) (string, bool) {
+	tag, ok := referrer.Annotations[ocispec.AnnotationRefName]
+	if !ok || subject.Digest.Validate() != nil {
+		return "", false
+	}
+	prefix := subject.Digest.Algorithm().String() + "-" + subject.Digest.Encoded()
+	for _, s := range cosignTagSuffixes {
+		if tag == prefix+s.suffix && referrer.ArtifactType == s.artifactType {
+			return tag, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,109 @@
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content/memory"
+)
+
+func TestCosignTagReferrers(t *testing.T) {
+	ctx := context.Background()
+	store := memory.New()
+	push := func(blob []byte) ocispec.Descriptor {
+		desc := ocispec.Descriptor{
+			MediaType: ocispec.MediaTypeImageManifest,
+			Digest:    digest.FromBytes(blob),
+			Size:      int64(len(blob)),
+		}
+		if err := store.Push(ctx, desc, bytes.NewReader(blob)); err != nil {
+			t.Fatalf("failed to push test content: %v", err)
+		}
+		return desc
+	}
+	subject := push([]byte(`{"layers":[]}`))
+	signature := push([]byte(`{"layers":[{"digest":"sig"}]}`))
+	sbom := push([]byte(`{"layers":[{"digest":"sbom"}]}`))
+	prefix := strings.Replace(subject.Digest.String(), ":", "-", 1)
+	if err := store.Tag(ctx, signature, prefix+".sig"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Tag(ctx, sbom, prefix+".sbom"); err != nil {
+		t.Fatal(err)
+	}
+
+	wantSignature := signature
+	wantSignature.ArtifactType = ArtifactTypeCosignSignature
+	wantSignature.Annotations = map[string]string{ocispec.AnnotationRefName: prefix + ".sig"}
+	wantSBOM := sbom
+	wantSBOM.ArtifactType = ArtifactTypeCosignSBOM
+	wantSBOM.Annotations = map[string]string{ocispec.AnnotationRefName: prefix + ".sbom"}
+
+	tests := []struct {
+		name         string
+		artifactType string
+		want         []ocispec.Descriptor
+	}{
+		{
+			name: "all",
+			want: []ocispec.Descriptor{wantSignature, wantSBOM},
+		},
+		{
+			name:         "filter by artifact type",
+			artifactType: ArtifactTypeCosignSBOM,
+			want:         []ocispec.Descriptor{wantSBOM},
+		},
+		{
+			name:         "no match",
+			artifactType: ArtifactTypeCosignAttestation,
+			want:         nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := CosignTagReferrers(ctx, store, subject, tt.artifactType)
+			if err != nil {
+				t.Fatalf("CosignTagReferrers() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CosignTagReferrers() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// test recognizing discovered referrers
+	if tag, ok := IsCosignTagReferrer(subject, wantSignature); !ok || tag != prefix+".sig" {
+		t.Errorf("IsCosignTagReferrer() = %v, %v, want %v, %v", tag, ok, prefix+".sig", true)
+	}
+	if _, ok := IsCosignTagReferrer(sbom, wantSignature); ok {
+		t.Errorf("IsCosignTagReferrer() = %v, want %v", ok, false)
+	}
+	if _, ok := IsCosignTagReferrer(subject, signature); ok {
+		t.Errorf("IsCosignTagReferrer() = %v, want %v", ok, false)
+	}
+
+	// test invalid subject
+	if _, err := CosignTagReferrers(ctx, store, ocispec.Descriptor{Digest: "invalid"}, ""); err == nil {
+		t.Errorf("CosignTagReferrers() error = %v, wantErr %v", err, true)
+	}
+}
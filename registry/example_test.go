@@ -110,3 +110,58 @@ func ExampleTags() {
 	// tag1
 	// tag2
 }
+
+// ExampleAllRepositories gives example snippets for iterating repositories in
+// the registry, where the pages are requested as the iteration goes.
+func ExampleAllRepositories() {
+	reg, err := remote.NewRegistry(host)
+	if err != nil {
+		panic(err) // Handle error
+	}
+
+	ctx := context.Background()
+	for repo, err := range registry.AllRepositories(ctx, reg) {
+		if err != nil {
+			panic(err) // Handle error
+		}
+		fmt.Println(repo)
+	}
+	// Output:
+	// public/repo1
+	// public/repo2
+	// internal/repo3
+}
+
+// ExampleAllTags gives example snippets for iterating tags in the repository,
+// where the pages are requested as the iteration goes.
+func ExampleAllTags() {
+	repo, err := remote.NewRepository(fmt.Sprintf("%s/%s", host, exampleRepositoryName))
+	if err != nil {
+		panic(err) // Handle error
+	}
+
+	ctx := context.Background()
+	for tag, err := range registry.AllTags(ctx, repo) {
+		if err != nil {
+			panic(err) // Handle error
+		}
+		fmt.Println(tag)
+	}
+	// Output:
+	// tag1
+	// tag2
+}
+
+// ExampleParseNormalizedReference gives example snippets for parsing familiar
+// Docker references and printing them back in the familiar short form.
+func ExampleParseNormalizedReference() {
+	ref, err := registry.ParseNormalizedReference("ubuntu:22.04")
+	if err != nil {
+		panic(err) // Handle error
+	}
+	fmt.Println(ref)
+	fmt.Println(registry.FamiliarString(ref))
+	// Output:
+	// docker.io/library/ubuntu:22.04
+	// ubuntu:22.04
+}
@@ -0,0 +1,194 @@
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"strings"
+)
+
+const (
+	// dockerRegistry is the canonical name of Docker Hub.
+	dockerRegistry = "docker.io"
+
+	// dockerOfficialRepositoryPrefix is the namespace of the official
+	// repositories on Docker Hub.
+	dockerOfficialRepositoryPrefix = "library/"
+
+	// defaultTag is the tag used when a reference has neither a tag nor a
+	// digest.
+	defaultTag = "latest"
+)
+
+// Normalizer parses references in the familiar forms accepted by the Docker
+// CLI, such as "ubuntu:22.04" or "library/nginx", into fully qualified
+// references.
+//
+// The zero value of Normalizer applies the Docker rules.
+//
+// Reference: https://github.com/distribution/reference/blob/v0.6.0/normalize.go
+type Normalizer struct {
+	// DefaultRegistry is the registry used when a reference has no registry.
+	// If empty, "docker.io" is used.
+	DefaultRegistry string
+
+	// DefaultTag is the tag used when a reference has neither a tag nor a
+	// digest.
+	// If empty, "latest" is used.
+	DefaultTag string
+
+	// Aliases maps short names to fully qualified repositories. For example,
+	// with the alias "fedora" to "registry.fedoraproject.org/fedora", the
+	// reference "fedora:39" is parsed as
+	// "registry.fedoraproject.org/fedora:39".
+	// Aliases are applied only to references without registries.
+	Aliases map[string]string
+}
+
+// ParseNormalizedReference parses a reference in the familiar form accepted by
+// the Docker CLI into a fully qualified reference. The following rules are
+// applied:
+//   - A reference without a registry is qualified with "docker.io".
+//   - The registries "index.docker.io" and "registry-1.docker.io" are
+//     normalized to "docker.io".
+//   - A single-component repository on "docker.io" is prefixed with
+//     "library/".
+//   - A reference without a tag or a digest is tagged with "latest".
+//
+// For example, "ubuntu" is parsed as "docker.io/library/ubuntu:latest".
+//
+// The Registry of a reference on Docker Hub is always "docker.io", which is
+// not the host serving the registry API. The API host "registry-1.docker.io"
+// is returned by [Reference.Host], which the clients in the package
+// `oras.land/oras-go/v2/registry/remote` connect to, so the string of the
+// parsed reference can be passed to remote.NewRepository as is.
+//
+// ParseNormalizedReference uses the zero value of [Normalizer].
+func ParseNormalizedReference(artifact string) (Reference, error) {
+	var n Normalizer
+	return n.Parse(artifact)
+}
+
+// FamiliarString returns the familiar short form of the reference as printed
+// by the Docker CLI. For example, "docker.io/library/ubuntu:22.04" is
+// printed as "ubuntu:22.04".
+//
+// FamiliarString uses the zero value of [Normalizer].
+func FamiliarString(ref Reference) string {
+	var n Normalizer
+	return n.FamiliarString(ref)
+}
+
+// Parse parses a reference in the familiar form into a fully qualified
+// reference. See [ParseNormalizedReference] for the rules applied, where the
+// default registry and the default tag are configurable by n.
+func (n *Normalizer) Parse(artifact string) (Reference, error) {
+	registry, path, ok := splitRegistry(artifact)
+	if !ok {
+		// the reference has no registry, try aliases first
+		registry = n.defaultRegistry()
+		name, suffix := splitNameSuffix(path)
+		if alias, found := n.Aliases[name]; found {
+			if aliasRegistry, aliasPath, ok := splitRegistry(alias); ok {
+				registry, path = aliasRegistry, aliasPath+suffix
+			} else {
+				path = alias + suffix
+			}
+		}
+	}
+
+	ref, err := ParseReference(normalizeRegistry(registry) + "/" + path)
+	if err != nil {
+		return Reference{}, err
+	}
+	if ref.Registry == dockerRegistry && !strings.Contains(ref.Repository, "/") {
+		ref.Repository = dockerOfficialRepositoryPrefix + ref.Repository
+	}
+	if ref.Reference == "" {
+		ref.Reference = n.defaultTag()
+	}
+	return ref, nil
+}
+
+// FamiliarString returns the familiar short form of the reference, where the
+// default registry and the "library/" prefix of the official repositories on
+// Docker Hub are omitted.
+func (n *Normalizer) FamiliarString(ref Reference) string {
+	if ref.Repository == "" || normalizeRegistry(ref.Registry) != n.defaultRegistry() {
+		return ref.String()
+	}
+	repository := ref.Repository
+	if n.defaultRegistry() == dockerRegistry {
+		if name, ok := strings.CutPrefix(repository, dockerOfficialRepositoryPrefix); ok && !strings.Contains(name, "/") {
+			repository = name
+		}
+	}
+	if _, _, ok := splitRegistry(repository); ok {
+		// the short form would be mistaken for a reference with a registry
+		return ref.String()
+	}
+	full := ref.String()
+	return repository + full[len(ref.Registry)+1+len(ref.Repository):]
+}
+
+// defaultRegistry returns the default registry.
+func (n *Normalizer) defaultRegistry() string {
+	if n.DefaultRegistry == "" {
+		return dockerRegistry
+	}
+	return normalizeRegistry(n.DefaultRegistry)
+}
+
+// defaultTag returns the default tag.
+func (n *Normalizer) defaultTag() string {
+	if n.DefaultTag == "" {
+		return defaultTag
+	}
+	return n.DefaultTag
+}
+
+// splitRegistry splits the registry from the rest of the reference. It returns
+// false if the reference has no registry, i.e. the first component is neither
+// "localhost" nor contains "." or ":".
+//
+// Reference: https://github.com/distribution/reference/blob/v0.6.0/normalize.go#L88-L105
+func splitRegistry(artifact string) (registry, path string, ok bool) {
+	registry, path, found := strings.Cut(artifact, "/")
+	if !found {
+		return "", artifact, false
+	}
+	if !strings.ContainsAny(registry, ".:") && registry != "localhost" && strings.ToLower(registry) == registry {
+		return "", artifact, false
+	}
+	return registry, path, true
+}
+
+// splitNameSuffix splits a path into the repository name and the suffix of a
+// tag and/or a digest.
+func splitNameSuffix(path string) (name, suffix string) {
+	if index := strings.IndexAny(path, ":@"); index != -1 {
+		return path[:index], path[index:]
+	}
+	return path, ""
+}
+
+// normalizeRegistry normalizes the aliases of Docker Hub to "docker.io".
+func normalizeRegistry(registry string) string {
+	switch registry {
+	case "index.docker.io", "registry-1.docker.io":
+		return dockerRegistry
+	}
+	return registry
+}
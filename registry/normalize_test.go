@@ -0,0 +1,228 @@
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"oras.land/oras-go/v2/errdef"
+)
+
+func TestParseNormalizedReference(t *testing.T) {
+	tests := []struct {
+		name     string
+		artifact string
+		want     Reference
+		wantErr  error
+	}{
+		{
+			name:     "official repository",
+			artifact: "ubuntu",
+			want: Reference{
+				Registry:   "docker.io",
+				Repository: "library/ubuntu",
+				Reference:  "latest",
+			},
+		},
+		{
+			name:     "official repository with tag",
+			artifact: "ubuntu:22.04",
+			want: Reference{
+				Registry:   "docker.io",
+				Repository: "library/ubuntu",
+				Reference:  "22.04",
+			},
+		},
+		{
+			name:     "official repository with library prefix",
+			artifact: "library/nginx",
+			want: Reference{
+				Registry:   "docker.io",
+				Repository: "library/nginx",
+				Reference:  "latest",
+			},
+		},
+		{
+			name:     "user repository with digest",
+			artifact: "foo/bar@" + ValidDigest,
+			want: Reference{
+				Registry:   "docker.io",
+				Repository: "foo/bar",
+				Reference:  ValidDigest,
+			},
+		},
+		{
+			name:     "docker.io",
+			artifact: "docker.io/ubuntu",
+			want: Reference{
+				Registry:   "docker.io",
+				Repository: "library/ubuntu",
+				Reference:  "latest",
+			},
+		},
+		{
+			name:     "index.docker.io",
+			artifact: "index.docker.io/foo/bar:v1",
+			want: Reference{
+				Registry:   "docker.io",
+				Repository: "foo/bar",
+				Reference:  "v1",
+			},
+		},
+		{
+			name:     "registry-1.docker.io",
+			artifact: "registry-1.docker.io/alpine",
+			want: Reference{
+				Registry:   "docker.io",
+				Repository: "library/alpine",
+				Reference:  "latest",
+			},
+		},
+		{
+			name:     "localhost",
+			artifact: "localhost/foo",
+			want: Reference{
+				Registry:   "localhost",
+				Repository: "foo",
+				Reference:  "latest",
+			},
+		},
+		{
+			name:     "registry with port",
+			artifact: "localhost:5000/foo:v1",
+			want: Reference{
+				Registry:   "localhost:5000",
+				Repository: "foo",
+				Reference:  "v1",
+			},
+		},
+		{
+			name:     "fully qualified",
+			artifact: "registry.example.com/foo/bar:v1",
+			want: Reference{
+				Registry:   "registry.example.com",
+				Repository: "foo/bar",
+				Reference:  "v1",
+			},
+		},
+		{
+			name:     "upper case repository",
+			artifact: "Ubuntu",
+			wantErr:  errdef.ErrInvalidReference,
+		},
+		{
+			name:     "invalid tag",
+			artifact: "ubuntu:-22.04",
+			wantErr:  errdef.ErrInvalidReference,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseNormalizedReference(tt.artifact)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParseNormalizedReference() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseNormalizedReference() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizer_Parse(t *testing.T) {
+	n := &Normalizer{
+		DefaultRegistry: "registry.example.com",
+		DefaultTag:      "stable",
+		Aliases: map[string]string{
+			"fedora":   "registry.fedoraproject.org/fedora",
+			"team/app": "team-a/app",
+		},
+	}
+	tests := []struct {
+		artifact string
+		want     string
+	}{
+		{"foo", "registry.example.com/foo:stable"},
+		{"foo/bar:v1", "registry.example.com/foo/bar:v1"},
+		{"fedora", "registry.fedoraproject.org/fedora:stable"},
+		{"fedora:39", "registry.fedoraproject.org/fedora:39"},
+		{"team/app@" + ValidDigest, "registry.example.com/team-a/app@" + ValidDigest},
+		{"localhost:5000/fedora", "localhost:5000/fedora:stable"},
+		{"docker.io/ubuntu", "docker.io/library/ubuntu:stable"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.artifact, func(t *testing.T) {
+			got, err := n.Parse(tt.artifact)
+			if err != nil {
+				t.Fatalf("Normalizer.Parse() error = %v", err)
+			}
+			if got.String() != tt.want {
+				t.Errorf("Normalizer.Parse() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFamiliarString(t *testing.T) {
+	tests := []struct {
+		ref  Reference
+		want string
+	}{
+		{
+			ref:  Reference{Registry: "docker.io", Repository: "library/ubuntu", Reference: "22.04"},
+			want: "ubuntu:22.04",
+		},
+		{
+			ref:  Reference{Registry: "registry-1.docker.io", Repository: "library/ubuntu"},
+			want: "ubuntu",
+		},
+		{
+			ref:  Reference{Registry: "docker.io", Repository: "foo/bar", Reference: ValidDigest},
+			want: "foo/bar@" + ValidDigest,
+		},
+		{
+			ref:  Reference{Registry: "docker.io", Repository: "library/foo/bar", Reference: "v1"},
+			want: "library/foo/bar:v1",
+		},
+		{
+			ref:  Reference{Registry: "docker.io", Repository: "foo.com/bar", Reference: "v1"},
+			want: "docker.io/foo.com/bar:v1",
+		},
+		{
+			ref:  Reference{Registry: "registry.example.com", Repository: "foo", Reference: "v1"},
+			want: "registry.example.com/foo:v1",
+		},
+		{
+			ref:  Reference{Registry: "docker.io"},
+			want: "docker.io",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := FamiliarString(tt.ref); got != tt.want {
+				t.Errorf("FamiliarString() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	n := &Normalizer{DefaultRegistry: "registry.example.com"}
+	ref := Reference{Registry: "registry.example.com", Repository: "library/foo", Reference: "v1"}
+	if got, want := n.FamiliarString(ref), "library/foo:v1"; got != want {
+		t.Errorf("Normalizer.FamiliarString() = %v, want %v", got, want)
+	}
+}
@@ -236,7 +236,8 @@ func (r Reference) ValidateReference() error {
 	return r.ValidateReferenceAsTag()
 }
 
-// Host returns the host name of the registry.
+// Host returns the host name of the registry API, which is the registry
+// except for "docker.io", whose API is served by "registry-1.docker.io".
 func (r Reference) Host() string {
 	if r.Registry == "docker.io" {
 		return "registry-1.docker.io"
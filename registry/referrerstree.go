@@ -0,0 +1,188 @@
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/internal/container/set"
+	"oras.land/oras-go/v2/internal/descriptor"
+)
+
+// ReferrersTreeOptions contains parameters for [ReferrersTree].
+type ReferrersTreeOptions struct {
+	// Depth limits the maximum depth of the referrers tree, where the direct
+	// referrers of the subject are at depth 1.
+	// If less than or equal to 0, the depth is unlimited.
+	Depth int
+
+	// ArtifactType keeps only the referrers whose artifact type matches
+	// ArtifactType. The referrers of a filtered out referrer are not
+	// discovered.
+	// If nil, referrers of all artifact types are kept.
+	ArtifactType *regexp.Regexp
+
+	// Annotations keeps only the referrers having all the annotation keys in
+	// Annotations, where the annotation values match the corresponding
+	// regular expressions. A nil regular expression matches any value.
+	// The referrers of a filtered out referrer are not discovered.
+	// If empty, referrers are not filtered by annotations.
+	Annotations map[string]*regexp.Regexp
+}
+
+// ReferrerNode is a node of a referrers tree.
+type ReferrerNode struct {
+	// Descriptor is the descriptor of the node. The descriptors of referrers
+	// carry the artifact types and the annotations of the referrers.
+	Descriptor ocispec.Descriptor `json:"descriptor"`
+
+	// Referrers are the nodes directly referencing this node.
+	Referrers []*ReferrerNode `json:"referrers,omitempty"`
+}
+
+// ReferrersTree discovers the referrers of the subject recursively, such as
+// the signatures of the SBOMs of an image, and returns the referrers tree
+// rooted at the subject.
+//
+// The returned tree can be serialized to JSON, rendered by
+// [ReferrerNode.String], or copied with ExtendedCopyGraph by using
+// [ReferrerNode.FindPredecessors].
+func ReferrersTree(ctx context.Context, store content.ReadOnlyGraphStorage, subject ocispec.Descriptor, opts ReferrersTreeOptions) (*ReferrerNode, error) {
+	root := &ReferrerNode{Descriptor: subject}
+	visited := set.New[descriptor.Descriptor]()
+	visited.Add(descriptor.FromOCI(subject))
+
+	current := []*ReferrerNode{root}
+	for depth := 1; len(current) > 0 && (opts.Depth <= 0 || depth <= opts.Depth); depth++ {
+		var next []*ReferrerNode
+		for _, node := range current {
+			if !descriptor.IsManifest(node.Descriptor) {
+				// only manifests can be referenced as subjects
+				continue
+			}
+			referrers, err := Referrers(ctx, store, node.Descriptor, "")
+			if err != nil {
+				return nil, fmt.Errorf("failed to find referrers of %s: %w", node.Descriptor.Digest, err)
+			}
+			for _, referrer := range referrers {
+				key := descriptor.FromOCI(referrer)
+				if visited.Contains(key) || !opts.keep(referrer) {
+					continue
+				}
+				visited.Add(key)
+				child := &ReferrerNode{Descriptor: referrer}
+				node.Referrers = append(node.Referrers, child)
+				next = append(next, child)
+			}
+		}
+		current = next
+	}
+	return root, nil
+}
+
+// keep returns true if the referrer passes the filters of opts.
+func (opts *ReferrersTreeOptions) keep(referrer ocispec.Descriptor) bool {
+	if opts.ArtifactType != nil && !opts.ArtifactType.MatchString(referrer.ArtifactType) {
+		return false
+	}
+	for key, regex := range opts.Annotations {
+		value, ok := referrer.Annotations[key]
+		if !ok || (regex != nil && !regex.MatchString(value)) {
+			return false
+		}
+	}
+	return true
+}
+
+// Walk calls fn for each node of the tree rooted at n in depth-first
+// pre-order, where the depth of n is 0. If fn returns an error, Walk stops
+// and returns the error.
+func (n *ReferrerNode) Walk(fn func(node *ReferrerNode, depth int) error) error {
+	return n.walk(fn, 0)
+}
+
+func (n *ReferrerNode) walk(fn func(node *ReferrerNode, depth int) error, depth int) error {
+	if err := fn(n, depth); err != nil {
+		return err
+	}
+	for _, referrer := range n.Referrers {
+		if err := referrer.walk(fn, depth+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FindPredecessors returns the descriptors of the direct referrers of the node
+// described by desc in the tree rooted at n. It is compatible with the
+// FindPredecessors option of ExtendedCopyGraph so that exactly the nodes of
+// the tree are copied along with the subject.
+func (n *ReferrerNode) FindPredecessors(_ context.Context, _ content.ReadOnlyGraphStorage, desc ocispec.Descriptor) ([]ocispec.Descriptor, error) {
+	key := descriptor.FromOCI(desc)
+	var found *ReferrerNode
+	_ = n.Walk(func(node *ReferrerNode, _ int) error {
+		if found == nil && descriptor.FromOCI(node.Descriptor) == key {
+			found = node
+		}
+		return nil
+	})
+	if found == nil {
+		return nil, nil
+	}
+	predecessors := make([]ocispec.Descriptor, 0, len(found.Referrers))
+	for _, referrer := range found.Referrers {
+		predecessors = append(predecessors, referrer.Descriptor)
+	}
+	return predecessors, nil
+}
+
+// String renders the tree rooted at n, where each referrer is printed with
+// its artifact type and digest. For example:
+//
+//	sha256:4c8a...
+//	└── application/vnd.example.sbom sha256:9d2b...
+//	    └── application/vnd.example.signature sha256:e5f1...
+func (n *ReferrerNode) String() string {
+	var sb strings.Builder
+	sb.WriteString(n.Descriptor.Digest.String())
+	sb.WriteByte('\n')
+	n.render(&sb, "")
+	return sb.String()
+}
+
+// render renders the referrers of n with the given indentation prefix.
+func (n *ReferrerNode) render(sb *strings.Builder, prefix string) {
+	for i, referrer := range n.Referrers {
+		branch, indent := "├── ", "│   "
+		if i == len(n.Referrers)-1 {
+			branch, indent = "└── ", "    "
+		}
+		sb.WriteString(prefix)
+		sb.WriteString(branch)
+		if referrer.Descriptor.ArtifactType != "" {
+			sb.WriteString(referrer.Descriptor.ArtifactType)
+			sb.WriteByte(' ')
+		}
+		sb.WriteString(referrer.Descriptor.Digest.String())
+		sb.WriteByte('\n')
+		referrer.render(sb, prefix+indent)
+	}
+}
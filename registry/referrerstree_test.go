@@ -0,0 +1,189 @@
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content/memory"
+)
+
+func TestReferrersTree(t *testing.T) {
+	ctx := context.Background()
+	store := memory.New()
+	push := func(mediaType string, blob []byte) ocispec.Descriptor {
+		desc := ocispec.Descriptor{
+			MediaType: mediaType,
+			Digest:    digest.FromBytes(blob),
+			Size:      int64(len(blob)),
+		}
+		if err := store.Push(ctx, desc, bytes.NewReader(blob)); err != nil {
+			t.Fatalf("failed to push test content: %v", err)
+		}
+		return desc
+	}
+	pushReferrer := func(subject ocispec.Descriptor, artifactType string, annotations map[string]string) ocispec.Descriptor {
+		manifest := ocispec.Manifest{
+			MediaType:    ocispec.MediaTypeImageManifest,
+			ArtifactType: artifactType,
+			Config:       ocispec.DescriptorEmptyJSON,
+			Subject:      &subject,
+			Annotations:  annotations,
+		}
+		manifestJSON, err := json.Marshal(manifest)
+		if err != nil {
+			t.Fatal(err)
+		}
+		desc := push(ocispec.MediaTypeImageManifest, manifestJSON)
+		desc.ArtifactType = artifactType
+		desc.Annotations = annotations
+		return desc
+	}
+
+	push(ocispec.MediaTypeImageConfig, ocispec.DescriptorEmptyJSON.Data)
+	image := push(ocispec.MediaTypeImageManifest, []byte(`{"layers":[]}`))
+	sbom := pushReferrer(image, "application/vnd.test.sbom", map[string]string{"kind": "sbom"})
+	sbomSig := pushReferrer(sbom, "application/vnd.test.signature", map[string]string{"kind": "signature"})
+	imageSig := pushReferrer(image, "application/vnd.test.signature", nil)
+
+	sortReferrers := func(node *ReferrerNode) {
+		_ = node.Walk(func(n *ReferrerNode, _ int) error {
+			if len(n.Referrers) == 2 && n.Referrers[0].Descriptor.Digest != sbom.Digest {
+				n.Referrers[0], n.Referrers[1] = n.Referrers[1], n.Referrers[0]
+			}
+			return nil
+		})
+	}
+
+	tests := []struct {
+		name string
+		opts ReferrersTreeOptions
+		want *ReferrerNode
+	}{
+		{
+			name: "full tree",
+			want: &ReferrerNode{
+				Descriptor: image,
+				Referrers: []*ReferrerNode{
+					{Descriptor: sbom, Referrers: []*ReferrerNode{{Descriptor: sbomSig}}},
+					{Descriptor: imageSig},
+				},
+			},
+		},
+		{
+			name: "depth limit",
+			opts: ReferrersTreeOptions{Depth: 1},
+			want: &ReferrerNode{
+				Descriptor: image,
+				Referrers: []*ReferrerNode{
+					{Descriptor: sbom},
+					{Descriptor: imageSig},
+				},
+			},
+		},
+		{
+			name: "artifact type filter",
+			opts: ReferrersTreeOptions{ArtifactType: regexp.MustCompile(`signature$`)},
+			want: &ReferrerNode{
+				Descriptor: image,
+				Referrers:  []*ReferrerNode{{Descriptor: imageSig}},
+			},
+		},
+		{
+			name: "annotation filter",
+			opts: ReferrersTreeOptions{Annotations: map[string]*regexp.Regexp{"kind": nil}},
+			want: &ReferrerNode{
+				Descriptor: image,
+				Referrers: []*ReferrerNode{
+					{Descriptor: sbom, Referrers: []*ReferrerNode{{Descriptor: sbomSig}}},
+				},
+			},
+		},
+		{
+			name: "annotation value filter",
+			opts: ReferrersTreeOptions{Annotations: map[string]*regexp.Regexp{"kind": regexp.MustCompile(`^sbom$`)}},
+			want: &ReferrerNode{
+				Descriptor: image,
+				Referrers:  []*ReferrerNode{{Descriptor: sbom}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ReferrersTree(ctx, store, image, tt.opts)
+			if err != nil {
+				t.Fatalf("ReferrersTree() error = %v", err)
+			}
+			sortReferrers(got)
+			if !reflect.DeepEqual(got, tt.want) {
+				gotJSON, _ := json.Marshal(got)
+				wantJSON, _ := json.Marshal(tt.want)
+				t.Errorf("ReferrersTree() = %s, want %s", gotJSON, wantJSON)
+			}
+		})
+	}
+
+	tree, err := ReferrersTree(ctx, store, image, ReferrersTreeOptions{})
+	if err != nil {
+		t.Fatalf("ReferrersTree() error = %v", err)
+	}
+	sortReferrers(tree)
+
+	// test JSON round trip
+	treeJSON, err := json.Marshal(tree)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	var decoded ReferrerNode
+	if err := json.Unmarshal(treeJSON, &decoded); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	if !reflect.DeepEqual(&decoded, tree) {
+		t.Errorf("json round trip = %v, want %v", &decoded, tree)
+	}
+
+	// test rendering
+	wantString := image.Digest.String() + "\n" +
+		"├── application/vnd.test.sbom " + sbom.Digest.String() + "\n" +
+		"│   └── application/vnd.test.signature " + sbomSig.Digest.String() + "\n" +
+		"└── application/vnd.test.signature " + imageSig.Digest.String() + "\n"
+	if got := tree.String(); got != wantString {
+		t.Errorf("ReferrerNode.String() = %s, want %s", got, wantString)
+	}
+
+	// test predecessors
+	predecessors, err := tree.FindPredecessors(ctx, store, sbom)
+	if err != nil {
+		t.Fatalf("ReferrerNode.FindPredecessors() error = %v", err)
+	}
+	if want := []ocispec.Descriptor{sbomSig}; !reflect.DeepEqual(predecessors, want) {
+		t.Errorf("ReferrerNode.FindPredecessors() = %v, want %v", predecessors, want)
+	}
+	predecessors, err = tree.FindPredecessors(ctx, store, sbomSig)
+	if err != nil {
+		t.Fatalf("ReferrerNode.FindPredecessors() error = %v", err)
+	}
+	if len(predecessors) != 0 {
+		t.Errorf("ReferrerNode.FindPredecessors() = %v, want empty", predecessors)
+	}
+}
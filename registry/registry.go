@@ -16,7 +16,12 @@ limitations under the License.
 // Package registry provides high-level operations to manage registries.
 package registry
 
-import "context"
+import (
+	"context"
+	"iter"
+
+	"oras.land/oras-go/v2/internal/iterutil"
+)
 
 // Registry represents a collection of repositories.
 type Registry interface {
@@ -50,3 +55,15 @@ func Repositories(ctx context.Context, reg Registry) ([]string, error) {
 	}
 	return res, nil
 }
+
+// AllRepositories returns an iterator over the names of repositories available
+// in the registry.
+// Unlike Repositories(), the paginated repository list is requested lazily as
+// the iterator is consumed, and no further pages are requested if the
+// iteration stops early. An error, including the error of ctx, is yielded as
+// the last element of the iterator.
+func AllRepositories(ctx context.Context, reg Registry) iter.Seq2[string, error] {
+	return iterutil.Pages(ctx, func(fn func(repos []string) error) error {
+		return reg.Repositories(ctx, "", fn)
+	})
+}
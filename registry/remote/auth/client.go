@@ -25,6 +25,7 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"regexp"
 	"strings"
 
 	"oras.land/oras-go/v2/registry/remote/internal/errutil"
@@ -65,6 +66,21 @@ var defaultClientID = "oras-go"
 // an error.
 type CredentialFunc func(ctx context.Context, hostport string) (Credential, error)
 
+// RepositoryCredentialFunc represents a function that resolves the credential
+// for the given repository on the given registry (i.e. host:port).
+//
+// The repository is empty if the request does not target a repository, such
+// as pinging the registry or listing the catalog.
+//
+// [EmptyCredential] is a valid return value and should not be considered as
+// an error.
+type RepositoryCredentialFunc func(ctx context.Context, hostport, repository string) (Credential, error)
+
+// repositoryPathRegexp matches the request paths of the distribution API
+// endpoints targeting a repository.
+// Reference: https://github.com/opencontainers/distribution-spec/blob/v1.1.1/spec.md#endpoints
+var repositoryPathRegexp = regexp.MustCompile(`^/v2/(.+)/(?:manifests/[^/]+|blobs/uploads/[^/]*|blobs/[^/]+|tags/list|referrers/[^/]+)$`)
+
 // StaticCredential specifies static credentials for the given host.
 func StaticCredential(registry string, cred Credential) CredentialFunc {
 	if registry == "docker.io" {
@@ -104,6 +120,15 @@ type Client struct {
 	// If nil, the credential is always resolved to EmptyCredential.
 	Credential CredentialFunc
 
+	// RepositoryCredential specifies the function for resolving the
+	// credential for the given repository on the given registry (i.e.
+	// host:port). It allows a registry to have different credentials for
+	// different repositories.
+	// EmptyCredential is a valid return value and should not be considered as
+	// an error.
+	// If set, RepositoryCredential takes precedence over Credential.
+	RepositoryCredential RepositoryCredentialFunc
+
 	// Cache caches credentials for direct accessing the remote registry.
 	// If nil, no cache is used.
 	Cache Cache
@@ -139,14 +164,39 @@ func (c *Client) send(req *http.Request) (*http.Response, error) {
 	return c.client().Do(req)
 }
 
-// credential resolves the credential for the given registry.
-func (c *Client) credential(ctx context.Context, reg string) (Credential, error) {
+// credential resolves the credential for the given repository on the given
+// registry.
+func (c *Client) credential(ctx context.Context, reg, repo string) (Credential, error) {
+	if c.RepositoryCredential != nil {
+		return c.RepositoryCredential(ctx, reg, repo)
+	}
 	if c.Credential == nil {
 		return EmptyCredential, nil
 	}
 	return c.Credential(ctx, reg)
 }
 
+// repository returns the repository targeted by the given request if the
+// credential is resolved per repository. Otherwise, an empty string is
+// returned so that the credential is resolved and cached per host.
+func (c *Client) repository(req *http.Request) string {
+	if c.RepositoryCredential == nil {
+		return ""
+	}
+	return repositoryFromPath(req.URL.Path)
+}
+
+// repositoryFromPath returns the repository name from the request path of a
+// distribution API endpoint. An empty string is returned if the path does not
+// target a repository.
+func repositoryFromPath(path string) string {
+	matches := repositoryPathRegexp.FindStringSubmatch(path)
+	if matches == nil {
+		return ""
+	}
+	return matches[1]
+}
+
 // cache resolves the cache.
 // noCache is return if the cache is not configured.
 func (c *Client) cache() Cache {
@@ -182,11 +232,12 @@ func (c *Client) Do(originalReq *http.Request) (*http.Response, error) {
 	var attemptedKey string
 	cache := c.cache()
 	host := originalReq.Host
+	repo := c.repository(originalReq)
 	scheme, err := cache.GetScheme(ctx, host)
 	if err == nil {
 		switch scheme {
 		case SchemeBasic:
-			token, err := cache.GetToken(ctx, host, SchemeBasic, "")
+			token, err := cache.GetToken(ctx, host, SchemeBasic, repo)
 			if err == nil {
 				req.Header.Set("Authorization", "Basic "+token)
 			}
@@ -215,8 +266,8 @@ func (c *Client) Do(originalReq *http.Request) (*http.Response, error) {
 	case SchemeBasic:
 		resp.Body.Close()
 
-		token, err := cache.Set(ctx, host, SchemeBasic, "", func(ctx context.Context) (string, error) {
-			return c.fetchBasicAuth(ctx, host)
+		token, err := cache.Set(ctx, host, SchemeBasic, repo, func(ctx context.Context) (string, error) {
+			return c.fetchBasicAuth(ctx, host, repo)
 		})
 		if err != nil {
 			return nil, fmt.Errorf("%s %q: %w", resp.Request.Method, resp.Request.URL, err)
@@ -259,7 +310,7 @@ func (c *Client) Do(originalReq *http.Request) (*http.Response, error) {
 		realm := params["realm"]
 		service := params["service"]
 		token, err := cache.Set(ctx, host, SchemeBearer, key, func(ctx context.Context) (string, error) {
-			return c.fetchBearerToken(ctx, host, realm, service, scopes)
+			return c.fetchBearerToken(ctx, host, repo, realm, service, scopes)
 		})
 		if err != nil {
 			return nil, fmt.Errorf("%s %q: %w", resp.Request.Method, resp.Request.URL, err)
@@ -278,8 +329,8 @@ func (c *Client) Do(originalReq *http.Request) (*http.Response, error) {
 }
 
 // fetchBasicAuth fetches a basic auth token for the basic challenge.
-func (c *Client) fetchBasicAuth(ctx context.Context, registry string) (string, error) {
-	cred, err := c.credential(ctx, registry)
+func (c *Client) fetchBasicAuth(ctx context.Context, registry, repo string) (string, error) {
+	cred, err := c.credential(ctx, registry, repo)
 	if err != nil {
 		return "", fmt.Errorf("failed to resolve credential: %w", err)
 	}
@@ -294,8 +345,8 @@ func (c *Client) fetchBasicAuth(ctx context.Context, registry string) (string, e
 }
 
 // fetchBearerToken fetches an access token for the bearer challenge.
-func (c *Client) fetchBearerToken(ctx context.Context, registry, realm, service string, scopes []string) (string, error) {
-	cred, err := c.credential(ctx, registry)
+func (c *Client) fetchBearerToken(ctx context.Context, registry, repo, realm, service string, scopes []string) (string, error) {
+	cred, err := c.credential(ctx, registry, repo)
 	if err != nil {
 		return "", err
 	}
@@ -3971,8 +3971,116 @@ func TestClient_fetchBasicAuth(t *testing.T) {
 			return EmptyCredential, nil
 		},
 	}
-	_, err := c.fetchBasicAuth(context.Background(), "")
+	_, err := c.fetchBasicAuth(context.Background(), "", "")
 	if err != ErrBasicCredentialNotFound {
 		t.Errorf("incorrect error: %v, expected %v", err, ErrBasicCredentialNotFound)
 	}
 }
+
+func TestClient_Do_Basic_Auth_RepositoryCredential(t *testing.T) {
+	creds := map[string]Credential{
+		"":           {Username: "host_user", Password: "host_password"},
+		"team-a/app": {Username: "team_a_user", Password: "team_a_password"},
+		"team-b/app": {Username: "team_b_user", Password: "team_b_password"},
+	}
+	var requestCount, wantRequestCount int64
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&requestCount, 1)
+		cred := creds[repositoryFromPath(r.URL.Path)]
+		header := "Basic " + base64.StdEncoding.EncodeToString([]byte(cred.Username+":"+cred.Password))
+		if auth := r.Header.Get("Authorization"); auth != header {
+			w.Header().Set("Www-Authenticate", `Basic realm="Test Server"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+	}))
+	defer ts.Close()
+	uri, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatalf("invalid test http server: %v", err)
+	}
+
+	client := &Client{
+		Credential: func(ctx context.Context, reg string) (Credential, error) {
+			t.Error("Credential() should not be called when RepositoryCredential is set")
+			return EmptyCredential, nil
+		},
+		RepositoryCredential: func(ctx context.Context, reg, repo string) (Credential, error) {
+			if reg != uri.Host {
+				err := fmt.Errorf("registry mismatch: got %v, want %v", reg, uri.Host)
+				t.Error(err)
+				return EmptyCredential, err
+			}
+			return creds[repo], nil
+		},
+		Cache: NewCache(),
+	}
+
+	for _, path := range []string{
+		"/v2/",
+		"/v2/team-a/app/manifests/latest",
+		"/v2/team-b/app/manifests/latest",
+	} {
+		req, err := http.NewRequest(http.MethodGet, ts.URL+path, nil)
+		if err != nil {
+			t.Fatalf("failed to create test request: %v", err)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("Client.Do() error = %v", err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("Client.Do(%s) = %v, want %v", path, resp.StatusCode, http.StatusOK)
+		}
+		if wantRequestCount += 2; requestCount != wantRequestCount {
+			t.Errorf("unexpected number of requests: %d, want %d", requestCount, wantRequestCount)
+		}
+	}
+
+	// cached tokens are scoped per repository
+	for _, path := range []string{
+		"/v2/team-a/app/blobs/sha256:b5bb9d8014a0f9b1d61e21e796d78dccdf1352f23cd32812f4850b878ae4944c",
+		"/v2/team-b/app/tags/list",
+	} {
+		req, err := http.NewRequest(http.MethodGet, ts.URL+path, nil)
+		if err != nil {
+			t.Fatalf("failed to create test request: %v", err)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("Client.Do() error = %v", err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("Client.Do(%s) = %v, want %v", path, resp.StatusCode, http.StatusOK)
+		}
+		if wantRequestCount++; requestCount != wantRequestCount {
+			t.Errorf("unexpected number of requests: %d, want %d", requestCount, wantRequestCount)
+		}
+	}
+}
+
+func Test_repositoryFromPath(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/v2/", ""},
+		{"/v2/_catalog", ""},
+		{"/", ""},
+		{"/v2/hello-world/manifests/latest", "hello-world"},
+		{"/v2/team-a/app/manifests/sha256:b5bb9d8014a0f9b1d61e21e796d78dccdf1352f23cd32812f4850b878ae4944c", "team-a/app"},
+		{"/v2/team-a/app/blobs/sha256:b5bb9d8014a0f9b1d61e21e796d78dccdf1352f23cd32812f4850b878ae4944c", "team-a/app"},
+		{"/v2/team-a/app/blobs/uploads/", "team-a/app"},
+		{"/v2/team-a/app/blobs/uploads/6a1b2c3d", "team-a/app"},
+		{"/v2/team-a/blobs/blobs/uploads/6a1b2c3d", "team-a/blobs"},
+		{"/v2/team-a/app/tags/list", "team-a/app"},
+		{"/v2/team-a/app/referrers/sha256:b5bb9d8014a0f9b1d61e21e796d78dccdf1352f23cd32812f4850b878ae4944c", "team-a/app"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := repositoryFromPath(tt.path); got != tt.want {
+				t.Errorf("repositoryFromPath() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,172 @@
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package credentials
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+
+	"oras.land/oras-go/v2/registry/remote/auth"
+)
+
+// EnvServerAddressPlaceholder is the placeholder in the environment variable
+// name patterns of [EnvStoreOptions], which is replaced by the normalized
+// server address.
+const EnvServerAddressPlaceholder = "{SERVER}"
+
+// Default environment variable name patterns used by [NewEnvStore].
+const (
+	defaultEnvUsernamePattern     = "ORAS_" + EnvServerAddressPlaceholder + "_USERNAME"
+	defaultEnvPasswordPattern     = "ORAS_" + EnvServerAddressPlaceholder + "_PASSWORD"
+	defaultEnvRefreshTokenPattern = "ORAS_" + EnvServerAddressPlaceholder + "_REFRESH_TOKEN"
+	defaultEnvAccessTokenPattern  = "ORAS_" + EnvServerAddressPlaceholder + "_ACCESS_TOKEN"
+)
+
+// ErrReadOnlyStore is returned by Put() and Delete() of read-only stores.
+var ErrReadOnlyStore = errors.New("credentials store is read-only")
+
+// EnvStore is a read-only credentials store that reads credentials from
+// environment variables.
+type EnvStore struct {
+	usernamePattern     string
+	passwordPattern     string
+	refreshTokenPattern string
+	accessTokenPattern  string
+	lookupEnv           func(key string) (string, bool)
+}
+
+// EnvStoreOptions provides options for NewEnvStore.
+//
+// Each pattern is the name of an environment variable, where
+// [EnvServerAddressPlaceholder] is replaced by the server address converted
+// to upper case with every character other than letters and digits replaced
+// by an underscore. For example, with the pattern "REGISTRY_{SERVER}_USERNAME",
+// the username for "registry.example.com:5000" is read from the environment
+// variable "REGISTRY_REGISTRY_EXAMPLE_COM_5000_USERNAME".
+//
+// A pattern without the placeholder matches any server address.
+type EnvStoreOptions struct {
+	// UsernamePattern is the pattern of the environment variable holding the
+	// username.
+	// If empty, "ORAS_{SERVER}_USERNAME" is used.
+	UsernamePattern string
+
+	// PasswordPattern is the pattern of the environment variable holding the
+	// password.
+	// If empty, "ORAS_{SERVER}_PASSWORD" is used.
+	PasswordPattern string
+
+	// RefreshTokenPattern is the pattern of the environment variable holding
+	// the refresh token.
+	// If empty, "ORAS_{SERVER}_REFRESH_TOKEN" is used.
+	RefreshTokenPattern string
+
+	// AccessTokenPattern is the pattern of the environment variable holding
+	// the access token.
+	// If empty, "ORAS_{SERVER}_ACCESS_TOKEN" is used.
+	AccessTokenPattern string
+
+	// LookupEnv retrieves the value of the environment variable named by the
+	// key.
+	// If nil, os.LookupEnv is used.
+	LookupEnv func(key string) (string, bool)
+}
+
+// NewEnvStore returns a read-only credentials store that reads credentials
+// from environment variables named by the given patterns.
+//
+// Put() and Delete() of the returned store return ErrReadOnlyStore. The store
+// can be used as a fallback store of [NewStoreWithFallbacks].
+func NewEnvStore(opts EnvStoreOptions) *EnvStore {
+	es := &EnvStore{
+		usernamePattern:     opts.UsernamePattern,
+		passwordPattern:     opts.PasswordPattern,
+		refreshTokenPattern: opts.RefreshTokenPattern,
+		accessTokenPattern:  opts.AccessTokenPattern,
+		lookupEnv:           opts.LookupEnv,
+	}
+	if es.usernamePattern == "" {
+		es.usernamePattern = defaultEnvUsernamePattern
+	}
+	if es.passwordPattern == "" {
+		es.passwordPattern = defaultEnvPasswordPattern
+	}
+	if es.refreshTokenPattern == "" {
+		es.refreshTokenPattern = defaultEnvRefreshTokenPattern
+	}
+	if es.accessTokenPattern == "" {
+		es.accessTokenPattern = defaultEnvAccessTokenPattern
+	}
+	if es.lookupEnv == nil {
+		es.lookupEnv = os.LookupEnv
+	}
+	return es
+}
+
+// Get retrieves credentials from the environment variables for the given
+// server address.
+func (es *EnvStore) Get(_ context.Context, serverAddress string) (auth.Credential, error) {
+	if serverAddress == "" {
+		return auth.EmptyCredential, nil
+	}
+	server := envServerAddress(serverAddress)
+	return auth.Credential{
+		Username:     es.getenv(es.usernamePattern, server),
+		Password:     es.getenv(es.passwordPattern, server),
+		RefreshToken: es.getenv(es.refreshTokenPattern, server),
+		AccessToken:  es.getenv(es.accessTokenPattern, server),
+	}, nil
+}
+
+// Put always returns ErrReadOnlyStore.
+func (es *EnvStore) Put(_ context.Context, _ string, _ auth.Credential) error {
+	return ErrReadOnlyStore
+}
+
+// Delete always returns ErrReadOnlyStore.
+func (es *EnvStore) Delete(_ context.Context, _ string) error {
+	return ErrReadOnlyStore
+}
+
+// getenv returns the value of the environment variable named by the pattern
+// for the given normalized server address.
+func (es *EnvStore) getenv(pattern, server string) string {
+	key := strings.ReplaceAll(pattern, EnvServerAddressPlaceholder, server)
+	value, _ := es.lookupEnv(key)
+	return value
+}
+
+// envServerAddress normalizes a server address for environment variable
+// names. For example, "registry.example.com:5000" is normalized to
+// "REGISTRY_EXAMPLE_COM_5000", and "https://index.docker.io/v1/" is normalized
+// to "INDEX_DOCKER_IO_V1".
+func envServerAddress(serverAddress string) string {
+	serverAddress = strings.TrimPrefix(serverAddress, "http://")
+	serverAddress = strings.TrimPrefix(serverAddress, "https://")
+	serverAddress = strings.TrimSuffix(serverAddress, "/")
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		default:
+			return '_'
+		}
+	}, serverAddress)
+}
@@ -0,0 +1,146 @@
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package credentials
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"oras.land/oras-go/v2/registry/remote/auth"
+)
+
+func TestEnvStore_Get(t *testing.T) {
+	t.Setenv("ORAS_REGISTRY_EXAMPLE_COM_5000_USERNAME", "username")
+	t.Setenv("ORAS_REGISTRY_EXAMPLE_COM_5000_PASSWORD", "password")
+	t.Setenv("ORAS_REGISTRY_EXAMPLE_COM_REFRESH_TOKEN", "refresh_token")
+	t.Setenv("ORAS_INDEX_DOCKER_IO_V1_ACCESS_TOKEN", "access_token")
+	es := NewEnvStore(EnvStoreOptions{})
+
+	tests := []struct {
+		name          string
+		serverAddress string
+		want          auth.Credential
+	}{
+		{
+			name:          "Username and password",
+			serverAddress: "registry.example.com:5000",
+			want: auth.Credential{
+				Username: "username",
+				Password: "password",
+			},
+		},
+		{
+			name:          "Refresh token",
+			serverAddress: "registry.example.com",
+			want: auth.Credential{
+				RefreshToken: "refresh_token",
+			},
+		},
+		{
+			name:          "Access token for Docker Hub",
+			serverAddress: "https://index.docker.io/v1/",
+			want: auth.Credential{
+				AccessToken: "access_token",
+			},
+		},
+		{
+			name:          "Not found",
+			serverAddress: "whatever.example.com",
+			want:          auth.EmptyCredential,
+		},
+		{
+			name:          "Empty server address",
+			serverAddress: "",
+			want:          auth.EmptyCredential,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := es.Get(context.Background(), tt.serverAddress)
+			if err != nil {
+				t.Fatalf("EnvStore.Get() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("EnvStore.Get() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnvStore_Get_customPatterns(t *testing.T) {
+	env := map[string]string{
+		"CI_REGISTRY_USER":                        "ci_user",
+		"CI_REGISTRY_PASSWORD":                    "ci_password",
+		"REGISTRY_EXAMPLE_COM_TEAM_A_CI_PASSWORD": "team_a_password",
+	}
+	es := NewEnvStore(EnvStoreOptions{
+		UsernamePattern: "CI_REGISTRY_USER",
+		PasswordPattern: "{SERVER}_CI_PASSWORD",
+		LookupEnv: func(key string) (string, bool) {
+			value, ok := env[key]
+			return value, ok
+		},
+	})
+
+	got, err := es.Get(context.Background(), "registry.example.com/team-a")
+	if err != nil {
+		t.Fatalf("EnvStore.Get() error = %v", err)
+	}
+	want := auth.Credential{
+		Username: "ci_user",
+		Password: "team_a_password",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("EnvStore.Get() = %v, want %v", got, want)
+	}
+}
+
+func TestEnvStore_readOnly(t *testing.T) {
+	es := NewEnvStore(EnvStoreOptions{})
+	ctx := context.Background()
+	cred := auth.Credential{
+		Username: "username",
+		Password: "password",
+	}
+	if err := es.Put(ctx, "registry.example.com", cred); !errors.Is(err, ErrReadOnlyStore) {
+		t.Errorf("EnvStore.Put() error = %v, wantErr %v", err, ErrReadOnlyStore)
+	}
+	if err := es.Delete(ctx, "registry.example.com"); !errors.Is(err, ErrReadOnlyStore) {
+		t.Errorf("EnvStore.Delete() error = %v, wantErr %v", err, ErrReadOnlyStore)
+	}
+}
+
+func Test_envServerAddress(t *testing.T) {
+	tests := []struct {
+		serverAddress string
+		want          string
+	}{
+		{"localhost:5000", "LOCALHOST_5000"},
+		{"Registry.Example.com", "REGISTRY_EXAMPLE_COM"},
+		{"registry.example.com/team-a", "REGISTRY_EXAMPLE_COM_TEAM_A"},
+		{"https://index.docker.io/v1/", "INDEX_DOCKER_IO_V1"},
+		{"http://registry.example.com", "REGISTRY_EXAMPLE_COM"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.serverAddress, func(t *testing.T) {
+			if got := envServerAddress(tt.serverAddress); got != tt.want {
+				t.Errorf("envServerAddress() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"strings"
 
+	"oras.land/oras-go/v2/internal/container/set"
 	"oras.land/oras-go/v2/registry/remote/auth"
 	"oras.land/oras-go/v2/registry/remote/credentials/internal/config"
 )
@@ -85,6 +86,18 @@ func (fs *FileStore) Delete(_ context.Context, serverAddress string) error {
 	return fs.config.DeleteCredential(serverAddress)
 }
 
+// List returns the server addresses that the store holds credentials for.
+// Legacy server addresses with a http/https prefix or an API version path,
+// such as "https://registry.example.com/v1/" or "registry.example.com/v1/",
+// are normalized to their hostnames, as matched by Get().
+func (fs *FileStore) List(_ context.Context) ([]string, error) {
+	addrs := set.New[string]()
+	for _, addr := range fs.config.ServerAddresses() {
+		addrs.Add(config.NormalizeServerAddress(addr))
+	}
+	return sortedServerAddresses(addrs), nil
+}
+
 // validateCredentialFormat validates the format of cred.
 func validateCredentialFormat(cred auth.Credential) error {
 	if strings.ContainsRune(cred.Username, ':') {
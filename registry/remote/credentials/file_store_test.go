@@ -908,3 +908,49 @@ func Test_validateCredentialFormat(t *testing.T) {
 		})
 	}
 }
+
+func TestFileStore_List(t *testing.T) {
+	fs, err := NewFileStore("testdata/valid_auths_config.json")
+	if err != nil {
+		t.Fatal("NewFileStore() error =", err)
+	}
+	got, err := fs.List(context.Background())
+	if err != nil {
+		t.Fatalf("FileStore.List() error = %v", err)
+	}
+	want := []string{
+		"registry1.example.com",
+		"registry2.example.com",
+		"registry3.example.com",
+		"registry4.example.com",
+		"registry5.example.com",
+		"registry6.example.com",
+		"registry7.example.com",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FileStore.List() = %v, want %v", got, want)
+	}
+}
+
+func TestFileStore_List_LegacyConfig(t *testing.T) {
+	fs, err := NewFileStore("testdata/legacy_auths_config.json")
+	if err != nil {
+		t.Fatal("NewFileStore() error =", err)
+	}
+	got, err := fs.List(context.Background())
+	if err != nil {
+		t.Fatalf("FileStore.List() error = %v", err)
+	}
+	want := []string{
+		"registry1.example.com",
+		"registry2.example.com",
+		"registry3.example.com",
+		"registry4.example.com",
+		"registry5.example.com",
+		"registry6.example.com",
+		"registry7.example.com/team-a",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FileStore.List() = %v, want %v", got, want)
+	}
+}
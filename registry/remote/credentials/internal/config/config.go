@@ -172,8 +172,14 @@ func (cfg *Config) GetCredential(serverAddress string) (auth.Credential, error)
 		// NOTE: the auth key for the server address may have been stored with
 		// a http/https prefix in legacy config files, e.g. "registry.example.com"
 		// can be stored as "https://registry.example.com/".
+		// Keys of path-prefixed server addresses without schemes, e.g.
+		// "registry.example.com/team-a", are repository-scoped and therefore
+		// not considered as legacy keys.
 		var matched bool
 		for addr, auth := range cfg.authsCache {
+			if isRepositoryScoped(addr) {
+				continue
+			}
 			if ToHostname(addr) == serverAddress {
 				matched = true
 				authCfgBytes = auth
@@ -218,6 +224,28 @@ func (cfg *Config) DeleteCredential(serverAddress string) error {
 	return cfg.saveFile()
 }
 
+// ServerAddresses returns the server addresses of the auths field.
+func (cfg *Config) ServerAddresses() []string {
+	cfg.rwLock.RLock()
+	defer cfg.rwLock.RUnlock()
+
+	addrs := make([]string, 0, len(cfg.authsCache))
+	for addr := range cfg.authsCache {
+		addrs = append(addrs, addr)
+	}
+	return addrs
+}
+
+// CredentialHelperServerAddresses returns the server addresses of the
+// credHelpers field.
+func (cfg *Config) CredentialHelperServerAddresses() []string {
+	addrs := make([]string, 0, len(cfg.credentialHelpers))
+	for addr := range cfg.credentialHelpers {
+		addrs = append(addrs, addr)
+	}
+	return addrs
+}
+
 // GetCredentialHelper returns the credential helpers for serverAddress.
 func (cfg *Config) GetCredentialHelper(serverAddress string) string {
 	return cfg.credentialHelpers[serverAddress]
@@ -326,6 +354,32 @@ func decodeAuth(authStr string) (username string, password string, err error) {
 	return username, password, nil
 }
 
+const (
+	// dockerHubServerAddress is the key of the Docker Hub credentials.
+	dockerHubServerAddress = "https://index.docker.io/v1/"
+	// dockerHubHostname is the hostname of dockerHubServerAddress.
+	dockerHubHostname = "index.docker.io"
+)
+
+// isRepositoryScoped returns whether the auth key is a path-prefixed server
+// address without a scheme, e.g. "registry.example.com/team-a".
+// Keys with only an API version path, e.g. "registry.example.com/v1/", are
+// legacy keys rather than repository-scoped ones.
+func isRepositoryScoped(addr string) bool {
+	if strings.HasPrefix(addr, "http://") || strings.HasPrefix(addr, "https://") {
+		return false
+	}
+	_, path, ok := strings.Cut(addr, "/")
+	if !ok {
+		return false
+	}
+	switch strings.Trim(path, "/") {
+	case "", "v1", "v2":
+		return false
+	}
+	return true
+}
+
 // ToHostname normalizes a server address to just its hostname, removing
 // the scheme and the path parts.
 // It is used to match keys in the auths map, which may be either stored as
@@ -337,3 +391,18 @@ func ToHostname(addr string) string {
 	addr, _, _ = strings.Cut(addr, "/")
 	return addr
 }
+
+// NormalizeServerAddress normalizes an auth key to the server address that
+// GetCredential resolves it by. Legacy keys with a http/https prefix or an
+// API version path are normalized to their hostnames, except the keys of
+// Docker Hub, which are normalized to "https://index.docker.io/v1/".
+// Hostnames and repository-scoped keys are kept as is.
+func NormalizeServerAddress(addr string) string {
+	if !strings.Contains(addr, "/") || isRepositoryScoped(addr) {
+		return addr
+	}
+	if hostname := ToHostname(addr); hostname != dockerHubHostname {
+		return hostname
+	}
+	return dockerHubServerAddress
+}
@@ -253,6 +253,19 @@ func TestConfig_GetCredential_legacyConfig(t *testing.T) {
 				Password: "password6",
 			},
 		},
+		{
+			name:          "Path-prefixed address matched",
+			serverAddress: "registry7.example.com/team-a",
+			want: auth.Credential{
+				Username: "username7",
+				Password: "password7",
+			},
+		},
+		{
+			name:          "Path-prefixed address unmatched for hostname",
+			serverAddress: "registry7.example.com",
+			want:          auth.EmptyCredential,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -1493,3 +1506,54 @@ func TestConfig_Path(t *testing.T) {
 		t.Errorf("Config.Path() = %v, want %v", got, mockedPath)
 	}
 }
+
+func Test_isRepositoryScoped(t *testing.T) {
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{addr: "registry.example.com", want: false},
+		{addr: "registry.example.com/", want: false},
+		{addr: "registry.example.com/team-a", want: true},
+		{addr: "registry.example.com/team-a/app", want: true},
+		{addr: "registry.example.com:5000/team-a", want: true},
+		{addr: "registry.example.com/v1/", want: false},
+		{addr: "registry.example.com/v2", want: false},
+		{addr: "https://registry.example.com/team-a", want: false},
+		{addr: "http://registry.example.com/team-a", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.addr, func(t *testing.T) {
+			if got := isRepositoryScoped(tt.addr); got != tt.want {
+				t.Errorf("isRepositoryScoped() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeServerAddress(t *testing.T) {
+	tests := []struct {
+		addr string
+		want string
+	}{
+		{addr: "registry.example.com", want: "registry.example.com"},
+		{addr: "https://registry.example.com/", want: "registry.example.com"},
+		{addr: "http://registry.example.com:5000/v1/", want: "registry.example.com:5000"},
+		{addr: "registry.example.com/team-a", want: "registry.example.com/team-a"},
+		{addr: "https://index.docker.io/v1/", want: "https://index.docker.io/v1/"},
+		{addr: "index.docker.io", want: "index.docker.io"},
+		{addr: "registry.example.com/v1/", want: "registry.example.com"},
+		{addr: "index.docker.io/v1/", want: "https://index.docker.io/v1/"},
+		{addr: "registry.example.com:5000/v2", want: "registry.example.com:5000"},
+		{addr: "registry.example.com/team-a/app", want: "registry.example.com/team-a/app"},
+		{addr: "https://registry.example.com/team-a", want: "registry.example.com"},
+		{addr: "https://index.docker.io/", want: "https://index.docker.io/v1/"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.addr, func(t *testing.T) {
+			if got := NormalizeServerAddress(tt.addr); got != tt.want {
+				t.Errorf("NormalizeServerAddress() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -19,6 +19,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"slices"
 	"sync"
 
 	"oras.land/oras-go/v2/registry/remote/auth"
@@ -39,6 +40,12 @@ func NewMemoryStore() Store {
 //
 // Reference: https://docs.docker.com/engine/reference/commandline/cli/#docker-cli-configuration-file-configjson-properties
 func NewMemoryStoreFromDockerConfig(c []byte) (Store, error) {
+	return newMemoryStoreFromDockerConfig(c)
+}
+
+// newMemoryStoreFromDockerConfig creates a new in-memory credentials store
+// from the given configuration.
+func newMemoryStoreFromDockerConfig(c []byte) (*memoryStore, error) {
 	cfg := struct {
 		Auths map[string]config.AuthConfig `json:"auths"`
 	}{}
@@ -79,3 +86,14 @@ func (ms *memoryStore) Delete(_ context.Context, serverAddress string) error {
 	ms.store.Delete(serverAddress)
 	return nil
 }
+
+// List returns the server addresses that the store holds credentials for.
+func (ms *memoryStore) List(_ context.Context) ([]string, error) {
+	var addrs []string
+	ms.store.Range(func(key, _ any) bool {
+		addrs = append(addrs, key.(string))
+		return true
+	})
+	slices.Sort(addrs)
+	return addrs, nil
+}
@@ -227,3 +227,22 @@ func TestMemoryStore_Delete_notExistRecord(t *testing.T) {
 		return
 	}
 }
+
+func TestMemoryStore_List(t *testing.T) {
+	ms := NewMemoryStore()
+	ctx := context.Background()
+	for _, serverAddress := range []string{"registry2.example.com", "registry1.example.com"} {
+		cred := auth.Credential{Username: "username", Password: "password"}
+		if err := ms.Put(ctx, serverAddress, cred); err != nil {
+			t.Fatalf("MemoryStore.Put() error = %v", err)
+		}
+	}
+
+	got, err := ms.(Lister).List(ctx)
+	if err != nil {
+		t.Fatalf("MemoryStore.List() error = %v", err)
+	}
+	if want := []string{"registry1.example.com", "registry2.example.com"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("MemoryStore.List() = %v, want %v", got, want)
+	}
+}
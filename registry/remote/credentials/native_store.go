@@ -20,6 +20,7 @@ import (
 	"context"
 	"encoding/json"
 	"os/exec"
+	"slices"
 	"strings"
 
 	"oras.land/oras-go/v2/registry/remote/auth"
@@ -129,6 +130,25 @@ func (ns *nativeStore) Delete(ctx context.Context, serverAddress string) error {
 	return err
 }
 
+// List returns the server addresses that the store holds credentials for.
+func (ns *nativeStore) List(ctx context.Context) ([]string, error) {
+	out, err := ns.exec.Execute(ctx, strings.NewReader(""), "list")
+	if err != nil {
+		return nil, err
+	}
+	// the output of the list action maps server addresses to usernames
+	var creds map[string]string
+	if err := json.Unmarshal(out, &creds); err != nil {
+		return nil, err
+	}
+	addrs := make([]string, 0, len(creds))
+	for addr := range creds {
+		addrs = append(addrs, addr)
+	}
+	slices.Sort(addrs)
+	return addrs, nil
+}
+
 // getDefaultHelperSuffix returns the default credential helper suffix.
 func getDefaultHelperSuffix() string {
 	platformDefault := getPlatformDefaultHelperSuffix()
@@ -19,6 +19,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 
 	"oras.land/oras-go/v2/registry/remote"
 	"oras.land/oras-go/v2/registry/remote/auth"
@@ -79,6 +80,50 @@ func Credential(store Store) auth.CredentialFunc {
 	}
 }
 
+// RepositoryCredential returns a RepositoryCredential() function that can be
+// used by auth.Client. It allows a registry to have different credentials for
+// different repository namespaces.
+//
+// The credentials are looked up in the store by path-prefixed server
+// addresses, from the most specific to the least specific, falling back to
+// the server address of the registry itself. For example, the credentials
+// for the repository "team-a/app" on "registry.example.com" are searched in
+// the following order, and the first non-empty credential is returned:
+//  1. "registry.example.com/team-a/app"
+//  2. "registry.example.com/team-a"
+//  3. "registry.example.com"
+//
+// Path-prefixed server addresses of Docker Hub are rooted at "docker.io", e.g.
+// "docker.io/library/ubuntu", while the registry itself is still mapped to
+// "https://index.docker.io/v1/". See [ServerAddressFromHostname].
+func RepositoryCredential(store Store) auth.RepositoryCredentialFunc {
+	return func(ctx context.Context, hostport, repository string) (auth.Credential, error) {
+		serverAddress := ServerAddressFromHostname(hostport)
+		if serverAddress == "" {
+			return auth.EmptyCredential, nil
+		}
+		prefix := hostport
+		if serverAddress != hostport {
+			prefix = "docker.io"
+		}
+		for repository != "" {
+			cred, err := store.Get(ctx, prefix+"/"+repository)
+			if err != nil {
+				return auth.EmptyCredential, err
+			}
+			if cred != auth.EmptyCredential {
+				return cred, nil
+			}
+			i := strings.LastIndex(repository, "/")
+			if i < 0 {
+				break
+			}
+			repository = repository[:i]
+		}
+		return store.Get(ctx, serverAddress)
+	}
+}
+
 // ServerAddressFromRegistry maps a registry to a server address, which is used as
 // a key for credentials store. The Docker CLI expects that the credentials of
 // the registry 'registry-1.docker.io' or the alias 'docker.io' will be added
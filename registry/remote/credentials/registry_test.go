@@ -250,3 +250,84 @@ func TestCredential(t *testing.T) {
 		})
 	}
 }
+
+func TestRepositoryCredential(t *testing.T) {
+	// create a test store
+	s := &testStore{}
+	s.storage = map[string]auth.Credential{
+		"localhost:2333":                   {Username: "test_user", Password: "test_word"},
+		"localhost:2333/team-a":            {Username: "team_a_user", Password: "team_a_word"},
+		"localhost:2333/team-b/app":        {Username: "team_b_app_user", Password: "team_b_app_word"},
+		"https://index.docker.io/v1/":      {Username: "user", Password: "word"},
+		"docker.io/library":                {Username: "library_user", Password: "library_word"},
+		"localhost:6666/team-a/app/nested": {Username: "nested_user", Password: "nested_word"},
+	}
+	// create a test client using RepositoryCredential
+	testClient := &auth.Client{}
+	testClient.RepositoryCredential = RepositoryCredential(s)
+	tests := []struct {
+		name           string
+		registry       string
+		repository     string
+		wantCredential auth.Credential
+	}{
+		{
+			name:           "get registry credentials for localhost:2333",
+			registry:       "localhost:2333",
+			wantCredential: auth.Credential{Username: "test_user", Password: "test_word"},
+		},
+		{
+			name:           "get namespace credentials for localhost:2333",
+			registry:       "localhost:2333",
+			repository:     "team-a/app",
+			wantCredential: auth.Credential{Username: "team_a_user", Password: "team_a_word"},
+		},
+		{
+			name:           "get repository credentials for localhost:2333",
+			registry:       "localhost:2333",
+			repository:     "team-b/app",
+			wantCredential: auth.Credential{Username: "team_b_app_user", Password: "team_b_app_word"},
+		},
+		{
+			name:           "fall back to registry credentials for localhost:2333",
+			registry:       "localhost:2333",
+			repository:     "team-b/other",
+			wantCredential: auth.Credential{Username: "test_user", Password: "test_word"},
+		},
+		{
+			name:           "get namespace credentials for registry-1.docker.io",
+			registry:       "registry-1.docker.io",
+			repository:     "library/ubuntu",
+			wantCredential: auth.Credential{Username: "library_user", Password: "library_word"},
+		},
+		{
+			name:           "fall back to registry credentials for registry-1.docker.io",
+			registry:       "registry-1.docker.io",
+			repository:     "hello/world",
+			wantCredential: auth.Credential{Username: "user", Password: "word"},
+		},
+		{
+			name:           "get no credentials for a parent repository",
+			registry:       "localhost:6666",
+			repository:     "team-a/app",
+			wantCredential: auth.EmptyCredential,
+		},
+		{
+			name:           "get credentials for an empty string",
+			registry:       "",
+			repository:     "team-a/app",
+			wantCredential: auth.EmptyCredential,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := testClient.RepositoryCredential(context.Background(), tt.registry, tt.repository)
+			if err != nil {
+				t.Errorf("could not get credential: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.wantCredential) {
+				t.Errorf("RepositoryCredential() = %v, want %v", got, tt.wantCredential)
+			}
+		})
+	}
+}
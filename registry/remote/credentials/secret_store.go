@@ -0,0 +1,155 @@
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package credentials
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"oras.land/oras-go/v2/registry/remote/auth"
+	"oras.land/oras-go/v2/registry/remote/credentials/internal/config"
+)
+
+// SecretFileStore is a read-only credentials store that reads credentials
+// from a mounted secret file in the format of a Docker configuration file,
+// such as the ".dockerconfigjson" key of a Kubernetes secret of the type
+// "kubernetes.io/dockerconfigjson".
+//
+// The secret file is watched for rotation: it is reloaded when its
+// modification time or size changes.
+//
+// Reference: https://kubernetes.io/docs/concepts/configuration/secret/#docker-config-secrets
+type SecretFileStore struct {
+	path     string
+	interval time.Duration
+
+	lock      sync.Mutex
+	store     *FileStore
+	modTime   time.Time
+	size      int64
+	checkedAt time.Time
+}
+
+// SecretFileStoreOptions provides options for NewSecretFileStore.
+type SecretFileStoreOptions struct {
+	// RefreshInterval is the minimum interval between checks of the secret
+	// file for rotation.
+	// If zero, the secret file is checked on every Get() and List().
+	RefreshInterval time.Duration
+}
+
+// NewSecretFileStore returns a read-only credentials store that reads
+// credentials from the secret file at the given path. The secret file is
+// loaded immediately and an error is returned if it cannot be loaded.
+//
+// Put() and Delete() of the returned store return ErrReadOnlyStore. The store
+// can be used as a fallback store of [NewStoreWithFallbacks].
+func NewSecretFileStore(path string, opts SecretFileStoreOptions) (*SecretFileStore, error) {
+	ss := &SecretFileStore{
+		path:     path,
+		interval: opts.RefreshInterval,
+	}
+	if err := ss.reload(); err != nil {
+		return nil, err
+	}
+	return ss, nil
+}
+
+// Get retrieves credentials from the secret file for the given server
+// address. As with [FileStore], legacy keys with a http/https prefix in the
+// secret file, e.g. "https://registry.example.com/", are matched by their
+// hostnames.
+func (ss *SecretFileStore) Get(ctx context.Context, serverAddress string) (auth.Credential, error) {
+	store, err := ss.load()
+	if err != nil {
+		return auth.EmptyCredential, err
+	}
+	return store.Get(ctx, serverAddress)
+}
+
+// Put always returns ErrReadOnlyStore.
+func (ss *SecretFileStore) Put(_ context.Context, _ string, _ auth.Credential) error {
+	return ErrReadOnlyStore
+}
+
+// Delete always returns ErrReadOnlyStore.
+func (ss *SecretFileStore) Delete(_ context.Context, _ string) error {
+	return ErrReadOnlyStore
+}
+
+// List returns the server addresses that the secret file holds credentials
+// for.
+func (ss *SecretFileStore) List(ctx context.Context) ([]string, error) {
+	store, err := ss.load()
+	if err != nil {
+		return nil, err
+	}
+	return store.List(ctx)
+}
+
+// load returns the in-memory store of the secret file, reloading the secret
+// file if it has been rotated.
+func (ss *SecretFileStore) load() (*FileStore, error) {
+	ss.lock.Lock()
+	defer ss.lock.Unlock()
+
+	if ss.interval > 0 && time.Since(ss.checkedAt) < ss.interval {
+		return ss.store, nil
+	}
+	fi, err := os.Stat(ss.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat secret file %s: %w", ss.path, err)
+	}
+	ss.checkedAt = time.Now()
+	if fi.ModTime().Equal(ss.modTime) && fi.Size() == ss.size {
+		return ss.store, nil
+	}
+	if err := ss.reload(); err != nil {
+		return nil, err
+	}
+	return ss.store, nil
+}
+
+// reload loads the secret file. The caller must hold ss.lock unless ss is
+// not shared yet.
+func (ss *SecretFileStore) reload() error {
+	// stat before reading so that a rotation during the read is caught by
+	// the next check
+	fi, err := os.Stat(ss.path)
+	if err != nil {
+		return fmt.Errorf("failed to stat secret file %s: %w", ss.path, err)
+	}
+	cfg, err := config.Load(ss.path)
+	if err != nil {
+		return fmt.Errorf("failed to load secret file %s: %w", ss.path, err)
+	}
+	// validate all the entries on loading rather than on Get()
+	for _, addr := range cfg.ServerAddresses() {
+		if _, err := cfg.GetCredential(addr); err != nil {
+			return fmt.Errorf("failed to load secret file %s: %w", ss.path, err)
+		}
+	}
+	store := newFileStore(cfg)
+	store.DisablePut = true
+	ss.store = store
+	ss.modTime = fi.ModTime()
+	ss.size = fi.Size()
+	ss.checkedAt = time.Now()
+	return nil
+}
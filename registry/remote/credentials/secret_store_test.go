@@ -0,0 +1,251 @@
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package credentials
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"oras.land/oras-go/v2/registry/remote/auth"
+)
+
+func TestSecretFileStore_Get(t *testing.T) {
+	ss, err := NewSecretFileStore("testdata/valid_auths_config.json", SecretFileStoreOptions{})
+	if err != nil {
+		t.Fatal("NewSecretFileStore() error =", err)
+	}
+	ctx := context.Background()
+
+	got, err := ss.Get(ctx, "registry1.example.com")
+	if err != nil {
+		t.Fatal("SecretFileStore.Get() error =", err)
+	}
+	want := auth.Credential{
+		Username: "username",
+		Password: "password",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("SecretFileStore.Get() = %v, want %v", got, want)
+	}
+
+	got, err = ss.Get(ctx, "whatever.example.com")
+	if err != nil {
+		t.Fatal("SecretFileStore.Get() error =", err)
+	}
+	if want := auth.EmptyCredential; !reflect.DeepEqual(got, want) {
+		t.Errorf("SecretFileStore.Get() = %v, want %v", got, want)
+	}
+}
+
+func TestSecretFileStore_Get_legacyKeys(t *testing.T) {
+	secret := `{"auths":{
+		"https://registry1.example.com":{"auth":"dXNlcm5hbWUxOnBhc3N3b3JkMQ=="},
+		"registry2.example.com/v1/":{"auth":"dXNlcm5hbWUyOnBhc3N3b3JkMg=="},
+		"https://index.docker.io/v1/":{"auth":"dXNlcm5hbWUzOnBhc3N3b3JkMw=="}
+	}}`
+	path := filepath.Join(t.TempDir(), ".dockerconfigjson")
+	if err := os.WriteFile(path, []byte(secret), 0600); err != nil {
+		t.Fatal("failed to write secret file:", err)
+	}
+	ss, err := NewSecretFileStore(path, SecretFileStoreOptions{})
+	if err != nil {
+		t.Fatal("NewSecretFileStore() error =", err)
+	}
+	ctx := context.Background()
+
+	tests := []struct {
+		serverAddress string
+		want          auth.Credential
+	}{
+		{
+			serverAddress: "registry1.example.com",
+			want:          auth.Credential{Username: "username1", Password: "password1"},
+		},
+		{
+			serverAddress: "registry2.example.com",
+			want:          auth.Credential{Username: "username2", Password: "password2"},
+		},
+		{
+			serverAddress: ServerAddressFromRegistry("docker.io"),
+			want:          auth.Credential{Username: "username3", Password: "password3"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.serverAddress, func(t *testing.T) {
+			got, err := ss.Get(ctx, tt.serverAddress)
+			if err != nil {
+				t.Fatal("SecretFileStore.Get() error =", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SecretFileStore.Get() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	got, err := ss.List(ctx)
+	if err != nil {
+		t.Fatal("SecretFileStore.List() error =", err)
+	}
+	want := []string{"https://index.docker.io/v1/", "registry1.example.com", "registry2.example.com"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("SecretFileStore.List() = %v, want %v", got, want)
+	}
+}
+
+func TestSecretFileStore_rotation(t *testing.T) {
+	// simulate a Kubernetes secret mount, which swaps a symbolic link to
+	// rotate the secret
+	tempDir := t.TempDir()
+	dataDir1 := filepath.Join(tempDir, "data1")
+	dataDir2 := filepath.Join(tempDir, "data2")
+	for dir, content := range map[string]string{
+		dataDir1: `{"auths":{"registry.example.com":{"auth":"dXNlcm5hbWU6cGFzc3dvcmQ="}}}`,
+		dataDir2: `{"auths":{"registry.example.com":{"identitytoken":"identity_token"},"registry2.example.com":{"registrytoken":"registry_token"}}}`,
+	} {
+		if err := os.Mkdir(dir, 0700); err != nil {
+			t.Fatal("os.Mkdir() error =", err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, ".dockerconfigjson"), []byte(content), 0600); err != nil {
+			t.Fatal("os.WriteFile() error =", err)
+		}
+	}
+	dataLink := filepath.Join(tempDir, "..data")
+	if err := os.Symlink(dataDir1, dataLink); err != nil {
+		t.Skip("symbolic links are not supported:", err)
+	}
+	path := filepath.Join(tempDir, ".dockerconfigjson")
+	if err := os.Symlink(filepath.Join(dataLink, ".dockerconfigjson"), path); err != nil {
+		t.Fatal("os.Symlink() error =", err)
+	}
+
+	ss, err := NewSecretFileStore(path, SecretFileStoreOptions{})
+	if err != nil {
+		t.Fatal("NewSecretFileStore() error =", err)
+	}
+	ctx := context.Background()
+	got, err := ss.Get(ctx, "registry.example.com")
+	if err != nil {
+		t.Fatal("SecretFileStore.Get() error =", err)
+	}
+	if want := (auth.Credential{Username: "username", Password: "password"}); !reflect.DeepEqual(got, want) {
+		t.Errorf("SecretFileStore.Get() = %v, want %v", got, want)
+	}
+
+	// rotate the secret
+	modTime := time.Now().Add(time.Minute)
+	if err := os.Chtimes(filepath.Join(dataDir2, ".dockerconfigjson"), modTime, modTime); err != nil {
+		t.Fatal("os.Chtimes() error =", err)
+	}
+	tempLink := filepath.Join(tempDir, "..data_tmp")
+	if err := os.Symlink(dataDir2, tempLink); err != nil {
+		t.Fatal("os.Symlink() error =", err)
+	}
+	if err := os.Rename(tempLink, dataLink); err != nil {
+		t.Fatal("os.Rename() error =", err)
+	}
+
+	got, err = ss.Get(ctx, "registry.example.com")
+	if err != nil {
+		t.Fatal("SecretFileStore.Get() error =", err)
+	}
+	if want := (auth.Credential{RefreshToken: "identity_token"}); !reflect.DeepEqual(got, want) {
+		t.Errorf("SecretFileStore.Get() = %v, want %v", got, want)
+	}
+	addrs, err := ss.List(ctx)
+	if err != nil {
+		t.Fatal("SecretFileStore.List() error =", err)
+	}
+	if want := []string{"registry.example.com", "registry2.example.com"}; !reflect.DeepEqual(addrs, want) {
+		t.Errorf("SecretFileStore.List() = %v, want %v", addrs, want)
+	}
+}
+
+func TestSecretFileStore_RefreshInterval(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".dockerconfigjson")
+	if err := os.WriteFile(path, []byte(`{"auths":{"registry.example.com":{"auth":"dXNlcm5hbWU6cGFzc3dvcmQ="}}}`), 0600); err != nil {
+		t.Fatal("os.WriteFile() error =", err)
+	}
+	ss, err := NewSecretFileStore(path, SecretFileStoreOptions{
+		RefreshInterval: time.Hour,
+	})
+	if err != nil {
+		t.Fatal("NewSecretFileStore() error =", err)
+	}
+
+	// the removal is not observed within the refresh interval
+	if err := os.Remove(path); err != nil {
+		t.Fatal("os.Remove() error =", err)
+	}
+	got, err := ss.Get(context.Background(), "registry.example.com")
+	if err != nil {
+		t.Fatal("SecretFileStore.Get() error =", err)
+	}
+	if want := (auth.Credential{Username: "username", Password: "password"}); !reflect.DeepEqual(got, want) {
+		t.Errorf("SecretFileStore.Get() = %v, want %v", got, want)
+	}
+}
+
+func TestSecretFileStore_errors(t *testing.T) {
+	if _, err := NewSecretFileStore("testdata/not_exist.json", SecretFileStoreOptions{}); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("NewSecretFileStore() error = %v, wantErr %v", err, os.ErrNotExist)
+	}
+	if _, err := NewSecretFileStore("testdata/invalid_auths_entry_config.json", SecretFileStoreOptions{}); err == nil {
+		t.Error("NewSecretFileStore() error = nil, wantErr true")
+	}
+
+	ss, err := NewSecretFileStore("testdata/valid_auths_config.json", SecretFileStoreOptions{})
+	if err != nil {
+		t.Fatal("NewSecretFileStore() error =", err)
+	}
+	ctx := context.Background()
+	if err := ss.Put(ctx, "registry.example.com", auth.Credential{}); !errors.Is(err, ErrReadOnlyStore) {
+		t.Errorf("SecretFileStore.Put() error = %v, wantErr %v", err, ErrReadOnlyStore)
+	}
+	if err := ss.Delete(ctx, "registry.example.com"); !errors.Is(err, ErrReadOnlyStore) {
+		t.Errorf("SecretFileStore.Delete() error = %v, wantErr %v", err, ErrReadOnlyStore)
+	}
+}
+
+func TestSecretFileStore_withFallbacks(t *testing.T) {
+	t.Setenv("ORAS_REGISTRY_EXAMPLE_COM_USERNAME", "env_username")
+	t.Setenv("ORAS_REGISTRY_EXAMPLE_COM_PASSWORD", "env_password")
+	ss, err := NewSecretFileStore("testdata/valid_auths_config.json", SecretFileStoreOptions{})
+	if err != nil {
+		t.Fatal("NewSecretFileStore() error =", err)
+	}
+	sf := NewStoreWithFallbacks(NewMemoryStore(), ss, NewEnvStore(EnvStoreOptions{}))
+	ctx := context.Background()
+
+	got, err := sf.Get(ctx, "registry1.example.com")
+	if err != nil {
+		t.Fatal("storeWithFallbacks.Get() error =", err)
+	}
+	if want := (auth.Credential{Username: "username", Password: "password"}); !reflect.DeepEqual(got, want) {
+		t.Errorf("storeWithFallbacks.Get() = %v, want %v", got, want)
+	}
+	got, err = sf.Get(ctx, "registry.example.com")
+	if err != nil {
+		t.Fatal("storeWithFallbacks.Get() error =", err)
+	}
+	if want := (auth.Credential{Username: "env_username", Password: "env_password"}); !reflect.DeepEqual(got, want) {
+		t.Errorf("storeWithFallbacks.Get() = %v, want %v", got, want)
+	}
+}
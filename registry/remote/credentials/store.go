@@ -25,7 +25,9 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 
+	"oras.land/oras-go/v2/internal/container/set"
 	"oras.land/oras-go/v2/internal/syncutil"
 	"oras.land/oras-go/v2/registry/remote/auth"
 	"oras.land/oras-go/v2/registry/remote/credentials/internal/config"
@@ -47,6 +49,14 @@ type Store interface {
 	Delete(ctx context.Context, serverAddress string) error
 }
 
+// Lister is an optional interface of [Store] for enumerating the server
+// addresses that the store holds credentials for.
+type Lister interface {
+	// List returns the server addresses that the store holds credentials for.
+	// The returned server addresses are sorted and de-duplicated.
+	List(ctx context.Context) ([]string, error)
+}
+
 // DynamicStore dynamically determines which store to use based on the settings
 // in the config file.
 type DynamicStore struct {
@@ -156,6 +166,41 @@ func (ds *DynamicStore) Delete(ctx context.Context, serverAddress string) error
 	return ds.getStore(serverAddress).Delete(ctx, serverAddress)
 }
 
+// List returns the server addresses that the store holds credentials for.
+// The server addresses are merged from the following sources:
+//  1. The "auths" field of the config file
+//  2. The "credHelpers" field of the config file
+//  3. The "list" action of the native credentials store, if configured or
+//     detected
+//
+// The server addresses are returned as stored, including the legacy ones with
+// a http/https prefix, so that each of them can be passed to Get().
+func (ds *DynamicStore) List(ctx context.Context) ([]string, error) {
+	addrs := set.New[string]()
+	for _, addr := range ds.config.ServerAddresses() {
+		addrs.Add(addr)
+	}
+	for _, addr := range ds.config.CredentialHelperServerAddresses() {
+		addrs.Add(addr)
+	}
+	credsStore := ds.config.CredentialsStore()
+	if credsStore == "" {
+		credsStore = ds.detectedCredsStore
+	}
+	if credsStore != "" {
+		if lister, ok := NewNativeStore(credsStore).(Lister); ok {
+			nativeAddrs, err := lister.List(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to list credentials from %s: %w", credsStore, err)
+			}
+			for _, addr := range nativeAddrs {
+				addrs.Add(addr)
+			}
+		}
+	}
+	return sortedServerAddresses(addrs), nil
+}
+
 // IsAuthConfigured returns whether there is authentication configured in the
 // config file or not.
 //
@@ -213,6 +258,17 @@ func getDockerConfigPath() (string, error) {
 	return filepath.Join(configDir, dockerConfigFileName), nil
 }
 
+// sortedServerAddresses returns the server addresses in the set in sorted
+// order.
+func sortedServerAddresses(addrs set.Set[string]) []string {
+	res := make([]string, 0, len(addrs))
+	for addr := range addrs {
+		res = append(res, addr)
+	}
+	slices.Sort(res)
+	return res
+}
+
 // storeWithFallbacks is a store that has multiple fallback stores.
 type storeWithFallbacks struct {
 	stores []Store
@@ -224,6 +280,8 @@ type storeWithFallbacks struct {
 //     credentials in any of the stores.
 //   - Put() saves the credentials into the primary store.
 //   - Delete() deletes the credentials from the primary store.
+//   - List() merges the server addresses listed by the primary and the
+//     fallback stores. Stores not implementing [Lister] are skipped.
 func NewStoreWithFallbacks(primary Store, fallbacks ...Store) Store {
 	if len(fallbacks) == 0 {
 		return primary
@@ -260,3 +318,25 @@ func (sf *storeWithFallbacks) Put(ctx context.Context, serverAddress string, cre
 func (sf *storeWithFallbacks) Delete(ctx context.Context, serverAddress string) error {
 	return sf.stores[0].Delete(ctx, serverAddress)
 }
+
+// List returns the server addresses that the primary and the fallback stores
+// hold credentials for. Stores not implementing [Lister] are skipped.
+// The server addresses are returned as listed by the stores, so that each of
+// them can be passed to Get().
+func (sf *storeWithFallbacks) List(ctx context.Context) ([]string, error) {
+	addrs := set.New[string]()
+	for _, s := range sf.stores {
+		lister, ok := s.(Lister)
+		if !ok {
+			continue
+		}
+		storeAddrs, err := lister.List(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, addr := range storeAddrs {
+			addrs.Add(addr)
+		}
+	}
+	return sortedServerAddresses(addrs), nil
+}
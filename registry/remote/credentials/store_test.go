@@ -708,6 +708,61 @@ func Test_DynamicStore_getStore_fileStore(t *testing.T) {
 	}
 }
 
+func Test_DynamicStore_List(t *testing.T) {
+	ds, err := NewStore("testdata/credHelpers_config.json", StoreOptions{})
+	if err != nil {
+		t.Fatal("NewStore() error =", err)
+	}
+	got, err := ds.List(context.Background())
+	if err != nil {
+		t.Fatalf("DynamicStore.List() error = %v", err)
+	}
+	want := []string{
+		"registry1.example.com",
+		"registry2.example.com",
+		"registry3.example.com",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DynamicStore.List() = %v, want %v", got, want)
+	}
+}
+
+func Test_DynamicStore_List_LegacyConfig(t *testing.T) {
+	ctx := context.Background()
+	ds, err := NewStore("testdata/legacy_auths_config.json", StoreOptions{})
+	if err != nil {
+		t.Fatal("NewStore() error =", err)
+	}
+	got, err := ds.List(ctx)
+	if err != nil {
+		t.Fatalf("DynamicStore.List() error = %v", err)
+	}
+	want := []string{
+		"http://registry2.example.com",
+		"http://registry4.example.com/",
+		"https://registry1.example.com/",
+		"https://registry3.example.com",
+		"https://registry5.example.com/",
+		"https://registry6.example.com/path/",
+		"registry1.example.com",
+		"registry7.example.com/team-a",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DynamicStore.List() = %v, want %v", got, want)
+	}
+
+	// the listed server addresses round-trip through Get()
+	for _, addr := range got {
+		cred, err := ds.Get(ctx, addr)
+		if err != nil {
+			t.Fatalf("DynamicStore.Get(%s) error = %v", addr, err)
+		}
+		if cred == auth.EmptyCredential {
+			t.Errorf("DynamicStore.Get(%s) = %v, want non-empty", addr, cred)
+		}
+	}
+}
+
 func Test_storeWithFallbacks_Get(t *testing.T) {
 	// prepare test content
 	server1 := "foo.registry.com"
@@ -993,3 +1048,51 @@ func TestNewStoreFromDocker(t *testing.T) {
 		t.Errorf("DynamicStore.Get() = %v, want %v", got, want)
 	}
 }
+
+func Test_storeWithFallbacks_List(t *testing.T) {
+	ctx := context.Background()
+	cred := auth.Credential{
+		Username: "username",
+		Password: "password",
+	}
+	primaryStore := NewMemoryStore()
+	if err := primaryStore.Put(ctx, "foo.registry.com", cred); err != nil {
+		t.Fatal("MemoryStore.Put() error =", err)
+	}
+	fallbackStore1 := NewMemoryStore()
+	if err := fallbackStore1.Put(ctx, "bar.registry.com", cred); err != nil {
+		t.Fatal("MemoryStore.Put() error =", err)
+	}
+	if err := fallbackStore1.Put(ctx, "foo.registry.com", cred); err != nil {
+		t.Fatal("MemoryStore.Put() error =", err)
+	}
+	if err := fallbackStore1.Put(ctx, "https://bar.registry.com/", cred); err != nil {
+		t.Fatal("MemoryStore.Put() error =", err)
+	}
+	// testStore does not implement Lister and is skipped
+	fallbackStore2 := &testStore{
+		storage: map[string]auth.Credential{
+			"baz.registry.com": cred,
+		},
+	}
+	sf := NewStoreWithFallbacks(primaryStore, fallbackStore1, fallbackStore2)
+
+	got, err := sf.(Lister).List(ctx)
+	if err != nil {
+		t.Fatalf("storeWithFallbacks.List() error = %v", err)
+	}
+	if want := []string{"bar.registry.com", "foo.registry.com", "https://bar.registry.com/"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("storeWithFallbacks.List() = %v, want %v", got, want)
+	}
+
+	// the listed server addresses round-trip through Get()
+	for _, addr := range got {
+		gotCred, err := sf.Get(ctx, addr)
+		if err != nil {
+			t.Fatalf("storeWithFallbacks.Get(%s) error = %v", addr, err)
+		}
+		if !reflect.DeepEqual(gotCred, cred) {
+			t.Errorf("storeWithFallbacks.Get(%s) = %v, want %v", addr, gotCred, cred)
+		}
+	}
+}
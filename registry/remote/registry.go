@@ -21,10 +21,12 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"iter"
 	"net/http"
 	"strconv"
 
 	"oras.land/oras-go/v2/errdef"
+	"oras.land/oras-go/v2/internal/iterutil"
 	"oras.land/oras-go/v2/registry"
 	"oras.land/oras-go/v2/registry/remote/auth"
 	"oras.land/oras-go/v2/registry/remote/internal/errutil"
@@ -141,6 +143,23 @@ func (r *Registry) Repositories(ctx context.Context, last string, fn func(repos
 	return nil
 }
 
+// AllRepositories returns an iterator over the names of repositories
+// available in the registry.
+// See also `RepositoryListPageSize`.
+// If `last` is NOT empty, the iteration starts after the repo specified by
+// `last`. Otherwise, the iteration starts from the top of the Repositories
+// list.
+//
+// Pages are requested lazily by following the Link header of the responses
+// as the iterator is consumed, and no further pages are requested if the
+// iteration stops early. An error, including the error of ctx, is yielded as
+// the last element of the iterator.
+func (r *Registry) AllRepositories(ctx context.Context, last string) iter.Seq2[string, error] {
+	return iterutil.Pages(ctx, func(fn func(repos []string) error) error {
+		return r.Repositories(ctx, last, fn)
+	})
+}
+
 // repositories returns a single page of repository list with the next link.
 func (r *Registry) repositories(ctx context.Context, last string, fn func(repos []string) error, url string) (string, error) {
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
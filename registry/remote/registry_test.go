@@ -25,6 +25,7 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"reflect"
+	"slices"
 	"strconv"
 	"strings"
 	"testing"
@@ -216,6 +217,87 @@ func TestRegistry_Repositories(t *testing.T) {
 	}
 }
 
+func TestRegistry_AllRepositories(t *testing.T) {
+	repoSet := [][]string{
+		{"the", "quick", "brown", "fox"},
+		{"jumps", "over", "the", "lazy"},
+		{"dog"},
+	}
+	var requests int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/v2/_catalog" {
+			t.Errorf("unexpected access: %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		requests++
+		var repos []string
+		switch r.URL.Query().Get("test") {
+		case "foo":
+			repos = repoSet[1]
+			w.Header().Set("Link", `</v2/_catalog?test=bar>; rel="next"`)
+		case "bar":
+			repos = repoSet[2]
+		default:
+			repos = repoSet[0]
+			w.Header().Set("Link", `</v2/_catalog?test=foo>; rel="next"`)
+		}
+		result := struct {
+			Repositories []string `json:"repositories"`
+		}{
+			Repositories: repos,
+		}
+		if err := json.NewEncoder(w).Encode(result); err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	}))
+	defer ts.Close()
+	uri, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatalf("invalid test http server: %v", err)
+	}
+
+	reg, err := NewRegistry(uri.Host)
+	if err != nil {
+		t.Fatalf("NewRegistry() error = %v", err)
+	}
+	reg.PlainHTTP = true
+
+	ctx := context.Background()
+	var got []string
+	for repo, err := range reg.AllRepositories(ctx, "") {
+		if err != nil {
+			t.Fatalf("Registry.AllRepositories() error = %v", err)
+		}
+		got = append(got, repo)
+	}
+	if want := slices.Concat(repoSet...); !reflect.DeepEqual(got, want) {
+		t.Errorf("Registry.AllRepositories() = %v, want %v", got, want)
+	}
+	if want := 3; requests != want {
+		t.Errorf("requests = %d, want %d", requests, want)
+	}
+
+	// test breaking early
+	requests = 0
+	got = nil
+	for repo, err := range reg.AllRepositories(ctx, "") {
+		if err != nil {
+			t.Fatalf("Registry.AllRepositories() error = %v", err)
+		}
+		got = append(got, repo)
+		if len(got) == 2 {
+			break
+		}
+	}
+	if want := []string{"the", "quick"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Registry.AllRepositories() = %v, want %v", got, want)
+	}
+	if want := 1; requests != want {
+		t.Errorf("requests = %d, want %d", requests, want)
+	}
+}
+
 func TestRegistry_Repository(t *testing.T) {
 	reg, err := NewRegistry("localhost:5000")
 	if err != nil {
@@ -223,6 +305,7 @@ func TestRegistry_Repository(t *testing.T) {
 	}
 	reg.PlainHTTP = true
 	reg.SkipReferrersGC = true
+	reg.CosignTagReferrers = true
 	reg.RepositoryListPageSize = 50
 	reg.TagListPageSize = 100
 	reg.ReferrerListPageSize = 10
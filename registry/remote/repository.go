@@ -22,6 +22,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"iter"
 	"mime"
 	"net/http"
 	"slices"
@@ -36,8 +37,10 @@ import (
 	"oras.land/oras-go/v2/content"
 	"oras.land/oras-go/v2/errdef"
 	"oras.land/oras-go/v2/internal/cas"
+	"oras.land/oras-go/v2/internal/container/set"
 	"oras.land/oras-go/v2/internal/httputil"
 	"oras.land/oras-go/v2/internal/ioutil"
+	"oras.land/oras-go/v2/internal/iterutil"
 	"oras.land/oras-go/v2/internal/spec"
 	"oras.land/oras-go/v2/internal/syncutil"
 	"oras.land/oras-go/v2/registry"
@@ -151,6 +154,14 @@ type Repository struct {
 	//   - https://www.rfc-editor.org/rfc/rfc7234#section-5.5
 	HandleWarning func(warning Warning)
 
+	// CosignTagReferrers specifies whether Referrers() also discovers the
+	// signatures, attestations and SBOMs stored under the conventional tags
+	// used by cosign, such as "sha256-<hex>.sig", in addition to the
+	// referrers found by the Referrers API or the referrers tag schema.
+	// By default, it is disabled (set to false).
+	// See also: registry.CosignTagReferrers()
+	CosignTagReferrers bool
+
 	// NOTE: Must keep fields in sync with clone().
 
 	// referrersState represents that if the repository supports Referrers API.
@@ -207,6 +218,7 @@ func (r *Repository) clone() *Repository {
 		MaxMetadataBytes:     r.MaxMetadataBytes,
 		SkipReferrersGC:      r.SkipReferrersGC,
 		HandleWarning:        r.HandleWarning,
+		CosignTagReferrers:   r.CosignTagReferrers,
 	}
 }
 
@@ -327,11 +339,31 @@ func (r *Repository) Resolve(ctx context.Context, reference string) (ocispec.Des
 	return r.Manifests().Resolve(ctx, reference)
 }
 
+// ResolveIfChanged resolves the reference unless it still resolves to the
+// last resolved descriptor, using a conditional HEAD request with the digest
+// of last as the entity tag. It returns last and false if the registry
+// responds with 304 Not Modified or the resolved digest equals to the digest
+// of last.
+// If last is empty, the reference is resolved unconditionally.
+func (r *Repository) ResolveIfChanged(ctx context.Context, reference string, last ocispec.Descriptor) (ocispec.Descriptor, bool, error) {
+	return (&manifestStore{repo: r}).ResolveIfChanged(ctx, reference, last)
+}
+
 // Tag tags a manifest descriptor with a reference string.
 func (r *Repository) Tag(ctx context.Context, desc ocispec.Descriptor, reference string) error {
 	return r.Manifests().Tag(ctx, desc, reference)
 }
 
+// Untag removes the tag from the repository.
+// The manifest identified by the tag is NOT deleted, and digest references
+// are rejected with ErrInvalidReference.
+// Untag returns ErrUnsupported if the registry does not support tag deletion.
+//
+// Reference: https://github.com/opencontainers/distribution-spec/blob/v1.1.1/spec.md#deleting-tags
+func (r *Repository) Untag(ctx context.Context, reference string) error {
+	return r.Manifests().(content.Untagger).Untag(ctx, reference)
+}
+
 // PushReference pushes the manifest with a reference tag.
 func (r *Repository) PushReference(ctx context.Context, expected ocispec.Descriptor, content io.Reader, reference string) error {
 	return r.Manifests().PushReference(ctx, expected, content, reference)
@@ -410,6 +442,21 @@ func (r *Repository) Tags(ctx context.Context, last string, fn func(tags []strin
 	return nil
 }
 
+// AllTags returns an iterator over the tags available in the repository.
+// See also `TagListPageSize`.
+// If `last` is NOT empty, the iteration starts after the tag specified by
+// `last`. Otherwise, the iteration starts from the top of the Tags list.
+//
+// Pages are requested lazily by following the Link header of the responses
+// as the iterator is consumed, and no further pages are requested if the
+// iteration stops early. An error, including the error of ctx, is yielded as
+// the last element of the iterator.
+func (r *Repository) AllTags(ctx context.Context, last string) iter.Seq2[string, error] {
+	return iterutil.Pages(ctx, func(fn func(tags []string) error) error {
+		return r.Tags(ctx, last, fn)
+	})
+}
+
 // tags returns a single page of tag list with the next link.
 func (r *Repository) tags(ctx context.Context, last string, fn func(tags []string) error, url string) (string, error) {
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
@@ -471,8 +518,45 @@ func (r *Repository) Predecessors(ctx context.Context, desc ocispec.Descriptor)
 // If artifactType is not empty, only referrers of the same artifact type are
 // fed to fn.
 //
+// If CosignTagReferrers is true, the referrers stored under the cosign tags
+// are fed to fn after the other referrers.
+//
 // Reference: https://github.com/opencontainers/distribution-spec/blob/v1.1.1/spec.md#listing-referrers
 func (r *Repository) Referrers(ctx context.Context, desc ocispec.Descriptor, artifactType string, fn func(referrers []ocispec.Descriptor) error) error {
+	if !r.CosignTagReferrers {
+		return r.referrers(ctx, desc, artifactType, fn)
+	}
+
+	seen := set.New[digest.Digest]()
+	if err := r.referrers(ctx, desc, artifactType, func(referrers []ocispec.Descriptor) error {
+		for _, referrer := range referrers {
+			seen.Add(referrer.Digest)
+		}
+		return fn(referrers)
+	}); err != nil {
+		return err
+	}
+
+	tagReferrers, err := registry.CosignTagReferrers(ctx, r, desc, artifactType)
+	if err != nil {
+		return err
+	}
+	var unseen []ocispec.Descriptor
+	for _, referrer := range tagReferrers {
+		if !seen.Contains(referrer.Digest) {
+			unseen = append(unseen, referrer)
+		}
+	}
+	if len(unseen) == 0 {
+		return nil
+	}
+	return fn(unseen)
+}
+
+// referrers lists the descriptors of image or artifact manifests directly
+// referencing the given manifest descriptor by the Referrers API or the
+// referrers tag schema.
+func (r *Repository) referrers(ctx context.Context, desc ocispec.Descriptor, artifactType string, fn func(referrers []ocispec.Descriptor) error) error {
 	state := r.loadReferrersState()
 	if state == referrersStateUnsupported {
 		// The repository is known to not support Referrers API, fallback to
@@ -500,6 +584,23 @@ func (r *Repository) Referrers(ctx context.Context, desc ocispec.Descriptor, art
 	return nil
 }
 
+// AllReferrers returns an iterator over the descriptors of image or artifact
+// manifests directly referencing the given manifest descriptor.
+// If artifactType is not empty, only referrers of the same artifact type are
+// yielded.
+//
+// When the Referrers API is used, pages are requested lazily by following
+// the Link header of the responses as the iterator is consumed, and no further
+// pages are requested if the iteration stops early. An error, including the
+// error of ctx, is yielded as the last element of the iterator.
+//
+// Reference: https://github.com/opencontainers/distribution-spec/blob/v1.1.1/spec.md#listing-referrers
+func (r *Repository) AllReferrers(ctx context.Context, desc ocispec.Descriptor, artifactType string) iter.Seq2[ocispec.Descriptor, error] {
+	return iterutil.Pages(ctx, func(fn func(referrers []ocispec.Descriptor) error) error {
+		return r.Referrers(ctx, desc, artifactType, fn)
+	})
+}
+
 // referrersByAPI lists the descriptors of manifests directly referencing
 // the given manifest descriptor by requesting Referrers API.
 // fn is called for the referrers result. If artifactType is not empty,
@@ -726,7 +827,16 @@ type blobStore struct {
 }
 
 // Fetch fetches the content identified by the descriptor.
+// If the descriptor embeds the content in its Data field, the embedded content
+// is returned after verification without network I/O.
 func (s *blobStore) Fetch(ctx context.Context, target ocispec.Descriptor) (rc io.ReadCloser, err error) {
+	if data, ok, err := content.EmbeddedData(target); ok {
+		if err != nil {
+			return nil, err
+		}
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}
+
 	ref := s.repo.Reference
 	ref.Reference = target.Digest.String()
 	ctx = auth.AppendRepositoryScope(ctx, ref, auth.ActionPull)
@@ -1060,7 +1170,16 @@ type manifestStore struct {
 }
 
 // Fetch fetches the content identified by the descriptor.
+// If the descriptor embeds the content in its Data field, the embedded content
+// is returned after verification without network I/O.
 func (s *manifestStore) Fetch(ctx context.Context, target ocispec.Descriptor) (rc io.ReadCloser, err error) {
+	if data, ok, err := content.EmbeddedData(target); ok {
+		if err != nil {
+			return nil, err
+		}
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}
+
 	ref := s.repo.Reference
 	ref.Reference = target.Digest.String()
 	ctx = auth.AppendRepositoryScope(ctx, ref, auth.ActionPull)
@@ -1186,31 +1305,54 @@ func (s *manifestStore) indexReferrersForDelete(ctx context.Context, desc ocispe
 // Resolve resolves a reference to a descriptor.
 // See also `ManifestMediaTypes`.
 func (s *manifestStore) Resolve(ctx context.Context, reference string) (ocispec.Descriptor, error) {
+	desc, _, err := s.ResolveIfChanged(ctx, reference, ocispec.Descriptor{})
+	return desc, err
+}
+
+// ResolveIfChanged resolves the reference unless it still resolves to the
+// last resolved descriptor. See also [Repository.ResolveIfChanged].
+func (s *manifestStore) ResolveIfChanged(ctx context.Context, reference string, last ocispec.Descriptor) (ocispec.Descriptor, bool, error) {
 	ref, err := s.repo.ParseReference(reference)
 	if err != nil {
-		return ocispec.Descriptor{}, err
+		return ocispec.Descriptor{}, false, err
 	}
 	ctx = auth.AppendRepositoryScope(ctx, ref, auth.ActionPull)
 	url := buildRepositoryManifestURL(s.repo.PlainHTTP, ref)
 	req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
 	if err != nil {
-		return ocispec.Descriptor{}, err
+		return ocispec.Descriptor{}, false, err
 	}
 	req.Header.Set("Accept", manifestAcceptHeader(s.repo.ManifestMediaTypes))
+	if last.Digest != "" {
+		// registries use the quoted manifest digest as the entity tag
+		req.Header.Set("If-None-Match", `"`+last.Digest.String()+`"`)
+	}
 
 	resp, err := s.repo.do(req)
 	if err != nil {
-		return ocispec.Descriptor{}, err
+		return ocispec.Descriptor{}, false, err
 	}
 	defer resp.Body.Close()
 
 	switch resp.StatusCode {
 	case http.StatusOK:
-		return s.generateDescriptor(resp, ref, req.Method)
+		desc, err := s.generateDescriptor(resp, ref, req.Method)
+		if err != nil {
+			return ocispec.Descriptor{}, false, err
+		}
+		if last.Digest != "" && desc.Digest == last.Digest {
+			return last, false, nil
+		}
+		return desc, true, nil
+	case http.StatusNotModified:
+		if last.Digest == "" {
+			return ocispec.Descriptor{}, false, errutil.ParseErrorResponse(resp)
+		}
+		return last, false, nil
 	case http.StatusNotFound:
-		return ocispec.Descriptor{}, fmt.Errorf("%s: %w", ref, errdef.ErrNotFound)
+		return ocispec.Descriptor{}, false, fmt.Errorf("%s: %w", ref, errdef.ErrNotFound)
 	default:
-		return ocispec.Descriptor{}, errutil.ParseErrorResponse(resp)
+		return ocispec.Descriptor{}, false, errutil.ParseErrorResponse(resp)
 	}
 }
 
@@ -1275,6 +1417,55 @@ func (s *manifestStore) Tag(ctx context.Context, desc ocispec.Descriptor, refere
 	return s.push(ctx, desc, rc, ref.Reference)
 }
 
+// Untag removes the tag from the repository.
+// The manifest identified by the tag is NOT deleted, and digest references
+// are rejected with ErrInvalidReference.
+// Untag returns ErrUnsupported if the registry does not support tag deletion.
+//
+// Reference: https://github.com/opencontainers/distribution-spec/blob/v1.1.1/spec.md#deleting-tags
+func (s *manifestStore) Untag(ctx context.Context, reference string) error {
+	if reference == "" {
+		return errdef.ErrMissingReference
+	}
+	ref, err := s.repo.ParseReference(reference)
+	if err != nil {
+		return err
+	}
+	if err := ref.ValidateReferenceAsTag(); err != nil {
+		// never delete the manifest by digest
+		return fmt.Errorf("reference %q is not a tag: %w", reference, errdef.ErrInvalidReference)
+	}
+
+	ctx = auth.AppendRepositoryScope(ctx, ref, auth.ActionDelete)
+	url := buildRepositoryManifestURL(s.repo.PlainHTTP, ref)
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, url, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.repo.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusAccepted, http.StatusOK, http.StatusNoContent:
+		return nil
+	case http.StatusNotFound:
+		return fmt.Errorf("%s: %w", ref, errdef.ErrNotFound)
+	case http.StatusMethodNotAllowed:
+		// the registry does not support tag deletion
+		return fmt.Errorf("%s: failed to delete tag: %w: %w", ref, errdef.ErrUnsupported, errutil.ParseErrorResponse(resp))
+	default:
+		err := errutil.ParseErrorResponse(resp)
+		if errutil.IsErrorCode(err, errcode.ErrorCodeUnsupported) {
+			return fmt.Errorf("%s: failed to delete tag: %w: %w", ref, errdef.ErrUnsupported, err)
+		}
+		return err
+	}
+}
+
 // PushReference pushes the manifest with a reference tag.
 func (s *manifestStore) PushReference(ctx context.Context, expected ocispec.Descriptor, content io.Reader, reference string) error {
 	ref, err := s.repo.ParseReference(reference)
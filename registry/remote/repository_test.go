@@ -29,6 +29,7 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"reflect"
+	"slices"
 	"strconv"
 	"strings"
 	"sync/atomic"
@@ -829,6 +830,90 @@ func TestRepository_Delete(t *testing.T) {
 	}
 }
 
+func TestRepository_Untag(t *testing.T) {
+	manifest := []byte(`{"layers":[]}`)
+	manifestDesc := ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Digest:    digest.FromBytes(manifest),
+		Size:      int64(len(manifest)),
+	}
+	var untagged []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			t.Errorf("unexpected access: %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		switch r.URL.Path {
+		case "/v2/test/manifests/foo":
+			untagged = append(untagged, "foo")
+			w.WriteHeader(http.StatusAccepted)
+		case "/v2/test/manifests/bar":
+			w.WriteHeader(http.StatusNotFound)
+		case "/v2/unsupported/manifests/foo":
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		case "/v2/unsupported-code/manifests/foo":
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"errors":[{"code":"UNSUPPORTED","message":"tag deletion is not supported"}]}`))
+		default:
+			t.Errorf("unexpected access: %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+	uri, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatalf("invalid test http server: %v", err)
+	}
+
+	repo, err := NewRepository(uri.Host + "/test")
+	if err != nil {
+		t.Fatalf("NewRepository() error = %v", err)
+	}
+	repo.PlainHTTP = true
+	ctx := context.Background()
+
+	if err := repo.Untag(ctx, "foo"); err != nil {
+		t.Fatalf("Repository.Untag() error = %v", err)
+	}
+	if want := []string{"foo"}; !reflect.DeepEqual(untagged, want) {
+		t.Errorf("Repository.Untag() untagged = %v, want %v", untagged, want)
+	}
+	if err := repo.Untag(ctx, uri.Host+"/test:foo"); err != nil {
+		t.Fatalf("Repository.Untag() error = %v", err)
+	}
+	if err := repo.Untag(ctx, "bar"); !errors.Is(err, errdef.ErrNotFound) {
+		t.Errorf("Repository.Untag() error = %v, wantErr %v", err, errdef.ErrNotFound)
+	}
+
+	// digest references are rejected without accessing the registry
+	if err := repo.Untag(ctx, manifestDesc.Digest.String()); !errors.Is(err, errdef.ErrInvalidReference) {
+		t.Errorf("Repository.Untag() error = %v, wantErr %v", err, errdef.ErrInvalidReference)
+	}
+	if err := repo.Untag(ctx, uri.Host+"/test:foo@"+manifestDesc.Digest.String()); !errors.Is(err, errdef.ErrInvalidReference) {
+		t.Errorf("Repository.Untag() error = %v, wantErr %v", err, errdef.ErrInvalidReference)
+	}
+	if err := repo.Untag(ctx, ""); !errors.Is(err, errdef.ErrMissingReference) {
+		t.Errorf("Repository.Untag() error = %v, wantErr %v", err, errdef.ErrMissingReference)
+	}
+	if err := repo.Untag(ctx, uri.Host+"/other:foo"); !errors.Is(err, errdef.ErrInvalidReference) {
+		t.Errorf("Repository.Untag() error = %v, wantErr %v", err, errdef.ErrInvalidReference)
+	}
+
+	// registries rejecting tag deletion
+	for _, name := range []string{"unsupported", "unsupported-code"} {
+		repo, err := NewRepository(uri.Host + "/" + name)
+		if err != nil {
+			t.Fatalf("NewRepository() error = %v", err)
+		}
+		repo.PlainHTTP = true
+		if err := repo.Untag(ctx, "foo"); !errors.Is(err, errdef.ErrUnsupported) {
+			t.Errorf("Repository.Untag() error = %v, wantErr %v", err, errdef.ErrUnsupported)
+		}
+	}
+}
+
 func TestRepository_Resolve(t *testing.T) {
 	blob := []byte("hello world")
 	blobDesc := ocispec.Descriptor{
@@ -921,6 +1006,84 @@ func TestRepository_Resolve(t *testing.T) {
 	}
 }
 
+func TestRepository_ResolveIfChanged(t *testing.T) {
+	index := []byte(`{"manifests":[]}`)
+	indexDesc := ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageIndex,
+		Digest:    digest.FromBytes(index),
+		Size:      int64(len(index)),
+	}
+	oldIndex := []byte(`{"manifests":[],"annotations":{"foo":"bar"}}`)
+	oldIndexDesc := ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageIndex,
+		Digest:    digest.FromBytes(oldIndex),
+		Size:      int64(len(oldIndex)),
+	}
+	ref := "foobar"
+	var supportETag bool
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodHead || r.URL.Path != "/v2/test/manifests/"+ref {
+			t.Errorf("unexpected access: %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		etag := `"` + indexDesc.Digest.String() + `"`
+		if supportETag && r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Content-Type", indexDesc.MediaType)
+		w.Header().Set("Docker-Content-Digest", indexDesc.Digest.String())
+		w.Header().Set("Content-Length", strconv.Itoa(int(indexDesc.Size)))
+		w.Header().Set("ETag", etag)
+	}))
+	defer ts.Close()
+	uri, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatalf("invalid test http server: %v", err)
+	}
+
+	repo, err := NewRepository(uri.Host + "/test")
+	if err != nil {
+		t.Fatalf("NewRepository() error = %v", err)
+	}
+	repo.PlainHTTP = true
+	ctx := context.Background()
+
+	for _, etag := range []bool{false, true} {
+		supportETag = etag
+
+		// resolve unconditionally
+		got, changed, err := repo.ResolveIfChanged(ctx, ref, ocispec.Descriptor{})
+		if err != nil {
+			t.Fatalf("Repository.ResolveIfChanged() error = %v", err)
+		}
+		if !changed || !reflect.DeepEqual(got, indexDesc) {
+			t.Errorf("Repository.ResolveIfChanged() = %v, %v, want %v, %v", got, changed, indexDesc, true)
+		}
+
+		// resolve with a stale descriptor
+		got, changed, err = repo.ResolveIfChanged(ctx, ref, oldIndexDesc)
+		if err != nil {
+			t.Fatalf("Repository.ResolveIfChanged() error = %v", err)
+		}
+		if !changed || !reflect.DeepEqual(got, indexDesc) {
+			t.Errorf("Repository.ResolveIfChanged() = %v, %v, want %v, %v", got, changed, indexDesc, true)
+		}
+
+		// resolve with the latest descriptor
+		last := indexDesc
+		last.Annotations = map[string]string{"foo": "bar"}
+		got, changed, err = repo.ResolveIfChanged(ctx, ref, last)
+		if err != nil {
+			t.Fatalf("Repository.ResolveIfChanged() error = %v", err)
+		}
+		if changed || !reflect.DeepEqual(got, last) {
+			t.Errorf("Repository.ResolveIfChanged() = %v, %v, want %v, %v", got, changed, last, false)
+		}
+	}
+}
+
 func TestRepository_Tag(t *testing.T) {
 	blob := []byte("hello world")
 	blobDesc := ocispec.Descriptor{
@@ -1265,6 +1428,124 @@ func TestRepository_Tags(t *testing.T) {
 	}
 }
 
+func TestRepository_AllTags(t *testing.T) {
+	tagSet := [][]string{
+		{"the", "quick", "brown", "fox"},
+		{"jumps", "over", "the", "lazy"},
+		{"dog"},
+	}
+	var requests atomic.Int64
+	var ts *httptest.Server
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/v2/test/tags/list" {
+			t.Errorf("unexpected access: %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		requests.Add(1)
+		q := r.URL.Query()
+		var tags []string
+		switch q.Get("test") {
+		case "foo":
+			tags = tagSet[1]
+			w.Header().Set("Link", fmt.Sprintf(`<%s/v2/test/tags/list?n=4&test=bar>; rel="next"`, ts.URL))
+		case "bar":
+			tags = tagSet[2]
+		default:
+			if last := q.Get("last"); last != "" {
+				if last != "fox" {
+					t.Errorf("unexpected last: %s", last)
+				}
+				tags = tagSet[1]
+				w.Header().Set("Link", `</v2/test/tags/list?n=4&test=bar>; rel="next"`)
+				break
+			}
+			tags = tagSet[0]
+			w.Header().Set("Link", `</v2/test/tags/list?n=4&test=foo>; rel="next"`)
+		}
+		result := struct {
+			Tags []string `json:"tags"`
+		}{
+			Tags: tags,
+		}
+		if err := json.NewEncoder(w).Encode(result); err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	}))
+	defer ts.Close()
+	uri, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatalf("invalid test http server: %v", err)
+	}
+
+	repo, err := NewRepository(uri.Host + "/test")
+	if err != nil {
+		t.Fatalf("NewRepository() error = %v", err)
+	}
+	repo.PlainHTTP = true
+	ctx := context.Background()
+
+	// test iterating all tags
+	var got []string
+	for tag, err := range repo.AllTags(ctx, "") {
+		if err != nil {
+			t.Fatalf("Repository.AllTags() error = %v", err)
+		}
+		got = append(got, tag)
+	}
+	want := slices.Concat(tagSet...)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Repository.AllTags() = %v, want %v", got, want)
+	}
+	if got, want := requests.Load(), int64(3); got != want {
+		t.Errorf("requests = %d, want %d", got, want)
+	}
+
+	// test breaking early
+	requests.Store(0)
+	got = nil
+	for tag, err := range repo.AllTags(ctx, "") {
+		if err != nil {
+			t.Fatalf("Repository.AllTags() error = %v", err)
+		}
+		got = append(got, tag)
+		if tag == "jumps" {
+			break
+		}
+	}
+	want = []string{"the", "quick", "brown", "fox", "jumps"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Repository.AllTags() = %v, want %v", got, want)
+	}
+	if got, want := requests.Load(), int64(2); got != want {
+		t.Errorf("requests = %d, want %d", got, want)
+	}
+
+	// test iterating after last
+	got = nil
+	for tag, err := range repo.AllTags(ctx, "fox") {
+		if err != nil {
+			t.Fatalf("Repository.AllTags() error = %v", err)
+		}
+		got = append(got, tag)
+	}
+	want = slices.Concat(tagSet[1:]...)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Repository.AllTags() = %v, want %v", got, want)
+	}
+
+	// test cancelled context
+	cancelCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	var gotErr error
+	for _, err := range repo.AllTags(cancelCtx, "") {
+		gotErr = err
+	}
+	if !errors.Is(gotErr, context.Canceled) {
+		t.Errorf("Repository.AllTags() error = %v, wantErr %v", gotErr, context.Canceled)
+	}
+}
+
 func TestRepository_Predecessors(t *testing.T) {
 	manifest := []byte(`{"layers":[]}`)
 	manifestDesc := ocispec.Descriptor{
@@ -2553,6 +2834,102 @@ func TestRepository_Referrers_TagSchemaFallback_ClientFiltering(t *testing.T) {
 	}
 }
 
+func TestRepository_Referrers_CosignTagReferrers(t *testing.T) {
+	manifest := []byte(`{"layers":[]}`)
+	manifestDesc := ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Digest:    digest.FromBytes(manifest),
+		Size:      int64(len(manifest)),
+	}
+	referrer := ocispec.Descriptor{
+		MediaType:    ocispec.MediaTypeImageManifest,
+		Size:         1,
+		Digest:       digest.FromString("1"),
+		ArtifactType: "application/vnd.test",
+	}
+	signature := ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Size:      2,
+		Digest:    digest.FromString("2"),
+	}
+	cosignTag := strings.Replace(manifestDesc.Digest.String(), ":", "-", 1)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var desc ocispec.Descriptor
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/v2/test/referrers/"+manifestDesc.Digest.String():
+			result := ocispec.Index{
+				Versioned: specs.Versioned{
+					SchemaVersion: 2, // historical value. does not pertain to OCI or docker version
+				},
+				MediaType: ocispec.MediaTypeImageIndex,
+				Manifests: []ocispec.Descriptor{referrer},
+			}
+			w.Header().Set("Content-Type", ocispec.MediaTypeImageIndex)
+			if err := json.NewEncoder(w).Encode(result); err != nil {
+				t.Errorf("failed to write response: %v", err)
+			}
+			return
+		case r.Method == http.MethodHead && r.URL.Path == "/v2/test/manifests/"+cosignTag+".sig":
+			desc = signature
+		case r.Method == http.MethodHead && r.URL.Path == "/v2/test/manifests/"+cosignTag+".att":
+			// the attestation is also discovered by the Referrers API
+			desc = referrer
+		case r.Method == http.MethodHead && r.URL.Path == "/v2/test/manifests/"+cosignTag+".sbom":
+			w.WriteHeader(http.StatusNotFound)
+			return
+		default:
+			t.Errorf("unexpected access: %s %q", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", desc.MediaType)
+		w.Header().Set("Docker-Content-Digest", desc.Digest.String())
+		w.Header().Set("Content-Length", strconv.FormatInt(desc.Size, 10))
+	}))
+	defer ts.Close()
+	uri, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatalf("invalid test http server: %v", err)
+	}
+
+	repo, err := NewRepository(uri.Host + "/test")
+	if err != nil {
+		t.Fatalf("NewRepository() error = %v", err)
+	}
+	repo.PlainHTTP = true
+	repo.CosignTagReferrers = true
+	ctx := context.Background()
+
+	var got []ocispec.Descriptor
+	if err := repo.Referrers(ctx, manifestDesc, "", func(referrers []ocispec.Descriptor) error {
+		got = append(got, referrers...)
+		return nil
+	}); err != nil {
+		t.Fatalf("Repository.Referrers() error = %v", err)
+	}
+	wantSignature := signature
+	wantSignature.ArtifactType = registry.ArtifactTypeCosignSignature
+	wantSignature.Annotations = map[string]string{
+		ocispec.AnnotationRefName: cosignTag + ".sig",
+	}
+	want := []ocispec.Descriptor{referrer, wantSignature}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Repository.Referrers() = %v, want %v", got, want)
+	}
+
+	// test filtering by artifact type
+	got = nil
+	if err := repo.Referrers(ctx, manifestDesc, registry.ArtifactTypeCosignSignature, func(referrers []ocispec.Descriptor) error {
+		got = append(got, referrers...)
+		return nil
+	}); err != nil {
+		t.Fatalf("Repository.Referrers() error = %v", err)
+	}
+	if want := []ocispec.Descriptor{wantSignature}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Repository.Referrers() = %v, want %v", got, want)
+	}
+}
+
 func TestRepository_BadDigest(t *testing.T) {
 	data := []byte("hello world")
 	invalidDesc := ocispec.Descriptor{
@@ -2789,6 +3166,54 @@ func Test_BlobStore_Fetch(t *testing.T) {
 	}
 }
 
+func Test_BlobStore_Fetch_EmbeddedData(t *testing.T) {
+	blob := []byte("hello world")
+	blobDesc := ocispec.Descriptor{
+		MediaType: "test",
+		Digest:    digest.FromBytes(blob),
+		Size:      int64(len(blob)),
+		Data:      blob,
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected access: %s %s", r.Method, r.URL)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer ts.Close()
+	uri, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatalf("invalid test http server: %v", err)
+	}
+
+	repo, err := NewRepository(uri.Host + "/test")
+	if err != nil {
+		t.Fatalf("NewRepository() error = %v", err)
+	}
+	repo.PlainHTTP = true
+	store := repo.Blobs()
+	ctx := context.Background()
+
+	rc, err := store.Fetch(ctx, blobDesc)
+	if err != nil {
+		t.Fatalf("Blobs.Fetch() error = %v", err)
+	}
+	got, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatalf("Blobs.Fetch().Read() error = %v", err)
+	}
+	if err := rc.Close(); err != nil {
+		t.Errorf("Blobs.Fetch().Close() error = %v", err)
+	}
+	if !bytes.Equal(got, blob) {
+		t.Errorf("Blobs.Fetch() = %v, want %v", got, blob)
+	}
+
+	// test mismatched embedded data
+	blobDesc.Data = []byte("hello World")
+	if _, err := store.Fetch(ctx, blobDesc); !errors.Is(err, content.ErrMismatchedDigest) {
+		t.Errorf("Blobs.Fetch() error = %v, wantErr %v", err, content.ErrMismatchedDigest)
+	}
+}
+
 func Test_BlobStore_Fetch_Seek(t *testing.T) {
 	blob := []byte("hello world")
 	blobDesc := ocispec.Descriptor{
@@ -8386,3 +8811,70 @@ func Test_generateBlobDescriptor(t *testing.T) {
 		})
 	}
 }
+
+func TestNewRepository_NormalizedReference(t *testing.T) {
+	manifest := []byte(`{"layers":[]}`)
+	manifestDesc := ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Digest:    digest.FromBytes(manifest),
+		Size:      int64(len(manifest)),
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodHead || r.URL.Path != "/v2/library/ubuntu/manifests/latest" {
+			t.Errorf("unexpected access: %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", manifestDesc.MediaType)
+		w.Header().Set("Docker-Content-Digest", manifestDesc.Digest.String())
+		w.Header().Set("Content-Length", strconv.Itoa(int(manifestDesc.Size)))
+	}))
+	defer ts.Close()
+	uri, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatalf("invalid test http server: %v", err)
+	}
+
+	for _, artifact := range []string{
+		"ubuntu",
+		"docker.io/ubuntu",
+		"index.docker.io/library/ubuntu",
+		"registry-1.docker.io/library/ubuntu:latest",
+	} {
+		t.Run(artifact, func(t *testing.T) {
+			ref, err := registry.ParseNormalizedReference(artifact)
+			if err != nil {
+				t.Fatalf("ParseNormalizedReference() error = %v", err)
+			}
+			if got, want := ref.String(), "docker.io/library/ubuntu:latest"; got != want {
+				t.Errorf("Reference.String() = %v, want %v", got, want)
+			}
+			if got, want := ref.Host(), "registry-1.docker.io"; got != want {
+				t.Errorf("Reference.Host() = %v, want %v", got, want)
+			}
+
+			// the requests are sent to the API host of Docker Hub
+			repo, err := NewRepository(ref.String())
+			if err != nil {
+				t.Fatalf("NewRepository() error = %v", err)
+			}
+			repo.Client = &auth.Client{
+				Client: &http.Client{
+					Transport: &testTransport{
+						proxyHost:           uri.Host,
+						underlyingTransport: http.DefaultTransport,
+						mockHost:            ref.Host(),
+					},
+				},
+			}
+			repo.PlainHTTP = true
+			got, err := repo.Resolve(context.Background(), ref.Reference)
+			if err != nil {
+				t.Fatalf("Repository.Resolve() error = %v", err)
+			}
+			if !content.Equal(got, manifestDesc) {
+				t.Errorf("Repository.Resolve() = %v, want %v", got, manifestDesc)
+			}
+		})
+	}
+}
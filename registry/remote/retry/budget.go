@@ -0,0 +1,150 @@
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package retry
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Default parameters of Budget.
+const (
+	defaultBudgetRatio      = 0.2
+	defaultBudgetMinRetries = 10
+	defaultBudgetWindow     = 10 * time.Second
+)
+
+// budgetBuckets is the number of buckets of the sliding window of Budget.
+const budgetBuckets = 10
+
+// minBudgetWindow is the minimum window of Budget, which keeps every bucket
+// of the sliding window at least a nanosecond long.
+const minBudgetWindow time.Duration = budgetBuckets * time.Nanosecond
+
+// Budget is a retry budget, which limits the number of retries to a ratio of
+// the recent successful requests. It prevents retries from multiplying the
+// traffic against an unhealthy registry.
+//
+// A retry is allowed if the number of retries in the sliding window, including
+// the retry itself, does not exceed
+//
+//	MinRetries + Ratio * (number of successful requests in the window)
+//
+// A request is successful if it results in neither an error, a 5xx response
+// nor a 429 response.
+//
+// A Budget is safe for concurrent use and can be shared by multiple
+// transports. Its zero value is a usable budget with the default parameters.
+type Budget struct {
+	// Ratio is the maximum ratio of retries to successful requests.
+	// If zero or negative, 0.2 is used.
+	Ratio float64
+
+	// MinRetries is the number of retries allowed in the window regardless of
+	// the number of successful requests.
+	// If zero, 10 is used. If negative, no retries are allowed without
+	// successful requests.
+	MinRetries int
+
+	// Window is the length of the sliding window.
+	// If zero or negative, 10 seconds is used.
+	Window time.Duration
+
+	lock    sync.Mutex
+	buckets [budgetBuckets]budgetBucket
+}
+
+// budgetBucket counts requests in a time slot of the sliding window.
+type budgetBucket struct {
+	slot      int64
+	successes int
+	retries   int
+}
+
+// deposit records the result of a request.
+func (b *Budget) deposit(resp *http.Response, err error, now time.Time) {
+	if err != nil || resp.StatusCode == 0 || resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests {
+		return
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.bucket(now).successes++
+}
+
+// withdraw returns true if a retry is allowed by the budget and records the
+// retry.
+func (b *Budget) withdraw(now time.Time) bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	current := b.bucket(now)
+	oldest := current.slot - budgetBuckets + 1
+	var successes, retries int
+	for _, bucket := range b.buckets {
+		if bucket.slot >= oldest {
+			successes += bucket.successes
+			retries += bucket.retries
+		}
+	}
+	if float64(retries+1) > float64(b.minRetries())+b.ratio()*float64(successes) {
+		return false
+	}
+	current.retries++
+	return true
+}
+
+// bucket returns the bucket of the time slot of now, resetting the bucket if
+// it is stale. The caller must hold b.lock.
+func (b *Budget) bucket(now time.Time) *budgetBucket {
+	slotSize := b.window() / budgetBuckets
+	slot := now.UnixNano() / int64(slotSize)
+	bucket := &b.buckets[slot%budgetBuckets]
+	if bucket.slot != slot {
+		*bucket = budgetBucket{slot: slot}
+	}
+	return bucket
+}
+
+func (b *Budget) ratio() float64 {
+	if b.Ratio <= 0 {
+		return defaultBudgetRatio
+	}
+	return b.Ratio
+}
+
+func (b *Budget) minRetries() int {
+	switch {
+	case b.MinRetries == 0:
+		return defaultBudgetMinRetries
+	case b.MinRetries < 0:
+		return 0
+	default:
+		return b.MinRetries
+	}
+}
+
+func (b *Budget) window() time.Duration {
+	switch {
+	case b.Window <= 0:
+		return defaultBudgetWindow
+	case b.Window < minBudgetWindow:
+		return minBudgetWindow
+	default:
+		return b.Window
+	}
+}
@@ -0,0 +1,170 @@
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package retry
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBudget(t *testing.T) {
+	b := &Budget{
+		Ratio:      0.5,
+		MinRetries: 1,
+		Window:     10 * time.Second,
+	}
+	now := time.Now()
+	success := &http.Response{StatusCode: http.StatusOK}
+	failure := &http.Response{StatusCode: http.StatusServiceUnavailable}
+
+	// only the minimum retries are allowed without successful requests
+	b.deposit(failure, nil, now)
+	if !b.withdraw(now) {
+		t.Fatal("Budget.withdraw() = false, want true")
+	}
+	if b.withdraw(now) {
+		t.Fatal("Budget.withdraw() = true, want false")
+	}
+
+	// successful requests earn retries
+	for i := 0; i < 4; i++ {
+		b.deposit(success, nil, now)
+	}
+	for i := 0; i < 2; i++ {
+		if !b.withdraw(now) {
+			t.Fatalf("Budget.withdraw() #%d = false, want true", i)
+		}
+	}
+	if b.withdraw(now) {
+		t.Fatal("Budget.withdraw() = true, want false")
+	}
+
+	// the window slides
+	now = now.Add(10 * time.Second)
+	if !b.withdraw(now) {
+		t.Fatal("Budget.withdraw() after the window = false, want true")
+	}
+	if b.withdraw(now) {
+		t.Fatal("Budget.withdraw() = true, want false")
+	}
+}
+
+func TestTransport_Budget(t *testing.T) {
+	var count int64
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&count, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	client := &http.Client{
+		Transport: &Transport{
+			Policy: func() Policy {
+				return &GenericPolicy{
+					Retryable: DefaultPredicate,
+					Backoff:   DefaultBackoff,
+					MaxRetry:  5,
+				}
+			},
+			Budget: &Budget{
+				MinRetries: 2,
+			},
+		},
+	}
+
+	// 1 request + 2 retries allowed by the budget
+	resp, err := client.Get(ts.URL)
+	if err != nil {
+		t.Fatalf("Client.Get() error = %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("unexpected status code: %d, want %d", resp.StatusCode, http.StatusServiceUnavailable)
+	}
+	if count != 3 {
+		t.Errorf("unexpected number of requests: %d, want %d", count, 3)
+	}
+
+	// no retries as the budget is exhausted
+	resp, err = client.Get(ts.URL)
+	if err != nil {
+		t.Fatalf("Client.Get() error = %v", err)
+	}
+	resp.Body.Close()
+	if count != 4 {
+		t.Errorf("unexpected number of requests: %d, want %d", count, 4)
+	}
+}
+
+func TestTransport_Budget_unrewindableBody(t *testing.T) {
+	var count int64
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&count, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	budget := &Budget{
+		MinRetries: 1,
+	}
+	client := &http.Client{
+		Transport: &Transport{
+			Budget: budget,
+		},
+	}
+
+	// the retry cannot be sent as the body cannot be rewound
+	req, err := http.NewRequest(http.MethodPost, ts.URL, io.NopCloser(strings.NewReader("hello")))
+	if err != nil {
+		t.Fatalf("failed to create test request: %v", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Client.Do() error = %v", err)
+	}
+	resp.Body.Close()
+	if count != 1 {
+		t.Errorf("unexpected number of requests: %d, want %d", count, 1)
+	}
+
+	// the budget is not spent
+	if !budget.withdraw(time.Now()) {
+		t.Error("Budget.withdraw() = false, want true")
+	}
+}
+
+func TestBudget_window(t *testing.T) {
+	tests := []struct {
+		window time.Duration
+		want   time.Duration
+	}{
+		{window: 0, want: defaultBudgetWindow},
+		{window: -time.Second, want: defaultBudgetWindow},
+		{window: time.Nanosecond, want: minBudgetWindow},
+		{window: time.Minute, want: time.Minute},
+	}
+	for _, tt := range tests {
+		b := &Budget{Window: tt.window}
+		if got := b.window(); got != tt.want {
+			t.Errorf("Budget.window() with Window %v = %v, want %v", tt.window, got, tt.want)
+		}
+	}
+}
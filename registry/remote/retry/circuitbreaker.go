@@ -0,0 +1,239 @@
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Default parameters of CircuitBreaker.
+const (
+	defaultFailureThreshold = 5
+	defaultOpenTimeout      = 30 * time.Second
+	defaultHalfOpenRequests = 1
+)
+
+// CircuitState is the state of a circuit.
+type CircuitState int
+
+// Circuit states.
+const (
+	// CircuitClosed indicates that requests are allowed.
+	CircuitClosed CircuitState = iota
+
+	// CircuitOpen indicates that requests are rejected.
+	CircuitOpen
+
+	// CircuitHalfOpen indicates that a limited number of probe requests are
+	// allowed to determine whether the host has recovered.
+	CircuitHalfOpen
+)
+
+// String returns the string representation of the state.
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return fmt.Sprintf("CircuitState(%d)", int(s))
+	}
+}
+
+// CircuitOpenError is returned by [Transport] when a request is rejected
+// because the circuit of the host is open.
+type CircuitOpenError struct {
+	// Host is the host (i.e. host:port) of the rejected request.
+	Host string
+
+	// Until is the time when the circuit becomes half-open.
+	Until time.Time
+}
+
+// Error returns the error message.
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("circuit breaker is open for %s until %s", e.Host, e.Until.Format(time.RFC3339))
+}
+
+// CircuitBreaker is a per-host circuit breaker, which stops sending requests
+// to a host after consecutive failures.
+//
+// The circuit of a host is initially closed. It opens after FailureThreshold
+// consecutive failures, and rejects requests for OpenTimeout. Then it becomes
+// half-open and allows up to HalfOpenRequests probe requests. A successful
+// probe closes the circuit while a failed probe opens it again.
+//
+// A request fails if it results in an error or a 5xx response.
+//
+// A CircuitBreaker is safe for concurrent use and can be shared by multiple
+// transports. Its zero value is a usable circuit breaker with the default
+// parameters.
+type CircuitBreaker struct {
+	// FailureThreshold is the number of consecutive failures to open the
+	// circuit.
+	// If zero or negative, 5 is used.
+	FailureThreshold int
+
+	// OpenTimeout is the duration for an open circuit to become half-open.
+	// If zero or negative, 30 seconds is used.
+	OpenTimeout time.Duration
+
+	// HalfOpenRequests is the maximum number of concurrent probe requests
+	// allowed when the circuit is half-open.
+	// If zero or negative, 1 is used.
+	HalfOpenRequests int
+
+	lock     sync.Mutex
+	circuits map[string]*circuit
+}
+
+// circuit is the circuit of a host.
+type circuit struct {
+	state    CircuitState
+	failures int
+	openedAt time.Time
+	probes   int
+}
+
+// State returns the state of the circuit of the given host (i.e. host:port).
+func (cb *CircuitBreaker) State(host string) CircuitState {
+	cb.lock.Lock()
+	defer cb.lock.Unlock()
+
+	c, ok := cb.circuits[host]
+	if !ok {
+		return CircuitClosed
+	}
+	if c.state == CircuitOpen && time.Since(c.openedAt) >= cb.openTimeout() {
+		return CircuitHalfOpen
+	}
+	return c.state
+}
+
+// allow returns a *CircuitOpenError if a request to the host is not allowed.
+func (cb *CircuitBreaker) allow(host string, now time.Time) error {
+	cb.lock.Lock()
+	defer cb.lock.Unlock()
+
+	c := cb.circuit(host)
+	if c.state == CircuitOpen {
+		until := c.openedAt.Add(cb.openTimeout())
+		if now.Before(until) {
+			return &CircuitOpenError{
+				Host:  host,
+				Until: until,
+			}
+		}
+		c.state = CircuitHalfOpen
+		c.probes = 0
+	}
+	if c.state == CircuitHalfOpen {
+		if c.probes >= cb.halfOpenRequests() {
+			return &CircuitOpenError{
+				Host:  host,
+				Until: now,
+			}
+		}
+		c.probes++
+	}
+	return nil
+}
+
+// record records the result of a request to the host.
+func (cb *CircuitBreaker) record(host string, resp *http.Response, err error, now time.Time) {
+	cb.lock.Lock()
+	defer cb.lock.Unlock()
+
+	c := cb.circuit(host)
+	if isCanceled(err) {
+		// the caller gave up on the request, which says nothing about the
+		// health of the host. Release the probe slot if any.
+		if c.state == CircuitHalfOpen && c.probes > 0 {
+			c.probes--
+		}
+		return
+	}
+	if !isFailure(resp, err) {
+		c.state = CircuitClosed
+		c.failures = 0
+		return
+	}
+	switch c.state {
+	case CircuitClosed:
+		c.failures++
+		if c.failures >= cb.failureThreshold() {
+			c.state = CircuitOpen
+			c.openedAt = now
+		}
+	case CircuitHalfOpen:
+		c.state = CircuitOpen
+		c.openedAt = now
+	}
+}
+
+// circuit returns the circuit of the host. The caller must hold cb.lock.
+func (cb *CircuitBreaker) circuit(host string) *circuit {
+	if c, ok := cb.circuits[host]; ok {
+		return c
+	}
+	if cb.circuits == nil {
+		cb.circuits = make(map[string]*circuit)
+	}
+	c := &circuit{}
+	cb.circuits[host] = c
+	return c
+}
+
+func (cb *CircuitBreaker) failureThreshold() int {
+	if cb.FailureThreshold <= 0 {
+		return defaultFailureThreshold
+	}
+	return cb.FailureThreshold
+}
+
+func (cb *CircuitBreaker) openTimeout() time.Duration {
+	if cb.OpenTimeout <= 0 {
+		return defaultOpenTimeout
+	}
+	return cb.OpenTimeout
+}
+
+func (cb *CircuitBreaker) halfOpenRequests() int {
+	if cb.HalfOpenRequests <= 0 {
+		return defaultHalfOpenRequests
+	}
+	return cb.HalfOpenRequests
+}
+
+// isCanceled returns true if the request is canceled or timed out by the
+// context of the caller.
+func isCanceled(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// isFailure returns true if the request results in an error or a 5xx
+// response, which indicates that the host is unhealthy.
+func isFailure(resp *http.Response, err error) bool {
+	return err != nil || resp.StatusCode == 0 || resp.StatusCode >= 500
+}
@@ -0,0 +1,193 @@
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package retry
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker_transitions(t *testing.T) {
+	cb := &CircuitBreaker{
+		FailureThreshold: 2,
+		OpenTimeout:      time.Minute,
+	}
+	host := "registry.example.com"
+	failure := &http.Response{StatusCode: http.StatusServiceUnavailable}
+	success := &http.Response{StatusCode: http.StatusOK}
+	now := time.Now()
+
+	// closed
+	for i := 0; i < 2; i++ {
+		if err := cb.allow(host, now); err != nil {
+			t.Fatalf("CircuitBreaker.allow() error = %v", err)
+		}
+		if got := cb.State(host); got != CircuitClosed {
+			t.Fatalf("CircuitBreaker.State() = %v, want %v", got, CircuitClosed)
+		}
+		cb.record(host, failure, nil, now)
+	}
+
+	// open
+	if got := cb.State(host); got != CircuitOpen {
+		t.Fatalf("CircuitBreaker.State() = %v, want %v", got, CircuitOpen)
+	}
+	err := cb.allow(host, now.Add(time.Second))
+	var openErr *CircuitOpenError
+	if !errors.As(err, &openErr) {
+		t.Fatalf("CircuitBreaker.allow() error = %v, want %T", err, openErr)
+	}
+	if openErr.Host != host || !openErr.Until.Equal(now.Add(time.Minute)) {
+		t.Errorf("CircuitBreaker.allow() error = %v", openErr)
+	}
+	if err := cb.allow("other.example.com", now); err != nil {
+		t.Errorf("CircuitBreaker.allow() for other host error = %v", err)
+	}
+
+	// half-open allows a single probe
+	now = now.Add(time.Minute)
+	if err := cb.allow(host, now); err != nil {
+		t.Fatalf("CircuitBreaker.allow() error = %v", err)
+	}
+	if err := cb.allow(host, now); !errors.As(err, &openErr) {
+		t.Fatalf("CircuitBreaker.allow() error = %v, want %T", err, openErr)
+	}
+
+	// failed probe opens the circuit again
+	cb.record(host, nil, errors.New("connection refused"), now)
+	if err := cb.allow(host, now.Add(time.Second)); !errors.As(err, &openErr) {
+		t.Fatalf("CircuitBreaker.allow() error = %v, want %T", err, openErr)
+	}
+
+	// successful probe closes the circuit
+	now = now.Add(time.Minute)
+	if err := cb.allow(host, now); err != nil {
+		t.Fatalf("CircuitBreaker.allow() error = %v", err)
+	}
+	cb.record(host, success, nil, now)
+	if got := cb.State(host); got != CircuitClosed {
+		t.Fatalf("CircuitBreaker.State() = %v, want %v", got, CircuitClosed)
+	}
+}
+
+func TestCircuitBreaker_canceled(t *testing.T) {
+	cb := &CircuitBreaker{
+		FailureThreshold: 1,
+		OpenTimeout:      time.Minute,
+	}
+	host := "registry.example.com"
+	now := time.Now()
+
+	// canceled requests are not failures
+	for _, err := range []error{context.Canceled, &url.Error{Op: "Get", Err: context.DeadlineExceeded}} {
+		if err := cb.allow(host, now); err != nil {
+			t.Fatalf("CircuitBreaker.allow() error = %v", err)
+		}
+		cb.record(host, nil, err, now)
+		if got := cb.State(host); got != CircuitClosed {
+			t.Fatalf("CircuitBreaker.State() = %v, want %v", got, CircuitClosed)
+		}
+	}
+
+	// canceled probes release their slots
+	cb.record(host, nil, errors.New("connection refused"), now)
+	now = now.Add(time.Minute)
+	if err := cb.allow(host, now); err != nil {
+		t.Fatalf("CircuitBreaker.allow() error = %v", err)
+	}
+	cb.record(host, nil, context.Canceled, now)
+	if got := cb.State(host); got != CircuitHalfOpen {
+		t.Fatalf("CircuitBreaker.State() = %v, want %v", got, CircuitHalfOpen)
+	}
+	if err := cb.allow(host, now); err != nil {
+		t.Fatalf("CircuitBreaker.allow() error = %v", err)
+	}
+}
+
+func TestTransport_CircuitBreaker(t *testing.T) {
+	var count int64
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&count, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+	uri, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatalf("invalid test http server: %v", err)
+	}
+
+	cb := &CircuitBreaker{
+		FailureThreshold: 3,
+	}
+	client := &http.Client{
+		Transport: &Transport{
+			Policy: func() Policy {
+				return &GenericPolicy{
+					Retryable: DefaultPredicate,
+					Backoff:   DefaultBackoff,
+					MaxRetry:  5,
+				}
+			},
+			CircuitBreaker: cb,
+		},
+	}
+
+	_, err = client.Get(ts.URL)
+	var openErr *CircuitOpenError
+	if !errors.As(err, &openErr) {
+		t.Fatalf("Client.Get() error = %v, want %T", err, openErr)
+	}
+	if openErr.Host != uri.Host {
+		t.Errorf("CircuitOpenError.Host = %v, want %v", openErr.Host, uri.Host)
+	}
+	if count != 3 {
+		t.Errorf("unexpected number of requests: %d, want %d", count, 3)
+	}
+	if got := cb.State(uri.Host); got != CircuitOpen {
+		t.Errorf("CircuitBreaker.State() = %v, want %v", got, CircuitOpen)
+	}
+
+	// subsequent requests are rejected without reaching the server
+	if _, err := client.Get(ts.URL); !errors.As(err, &openErr) {
+		t.Fatalf("Client.Get() error = %v, want %T", err, openErr)
+	}
+	if count != 3 {
+		t.Errorf("unexpected number of requests: %d, want %d", count, 3)
+	}
+}
+
+func TestCircuitState_String(t *testing.T) {
+	tests := []struct {
+		state CircuitState
+		want  string
+	}{
+		{CircuitClosed, "closed"},
+		{CircuitOpen, "open"},
+		{CircuitHalfOpen, "half-open"},
+		{CircuitState(42), "CircuitState(42)"},
+	}
+	for _, tt := range tests {
+		if got := tt.state.String(); got != tt.want {
+			t.Errorf("CircuitState.String() = %v, want %v", got, tt.want)
+		}
+	}
+}
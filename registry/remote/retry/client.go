@@ -16,6 +16,7 @@ limitations under the License.
 package retry
 
 import (
+	"io"
 	"net/http"
 	"time"
 )
@@ -39,6 +40,18 @@ type Transport struct {
 	// Policy returns a retry Policy to use for the request.
 	// If nil, DefaultPolicy is used to determine if the request should be retried.
 	Policy func() Policy
+
+	// CircuitBreaker stops sending requests to a host after consecutive
+	// failures. Requests rejected by the circuit breaker fail with
+	// *CircuitOpenError.
+	// If nil, no circuit breaker is used.
+	CircuitBreaker *CircuitBreaker
+
+	// Budget limits the retries to a ratio of the recent successful requests.
+	// When the budget is exhausted, the response of the last attempt is
+	// returned without further retries.
+	// If nil, retries are limited by Policy only.
+	Budget *Budget
 }
 
 // NewTransport creates an HTTP Transport with the default retry policy.
@@ -52,12 +65,26 @@ func NewTransport(base http.RoundTripper) *Transport {
 // provided Request.
 // It relies on the configured Policy to determine if the request should be
 // retried and to backoff.
+// If CircuitBreaker is configured, it returns *CircuitOpenError when the
+// circuit of the host is open.
 func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
 	ctx := req.Context()
 	policy := t.policy()
+	host := req.URL.Host
 	attempt := 0
 	for {
+		if t.CircuitBreaker != nil {
+			if err := t.CircuitBreaker.allow(host, time.Now()); err != nil {
+				return nil, err
+			}
+		}
 		resp, respErr := t.roundTrip(req)
+		if t.CircuitBreaker != nil {
+			t.CircuitBreaker.record(host, resp, respErr, time.Now())
+		}
+		if t.Budget != nil {
+			t.Budget.deposit(resp, respErr, time.Now())
+		}
 		duration, err := policy.Retry(attempt, resp, respErr)
 		if err != nil {
 			if respErr == nil {
@@ -70,16 +97,28 @@ func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
 		}
 
 		// rewind the body if possible
+		var body io.ReadCloser
 		if req.Body != nil {
 			if req.GetBody == nil {
 				// body can't be rewound, so we can't retry
 				return resp, respErr
 			}
-			body, err := req.GetBody()
+			body, err = req.GetBody()
 			if err != nil {
 				// failed to rewind the body, so we can't retry
 				return resp, respErr
 			}
+		}
+
+		// spend the retry budget only on retries that can be sent
+		if t.Budget != nil && !t.Budget.withdraw(time.Now()) {
+			// retry budget exhausted
+			if body != nil {
+				body.Close()
+			}
+			return resp, respErr
+		}
+		if body != nil {
 			req.Body = body
 		}
 
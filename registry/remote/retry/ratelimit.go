@@ -0,0 +1,422 @@
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package retry
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Rate limit headers.
+// References:
+//   - https://docs.docker.com/docker-hub/download-rate-limit/
+//   - https://datatracker.ietf.org/doc/draft-ietf-httpapi-ratelimit-headers/
+const (
+	headerRateLimitLimit      = "RateLimit-Limit"
+	headerRateLimitRemaining  = "RateLimit-Remaining"
+	headerRateLimitReset      = "RateLimit-Reset"
+	headerXRateLimitLimit     = "X-RateLimit-Limit"
+	headerXRateLimitRemaining = "X-RateLimit-Remaining"
+	headerXRateLimitReset     = "X-RateLimit-Reset"
+)
+
+// hostIdleTimeout is the duration after which the rate limiter of a host
+// without activity is evicted, if it no longer delays any request.
+const hostIdleTimeout = 10 * time.Minute
+
+// quotaProbeInterval is the maximum duration to pause the requests to a host
+// whose quota is exhausted without an advertised reset time, after which the
+// host is probed for the recovered quota.
+const quotaProbeInterval = time.Minute
+
+// minUnixResetTime is the minimum value of a reset header to be considered as
+// a Unix timestamp instead of a number of seconds.
+const minUnixResetTime = 1000000000
+
+// RateLimit is the request quota advertised by a registry.
+type RateLimit struct {
+	// Limit is the maximum number of requests allowed in the window.
+	Limit int
+
+	// Remaining is the number of requests remaining in the window.
+	Remaining int
+
+	// Window is the length of the window.
+	// It is zero if the registry does not advertise the window.
+	Window time.Duration
+
+	// Reset is the time when the quota is reset.
+	// It is zero if the registry does not advertise the reset time.
+	Reset time.Time
+}
+
+// ParseRateLimit parses the rate limit headers of a response. It returns false
+// if the response does not advertise the remaining quota.
+//
+// Both the "RateLimit-*" headers and the "X-RateLimit-*" headers are
+// recognized, where the values may carry a window in the form of
+// "100;w=21600" as used by Docker Hub. The reset header is interpreted as a
+// number of seconds or, if large enough, as a Unix timestamp.
+func ParseRateLimit(header http.Header) (RateLimit, bool) {
+	return parseRateLimit(header, time.Now())
+}
+
+// parseRateLimit parses the rate limit headers relative to now.
+func parseRateLimit(header http.Header, now time.Time) (RateLimit, bool) {
+	remaining, window, ok := parseRateLimitValue(header, headerRateLimitRemaining, headerXRateLimitRemaining)
+	if !ok {
+		return RateLimit{}, false
+	}
+	rl := RateLimit{
+		Remaining: remaining,
+		Window:    window,
+	}
+	if limit, window, ok := parseRateLimitValue(header, headerRateLimitLimit, headerXRateLimitLimit); ok {
+		rl.Limit = limit
+		if rl.Window == 0 {
+			rl.Window = window
+		}
+	}
+	if reset, _, ok := parseRateLimitValue(header, headerRateLimitReset, headerXRateLimitReset); ok {
+		if reset >= minUnixResetTime {
+			rl.Reset = time.Unix(int64(reset), 0)
+		} else {
+			rl.Reset = now.Add(time.Duration(reset) * time.Second)
+		}
+	}
+	return rl, true
+}
+
+// parseRateLimitValue parses the first present header among the given keys
+// in the form of "<value>" or "<value>;w=<window in seconds>".
+func parseRateLimitValue(header http.Header, keys ...string) (int, time.Duration, bool) {
+	for _, key := range keys {
+		v := header.Get(key)
+		if v == "" {
+			continue
+		}
+		v, params, _ := strings.Cut(v, ";")
+		value, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil || value < 0 {
+			return 0, 0, false
+		}
+		var window time.Duration
+		for _, param := range strings.Split(params, ";") {
+			k, v, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if k != "w" {
+				continue
+			}
+			if seconds, err := strconv.Atoi(v); err == nil && seconds > 0 {
+				window = time.Duration(seconds) * time.Second
+			}
+		}
+		return value, window, true
+	}
+	return 0, 0, false
+}
+
+// RateLimitTransport is an HTTP transport that paces requests per host.
+//
+// Requests are paced by a client-side token bucket per host if Rate is
+// positive. In addition, RateLimitTransport is aware of the rate limit headers
+// returned by registries. When a registry advertises both the remaining quota
+// and its reset time, the requests are spread evenly until the reset time, and
+// paused if the quota is exhausted. When a registry advertises the remaining
+// quota with a window but without a reset time, such as Docker Hub does, the
+// quota is not counted down on the client side, since not every request is
+// charged against it. Instead, requests are paused only once a response
+// reports the quota exhausted, until the time given by Retry-After if any, or
+// for a short interval after which the host is probed again. When a registry
+// responds with 429 Too Many Requests and a Retry-After header, further
+// requests to the host are paused accordingly.
+//
+// The states of the hosts without activity for a while are evicted, after
+// which RateLimit no longer reports their quotas.
+//
+// To pace retried requests as well, RateLimitTransport should be used as the
+// Base of [Transport].
+type RateLimitTransport struct {
+	// Base is the underlying HTTP transport to use.
+	// If nil, http.DefaultTransport is used for round trips.
+	Base http.RoundTripper
+
+	// Rate is the maximum number of requests per second allowed per host.
+	// If zero or negative, requests are not limited on the client side.
+	Rate float64
+
+	// Burst is the maximum number of requests allowed per host at once.
+	// If zero or negative, 1 is used.
+	// Burst takes effect only if Rate is positive.
+	Burst int
+
+	lock  sync.Mutex
+	hosts map[string]*hostRateLimiter
+	// swept is the last time the idle hosts are evicted.
+	swept time.Time
+}
+
+// NewRateLimitTransport creates an HTTP transport that paces requests per host
+// with the given rate and burst.
+func NewRateLimitTransport(base http.RoundTripper, rate float64, burst int) *RateLimitTransport {
+	return &RateLimitTransport{
+		Base:  base,
+		Rate:  rate,
+		Burst: burst,
+	}
+}
+
+// RoundTrip executes a single HTTP transaction, returning a Response for the
+// provided Request.
+// It waits until the request is allowed by the rate limit of the host.
+func (t *RateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	limiter := t.limiter(req.URL.Host)
+	if delay, quota := limiter.reserve(time.Now()); delay > 0 {
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			limiter.cancel(quota)
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+
+	resp, err := t.roundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	limiter.update(resp, time.Now())
+	return resp, nil
+}
+
+// RateLimit returns the latest request quota advertised by the given host
+// (i.e. host:port). It returns false if the host has not advertised any quota.
+func (t *RateLimitTransport) RateLimit(host string) (RateLimit, bool) {
+	t.lock.Lock()
+	limiter, ok := t.hosts[host]
+	t.lock.Unlock()
+	if !ok {
+		return RateLimit{}, false
+	}
+	return limiter.rateLimit()
+}
+
+func (t *RateLimitTransport) roundTrip(req *http.Request) (*http.Response, error) {
+	if t.Base == nil {
+		return http.DefaultTransport.RoundTrip(req)
+	}
+	return t.Base.RoundTrip(req)
+}
+
+// limiter returns the rate limiter of the given host.
+func (t *RateLimitTransport) limiter(host string) *hostRateLimiter {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.evictIdle(time.Now())
+	if limiter, ok := t.hosts[host]; ok {
+		return limiter
+	}
+	if t.hosts == nil {
+		t.hosts = make(map[string]*hostRateLimiter)
+	}
+	limiter := &hostRateLimiter{}
+	if t.Rate > 0 {
+		burst := float64(t.Burst)
+		if burst <= 0 {
+			burst = 1
+		}
+		limiter.bucket = &tokenBucket{
+			rate:   t.Rate,
+			burst:  burst,
+			tokens: burst,
+		}
+	}
+	t.hosts[host] = limiter
+	return limiter
+}
+
+// evictIdle evicts the rate limiters of the idle hosts, at most once per
+// hostIdleTimeout. The caller must hold t.lock.
+func (t *RateLimitTransport) evictIdle(now time.Time) {
+	if now.Sub(t.swept) < hostIdleTimeout {
+		return
+	}
+	t.swept = now
+	for host, limiter := range t.hosts {
+		if limiter.idle(now) {
+			delete(t.hosts, host)
+		}
+	}
+}
+
+// hostRateLimiter paces the requests to a host.
+type hostRateLimiter struct {
+	lock sync.Mutex
+	// bucket is the client-side token bucket. It is nil if there is no
+	// client-side limit.
+	bucket *tokenBucket
+	// quota is the latest quota advertised by the host.
+	quota RateLimit
+	// hasQuota indicates whether the host has advertised any quota.
+	hasQuota bool
+	// next is the earliest time for the next request to be sent.
+	next time.Time
+	// reset is the time when the quota is reset, which is either advertised
+	// by the host, or the time to probe the host if its quota is exhausted
+	// without an advertised reset time.
+	reset time.Time
+	// spread indicates whether the remaining quota is spread until reset,
+	// which applies only to the reset time advertised by the host.
+	spread bool
+	// lastUsed is the last time the limiter is used.
+	lastUsed time.Time
+}
+
+// reserve reserves a slot for a request and returns the duration to wait
+// before sending the request, and whether a slot of the quota is taken.
+func (l *hostRateLimiter) reserve(now time.Time) (time.Duration, bool) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	l.lastUsed = now
+	start := now
+	if l.bucket != nil {
+		start = start.Add(l.bucket.reserve(now))
+	}
+	if l.next.After(start) {
+		start = l.next
+	}
+	var quota bool
+	if l.hasQuota && start.Before(l.reset) {
+		switch {
+		case l.quota.Remaining <= 0:
+			// the quota is exhausted, wait until it is reset
+			start = l.reset
+		case l.spread:
+			// spread the remaining quota evenly until it is reset
+			l.next = start.Add(l.reset.Sub(start) / time.Duration(l.quota.Remaining))
+			l.quota.Remaining--
+			quota = true
+		}
+	}
+	return start.Sub(now), quota
+}
+
+// cancel gives back the slot reserved by the last call of reserve, including
+// the slot of the quota if taken.
+func (l *hostRateLimiter) cancel(quota bool) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if l.bucket != nil {
+		l.bucket.cancel()
+	}
+	if quota {
+		l.quota.Remaining++
+	}
+}
+
+// update updates the quota of the host based on the response.
+func (l *hostRateLimiter) update(resp *http.Response, now time.Time) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	l.lastUsed = now
+	var retryAt time.Time
+	if v := resp.Header.Get(headerRetryAfter); v != "" {
+		if retryAfter, _ := strconv.ParseInt(v, 10, 64); retryAfter > 0 {
+			retryAt = now.Add(time.Duration(retryAfter) * time.Second)
+		}
+	}
+	if rl, ok := parseRateLimit(resp.Header, now); ok {
+		l.quota = rl
+		l.hasQuota = true
+		l.reset = rl.Reset
+		l.spread = !rl.Reset.IsZero()
+		if rl.Reset.IsZero() && rl.Remaining <= 0 {
+			// the quota is exhausted without a known reset time, so pause
+			// until Retry-After, or probe the host after a short while
+			if !retryAt.IsZero() {
+				l.reset = retryAt
+			} else {
+				l.reset = now.Add(quotaProbeInterval)
+			}
+		}
+	}
+	if resp.StatusCode == http.StatusTooManyRequests && retryAt.After(l.next) {
+		l.next = retryAt
+	}
+}
+
+// idle checks if the limiter has not been used for hostIdleTimeout and no
+// longer delays any request.
+func (l *hostRateLimiter) idle(now time.Time) bool {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if now.Sub(l.lastUsed) < hostIdleTimeout || now.Before(l.next) || now.Before(l.reset) {
+		return false
+	}
+	return l.bucket == nil || l.bucket.tokens >= 0
+}
+
+// rateLimit returns the latest quota advertised by the host.
+func (l *hostRateLimiter) rateLimit() (RateLimit, bool) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	return l.quota, l.hasQuota
+}
+
+// tokenBucket is a token bucket rate limiter, which is not safe for
+// concurrent use.
+type tokenBucket struct {
+	// rate is the number of tokens added per second.
+	rate float64
+	// burst is the capacity of the bucket.
+	burst float64
+	// tokens is the number of tokens in the bucket. It is negative if there
+	// are pending reservations.
+	tokens float64
+	// last is the last time the tokens are updated.
+	last time.Time
+}
+
+// reserve takes a token from the bucket and returns the duration to wait for
+// the token to be available.
+func (b *tokenBucket) reserve(now time.Time) time.Duration {
+	if !b.last.IsZero() {
+		if elapsed := now.Sub(b.last); elapsed > 0 {
+			b.tokens = min(b.burst, b.tokens+elapsed.Seconds()*b.rate)
+		}
+	}
+	b.last = now
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// cancel gives back a token to the bucket.
+func (b *tokenBucket) cancel() {
+	b.tokens = min(b.burst, b.tokens+1)
+}
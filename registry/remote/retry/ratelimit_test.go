@@ -0,0 +1,339 @@
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package retry
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func Test_parseRateLimit(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	tests := []struct {
+		name   string
+		header http.Header
+		want   RateLimit
+		wantOk bool
+	}{
+		{
+			name:   "no headers",
+			header: http.Header{},
+		},
+		{
+			name: "Docker Hub",
+			header: http.Header{
+				"Ratelimit-Limit":     {"100;w=21600"},
+				"Ratelimit-Remaining": {"76;w=21600"},
+			},
+			want: RateLimit{
+				Limit:     100,
+				Remaining: 76,
+				Window:    6 * time.Hour,
+			},
+			wantOk: true,
+		},
+		{
+			name: "IETF draft with reset in seconds",
+			header: http.Header{
+				"Ratelimit-Limit":     {"10"},
+				"Ratelimit-Remaining": {"0"},
+				"Ratelimit-Reset":     {"30"},
+			},
+			want: RateLimit{
+				Limit:     10,
+				Remaining: 0,
+				Reset:     now.Add(30 * time.Second),
+			},
+			wantOk: true,
+		},
+		{
+			name: "X-RateLimit with reset in Unix time",
+			header: http.Header{
+				"X-Ratelimit-Limit":     {"5000"},
+				"X-Ratelimit-Remaining": {"4999"},
+				"X-Ratelimit-Reset":     {"1700003600"},
+			},
+			want: RateLimit{
+				Limit:     5000,
+				Remaining: 4999,
+				Reset:     time.Unix(1700003600, 0),
+			},
+			wantOk: true,
+		},
+		{
+			name: "limit without remaining",
+			header: http.Header{
+				"Ratelimit-Limit": {"100;w=21600"},
+			},
+		},
+		{
+			name: "invalid remaining",
+			header: http.Header{
+				"Ratelimit-Remaining": {"many"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseRateLimit(tt.header, now)
+			if ok != tt.wantOk {
+				t.Fatalf("parseRateLimit() ok = %v, want %v", ok, tt.wantOk)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseRateLimit() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_tokenBucket(t *testing.T) {
+	now := time.Now()
+	b := &tokenBucket{
+		rate:   10,
+		burst:  2,
+		tokens: 2,
+	}
+	for i, want := range []time.Duration{0, 0, 100 * time.Millisecond, 200 * time.Millisecond} {
+		if got := b.reserve(now); got != want {
+			t.Errorf("tokenBucket.reserve() #%d = %v, want %v", i, got, want)
+		}
+	}
+	b.cancel()
+	if got, want := b.reserve(now), 200*time.Millisecond; got != want {
+		t.Errorf("tokenBucket.reserve() after cancel = %v, want %v", got, want)
+	}
+	if got, want := b.reserve(now.Add(time.Second)), time.Duration(0); got != want {
+		t.Errorf("tokenBucket.reserve() after refill = %v, want %v", got, want)
+	}
+}
+
+func TestRateLimitTransport_Rate(t *testing.T) {
+	var count int64
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&count, 1)
+	}))
+	defer ts.Close()
+
+	client := &http.Client{
+		Transport: NewRateLimitTransport(nil, 20, 1),
+	}
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		resp, err := client.Get(ts.URL)
+		if err != nil {
+			t.Fatalf("failed to do test request: %v", err)
+		}
+		resp.Body.Close()
+	}
+	if elapsed, want := time.Since(start), 150*time.Millisecond; elapsed < want {
+		t.Errorf("requests took %v, want at least %v", elapsed, want)
+	}
+	if count != 4 {
+		t.Errorf("unexpected number of requests: %d, want %d", count, 4)
+	}
+}
+
+func TestRateLimitTransport_Quota(t *testing.T) {
+	var count int64
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&count, 1)
+		w.Header().Set("RateLimit-Limit", "1;w=1")
+		w.Header().Set("RateLimit-Remaining", "0;w=1")
+		w.Header().Set("RateLimit-Reset", "1")
+	}))
+	defer ts.Close()
+	uri, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatalf("invalid test http server: %v", err)
+	}
+
+	transport := &RateLimitTransport{}
+	client := &http.Client{
+		Transport: transport,
+	}
+	if _, ok := transport.RateLimit(uri.Host); ok {
+		t.Error("RateLimitTransport.RateLimit() ok = true, want false")
+	}
+	resp, err := client.Get(ts.URL)
+	if err != nil {
+		t.Fatalf("failed to do test request: %v", err)
+	}
+	resp.Body.Close()
+	rl, ok := transport.RateLimit(uri.Host)
+	if !ok {
+		t.Fatal("RateLimitTransport.RateLimit() ok = false, want true")
+	}
+	if rl.Limit != 1 || rl.Remaining != 0 || rl.Window != time.Second {
+		t.Errorf("RateLimitTransport.RateLimit() = %v", rl)
+	}
+
+	// the next request is paused until the quota is reset
+	start := time.Now()
+	resp, err = client.Get(ts.URL)
+	if err != nil {
+		t.Fatalf("failed to do test request: %v", err)
+	}
+	resp.Body.Close()
+	if elapsed, want := time.Since(start), 500*time.Millisecond; elapsed < want {
+		t.Errorf("request took %v, want at least %v", elapsed, want)
+	}
+
+	// the pause respects the context
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL, nil)
+	if err != nil {
+		t.Fatalf("failed to create test request: %v", err)
+	}
+	if _, err := client.Do(req); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Client.Do() error = %v, wantErr %v", err, context.DeadlineExceeded)
+	}
+	if count != 2 {
+		t.Errorf("unexpected number of requests: %d, want %d", count, 2)
+	}
+}
+
+func Test_hostRateLimiter_window(t *testing.T) {
+	// Docker Hub advertises the window without the reset time
+	header := http.Header{}
+	header.Set("RateLimit-Limit", "100;w=21600")
+	header.Set("RateLimit-Remaining", "1;w=21600")
+	now := time.Now()
+	l := &hostRateLimiter{}
+	l.update(&http.Response{Header: header}, now)
+
+	// the quota is not counted down on the client side, since not every
+	// request is charged against it
+	for i := 0; i < 3; i++ {
+		if got, quota := l.reserve(now); got != 0 || quota {
+			t.Errorf("hostRateLimiter.reserve() #%d = %v, %v, want %v, %v", i, got, quota, time.Duration(0), false)
+		}
+	}
+	if rl, _ := l.rateLimit(); rl.Remaining != 1 {
+		t.Errorf("hostRateLimiter.rateLimit() Remaining = %v, want %v", rl.Remaining, 1)
+	}
+
+	// the quota reported exhausted pauses requests for the probe interval
+	// instead of the window
+	header.Set("RateLimit-Remaining", "0;w=21600")
+	l.update(&http.Response{Header: header}, now)
+	if got, _ := l.reserve(now); got != quotaProbeInterval {
+		t.Errorf("hostRateLimiter.reserve() = %v, want %v", got, quotaProbeInterval)
+	}
+	if rl, _ := l.rateLimit(); !rl.Reset.IsZero() {
+		t.Errorf("hostRateLimiter.rateLimit() Reset = %v, want zero", rl.Reset)
+	}
+	// the probe is sent after the interval
+	if got, _ := l.reserve(now.Add(quotaProbeInterval)); got != 0 {
+		t.Errorf("hostRateLimiter.reserve() after the interval = %v, want %v", got, time.Duration(0))
+	}
+
+	// Retry-After bounds the pause
+	header.Set("Retry-After", "5")
+	l.update(&http.Response{Header: header}, now)
+	if got, _ := l.reserve(now); got != 5*time.Second {
+		t.Errorf("hostRateLimiter.reserve() = %v, want %v", got, 5*time.Second)
+	}
+
+	// the recovered quota resumes the requests
+	header.Del("Retry-After")
+	header.Set("RateLimit-Remaining", "100;w=21600")
+	l.update(&http.Response{Header: header}, now)
+	if got, _ := l.reserve(now); got != 0 {
+		t.Errorf("hostRateLimiter.reserve() = %v, want %v", got, time.Duration(0))
+	}
+}
+
+func Test_hostRateLimiter_cancel(t *testing.T) {
+	header := http.Header{}
+	header.Set("RateLimit-Remaining", "2")
+	header.Set("RateLimit-Reset", "10")
+	now := time.Now()
+	l := &hostRateLimiter{}
+	l.update(&http.Response{Header: header}, now)
+
+	_, quota := l.reserve(now)
+	if !quota {
+		t.Fatal("hostRateLimiter.reserve() quota = false, want true")
+	}
+	l.cancel(quota)
+	if rl, _ := l.rateLimit(); rl.Remaining != 2 {
+		t.Errorf("hostRateLimiter.rateLimit() Remaining = %v, want %v", rl.Remaining, 2)
+	}
+}
+
+func TestRateLimitTransport_evictIdle(t *testing.T) {
+	transport := &RateLimitTransport{}
+	now := time.Now()
+	active := transport.limiter("active.example.com")
+	active.reserve(now)
+	idle := transport.limiter("idle.example.com")
+	idle.reserve(now.Add(-2 * hostIdleTimeout))
+	paused := transport.limiter("paused.example.com")
+	paused.reserve(now.Add(-2 * hostIdleTimeout))
+	paused.next = now.Add(time.Minute)
+
+	transport.lock.Lock()
+	transport.swept = time.Time{}
+	transport.evictIdle(now)
+	transport.lock.Unlock()
+	for host, want := range map[string]bool{
+		"active.example.com": true,
+		"idle.example.com":   false,
+		"paused.example.com": true,
+	} {
+		if _, got := transport.hosts[host]; got != want {
+			t.Errorf("host %s kept = %v, want %v", host, got, want)
+		}
+	}
+}
+
+func TestRateLimitTransport_RetryAfter(t *testing.T) {
+	var count int64
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt64(&count, 1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+	}))
+	defer ts.Close()
+
+	client := &http.Client{
+		Transport: NewTransport(NewRateLimitTransport(nil, 0, 0)),
+	}
+	start := time.Now()
+	resp, err := client.Get(ts.URL)
+	if err != nil {
+		t.Fatalf("failed to do test request: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("unexpected status code: %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+	if elapsed, want := time.Since(start), 900*time.Millisecond; elapsed < want {
+		t.Errorf("request took %v, want at least %v", elapsed, want)
+	}
+	if count != 2 {
+		t.Errorf("unexpected number of requests: %d, want %d", count, 2)
+	}
+}
@@ -20,11 +20,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"iter"
 
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 	"oras.land/oras-go/v2/content"
 	"oras.land/oras-go/v2/errdef"
 	"oras.land/oras-go/v2/internal/descriptor"
+	"oras.land/oras-go/v2/internal/iterutil"
 	"oras.land/oras-go/v2/internal/spec"
 )
 
@@ -86,6 +88,16 @@ type ReferenceFetcher interface {
 	FetchReference(ctx context.Context, reference string) (ocispec.Descriptor, io.ReadCloser, error)
 }
 
+// ConditionalResolver resolves references conditionally to avoid redundant
+// work when the resolved content is unchanged.
+type ConditionalResolver interface {
+	// ResolveIfChanged resolves the reference unless it still resolves to the
+	// last resolved descriptor. It returns last and false if the content is
+	// unchanged, and the newly resolved descriptor and true otherwise.
+	// If last is empty, the reference is resolved unconditionally.
+	ResolveIfChanged(ctx context.Context, reference string, last ocispec.Descriptor) (ocispec.Descriptor, bool, error)
+}
+
 // ReferrerLister provides the Referrers API.
 // Reference: https://github.com/opencontainers/distribution-spec/blob/v1.1.1/spec.md#listing-referrers
 type ReferrerLister interface {
@@ -140,6 +152,17 @@ func Tags(ctx context.Context, repo TagLister) ([]string, error) {
 	return res, nil
 }
 
+// AllTags returns an iterator over the tags available in the repository.
+// Unlike Tags(), the paginated tag list is requested lazily as the iterator
+// is consumed, and no further pages are requested if the iteration stops
+// early. An error, including the error of ctx, is yielded as the last element
+// of the iterator.
+func AllTags(ctx context.Context, repo TagLister) iter.Seq2[string, error] {
+	return iterutil.Pages(ctx, func(fn func(tags []string) error) error {
+		return repo.Tags(ctx, "", fn)
+	})
+}
+
 // Referrers lists the descriptors of image or artifact manifests directly
 // referencing the given manifest descriptor.
 //
@@ -224,3 +247,25 @@ func Referrers(ctx context.Context, store content.ReadOnlyGraphStorage, desc oci
 	}
 	return results, nil
 }
+
+// AllReferrers returns an iterator over the descriptors of image or artifact
+// manifests directly referencing the given manifest descriptor.
+// If the store implements ReferrerLister, the paginated referrers list is
+// requested lazily as the iterator is consumed. Otherwise, the referrers are
+// discovered as Referrers() does before the first element is yielded.
+// An error, including the error of ctx, is yielded as the last element of the
+// iterator.
+//
+// Reference: https://github.com/opencontainers/distribution-spec/blob/v1.1.1/spec.md#listing-referrers
+func AllReferrers(ctx context.Context, store content.ReadOnlyGraphStorage, desc ocispec.Descriptor, artifactType string) iter.Seq2[ocispec.Descriptor, error] {
+	return iterutil.Pages(ctx, func(fn func(referrers []ocispec.Descriptor) error) error {
+		if rf, ok := store.(ReferrerLister); ok && descriptor.IsManifest(desc) {
+			return rf.Referrers(ctx, desc, artifactType, fn)
+		}
+		referrers, err := Referrers(ctx, store, desc, artifactType)
+		if err != nil {
+			return err
+		}
+		return fn(referrers)
+	})
+}
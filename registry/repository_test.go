@@ -403,3 +403,79 @@ func equalDescriptorSet(actual []ocispec.Descriptor, expected []ocispec.Descript
 	}
 	return true
 }
+
+func TestAllReferrers(t *testing.T) {
+	ctx := context.Background()
+	s := &testStorage{
+		store:    memory.New(),
+		badFetch: set.New[digest.Digest](),
+	}
+	subject := []byte(`{"layers":[]}`)
+	subjectDesc := ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Digest:    digest.FromBytes(subject),
+		Size:      int64(len(subject)),
+	}
+	manifest := ocispec.Manifest{
+		MediaType:    ocispec.MediaTypeImageManifest,
+		ArtifactType: "application/vnd.test",
+		Config:       ocispec.DescriptorEmptyJSON,
+		Subject:      &subjectDesc,
+	}
+	manifestJSON, err := json.Marshal(manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	manifestDesc := ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Digest:    digest.FromBytes(manifestJSON),
+		Size:      int64(len(manifestJSON)),
+	}
+	for _, blob := range []struct {
+		desc    ocispec.Descriptor
+		content []byte
+	}{
+		{subjectDesc, subject},
+		{ocispec.DescriptorEmptyJSON, ocispec.DescriptorEmptyJSON.Data},
+		{manifestDesc, manifestJSON},
+	} {
+		if err := s.Push(ctx, blob.desc, bytes.NewReader(blob.content)); err != nil {
+			t.Fatalf("failed to push test content: %v", err)
+		}
+	}
+
+	// test graph storage
+	var got []ocispec.Descriptor
+	for referrer, err := range AllReferrers(ctx, s, subjectDesc, "") {
+		if err != nil {
+			t.Fatalf("AllReferrers() error = %v", err)
+		}
+		got = append(got, referrer)
+	}
+	want := manifestDesc
+	want.ArtifactType = manifest.ArtifactType
+	if !reflect.DeepEqual(got, []ocispec.Descriptor{want}) {
+		t.Errorf("AllReferrers() = %v, want %v", got, []ocispec.Descriptor{want})
+	}
+
+	// test referrer lister
+	got = nil
+	for referrer, err := range AllReferrers(ctx, &TestReferrerLister{s}, subjectDesc, "") {
+		if err != nil {
+			t.Fatalf("AllReferrers() error = %v", err)
+		}
+		got = append(got, referrer)
+	}
+	if !reflect.DeepEqual(got, []ocispec.Descriptor{subjectDesc}) {
+		t.Errorf("AllReferrers() = %v, want %v", got, []ocispec.Descriptor{subjectDesc})
+	}
+
+	// test non-manifest
+	var gotErr error
+	for _, err := range AllReferrers(ctx, s, ocispec.DescriptorEmptyJSON, "") {
+		gotErr = err
+	}
+	if !errors.Is(gotErr, errdef.ErrUnsupported) {
+		t.Errorf("AllReferrers() error = %v, wantErr %v", gotErr, errdef.ErrUnsupported)
+	}
+}
@@ -0,0 +1,165 @@
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"cmp"
+	"strconv"
+	"strings"
+)
+
+// semver is a parsed semantic version.
+// Reference: https://semver.org/spec/v2.0.0.html
+type semver struct {
+	major, minor, patch uint64
+	prerelease          []string
+}
+
+// parseSemver parses a tag in the form of "MAJOR.MINOR.PATCH" with an optional
+// "v" prefix, an optional pre-release suffix and an optional build metadata
+// suffix, such as "v1.2.3-rc.1".
+// It returns false if the tag is not a semantic version.
+func parseSemver(tag string) (semver, bool) {
+	s := strings.TrimPrefix(tag, "v")
+	// build metadata does not take part in precedence
+	s, build, hasBuild := strings.Cut(s, "+")
+	if hasBuild && !isValidSemverIdentifiers(build, false) {
+		return semver{}, false
+	}
+	s, prerelease, hasPrerelease := strings.Cut(s, "-")
+	if hasPrerelease && !isValidSemverIdentifiers(prerelease, true) {
+		return semver{}, false
+	}
+
+	parts := strings.Split(s, ".")
+	if len(parts) != 3 {
+		return semver{}, false
+	}
+	var core [3]uint64
+	for i, part := range parts {
+		if !isSemverNumber(part) {
+			return semver{}, false
+		}
+		n, err := strconv.ParseUint(part, 10, 64)
+		if err != nil {
+			return semver{}, false
+		}
+		core[i] = n
+	}
+	v := semver{
+		major: core[0],
+		minor: core[1],
+		patch: core[2],
+	}
+	if hasPrerelease {
+		v.prerelease = strings.Split(prerelease, ".")
+	}
+	return v, true
+}
+
+// isPrerelease returns true if v is a pre-release version.
+func (v semver) isPrerelease() bool {
+	return len(v.prerelease) > 0
+}
+
+// compare returns -1, 0 or +1 depending on whether v precedes, equals to or
+// follows w.
+func (v semver) compare(w semver) int {
+	if c := cmp.Compare(v.major, w.major); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(v.minor, w.minor); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(v.patch, w.patch); c != 0 {
+		return c
+	}
+
+	// a pre-release version precedes the associated normal version
+	switch {
+	case !v.isPrerelease() && !w.isPrerelease():
+		return 0
+	case !v.isPrerelease():
+		return 1
+	case !w.isPrerelease():
+		return -1
+	}
+	for i := 0; i < len(v.prerelease) && i < len(w.prerelease); i++ {
+		if c := compareSemverIdentifier(v.prerelease[i], w.prerelease[i]); c != 0 {
+			return c
+		}
+	}
+	return cmp.Compare(len(v.prerelease), len(w.prerelease))
+}
+
+// compareSemverIdentifier compares two pre-release identifiers, where numeric
+// identifiers are compared numerically and precede alphanumeric identifiers.
+func compareSemverIdentifier(a, b string) int {
+	aNumeric, bNumeric := isSemverNumber(a), isSemverNumber(b)
+	switch {
+	case aNumeric && bNumeric:
+		// numbers without leading zeros are compared by length first
+		if c := cmp.Compare(len(a), len(b)); c != 0 {
+			return c
+		}
+		return strings.Compare(a, b)
+	case aNumeric:
+		return -1
+	case bNumeric:
+		return 1
+	default:
+		return strings.Compare(a, b)
+	}
+}
+
+// isValidSemverIdentifiers returns true if s is a non-empty dot-separated
+// list of non-empty identifiers consisting of alphanumerics and hyphens.
+// If strictNumbers is true, numeric identifiers must not have leading zeros.
+func isValidSemverIdentifiers(s string, strictNumbers bool) bool {
+	for _, id := range strings.Split(s, ".") {
+		if id == "" {
+			return false
+		}
+		numeric := true
+		for _, c := range id {
+			switch {
+			case c >= '0' && c <= '9':
+			case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c == '-':
+				numeric = false
+			default:
+				return false
+			}
+		}
+		if strictNumbers && numeric && !isSemverNumber(id) {
+			return false
+		}
+	}
+	return true
+}
+
+// isSemverNumber returns true if s is a non-empty decimal number without
+// leading zeros.
+func isSemverNumber(s string) bool {
+	if s == "" || (len(s) > 1 && s[0] == '0') {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
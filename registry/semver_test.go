@@ -0,0 +1,101 @@
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_parseSemver(t *testing.T) {
+	tests := []struct {
+		tag    string
+		want   semver
+		wantOk bool
+	}{
+		{tag: "1.2.3", want: semver{major: 1, minor: 2, patch: 3}, wantOk: true},
+		{tag: "v0.0.0", want: semver{}, wantOk: true},
+		{tag: "v1.2.3-rc.1", want: semver{major: 1, minor: 2, patch: 3, prerelease: []string{"rc", "1"}}, wantOk: true},
+		{tag: "1.0.0-alpha-beta", want: semver{major: 1, prerelease: []string{"alpha-beta"}}, wantOk: true},
+		{tag: "1.0.0+build.5", want: semver{major: 1}, wantOk: true},
+		{tag: "1.0.0-beta+exp.sha.5114f85", want: semver{major: 1, prerelease: []string{"beta"}}, wantOk: true},
+		{tag: "latest"},
+		{tag: "1.2"},
+		{tag: "1.2.3.4"},
+		{tag: "01.2.3"},
+		{tag: "1.2.x"},
+		{tag: "1.2.3-"},
+		{tag: "1.2.3-rc..1"},
+		{tag: "1.2.3-01"},
+		{tag: "1.2.3-rc_1"},
+		{tag: "1.2.3+"},
+		{tag: "vv1.2.3"},
+		{tag: "18446744073709551616.0.0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tag, func(t *testing.T) {
+			got, ok := parseSemver(tt.tag)
+			if ok != tt.wantOk {
+				t.Fatalf("parseSemver() ok = %v, want %v", ok, tt.wantOk)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseSemver() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_semver_compare(t *testing.T) {
+	// in the ascending order of precedence
+	// reference: https://semver.org/spec/v2.0.0.html#spec-item-11
+	tags := []string{
+		"0.9.9",
+		"1.0.0-alpha",
+		"1.0.0-alpha.1",
+		"1.0.0-alpha.beta",
+		"1.0.0-beta",
+		"1.0.0-beta.2",
+		"1.0.0-beta.11",
+		"1.0.0-rc.1",
+		"1.0.0",
+		"1.0.1",
+		"1.2.0",
+		"1.10.0",
+		"2.0.0",
+	}
+	for i, a := range tags {
+		va, ok := parseSemver(a)
+		if !ok {
+			t.Fatalf("parseSemver(%q) failed", a)
+		}
+		for j, b := range tags {
+			vb, ok := parseSemver(b)
+			if !ok {
+				t.Fatalf("parseSemver(%q) failed", b)
+			}
+			want := 0
+			switch {
+			case i < j:
+				want = -1
+			case i > j:
+				want = 1
+			}
+			if got := va.compare(vb); got != want {
+				t.Errorf("semver(%q).compare(%q) = %d, want %d", a, b, got, want)
+			}
+		}
+	}
+}
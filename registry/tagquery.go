@@ -0,0 +1,179 @@
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/internal/syncutil"
+)
+
+// defaultResolveTagsConcurrency is the default value of
+// ResolveTagsOptions.Concurrency.
+const defaultResolveTagsConcurrency int = 5
+
+// errTagListEnd is returned by the page callback to stop listing tags when
+// the remaining tags are known not to match the query.
+var errTagListEnd = errors.New("end of tag list")
+
+// TagSortOrder is the order of the tags returned by QueryTags.
+type TagSortOrder int
+
+const (
+	// TagSortNone keeps the order of the tags returned by the tag lister.
+	TagSortNone TagSortOrder = iota
+
+	// TagSortLexical sorts the tags in the ascending lexical order.
+	TagSortLexical
+
+	// TagSortSemver sorts the tags in the ascending order of semantic version
+	// precedence. Tags that are not semantic versions are excluded.
+	// Reference: https://semver.org/spec/v2.0.0.html#spec-item-11
+	TagSortSemver
+)
+
+// TagQuery specifies the tags to be selected by QueryTags.
+type TagQuery struct {
+	// Prefix selects the tags starting with Prefix.
+	// If empty, all tags are selected.
+	Prefix string
+
+	// Pattern selects the tags matching Pattern.
+	// If nil, all tags are selected.
+	Pattern *regexp.Regexp
+
+	// Sort specifies the order of the selected tags.
+	// The default is TagSortNone.
+	Sort TagSortOrder
+
+	// IncludePrerelease selects the pre-release versions, such as
+	// "v1.0.0-rc.1", when Sort is TagSortSemver.
+	// By default, pre-release versions are excluded.
+	IncludePrerelease bool
+
+	// Latest limits the result to the last N tags after sorting, i.e. the
+	// highest N versions when Sort is TagSortSemver.
+	// If zero or negative, all selected tags are returned.
+	Latest int
+
+	// SortedListing indicates that the tag lister returns the tags in the
+	// lexical order as required by the distribution specification.
+	// If true and Prefix is not empty, the tags before Prefix are skipped on
+	// the server side by the `last` parameter of the tags API, and the listing
+	// stops as soon as the tags go past Prefix.
+	// Setting SortedListing against a tag lister that does not list tags in
+	// the lexical order may miss tags.
+	//
+	// Reference: https://github.com/opencontainers/distribution-spec/blob/v1.1.1/spec.md#listing-tags
+	SortedListing bool
+}
+
+// QueryTags lists the tags available in the repository and returns the tags
+// selected by the query.
+//
+// The tags are filtered page by page so that only the selected tags are kept
+// in the memory.
+func QueryTags(ctx context.Context, repo TagLister, query TagQuery) ([]string, error) {
+	var last string
+	if query.SortedListing && query.Prefix != "" {
+		// all tags with the prefix follow the prefix without its last byte
+		last = query.Prefix[:len(query.Prefix)-1]
+	}
+
+	var tags []string
+	versions := make(map[string]semver)
+	err := repo.Tags(ctx, last, func(page []string) error {
+		for _, tag := range page {
+			if !strings.HasPrefix(tag, query.Prefix) {
+				if query.SortedListing && tag > query.Prefix {
+					return errTagListEnd
+				}
+				continue
+			}
+			if query.Pattern != nil && !query.Pattern.MatchString(tag) {
+				continue
+			}
+			if query.Sort == TagSortSemver {
+				v, ok := parseSemver(tag)
+				if !ok || (v.isPrerelease() && !query.IncludePrerelease) {
+					continue
+				}
+				versions[tag] = v
+			}
+			tags = append(tags, tag)
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errTagListEnd) {
+		return nil, err
+	}
+
+	switch query.Sort {
+	case TagSortLexical:
+		slices.Sort(tags)
+	case TagSortSemver:
+		slices.SortFunc(tags, func(a, b string) int {
+			if c := versions[a].compare(versions[b]); c != 0 {
+				return c
+			}
+			// break ties, such as "1.0.0" and "v1.0.0", deterministically
+			return strings.Compare(a, b)
+		})
+	}
+	if query.Latest > 0 && len(tags) > query.Latest {
+		tags = tags[len(tags)-query.Latest:]
+	}
+	return tags, nil
+}
+
+// ResolveTagsOptions contains parameters for [ResolveTags].
+type ResolveTagsOptions struct {
+	// Concurrency limits the maximum number of concurrent resolutions.
+	// If less than or equal to 0, a default (currently 5) is used.
+	Concurrency int
+}
+
+// ResolveTags resolves the tags to descriptors concurrently. The returned
+// descriptors are in the same order as the tags.
+func ResolveTags(ctx context.Context, resolver content.Resolver, tags []string, opts ResolveTagsOptions) ([]ocispec.Descriptor, error) {
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = defaultResolveTagsConcurrency
+	}
+
+	descs := make([]ocispec.Descriptor, len(tags))
+	eg, egCtx := syncutil.LimitGroup(ctx, opts.Concurrency)
+	for i, tag := range tags {
+		eg.Go(func() error {
+			desc, err := resolver.Resolve(egCtx, tag)
+			if err != nil {
+				return fmt.Errorf("failed to resolve tag %s: %w", tag, err)
+			}
+			descs[i] = desc
+			return nil
+		})
+	}
+	if err := eg.Wait(); err != nil {
+		return nil, err
+	}
+	return descs, nil
+}
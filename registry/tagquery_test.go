@@ -0,0 +1,222 @@
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"regexp"
+	"slices"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content/memory"
+	"oras.land/oras-go/v2/errdef"
+)
+
+// testTagLister lists the tags in pages of the given size, starting after
+// `last` if the tags are sorted.
+type testTagLister struct {
+	tags     []string
+	pageSize int
+	lasts    []string
+	pages    int
+}
+
+func (tl *testTagLister) Tags(ctx context.Context, last string, fn func(tags []string) error) error {
+	tl.lasts = append(tl.lasts, last)
+	tags := tl.tags
+	if last != "" {
+		i, _ := slices.BinarySearch(tags, last)
+		for i < len(tags) && tags[i] <= last {
+			i++
+		}
+		tags = tags[i:]
+	}
+	for len(tags) > 0 {
+		n := min(tl.pageSize, len(tags))
+		tl.pages++
+		if err := fn(tags[:n]); err != nil {
+			return err
+		}
+		tags = tags[n:]
+	}
+	return nil
+}
+
+func TestQueryTags(t *testing.T) {
+	tags := []string{
+		"1.0.0",
+		"1.10.0",
+		"1.2.0",
+		"1.2.0-rc.1",
+		"1.9.0",
+		"2.0.0",
+		"2.0.0-beta",
+		"latest",
+		"v1.0.1",
+		"v1.11.0",
+		"v2.1.0",
+		"v3.0.0-alpha",
+	}
+	tests := []struct {
+		name  string
+		query TagQuery
+		want  []string
+	}{
+		{
+			name:  "no filter",
+			query: TagQuery{},
+			want:  tags,
+		},
+		{
+			name:  "prefix",
+			query: TagQuery{Prefix: "v"},
+			want:  []string{"v1.0.1", "v1.11.0", "v2.1.0", "v3.0.0-alpha"},
+		},
+		{
+			name:  "pattern",
+			query: TagQuery{Pattern: regexp.MustCompile(`^v\d+`)},
+			want:  []string{"v1.0.1", "v1.11.0", "v2.1.0", "v3.0.0-alpha"},
+		},
+		{
+			name:  "lexical sort with latest",
+			query: TagQuery{Prefix: "1.", Sort: TagSortLexical, Latest: 2},
+			want:  []string{"1.2.0-rc.1", "1.9.0"},
+		},
+		{
+			name:  "semver sort",
+			query: TagQuery{Sort: TagSortSemver},
+			want:  []string{"1.0.0", "v1.0.1", "1.2.0", "1.9.0", "1.10.0", "v1.11.0", "2.0.0", "v2.1.0"},
+		},
+		{
+			name:  "semver sort with prerelease",
+			query: TagQuery{Sort: TagSortSemver, IncludePrerelease: true},
+			want:  []string{"1.0.0", "v1.0.1", "1.2.0-rc.1", "1.2.0", "1.9.0", "1.10.0", "v1.11.0", "2.0.0-beta", "2.0.0", "v2.1.0", "v3.0.0-alpha"},
+		},
+		{
+			name:  "highest 1.x",
+			query: TagQuery{Pattern: regexp.MustCompile(`^v?1\.`), Sort: TagSortSemver, Latest: 1},
+			want:  []string{"v1.11.0"},
+		},
+		{
+			name:  "latest more than selected",
+			query: TagQuery{Prefix: "2.", Latest: 5},
+			want:  []string{"2.0.0", "2.0.0-beta"},
+		},
+		{
+			name:  "no match",
+			query: TagQuery{Prefix: "foo"},
+			want:  nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &testTagLister{tags: tags, pageSize: 5}
+			got, err := QueryTags(context.Background(), repo, tt.query)
+			if err != nil {
+				t.Fatalf("QueryTags() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("QueryTags() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQueryTags_SortedListing(t *testing.T) {
+	var tags []string
+	for _, prefix := range []string{"a", "b", "c", "d"} {
+		for i := range 10 {
+			tags = append(tags, prefix+string(rune('0'+i)))
+		}
+	}
+	repo := &testTagLister{tags: tags, pageSize: 4}
+	got, err := QueryTags(context.Background(), repo, TagQuery{
+		Prefix:        "b",
+		SortedListing: true,
+	})
+	if err != nil {
+		t.Fatalf("QueryTags() error = %v", err)
+	}
+	if want := tags[10:20]; !reflect.DeepEqual(got, want) {
+		t.Errorf("QueryTags() = %v, want %v", got, want)
+	}
+	if want := []string{""}; !reflect.DeepEqual(repo.lasts, want) {
+		t.Errorf("QueryTags() lasts = %v, want %v", repo.lasts, want)
+	}
+	// the listing starts from the top as the prefix has a single character
+	// and stops after the page containing "c0"
+	if want := 6; repo.pages != want {
+		t.Errorf("QueryTags() pages = %d, want %d", repo.pages, want)
+	}
+
+	repo = &testTagLister{tags: tags, pageSize: 4}
+	got, err = QueryTags(context.Background(), repo, TagQuery{
+		Prefix:        "c5",
+		SortedListing: true,
+	})
+	if err != nil {
+		t.Fatalf("QueryTags() error = %v", err)
+	}
+	if want := []string{"c5"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("QueryTags() = %v, want %v", got, want)
+	}
+	if want := []string{"c"}; !reflect.DeepEqual(repo.lasts, want) {
+		t.Errorf("QueryTags() lasts = %v, want %v", repo.lasts, want)
+	}
+	if want := 2; repo.pages != want {
+		t.Errorf("QueryTags() pages = %d, want %d", repo.pages, want)
+	}
+}
+
+func TestResolveTags(t *testing.T) {
+	ctx := context.Background()
+	store := memory.New()
+	tags := []string{"v1", "v2", "v3", "v4", "v5", "v6", "v7"}
+	var want []ocispec.Descriptor
+	for _, tag := range tags {
+		blob := []byte(`{"tag":"` + tag + `"}`)
+		desc := ocispec.Descriptor{
+			MediaType: ocispec.MediaTypeImageManifest,
+			Digest:    digest.FromBytes(blob),
+			Size:      int64(len(blob)),
+		}
+		if err := store.Push(ctx, desc, bytes.NewReader(blob)); err != nil {
+			t.Fatalf("failed to push test content: %v", err)
+		}
+		if err := store.Tag(ctx, desc, tag); err != nil {
+			t.Fatalf("failed to tag test content: %v", err)
+		}
+		want = append(want, desc)
+	}
+
+	got, err := ResolveTags(ctx, store, tags, ResolveTagsOptions{Concurrency: 2})
+	if err != nil {
+		t.Fatalf("ResolveTags() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ResolveTags() = %v, want %v", got, want)
+	}
+
+	_, err = ResolveTags(ctx, store, []string{"v1", "unknown"}, ResolveTagsOptions{})
+	if !errors.Is(err, errdef.ErrNotFound) {
+		t.Errorf("ResolveTags() error = %v, wantErr %v", err, errdef.ErrNotFound)
+	}
+}
@@ -0,0 +1,414 @@
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/errdef"
+)
+
+// Default parameters of Watcher.
+const (
+	defaultWatchInterval         = time.Minute
+	defaultWatchMaxBackoffFactor = 10
+	defaultWatchJitter           = 0.1
+)
+
+// WatchEventType is the type of a change observed by a [Watcher].
+type WatchEventType int
+
+// Watch event types.
+const (
+	// WatchEventAdded indicates that a tag is created or a referrer is
+	// attached to a subject.
+	WatchEventAdded WatchEventType = iota + 1
+
+	// WatchEventRemoved indicates that a tag is deleted or a referrer is
+	// detached from a subject.
+	WatchEventRemoved
+
+	// WatchEventMoved indicates that a tag is moved to another digest.
+	WatchEventMoved
+
+	// WatchEventError indicates that a poll fails.
+	WatchEventError
+)
+
+// String returns the string representation of the event type.
+func (t WatchEventType) String() string {
+	switch t {
+	case WatchEventAdded:
+		return "added"
+	case WatchEventRemoved:
+		return "removed"
+	case WatchEventMoved:
+		return "moved"
+	case WatchEventError:
+		return "error"
+	default:
+		return fmt.Sprintf("WatchEventType(%d)", int(t))
+	}
+}
+
+// WatchEvent is a change observed by a [Watcher].
+type WatchEvent struct {
+	// Type is the type of the change.
+	Type WatchEventType
+
+	// Tag is the changed tag. It is empty for changes of referrers.
+	Tag string
+
+	// Subject is the subject whose referrers are changed. It is empty for
+	// changes of tags.
+	Subject ocispec.Descriptor
+
+	// Descriptor is the descriptor that the tag is created with or moved to,
+	// or the descriptor of the attached referrer. For removals, it is the
+	// last observed descriptor of the tag or the referrer.
+	Descriptor ocispec.Descriptor
+
+	// Previous is the descriptor that a moved tag pointed to.
+	Previous ocispec.Descriptor
+
+	// Err is the error of a failed poll.
+	Err error
+}
+
+// WatchTarget is a target watched by a [Watcher], such as a repository.
+type WatchTarget interface {
+	content.Resolver
+	content.ReadOnlyGraphStorage
+}
+
+// WatchStateStore persists the states last observed by a [Watcher], so that a
+// restarted watcher does not replay the events observed before.
+//
+// The keys are opaque strings generated by the watcher. A state store should
+// not be shared by watchers of different targets.
+type WatchStateStore interface {
+	// Load returns the descriptors last observed for the key. It returns
+	// false if there is no state of the key.
+	Load(ctx context.Context, key string) ([]ocispec.Descriptor, bool, error)
+
+	// Store saves the descriptors observed for the key.
+	Store(ctx context.Context, key string, descs []ocispec.Descriptor) error
+
+	// Delete removes the state of the key.
+	Delete(ctx context.Context, key string) error
+}
+
+// WatcherOptions contains parameters for [NewWatcher].
+type WatcherOptions struct {
+	// Tags are the tags to watch.
+	Tags []string
+
+	// Subjects are the manifests whose referrers are watched.
+	Subjects []ocispec.Descriptor
+
+	// ArtifactType filters the watched referrers by artifact type.
+	// If empty, all referrers are watched.
+	ArtifactType string
+
+	// Interval is the interval between polls.
+	// If zero or negative, 1 minute is used.
+	Interval time.Duration
+
+	// MaxBackoff is the maximum interval between polls after consecutive
+	// failures, where the interval doubles on each failure.
+	// If zero or negative, 10 times of Interval is used.
+	MaxBackoff time.Duration
+
+	// Jitter is the ratio of the interval randomized to avoid synchronized
+	// polls of multiple watchers. For example, with a jitter of 0.1, the
+	// intervals vary from 90% to 110% of the nominal interval.
+	// If zero, 0.1 is used. If negative, no jitter is applied.
+	Jitter float64
+
+	// StateStore persists the observed states.
+	// If nil, the states are kept in the memory.
+	StateStore WatchStateStore
+}
+
+// Watcher periodically resolves a set of tags and lists the referrers of a
+// set of subjects, and reports the changes since the last observation.
+//
+// If the target implements [ConditionalResolver], such as a remote
+// repository, the tags are resolved by conditional requests.
+//
+// Since the observed states are saved before the events are emitted, an event
+// may be lost if the process exits before the event is consumed, but it is
+// never replayed after the process restarts with a persistent state store.
+type Watcher struct {
+	target WatchTarget
+	opts   WatcherOptions
+	states WatchStateStore
+}
+
+// NewWatcher returns a watcher of the given target.
+func NewWatcher(target WatchTarget, opts WatcherOptions) *Watcher {
+	states := opts.StateStore
+	if states == nil {
+		states = &memoryWatchStateStore{}
+	}
+	return &Watcher{
+		target: target,
+		opts:   opts,
+		states: states,
+	}
+}
+
+// Poll observes the tags and the referrers once, and returns the changes
+// since the last observation. The first observation of a tag or a referrer
+// is reported as an addition.
+//
+// Failures of individual tags or subjects do not stop the poll: the changes
+// of the others are returned together with the joined errors.
+func (w *Watcher) Poll(ctx context.Context) ([]WatchEvent, error) {
+	var events []WatchEvent
+	var errs []error
+	for _, tag := range w.opts.Tags {
+		tagEvents, err := w.pollTag(ctx, tag)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to poll tag %s: %w", tag, err))
+			continue
+		}
+		events = append(events, tagEvents...)
+	}
+	for _, subject := range w.opts.Subjects {
+		referrerEvents, err := w.pollReferrers(ctx, subject)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to poll referrers of %s: %w", subject.Digest, err))
+			continue
+		}
+		events = append(events, referrerEvents...)
+	}
+	return events, errors.Join(errs...)
+}
+
+// Watch polls the target periodically and emits the changes on the returned
+// channel, which is closed when ctx is done. The first poll starts
+// immediately.
+// A failed poll is reported as a [WatchEventError] event, and the next poll
+// is backed off exponentially.
+func (w *Watcher) Watch(ctx context.Context) <-chan WatchEvent {
+	ch := make(chan WatchEvent)
+	go func() {
+		defer close(ch)
+
+		send := func(event WatchEvent) bool {
+			select {
+			case ch <- event:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+		var failures int
+		for {
+			events, err := w.Poll(ctx)
+			if ctx.Err() != nil {
+				return
+			}
+			for _, event := range events {
+				if !send(event) {
+					return
+				}
+			}
+			if err != nil {
+				failures++
+				if !send(WatchEvent{Type: WatchEventError, Err: err}) {
+					return
+				}
+			} else {
+				failures = 0
+			}
+
+			timer := time.NewTimer(w.delay(failures))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+		}
+	}()
+	return ch
+}
+
+// pollTag observes the tag and returns its change.
+func (w *Watcher) pollTag(ctx context.Context, tag string) ([]WatchEvent, error) {
+	key := "tag/" + tag
+	states, hasLast, err := w.states.Load(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	var last ocispec.Descriptor
+	if hasLast && len(states) == 1 {
+		last = states[0]
+	}
+
+	var desc ocispec.Descriptor
+	if resolver, ok := w.target.(ConditionalResolver); ok && last.Digest != "" {
+		desc, _, err = resolver.ResolveIfChanged(ctx, tag, last)
+	} else {
+		desc, err = w.target.Resolve(ctx, tag)
+	}
+	if err != nil {
+		if !errors.Is(err, errdef.ErrNotFound) {
+			return nil, err
+		}
+		if !hasLast {
+			return nil, nil
+		}
+		if err := w.states.Delete(ctx, key); err != nil {
+			return nil, err
+		}
+		return []WatchEvent{{
+			Type:       WatchEventRemoved,
+			Tag:        tag,
+			Descriptor: last,
+		}}, nil
+	}
+
+	if hasLast && desc.Digest == last.Digest {
+		return nil, nil
+	}
+	if err := w.states.Store(ctx, key, []ocispec.Descriptor{desc}); err != nil {
+		return nil, err
+	}
+	if !hasLast {
+		return []WatchEvent{{
+			Type:       WatchEventAdded,
+			Tag:        tag,
+			Descriptor: desc,
+		}}, nil
+	}
+	return []WatchEvent{{
+		Type:       WatchEventMoved,
+		Tag:        tag,
+		Descriptor: desc,
+		Previous:   last,
+	}}, nil
+}
+
+// pollReferrers observes the referrers of the subject and returns their
+// changes.
+func (w *Watcher) pollReferrers(ctx context.Context, subject ocispec.Descriptor) ([]WatchEvent, error) {
+	key := "referrers/" + subject.Digest.String() + "/" + w.opts.ArtifactType
+	last, _, err := w.states.Load(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	referrers, err := Referrers(ctx, w.target, subject, w.opts.ArtifactType)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []WatchEvent
+	lastSet := make(map[string]struct{}, len(last))
+	for _, desc := range last {
+		lastSet[desc.Digest.String()] = struct{}{}
+	}
+	currentSet := make(map[string]struct{}, len(referrers))
+	for _, desc := range referrers {
+		currentSet[desc.Digest.String()] = struct{}{}
+		if _, ok := lastSet[desc.Digest.String()]; !ok {
+			events = append(events, WatchEvent{
+				Type:       WatchEventAdded,
+				Subject:    subject,
+				Descriptor: desc,
+			})
+		}
+	}
+	for _, desc := range last {
+		if _, ok := currentSet[desc.Digest.String()]; !ok {
+			events = append(events, WatchEvent{
+				Type:       WatchEventRemoved,
+				Subject:    subject,
+				Descriptor: desc,
+			})
+		}
+	}
+	if len(events) == 0 {
+		return nil, nil
+	}
+	if err := w.states.Store(ctx, key, referrers); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// delay returns the randomized delay before the next poll after the given
+// number of consecutive failures.
+func (w *Watcher) delay(failures int) time.Duration {
+	interval := w.opts.Interval
+	if interval <= 0 {
+		interval = defaultWatchInterval
+	}
+	maxBackoff := w.opts.MaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = interval * defaultWatchMaxBackoffFactor
+	}
+	d := interval
+	for i := 0; i < failures && d < maxBackoff; i++ {
+		d *= 2
+	}
+	d = min(d, max(interval, maxBackoff))
+
+	jitter := w.opts.Jitter
+	if jitter == 0 {
+		jitter = defaultWatchJitter
+	}
+	if jitter > 0 {
+		jitter = min(jitter, 1)
+		d = time.Duration(float64(d) * (1 - jitter + 2*jitter*rand.Float64()))
+	}
+	return d
+}
+
+// memoryWatchStateStore is a WatchStateStore in the memory.
+type memoryWatchStateStore struct {
+	states sync.Map // map[string][]ocispec.Descriptor
+}
+
+// Load returns the descriptors last observed for the key.
+func (s *memoryWatchStateStore) Load(_ context.Context, key string) ([]ocispec.Descriptor, bool, error) {
+	value, ok := s.states.Load(key)
+	if !ok {
+		return nil, false, nil
+	}
+	return value.([]ocispec.Descriptor), true, nil
+}
+
+// Store saves the descriptors observed for the key.
+func (s *memoryWatchStateStore) Store(_ context.Context, key string, descs []ocispec.Descriptor) error {
+	s.states.Store(key, descs)
+	return nil
+}
+
+// Delete removes the state of the key.
+func (s *memoryWatchStateStore) Delete(_ context.Context, key string) error {
+	s.states.Delete(key)
+	return nil
+}
@@ -0,0 +1,385 @@
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/errdef"
+)
+
+// testWatchTarget is a WatchTarget with mutable tags and referrers.
+type testWatchTarget struct {
+	lock        sync.Mutex
+	tags        map[string]ocispec.Descriptor
+	referrers   map[digest.Digest][]ocispec.Descriptor
+	resolveErr  error
+	resolves    int
+	conditional int
+}
+
+func (t *testWatchTarget) Resolve(_ context.Context, reference string) (ocispec.Descriptor, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.resolves++
+	if t.resolveErr != nil {
+		return ocispec.Descriptor{}, t.resolveErr
+	}
+	desc, ok := t.tags[reference]
+	if !ok {
+		return ocispec.Descriptor{}, fmt.Errorf("%s: %w", reference, errdef.ErrNotFound)
+	}
+	return desc, nil
+}
+
+func (t *testWatchTarget) Fetch(_ context.Context, target ocispec.Descriptor) (io.ReadCloser, error) {
+	return nil, fmt.Errorf("%s: %w", target.Digest, errdef.ErrNotFound)
+}
+
+func (t *testWatchTarget) Exists(_ context.Context, _ ocispec.Descriptor) (bool, error) {
+	return false, nil
+}
+
+func (t *testWatchTarget) Predecessors(_ context.Context, _ ocispec.Descriptor) ([]ocispec.Descriptor, error) {
+	return nil, errors.New("unexpected call to Predecessors()")
+}
+
+func (t *testWatchTarget) Referrers(_ context.Context, desc ocispec.Descriptor, _ string, fn func(referrers []ocispec.Descriptor) error) error {
+	t.lock.Lock()
+	referrers := t.referrers[desc.Digest]
+	t.lock.Unlock()
+	return fn(referrers)
+}
+
+// testConditionalWatchTarget is a testWatchTarget resolving tags
+// conditionally.
+type testConditionalWatchTarget struct {
+	testWatchTarget
+}
+
+func (t *testConditionalWatchTarget) ResolveIfChanged(ctx context.Context, reference string, last ocispec.Descriptor) (ocispec.Descriptor, bool, error) {
+	t.lock.Lock()
+	t.conditional++
+	t.lock.Unlock()
+	desc, err := t.Resolve(ctx, reference)
+	if err != nil {
+		return ocispec.Descriptor{}, false, err
+	}
+	if desc.Digest == last.Digest {
+		return last, false, nil
+	}
+	return desc, true, nil
+}
+
+func (t *testWatchTarget) setTag(tag string, desc *ocispec.Descriptor) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if desc == nil {
+		delete(t.tags, tag)
+		return
+	}
+	t.tags[tag] = *desc
+}
+
+func (t *testWatchTarget) setReferrers(subject ocispec.Descriptor, referrers ...ocispec.Descriptor) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.referrers[subject.Digest] = referrers
+}
+
+func newTestDescriptor(s string) ocispec.Descriptor {
+	return ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Digest:    digest.FromString(s),
+		Size:      int64(len(s)),
+	}
+}
+
+func TestWatcher_Poll(t *testing.T) {
+	v1 := newTestDescriptor("v1")
+	v2 := newTestDescriptor("v2")
+	sig := newTestDescriptor("sig")
+	sbom := newTestDescriptor("sbom")
+	target := &testWatchTarget{
+		tags:      map[string]ocispec.Descriptor{"latest": v1},
+		referrers: map[digest.Digest][]ocispec.Descriptor{},
+	}
+	w := NewWatcher(target, WatcherOptions{
+		Tags:     []string{"latest", "stable"},
+		Subjects: []ocispec.Descriptor{v1},
+	})
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		change func()
+		want   []WatchEvent
+	}{
+		{
+			name: "initial observation",
+			want: []WatchEvent{
+				{Type: WatchEventAdded, Tag: "latest", Descriptor: v1},
+			},
+		},
+		{
+			name: "no changes",
+		},
+		{
+			name: "tag moved and created, referrers attached",
+			change: func() {
+				target.setTag("latest", &v2)
+				target.setTag("stable", &v1)
+				target.setReferrers(v1, sig, sbom)
+			},
+			want: []WatchEvent{
+				{Type: WatchEventMoved, Tag: "latest", Descriptor: v2, Previous: v1},
+				{Type: WatchEventAdded, Tag: "stable", Descriptor: v1},
+				{Type: WatchEventAdded, Subject: v1, Descriptor: sig},
+				{Type: WatchEventAdded, Subject: v1, Descriptor: sbom},
+			},
+		},
+		{
+			name: "tag deleted, referrer detached",
+			change: func() {
+				target.setTag("stable", nil)
+				target.setReferrers(v1, sbom)
+			},
+			want: []WatchEvent{
+				{Type: WatchEventRemoved, Tag: "stable", Descriptor: v1},
+				{Type: WatchEventRemoved, Subject: v1, Descriptor: sig},
+			},
+		},
+	}
+	for _, tt := range tests {
+		if tt.change != nil {
+			tt.change()
+		}
+		got, err := w.Poll(ctx)
+		if err != nil {
+			t.Fatalf("%s: Watcher.Poll() error = %v", tt.name, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: Watcher.Poll() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	// failed tags do not stop the poll
+	errResolve := errors.New("resolve failed")
+	target.lock.Lock()
+	target.resolveErr = errResolve
+	target.lock.Unlock()
+	target.setReferrers(v1)
+	got, err := w.Poll(ctx)
+	if !errors.Is(err, errResolve) {
+		t.Errorf("Watcher.Poll() error = %v, wantErr %v", err, errResolve)
+	}
+	want := []WatchEvent{
+		{Type: WatchEventRemoved, Subject: v1, Descriptor: sbom},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Watcher.Poll() = %v, want %v", got, want)
+	}
+}
+
+func TestWatcher_Poll_ConditionalResolver(t *testing.T) {
+	v1 := newTestDescriptor("v1")
+	v2 := newTestDescriptor("v2")
+	target := &testConditionalWatchTarget{
+		testWatchTarget: testWatchTarget{
+			tags: map[string]ocispec.Descriptor{"latest": v1},
+		},
+	}
+	w := NewWatcher(target, WatcherOptions{
+		Tags: []string{"latest"},
+	})
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if _, err := w.Poll(ctx); err != nil {
+			t.Fatalf("Watcher.Poll() error = %v", err)
+		}
+	}
+	target.setTag("latest", &v2)
+	got, err := w.Poll(ctx)
+	if err != nil {
+		t.Fatalf("Watcher.Poll() error = %v", err)
+	}
+	want := []WatchEvent{
+		{Type: WatchEventMoved, Tag: "latest", Descriptor: v2, Previous: v1},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Watcher.Poll() = %v, want %v", got, want)
+	}
+	if got, want := target.conditional, 3; got != want {
+		t.Errorf("count(ResolveIfChanged()) = %v, want %v", got, want)
+	}
+}
+
+func TestWatcher_Poll_FileWatchStateStore(t *testing.T) {
+	v1 := newTestDescriptor("v1")
+	v2 := newTestDescriptor("v2")
+	sig := newTestDescriptor("sig")
+	target := &testWatchTarget{
+		tags:      map[string]ocispec.Descriptor{"latest": v1},
+		referrers: map[digest.Digest][]ocispec.Descriptor{v1.Digest: {sig}},
+	}
+	path := filepath.Join(t.TempDir(), "state.json")
+	opts := WatcherOptions{
+		Tags:     []string{"latest"},
+		Subjects: []ocispec.Descriptor{v1},
+	}
+	ctx := context.Background()
+
+	store, err := NewFileWatchStateStore(path)
+	if err != nil {
+		t.Fatalf("NewFileWatchStateStore() error = %v", err)
+	}
+	opts.StateStore = store
+	got, err := NewWatcher(target, opts).Poll(ctx)
+	if err != nil {
+		t.Fatalf("Watcher.Poll() error = %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("Watcher.Poll() = %v, want 2 events", got)
+	}
+
+	// restart with the persisted states
+	target.setTag("latest", &v2)
+	store, err = NewFileWatchStateStore(path)
+	if err != nil {
+		t.Fatalf("NewFileWatchStateStore() error = %v", err)
+	}
+	opts.StateStore = store
+	got, err = NewWatcher(target, opts).Poll(ctx)
+	if err != nil {
+		t.Fatalf("Watcher.Poll() error = %v", err)
+	}
+	want := []WatchEvent{
+		{Type: WatchEventMoved, Tag: "latest", Descriptor: v2, Previous: v1},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Watcher.Poll() = %v, want %v", got, want)
+	}
+}
+
+func TestWatcher_Watch(t *testing.T) {
+	v1 := newTestDescriptor("v1")
+	v2 := newTestDescriptor("v2")
+	target := &testWatchTarget{
+		tags: map[string]ocispec.Descriptor{"latest": v1},
+	}
+	w := NewWatcher(target, WatcherOptions{
+		Tags:     []string{"latest"},
+		Interval: time.Millisecond,
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := w.Watch(ctx)
+
+	receive := func() WatchEvent {
+		select {
+		case event := <-events:
+			return event
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout waiting for events")
+		}
+		return WatchEvent{}
+	}
+	if got, want := receive(), (WatchEvent{Type: WatchEventAdded, Tag: "latest", Descriptor: v1}); !reflect.DeepEqual(got, want) {
+		t.Errorf("Watcher.Watch() = %v, want %v", got, want)
+	}
+	target.setTag("latest", &v2)
+	if got, want := receive(), (WatchEvent{Type: WatchEventMoved, Tag: "latest", Descriptor: v2, Previous: v1}); !reflect.DeepEqual(got, want) {
+		t.Errorf("Watcher.Watch() = %v, want %v", got, want)
+	}
+	errResolve := errors.New("resolve failed")
+	target.lock.Lock()
+	target.resolveErr = errResolve
+	target.lock.Unlock()
+	if got := receive(); got.Type != WatchEventError || !errors.Is(got.Err, errResolve) {
+		t.Errorf("Watcher.Watch() = %v, want error event of %v", got, errResolve)
+	}
+
+	cancel()
+	for range events {
+		// drain the events until the channel is closed
+	}
+}
+
+func TestWatcher_delay(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     WatcherOptions
+		failures int
+		min, max time.Duration
+	}{
+		{
+			name: "default",
+			min:  54 * time.Second,
+			max:  66 * time.Second,
+		},
+		{
+			name: "no jitter",
+			opts: WatcherOptions{Interval: time.Second, Jitter: -1},
+			min:  time.Second,
+			max:  time.Second,
+		},
+		{
+			name:     "backoff",
+			opts:     WatcherOptions{Interval: time.Second, Jitter: -1},
+			failures: 2,
+			min:      4 * time.Second,
+			max:      4 * time.Second,
+		},
+		{
+			name:     "max backoff",
+			opts:     WatcherOptions{Interval: time.Second, MaxBackoff: 3 * time.Second, Jitter: -1},
+			failures: 10,
+			min:      3 * time.Second,
+			max:      3 * time.Second,
+		},
+		{
+			name:     "default max backoff with jitter",
+			opts:     WatcherOptions{Interval: time.Second, Jitter: 0.5},
+			failures: 10,
+			min:      5 * time.Second,
+			max:      15 * time.Second,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := NewWatcher(&testWatchTarget{}, tt.opts)
+			for i := 0; i < 10; i++ {
+				if got := w.delay(tt.failures); got < tt.min || got > tt.max {
+					t.Errorf("Watcher.delay() = %v, want [%v, %v]", got, tt.min, tt.max)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// FileWatchStateStore is a [WatchStateStore] persisting the states in a JSON
+// file. The file is rewritten atomically on every change.
+type FileWatchStateStore struct {
+	path string
+
+	lock   sync.Mutex
+	states map[string][]ocispec.Descriptor
+}
+
+// NewFileWatchStateStore returns a state store persisting the states in the
+// file at the given path. The existing states are loaded if the file exists,
+// otherwise the file is created on the first change.
+func NewFileWatchStateStore(path string) (*FileWatchStateStore, error) {
+	s := &FileWatchStateStore{
+		path:   path,
+		states: make(map[string][]ocispec.Descriptor),
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return s, nil
+		}
+		return nil, fmt.Errorf("failed to read watch state file %s: %w", path, err)
+	}
+	if err := json.Unmarshal(data, &s.states); err != nil {
+		return nil, fmt.Errorf("failed to decode watch state file %s: %w", path, err)
+	}
+	return s, nil
+}
+
+// Load returns the descriptors last observed for the key.
+func (s *FileWatchStateStore) Load(_ context.Context, key string) ([]ocispec.Descriptor, bool, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	descs, ok := s.states[key]
+	return descs, ok, nil
+}
+
+// Store saves the descriptors observed for the key.
+func (s *FileWatchStateStore) Store(_ context.Context, key string, descs []ocispec.Descriptor) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.states[key] = descs
+	return s.save()
+}
+
+// Delete removes the state of the key.
+func (s *FileWatchStateStore) Delete(_ context.Context, key string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if _, ok := s.states[key]; !ok {
+		return nil
+	}
+	delete(s.states, key)
+	return s.save()
+}
+
+// save writes the states to a temporary file and renames it to the state
+// file. The caller must hold s.lock.
+func (s *FileWatchStateStore) save() error {
+	data, err := json.Marshal(s.states)
+	if err != nil {
+		return fmt.Errorf("failed to encode watch states: %w", err)
+	}
+	fp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary watch state file: %w", err)
+	}
+	tmpPath := fp.Name()
+	_, err = fp.Write(data)
+	if closeErr := fp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, s.path)
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to save watch state file %s: %w", s.path, err)
+	}
+	return nil
+}
@@ -0,0 +1,244 @@
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oras
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/errdef"
+	"oras.land/oras-go/v2/internal/container/set"
+	"oras.land/oras-go/v2/internal/descriptor"
+	"oras.land/oras-go/v2/registry"
+)
+
+// UnionTarget is a graph target which reads across multiple layers of
+// targets, such as local OCI layouts and remote repositories, as one target.
+// The layers are checked in priority order.
+//   - Fetch and Resolve are served by the first layer holding the content or
+//     the reference.
+//   - Exists checks all the layers of a read-only union target, and only the
+//     top layer of a writable union target. See [UnionTarget.Exists].
+//   - Predecessors merges the predecessors from all the layers.
+//   - Tags merges and deduplicates the tags from all the layers implementing
+//     registry.TagLister.
+//
+// A UnionTarget created by [NewWritableUnionTarget] has a writable top layer,
+// which receives pushes and tags, and has the highest priority. Otherwise,
+// Push and Tag return errdef.ErrUnsupported.
+type UnionTarget struct {
+	top    GraphTarget
+	layers []ReadOnlyGraphTarget
+}
+
+// NewUnionTarget creates a read-only union target of the layers, where the
+// former layers have higher priorities.
+func NewUnionTarget(layers ...ReadOnlyGraphTarget) *UnionTarget {
+	return &UnionTarget{
+		layers: layers,
+	}
+}
+
+// NewWritableUnionTarget creates a union target of the layers with the
+// writable top layer, where the top layer has the highest priority, and the
+// former layers have higher priorities.
+func NewWritableUnionTarget(top GraphTarget, layers ...ReadOnlyGraphTarget) *UnionTarget {
+	return &UnionTarget{
+		top:    top,
+		layers: append([]ReadOnlyGraphTarget{top}, layers...),
+	}
+}
+
+// Fetch fetches the content identified by the descriptor from the first layer
+// holding the content.
+func (u *UnionTarget) Fetch(ctx context.Context, target ocispec.Descriptor) (io.ReadCloser, error) {
+	for _, layer := range u.layers {
+		rc, err := layer.Fetch(ctx, target)
+		if err == nil {
+			return rc, nil
+		}
+		if !errors.Is(err, errdef.ErrNotFound) {
+			return nil, err
+		}
+	}
+	return nil, fmt.Errorf("%s: %s: %w", target.Digest, target.MediaType, errdef.ErrNotFound)
+}
+
+// Exists returns true if the described content exists in any layer of a
+// read-only union target.
+//
+// For a union target with a writable top layer, Exists checks the top layer
+// only, and returns false for the content held only by the lower layers, even
+// though Fetch serves it. This is part of the contract of a writable union
+// target: as a destination of Copy or CopyGraph, the content missing in the
+// top layer is copied to it instead of being skipped. Callers checking whether
+// the content is readable from a writable union target should use Fetch.
+func (u *UnionTarget) Exists(ctx context.Context, target ocispec.Descriptor) (bool, error) {
+	if u.top != nil {
+		return u.top.Exists(ctx, target)
+	}
+	for _, layer := range u.layers {
+		exists, err := layer.Exists(ctx, target)
+		if err != nil {
+			return false, err
+		}
+		if exists {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Resolve resolves a reference to a descriptor by the first layer holding
+// the reference.
+func (u *UnionTarget) Resolve(ctx context.Context, reference string) (ocispec.Descriptor, error) {
+	for _, layer := range u.layers {
+		desc, err := layer.Resolve(ctx, reference)
+		if err == nil {
+			return desc, nil
+		}
+		if !errors.Is(err, errdef.ErrNotFound) {
+			return ocispec.Descriptor{}, err
+		}
+	}
+	return ocispec.Descriptor{}, fmt.Errorf("%s: %w", reference, errdef.ErrNotFound)
+}
+
+// Predecessors returns the nodes directly pointing to the current node in
+// any layer, without duplicates.
+func (u *UnionTarget) Predecessors(ctx context.Context, node ocispec.Descriptor) ([]ocispec.Descriptor, error) {
+	visited := set.New[descriptor.Descriptor]()
+	var res []ocispec.Descriptor
+	for _, layer := range u.layers {
+		predecessors, err := layer.Predecessors(ctx, node)
+		if err != nil {
+			return nil, err
+		}
+		for _, predecessor := range predecessors {
+			key := descriptor.FromOCI(predecessor)
+			if !visited.Contains(key) {
+				visited.Add(key)
+				res = append(res, predecessor)
+			}
+		}
+	}
+	return res, nil
+}
+
+// Tags lists the tags of all the layers implementing registry.TagLister,
+// merged, deduplicated and returned in ascending order.
+// If `last` is NOT empty, the entries in the response start after the tag
+// specified by `last`. Otherwise, the response starts from the top of the tags
+// list.
+//
+// See also `Tags()` in the package `registry`.
+func (u *UnionTarget) Tags(ctx context.Context, last string, fn func(tags []string) error) error {
+	tagSet := set.New[string]()
+	for _, layer := range u.layers {
+		lister, ok := layer.(registry.TagLister)
+		if !ok {
+			continue
+		}
+		if err := lister.Tags(ctx, "", func(tags []string) error {
+			for _, tag := range tags {
+				tagSet.Add(tag)
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+
+	var tags []string
+	for tag := range tagSet {
+		if last == "" || tag > last {
+			tags = append(tags, tag)
+		}
+	}
+	if len(tags) == 0 {
+		return nil
+	}
+	slices.Sort(tags)
+	return fn(tags)
+}
+
+// Push pushes the content to the top layer.
+// It returns errdef.ErrUnsupported if there is no writable top layer.
+func (u *UnionTarget) Push(ctx context.Context, expected ocispec.Descriptor, content io.Reader) error {
+	if u.top == nil {
+		return fmt.Errorf("push to read-only union target: %w", errdef.ErrUnsupported)
+	}
+	return u.top.Push(ctx, expected, content)
+}
+
+// Tag tags the descriptor with the reference in the top layer. The content
+// reachable from the descriptor is copied up to the top layer from the lower
+// layers if missing in the top layer, at every level of the graph, even if
+// the descriptor or its intermediate nodes exist in the top layer. Foreign
+// layers are not copied up.
+// It returns errdef.ErrUnsupported if there is no writable top layer.
+func (u *UnionTarget) Tag(ctx context.Context, desc ocispec.Descriptor, reference string) error {
+	if u.top == nil {
+		return fmt.Errorf("tag in read-only union target: %w", errdef.ErrUnsupported)
+	}
+	if err := u.copyUp(ctx, desc, set.New[descriptor.Descriptor]()); err != nil {
+		return fmt.Errorf("failed to copy up %s: %w", desc.Digest, err)
+	}
+	return u.top.Tag(ctx, desc, reference)
+}
+
+// copyUp copies the graph rooted at node to the top layer, walking into the
+// nodes existing in the top layer as their successors may be missing.
+// The successors are copied before their predecessors.
+func (u *UnionTarget) copyUp(ctx context.Context, node ocispec.Descriptor, visited set.Set[descriptor.Descriptor]) error {
+	key := descriptor.FromOCI(node)
+	if visited.Contains(key) {
+		return nil
+	}
+	visited.Add(key)
+
+	successors, err := content.Successors(ctx, u, node)
+	if err != nil {
+		return err
+	}
+	for _, successor := range removeForeignLayers(successors) {
+		if err := u.copyUp(ctx, successor, visited); err != nil {
+			return err
+		}
+	}
+
+	exists, err := u.top.Exists(ctx, node)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return nil
+	}
+	rc, err := u.Fetch(ctx, node)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	if err := u.top.Push(ctx, node, rc); err != nil && !errors.Is(err, errdef.ErrAlreadyExists) {
+		return err
+	}
+	return nil
+}
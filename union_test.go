@@ -0,0 +1,268 @@
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oras_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/opencontainers/image-spec/specs-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/content/memory"
+	"oras.land/oras-go/v2/content/oci"
+	"oras.land/oras-go/v2/errdef"
+)
+
+func TestUnionTarget(t *testing.T) {
+	ctx := context.Background()
+	newStore := func() *oci.Store {
+		s, err := oci.New(t.TempDir())
+		if err != nil {
+			t.Fatal("oci.New() error =", err)
+		}
+		return s
+	}
+	push := func(s oras.Target, mediaType string, blob []byte) ocispec.Descriptor {
+		desc := content.NewDescriptorFromBytes(mediaType, blob)
+		if err := s.Push(ctx, desc, bytes.NewReader(blob)); err != nil && !errors.Is(err, errdef.ErrAlreadyExists) {
+			t.Fatal("Push() error =", err)
+		}
+		return desc
+	}
+	pushManifest := func(s oras.Target, subject *ocispec.Descriptor, layerBlob string) ocispec.Descriptor {
+		push(s, ocispec.MediaTypeEmptyJSON, ocispec.DescriptorEmptyJSON.Data)
+		layer := push(s, ocispec.MediaTypeImageLayer, []byte(layerBlob))
+		manifestJSON, err := json.Marshal(ocispec.Manifest{
+			MediaType: ocispec.MediaTypeImageManifest,
+			Config:    ocispec.DescriptorEmptyJSON,
+			Layers:    []ocispec.Descriptor{layer},
+			Subject:   subject,
+		})
+		if err != nil {
+			t.Fatal("json.Marshal() error =", err)
+		}
+		return push(s, ocispec.MediaTypeImageManifest, manifestJSON)
+	}
+	tag := func(s oras.Target, desc ocispec.Descriptor, reference string) {
+		if err := s.Tag(ctx, desc, reference); err != nil {
+			t.Fatal("Tag() error =", err)
+		}
+	}
+
+	// the vendor bundle
+	vendor := newStore()
+	app := pushManifest(vendor, nil, "app")
+	tool := pushManifest(vendor, nil, "tool")
+	vendorSig := pushManifest(vendor, &app, "vendor signature")
+	tag(vendor, app, "app")
+	tag(vendor, tool, "tool")
+	// the local overrides
+	override := newStore()
+	patchedTool := pushManifest(override, nil, "patched tool")
+	localSig := pushManifest(override, &app, "local signature")
+	tag(override, patchedTool, "tool")
+	tag(override, patchedTool, "patched")
+
+	union := oras.NewUnionTarget(override, vendor)
+
+	// references are resolved in priority order
+	for ref, want := range map[string]ocispec.Descriptor{
+		"app":     app,
+		"tool":    patchedTool,
+		"patched": patchedTool,
+	} {
+		got, err := union.Resolve(ctx, ref)
+		if err != nil {
+			t.Fatalf("UnionTarget.Resolve(%s) error = %v", ref, err)
+		}
+		if !content.Equal(got, want) {
+			t.Errorf("UnionTarget.Resolve(%s) = %v, want %v", ref, got, want)
+		}
+	}
+	if _, err := union.Resolve(ctx, "missing"); !errors.Is(err, errdef.ErrNotFound) {
+		t.Errorf("UnionTarget.Resolve() error = %v, want %v", err, errdef.ErrNotFound)
+	}
+	for _, desc := range []ocispec.Descriptor{app, tool, patchedTool} {
+		if exists, err := union.Exists(ctx, desc); err != nil || !exists {
+			t.Errorf("UnionTarget.Exists(%s) = %v, %v, want %v", desc.Digest, exists, err, true)
+		}
+		if _, err := content.FetchAll(ctx, union, desc); err != nil {
+			t.Errorf("UnionTarget.Fetch(%s) error = %v", desc.Digest, err)
+		}
+	}
+	missing := content.NewDescriptorFromBytes(ocispec.MediaTypeImageLayer, []byte("missing"))
+	if exists, err := union.Exists(ctx, missing); err != nil || exists {
+		t.Errorf("UnionTarget.Exists() = %v, %v, want %v", exists, err, false)
+	}
+	if _, err := union.Fetch(ctx, missing); !errors.Is(err, errdef.ErrNotFound) {
+		t.Errorf("UnionTarget.Fetch() error = %v, want %v", err, errdef.ErrNotFound)
+	}
+
+	// predecessors are merged
+	predecessors, err := union.Predecessors(ctx, app)
+	if err != nil {
+		t.Fatal("UnionTarget.Predecessors() error =", err)
+	}
+	if want := []ocispec.Descriptor{localSig, vendorSig}; !reflect.DeepEqual(predecessors, want) {
+		t.Errorf("UnionTarget.Predecessors() = %v, want %v", predecessors, want)
+	}
+
+	// tags are merged and deduplicated
+	var tags []string
+	if err := union.Tags(ctx, "", func(got []string) error {
+		tags = append(tags, got...)
+		return nil
+	}); err != nil {
+		t.Fatal("UnionTarget.Tags() error =", err)
+	}
+	if want := []string{"app", "patched", "tool"}; !reflect.DeepEqual(tags, want) {
+		t.Errorf("UnionTarget.Tags() = %v, want %v", tags, want)
+	}
+	tags = nil
+	if err := union.Tags(ctx, "app", func(got []string) error {
+		tags = append(tags, got...)
+		return nil
+	}); err != nil {
+		t.Fatal("UnionTarget.Tags() error =", err)
+	}
+	if want := []string{"patched", "tool"}; !reflect.DeepEqual(tags, want) {
+		t.Errorf("UnionTarget.Tags() = %v, want %v", tags, want)
+	}
+
+	// the union is a source of Copy
+	dst := memory.New()
+	got, err := oras.Copy(ctx, union, "tool", dst, "tool", oras.DefaultCopyOptions)
+	if err != nil {
+		t.Fatal("oras.Copy() error =", err)
+	}
+	if !content.Equal(got, patchedTool) {
+		t.Errorf("oras.Copy() = %v, want %v", got, patchedTool)
+	}
+
+	// the read-only union rejects writes
+	if err := union.Push(ctx, missing, bytes.NewReader([]byte("missing"))); !errors.Is(err, errdef.ErrUnsupported) {
+		t.Errorf("UnionTarget.Push() error = %v, want %v", err, errdef.ErrUnsupported)
+	}
+	if err := union.Tag(ctx, app, "latest"); !errors.Is(err, errdef.ErrUnsupported) {
+		t.Errorf("UnionTarget.Tag() error = %v, want %v", err, errdef.ErrUnsupported)
+	}
+
+	// the writable top layer receives pushes and tags
+	top := newStore()
+	writable := oras.NewWritableUnionTarget(top, override, vendor)
+	local := pushManifest(writable, nil, "local")
+	tag(writable, local, "tool")
+	tag(writable, app, "latest")
+	for ref, want := range map[string]ocispec.Descriptor{
+		"tool":   local,
+		"latest": app,
+	} {
+		got, err := writable.Resolve(ctx, ref)
+		if err != nil {
+			t.Fatalf("UnionTarget.Resolve(%s) error = %v", ref, err)
+		}
+		if !content.Equal(got, want) {
+			t.Errorf("UnionTarget.Resolve(%s) = %v, want %v", ref, got, want)
+		}
+		// the content is copied up to the top layer
+		if got, err := top.Resolve(ctx, ref); err != nil || !content.Equal(got, want) {
+			t.Errorf("top.Resolve(%s) = %v, %v, want %v", ref, got, err, want)
+		}
+	}
+	if exists, err := vendor.Exists(ctx, local); err != nil || exists {
+		t.Errorf("vendor.Exists() = %v, %v, want %v", exists, err, false)
+	}
+
+	// the writable union checks the existence in the top layer only, so that
+	// the content of the lower layers is copied up
+	if exists, err := writable.Exists(ctx, tool); err != nil || exists {
+		t.Errorf("UnionTarget.Exists() = %v, %v, want %v", exists, err, false)
+	}
+	if _, err := oras.Copy(ctx, vendor, "tool", writable, "vendor-tool", oras.DefaultCopyOptions); err != nil {
+		t.Fatal("oras.Copy() error =", err)
+	}
+	nodes := []ocispec.Descriptor{tool}
+	successors, err := content.Successors(ctx, vendor, tool)
+	if err != nil {
+		t.Fatal("content.Successors() error =", err)
+	}
+	nodes = append(nodes, successors...)
+	for _, desc := range nodes {
+		if exists, err := top.Exists(ctx, desc); err != nil || !exists {
+			t.Errorf("top.Exists(%s) = %v, %v, want %v", desc.Digest, exists, err, true)
+		}
+	}
+
+	// the successors missing in the top layer are copied up on tagging the
+	// root existing in the top layer
+	top = newStore()
+	writable = oras.NewWritableUnionTarget(top, vendor)
+	manifestJSON, err := content.FetchAll(ctx, vendor, app)
+	if err != nil {
+		t.Fatal("content.FetchAll() error =", err)
+	}
+	push(top, app.MediaType, manifestJSON)
+	tag(writable, app, "latest")
+	successors, err = content.Successors(ctx, vendor, app)
+	if err != nil {
+		t.Fatal("content.Successors() error =", err)
+	}
+	for _, desc := range successors {
+		if exists, err := top.Exists(ctx, desc); err != nil || !exists {
+			t.Errorf("top.Exists(%s) = %v, %v, want %v", desc.Digest, exists, err, true)
+		}
+	}
+
+	// the nodes missing in the top layer are copied up at every level of a
+	// nested graph existing partially in the top layer
+	indexJSON, err := json.Marshal(ocispec.Index{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+		MediaType: ocispec.MediaTypeImageIndex,
+		Manifests: []ocispec.Descriptor{app, tool},
+	})
+	if err != nil {
+		t.Fatal("json.Marshal() error =", err)
+	}
+	index := push(vendor, ocispec.MediaTypeImageIndex, indexJSON)
+	top = newStore()
+	writable = oras.NewWritableUnionTarget(top, vendor)
+	push(top, index.MediaType, indexJSON)
+	push(top, app.MediaType, manifestJSON)
+	tag(writable, index, "index")
+	nodes = []ocispec.Descriptor{index}
+	for _, manifest := range []ocispec.Descriptor{app, tool} {
+		successors, err := content.Successors(ctx, vendor, manifest)
+		if err != nil {
+			t.Fatal("content.Successors() error =", err)
+		}
+		nodes = append(nodes, manifest)
+		nodes = append(nodes, successors...)
+	}
+	for _, desc := range nodes {
+		if exists, err := top.Exists(ctx, desc); err != nil || !exists {
+			t.Errorf("top.Exists(%s) = %v, %v, want %v", desc.Digest, exists, err, true)
+		}
+	}
+	if got, err := top.Resolve(ctx, "index"); err != nil || !content.Equal(got, index) {
+		t.Errorf("top.Resolve(index) = %v, %v, want %v", got, err, index)
+	}
+}